@@ -0,0 +1,293 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// aesBlockSize is the AES block size in bytes.
+const aesBlockSize = 16
+
+// aesMul returns the product of a and b in GF(2^8), modulo the AES
+// polynomial x^8 + x^4 + x^3 + x + 1.
+func aesMul(a, b byte) byte {
+	var p byte
+	for b != 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+		a = a<<1 ^ 0x1b*(a>>7)
+		b >>= 1
+	}
+	return p
+}
+
+// aesSbox and aesInvSbox are the AES S-box and its inverse. The S-box maps a
+// byte to its multiplicative inverse in GF(2^8), with 0 mapped to 0, followed
+// by an affine transformation.
+var aesSbox, aesInvSbox = func() (s, inv [256]byte) {
+	for x := range 256 {
+		var y byte
+		for c := range 256 {
+			if aesMul(byte(x), byte(c)) == 1 {
+				y = byte(c)
+				break
+			}
+		}
+		b := y ^ (y<<1 | y>>7) ^ (y<<2 | y>>6) ^ (y<<3 | y>>5) ^ (y<<4 | y>>4) ^ 0x63
+		s[x] = b
+		inv[b] = byte(x)
+	}
+	return s, inv
+}()
+
+// AESSubBytes applies the AES S-box to each byte of the state s.
+//
+// The state functions work on a block in FIPS 197 order, column by column:
+// byte 4*c+r is row r of column c.
+func AESSubBytes(s *[aesBlockSize]byte) {
+	for i, b := range s {
+		s[i] = aesSbox[b]
+	}
+}
+
+// AESInvSubBytes undoes AESSubBytes.
+func AESInvSubBytes(s *[aesBlockSize]byte) {
+	for i, b := range s {
+		s[i] = aesInvSbox[b]
+	}
+}
+
+// AESShiftRows rotates row r of the state s left by r bytes.
+func AESShiftRows(s *[aesBlockSize]byte) {
+	t := *s
+	for c := range 4 {
+		for r := range 4 {
+			s[4*c+r] = t[4*((c+r)%4)+r]
+		}
+	}
+}
+
+// AESInvShiftRows undoes AESShiftRows.
+func AESInvShiftRows(s *[aesBlockSize]byte) {
+	t := *s
+	for c := range 4 {
+		for r := range 4 {
+			s[4*((c+r)%4)+r] = t[4*c+r]
+		}
+	}
+}
+
+// mixColumn multiplies col by the circulant matrix with first row m.
+func mixColumn(col, m [4]byte) [4]byte {
+	var res [4]byte
+	for r := range res {
+		res[r] = aesMul(m[0], col[r]) ^ aesMul(m[1], col[(r+1)%4]) ^
+			aesMul(m[2], col[(r+2)%4]) ^ aesMul(m[3], col[(r+3)%4])
+	}
+	return res
+}
+
+// The first rows of the MixColumns matrix and its inverse.
+var (
+	aesMixRow    = [4]byte{2, 3, 1, 1}
+	aesInvMixRow = [4]byte{14, 11, 13, 9}
+)
+
+// mixColumns multiplies each column of s by the circulant matrix with first
+// row m.
+func mixColumns(s *[aesBlockSize]byte, m [4]byte) {
+	for c := range 4 {
+		col := mixColumn([4]byte(s[4*c:]), m)
+		copy(s[4*c:], col[:])
+	}
+}
+
+// AESMixColumns multiplies each column of the state s, as a polynomial over
+// GF(2^8), by 3x^3 + x^2 + x + 2.
+func AESMixColumns(s *[aesBlockSize]byte) {
+	mixColumns(s, aesMixRow)
+}
+
+// AESInvMixColumns undoes AESMixColumns.
+func AESInvMixColumns(s *[aesBlockSize]byte) {
+	mixColumns(s, aesInvMixRow)
+}
+
+// AESAddRoundKey XORs the round key k into the state s.
+func AESAddRoundKey(s, k *[aesBlockSize]byte) {
+	for i := range s {
+		s[i] ^= k[i]
+	}
+}
+
+// aesRounds returns the number of rounds AES uses with a key of n bytes, or 0
+// if n isn't a valid key size.
+func aesRounds(n int) int {
+	switch n {
+	case 16, 24, 32:
+		return n/4 + 6
+	}
+	return 0
+}
+
+// AESExpandKey returns the rounds+1 round keys the AES key schedule derives
+// from key, which must be 16, 24, or 32 bytes. rounds is normally 10, 12, or
+// 14, but any positive count extends the schedule the same way. The first
+// round key starts with key. It panics if key is the wrong size or rounds
+// isn't positive.
+func AESExpandKey(key []byte, rounds int) [][aesBlockSize]byte {
+	if aesRounds(len(key)) == 0 {
+		panic("invalid key size")
+	}
+	if rounds <= 0 {
+		panic("invalid round count")
+	}
+
+	// The schedule works in 4-byte words, nk of them from the key.
+	nk := len(key) / 4
+	w := make([][4]byte, 4*(rounds+1))
+	rcon := byte(1)
+	for i := range w {
+		switch {
+		case i < nk:
+			w[i] = [4]byte(key[4*i:])
+			continue
+		case i%nk == 0:
+			t := w[i-1]
+			w[i] = [4]byte{aesSbox[t[1]] ^ rcon, aesSbox[t[2]], aesSbox[t[3]], aesSbox[t[0]]}
+			rcon = aesMul(rcon, 2)
+		case nk > 6 && i%nk == 4:
+			t := w[i-1]
+			w[i] = [4]byte{aesSbox[t[0]], aesSbox[t[1]], aesSbox[t[2]], aesSbox[t[3]]}
+		default:
+			w[i] = w[i-1]
+		}
+		for j := range 4 {
+			w[i][j] ^= w[i-nk][j]
+		}
+	}
+
+	keys := make([][aesBlockSize]byte, rounds+1)
+	for i := range keys {
+		for j := range 4 {
+			copy(keys[i][4*j:], w[4*i+j][:])
+		}
+	}
+	return keys
+}
+
+// InvertKeySchedule returns the AES-128 key whose schedule, from AESExpandKey,
+// has roundKey as its round key number round, where 0 is the key itself.
+//
+// Each schedule word is the XOR of the word four back and a function of the
+// word before it, so the words of any round key give the previous round's,
+// back to the key. A white-box implementation or a fault attack that leaks
+// one round key leaks the key. It panics if roundKey isn't 16 bytes or round
+// is negative.
+func InvertKeySchedule(roundKey []byte, round int) []byte {
+	if len(roundKey) != aesBlockSize {
+		panic("invalid round key size")
+	}
+	if round < 0 {
+		panic("invalid round")
+	}
+
+	// Round constant i is x^(i-1) in GF(2^8).
+	rcon := func(i int) byte {
+		r := byte(1)
+		for range i - 1 {
+			r = aesMul(r, 2)
+		}
+		return r
+	}
+
+	w := make([][4]byte, 4*(round+1))
+	for j := range 4 {
+		w[4*round+j] = [4]byte(roundKey[4*j:])
+	}
+	for i := 4*round + 3; i >= 4; i-- {
+		t := w[i-1]
+		if i%4 == 0 {
+			t = [4]byte{aesSbox[t[1]] ^ rcon(i/4), aesSbox[t[2]], aesSbox[t[3]], aesSbox[t[0]]}
+		}
+		for j := range 4 {
+			w[i-4][j] = w[i][j] ^ t[j]
+		}
+	}
+
+	key := make([]byte, 0, aesBlockSize)
+	for _, word := range w[:4] {
+		key = append(key, word[:]...)
+	}
+	return key
+}
+
+// aesCipher is AES with any number of rounds.
+type aesCipher struct {
+	keys [][aesBlockSize]byte
+}
+
+// NewAES returns a cipher.Block for AES with a 16, 24, or 32-byte key,
+// implemented from FIPS 197 rather than with crypto/aes, so its rounds can be
+// taken apart with AESSubBytes, AESShiftRows, AESMixColumns, AESAddRoundKey,
+// and AESExpandKey. It's slow and not constant time. It has the signature of
+// aes.NewCipher, so it can be passed to WithCipher.
+func NewAES(key []byte) (cipher.Block, error) {
+	return NewAESRounds(key, aesRounds(len(key)))
+}
+
+// NewAESRounds is like NewAES, but with the given number of rounds instead
+// of 10, 12, or 14, for attacks on round-reduced AES. As in full AES, the
+// last round has no MixColumns.
+func NewAESRounds(key []byte, rounds int) (cipher.Block, error) {
+	if aesRounds(len(key)) == 0 {
+		return nil, errors.New("invalid key size")
+	}
+	if rounds <= 0 {
+		return nil, errors.New("invalid round count")
+	}
+	return &aesCipher{keys: AESExpandKey(key, rounds)}, nil
+}
+
+func (c *aesCipher) BlockSize() int {
+	return aesBlockSize
+}
+
+func (c *aesCipher) Encrypt(dst, src []byte) {
+	if len(src) < aesBlockSize || len(dst) < aesBlockSize {
+		panic("input not full block")
+	}
+
+	s := [aesBlockSize]byte(src)
+	n := len(c.keys) - 1
+	AESAddRoundKey(&s, &c.keys[0])
+	for i := 1; i <= n; i++ {
+		AESSubBytes(&s)
+		AESShiftRows(&s)
+		if i < n {
+			AESMixColumns(&s)
+		}
+		AESAddRoundKey(&s, &c.keys[i])
+	}
+	copy(dst, s[:])
+}
+
+func (c *aesCipher) Decrypt(dst, src []byte) {
+	if len(src) < aesBlockSize || len(dst) < aesBlockSize {
+		panic("input not full block")
+	}
+
+	s := [aesBlockSize]byte(src)
+	n := len(c.keys) - 1
+	for i := n; i >= 1; i-- {
+		AESAddRoundKey(&s, &c.keys[i])
+		if i < n {
+			AESInvMixColumns(&s)
+		}
+		AESInvShiftRows(&s)
+		AESInvSubBytes(&s)
+	}
+	AESAddRoundKey(&s, &c.keys[0])
+	copy(dst, s[:])
+}
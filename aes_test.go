@@ -0,0 +1,179 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+func TestAES(t *testing.T) {
+	// FIPS 197, appendix C.
+	pt := decodeHex(t, "00112233445566778899aabbccddeeff")
+	tests := []struct {
+		key, ct string
+	}{
+		{"000102030405060708090a0b0c0d0e0f", "69c4e0d86a7b0430d8cdb78070b4c55a"},
+		{"000102030405060708090a0b0c0d0e0f1011121314151617", "dda97ca4864cdfe06eaf70a0ec0d7191"},
+		{"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f", "8ea2b7ca516745bfeafc49904b496089"},
+	}
+	for _, tt := range tests {
+		block, err := NewAES(decodeHex(t, tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := decodeHex(t, tt.ct)
+
+		got := make([]byte, aesBlockSize)
+		block.Encrypt(got, pt)
+		if !bytes.Equal(got, want) {
+			t.Errorf("key %s: got %x, want %x", tt.key, got, want)
+		}
+		block.Decrypt(got, got)
+		if !bytes.Equal(got, pt) {
+			t.Errorf("key %s: decrypted to %x", tt.key, got)
+		}
+	}
+}
+
+func TestAESMatchesStdlib(t *testing.T) {
+	for _, size := range []int64{16, 24, 32} {
+		for range 50 {
+			key, pt := randBytes(size), randBytes(aesBlockSize)
+			ours, err := NewAES(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			theirs, err := aes.NewCipher(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, want := make([]byte, aesBlockSize), make([]byte, aesBlockSize)
+			ours.Encrypt(got, pt)
+			theirs.Encrypt(want, pt)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("key %x, plaintext %x: got %x, want %x", key, pt, got, want)
+			}
+		}
+	}
+}
+
+func TestAESRounds(t *testing.T) {
+	key, pt := randBytes(16), randBytes(aesBlockSize)
+	for _, rounds := range []int{1, 4, 10, 20} {
+		block, err := NewAESRounds(key, rounds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := make([]byte, aesBlockSize)
+		block.Encrypt(ct, pt)
+		got := make([]byte, aesBlockSize)
+		block.Decrypt(got, ct)
+		if !bytes.Equal(got, pt) {
+			t.Errorf("%d rounds: decrypted to %x, want %x", rounds, got, pt)
+		}
+	}
+
+	// One round is SubBytes, ShiftRows, and AddRoundKey around the first
+	// round key.
+	block, _ := NewAESRounds(key, 1)
+	keys := AESExpandKey(key, 1)
+	s := [aesBlockSize]byte(pt)
+	AESAddRoundKey(&s, &keys[0])
+	AESSubBytes(&s)
+	AESShiftRows(&s)
+	AESAddRoundKey(&s, &keys[1])
+	got := make([]byte, aesBlockSize)
+	block.Encrypt(got, pt)
+	if !bytes.Equal(got, s[:]) {
+		t.Errorf("1 round: got %x, want %x", got, s)
+	}
+
+	for _, rounds := range []int{0, -1} {
+		if _, err := NewAESRounds(key, rounds); err == nil {
+			t.Errorf("%d rounds: no error", rounds)
+		}
+	}
+	if _, err := NewAES(key[:15]); err == nil {
+		t.Error("15-byte key: no error")
+	}
+}
+
+func TestExpandKey(t *testing.T) {
+	// FIPS 197, appendix A.1.
+	keys := AESExpandKey(decodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c"), 10)
+	if len(keys) != 11 {
+		t.Fatalf("got %d round keys", len(keys))
+	}
+	if want := decodeHex(t, "a0fafe1788542cb123a339392a6c7605"); !bytes.Equal(keys[1][:], want) {
+		t.Errorf("round key 1: got %x, want %x", keys[1], want)
+	}
+	if want := decodeHex(t, "d014f9a8c9ee2589e13f0cc8b6630ca6"); !bytes.Equal(keys[10][:], want) {
+		t.Errorf("round key 10: got %x, want %x", keys[10], want)
+	}
+}
+
+func TestAESSteps(t *testing.T) {
+	var s [aesBlockSize]byte
+	for i := range s {
+		s[i] = byte(17 * i)
+	}
+	orig := s
+
+	// Each step undoes its inverse.
+	steps := []struct {
+		name     string
+		fwd, inv func(*[aesBlockSize]byte)
+	}{
+		{"AESSubBytes", AESSubBytes, AESInvSubBytes},
+		{"AESShiftRows", AESShiftRows, AESInvShiftRows},
+		{"AESMixColumns", AESMixColumns, AESInvMixColumns},
+	}
+	for _, st := range steps {
+		st.fwd(&s)
+		if s == orig {
+			t.Errorf("%s changed nothing", st.name)
+		}
+		st.inv(&s)
+		if s != orig {
+			t.Errorf("%s: inverse gives %x, want %x", st.name, s, orig)
+		}
+	}
+
+	// The S-box of 0x53 is 0xed, from FIPS 197, section 5.1.1, and the
+	// MixColumns column db 13 53 45 becomes 8e 4d a1 bc.
+	if aesSbox[0x53] != 0xed {
+		t.Errorf("S-box of 53: got %02x", aesSbox[0x53])
+	}
+	s = [aesBlockSize]byte{0xdb, 0x13, 0x53, 0x45}
+	AESMixColumns(&s)
+	if want := [4]byte{0x8e, 0x4d, 0xa1, 0xbc}; [4]byte(s[:4]) != want {
+		t.Errorf("AESMixColumns: got %x, want %x", s[:4], want)
+	}
+}
+
+func TestChallenge12FromScratchAES(t *testing.T) {
+	secret := []byte("the from-scratch aes plugs into the oracles")
+	enc := NewECBSuffixOracle(secret, WithCipher(NewAES, 16))
+
+	if got := RecoverECBSuffixOracleSecret(enc); !bytes.Equal(got, secret) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
+
+func TestInvertKeySchedule(t *testing.T) {
+	for range 20 {
+		key := randBytes(16)
+		for round, rk := range AESExpandKey(key, 14) {
+			if got := InvertKeySchedule(rk[:], round); !bytes.Equal(got, key) {
+				t.Fatalf("round %d: got %x, want %x", round, got, key)
+			}
+		}
+	}
+
+	// FIPS 197, appendix A.1.
+	want := decodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c")
+	if got := InvertKeySchedule(decodeHex(t, "d014f9a8c9ee2589e13f0cc8b6630ca6"), 10); !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package cryptopals
+
+import "unsafe"
+
+// anyOverlap reports whether x and y share memory at any (not necessarily
+// corresponding) index. The memory beyond the slice length is ignored.
+func anyOverlap(x, y []byte) bool {
+	return len(x) > 0 && len(y) > 0 &&
+		uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
+
+// inexactOverlap reports whether x and y share memory at any non-corresponding
+// index. The memory beyond the slice length is ignored. Note that x and y can
+// have different lengths and still not have any inexact overlap.
+//
+// inexactOverlap can be used to implement the requirements of the
+// crypto/cipher AEAD, Block, BlockMode and Stream interfaces, which allow dst
+// and src to be the same slice, but not to otherwise overlap.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+	return anyOverlap(x, y)
+}
+
+// checkBuffers panics unless dst is at least as long as src and dst[:len(src)]
+// either is src or doesn't overlap it, as the crypto/cipher interfaces
+// require of every mode in this package.
+func checkBuffers(dst, src []byte) {
+	if len(dst) < len(src) {
+		panic("dst too small")
+	}
+	if inexactOverlap(dst[:len(src)], src) {
+		panic("invalid buffer overlap")
+	}
+}
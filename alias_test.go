@@ -0,0 +1,64 @@
+package cryptopals
+
+import (
+	"crypto/aes"
+	"testing"
+)
+
+func TestOverlap(t *testing.T) {
+	b := make([]byte, 32)
+	tests := []struct {
+		x, y         []byte
+		any, inexact bool
+	}{
+		{b[:16], b[:16], true, false},
+		{b[:16], b[:8], true, false},
+		{b[:16], b[8:24], true, true},
+		{b[8:24], b[:16], true, true},
+		{b[:16], b[16:], false, false},
+		{b[:16], b[15:], true, true},
+		{b[:16], make([]byte, 16), false, false},
+		{b[:0], b[:16], false, false},
+		{nil, nil, false, false},
+	}
+	for i, tt := range tests {
+		if got := anyOverlap(tt.x, tt.y); got != tt.any {
+			t.Errorf("%d: anyOverlap = %v, want %v", i, got, tt.any)
+		}
+		if got := inexactOverlap(tt.x, tt.y); got != tt.inexact {
+			t.Errorf("%d: inexactOverlap = %v, want %v", i, got, tt.inexact)
+		}
+	}
+}
+
+func TestModesRejectInexactOverlap(t *testing.T) {
+	block, err := aes.NewCipher(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, 16)
+
+	modes := map[string]func(dst, src []byte){
+		"ecb":    NewECBEncrypter(block).CryptBlocks,
+		"cbc":    NewCBCDecrypter(block, iv).CryptBlocks,
+		"cfb":    NewCFBEncrypter(block, iv).XORKeyStream,
+		"ofb":    NewOFB(block, iv).XORKeyStream,
+		"xor":    NewRepeatingKeyXORCipher([]byte("key")).XORKeyStream,
+		"caesar": NewCaesarCipher(3).XORKeyStream,
+	}
+	for name, crypt := range modes {
+		buf := make([]byte, 64)
+
+		// In place is fine.
+		crypt(buf[:32], buf[:32])
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: want a panic for overlapping buffers", name)
+				}
+			}()
+			crypt(buf[16:48], buf[:32])
+		}()
+	}
+}
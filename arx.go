@@ -0,0 +1,108 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// arxBlockSize is the size of a ChaCha or Salsa20 block in bytes.
+const arxBlockSize = 64
+
+// An ARXCore is a ChaCha or Salsa20 block function with a configurable number
+// of rounds, ChaChaCore or SalsaCore. Round-reduced cores are for
+// experimenting with distinguishers; see DifferentialProfile.
+type ARXCore func(in *[16]uint32, rounds int) [16]uint32
+
+// checkARXRounds panics if rounds isn't positive.
+func checkARXRounds(rounds int) {
+	if rounds <= 0 {
+		panic("invalid round count")
+	}
+}
+
+// arxBytes returns a block function's output words in little-endian order.
+func arxBytes(x [16]uint32) [arxBlockSize]byte {
+	var b [arxBlockSize]byte
+	for i, v := range x {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
+
+// arxStream is a ChaCha or Salsa20 stream cipher: the core in counter mode.
+type arxStream struct {
+	state  [16]uint32
+	core   ARXCore
+	rounds int
+	step   func(s *[16]uint32) bool // Increments the counter, reporting whether it wrapped.
+	buf    [arxBlockSize]byte
+	off    int // Bytes of buf used.
+	done   bool
+}
+
+func (c *arxStream) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for i := range src {
+		if c.off == arxBlockSize {
+			if c.done {
+				panic("counter overflow")
+			}
+			c.buf = arxBytes(c.core(&c.state, c.rounds))
+			c.off = 0
+			c.done = c.step(&c.state)
+		}
+		dst[i] = src[i] ^ c.buf[c.off]
+		c.off++
+	}
+}
+
+// DifferentialProfile returns, for each of the 512 output bits of core with
+// rounds rounds, the fraction of samples random inputs for which flipping
+// input bit in flips that output bit. Bit i is bit i%32 of word i/32. Draw
+// the inputs deterministically with WithSeed.
+//
+// For a random function every fraction is near 1/2, within a few multiples
+// of 1/(2*sqrt(samples)). Fractions far from 1/2 are a differential
+// distinguisher. An attacker controls only the nonce and counter, so in
+// should be one of their bits: words 12 to 15 for ChaCha, 6 to 9 for
+// Salsa20. Comparing the two cores' profiles at the same round count shows
+// how much faster ChaCha diffuses a difference: after three rounds, a nonce
+// bit difference still flips some Salsa20 output bits almost always or
+// never, while ChaCha's fractions are within about 0.06 of 1/2. From four
+// rounds up, the block function's final addition hides single-bit output
+// differences of both; distinguishers on those look at the state before it.
+func DifferentialProfile(core ARXCore, rounds, in, samples int, opts ...Option) [512]float64 {
+	checkARXRounds(rounds)
+	if in < 0 || in >= 512 || samples <= 0 {
+		panic("invalid parameters")
+	}
+	o := newOptions(opts)
+	rng := o.newRand()
+
+	var counts [512]int
+	for range samples {
+		var x [16]uint32
+		for i := range x {
+			x[i] = uint32(rng.Uint64())
+		}
+		y := x
+		y[in/32] ^= 1 << (in % 32)
+
+		a, b := core(&x, rounds), core(&y, rounds)
+		for w := range a {
+			d := a[w] ^ b[w]
+			for d != 0 {
+				j := bits.TrailingZeros32(d)
+				counts[32*w+j]++
+				d &= d - 1
+			}
+		}
+	}
+
+	var res [512]float64
+	for i, c := range counts {
+		res[i] = float64(c) / float64(samples)
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package cryptopals
+
+import (
+	"math"
+	"testing"
+)
+
+// maxBias returns the largest distance from 1/2 in a differential profile.
+func maxBias(p [512]float64) float64 {
+	var m float64
+	for _, x := range p {
+		m = max(m, math.Abs(x-0.5))
+	}
+	return m
+}
+
+func TestDifferentialProfile(t *testing.T) {
+	// Flip the low bit of the second nonce word of each.
+	chacha, salsa := 14*32, 7*32
+
+	tests := []struct {
+		name     string
+		core     ARXCore
+		in       int
+		rounds   int
+		min, max float64
+	}{
+		{"ChaCha/2", ChaChaCore, chacha, 2, 0.5, 0.5},
+		{"Salsa/2", SalsaCore, salsa, 2, 0.5, 0.5},
+		{"ChaCha/3", ChaChaCore, chacha, 3, 0.035, 0.1},
+		{"Salsa/3", SalsaCore, salsa, 3, 0.45, 0.5},
+		{"ChaCha/20", ChaChaCore, chacha, 20, 0, 0.035},
+		{"Salsa/20", SalsaCore, salsa, 20, 0, 0.035},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := maxBias(DifferentialProfile(tt.core, tt.rounds, tt.in, 4000, WithSeed(1)))
+			if got < tt.min || got > tt.max {
+				t.Errorf("max bias %.3f, want in [%.3f, %.3f]", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestReducedRoundStreams(t *testing.T) {
+	key := randBytes(32)
+
+	// 20 rounds is ChaCha20, and fewer rounds differ.
+	nonce := randBytes(ChaCha20NonceSize)
+	a, b := make([]byte, 64), make([]byte, 64)
+	NewChaCha20(key, nonce, 0).XORKeyStream(a, a)
+	NewChaCha(key, nonce, 0, 20).XORKeyStream(b, b)
+	if string(a) != string(b) {
+		t.Error("NewChaCha with 20 rounds isn't ChaCha20")
+	}
+	NewChaCha(key, nonce, 0, 8).XORKeyStream(b, make([]byte, 64))
+	if string(a) == string(b) {
+		t.Error("ChaCha8 is ChaCha20")
+	}
+
+	nonce = randBytes(Salsa20NonceSize)
+	NewSalsa20(key, nonce).XORKeyStream(a, make([]byte, 64))
+	NewSalsa(key, nonce, 0, 8).XORKeyStream(b, make([]byte, 64))
+	if string(a) == string(b) {
+		t.Error("Salsa20/8 is Salsa20")
+	}
+}
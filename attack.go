@@ -0,0 +1,193 @@
+package cryptopals
+
+import (
+	"context"
+	"hash"
+	"time"
+)
+
+// An Oracle answers an attack's queries. *RemoteOracle,
+// *RemotePaddingOracle, and *RemoteBleichenbacherOracle are Oracles, and
+// OracleFunc and ValidityFunc adapt the oracles in this package to one.
+type Oracle interface {
+	Query(input []byte) ([]byte, error)
+}
+
+// OracleFunc adapts an oracle such as the one NewECBSuffixOracle returns to
+// an Oracle.
+type OracleFunc func([]byte) []byte
+
+// Query returns f(input).
+func (f OracleFunc) Query(input []byte) ([]byte, error) {
+	return f(input), nil
+}
+
+// ValidityFunc adapts an oracle that reports whether its input is valid, such
+// as CBCPaddingOracle.IsValid, to an Oracle.
+type ValidityFunc func([]byte) bool
+
+// Query answers {1} if f(input) is true and {0} otherwise.
+func (f ValidityFunc) Query(input []byte) ([]byte, error) {
+	return validityAnswer(f(input)), nil
+}
+
+func validityAnswer(valid bool) []byte {
+	if valid {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// An Attack recovers a secret by querying an Oracle. The attacks that take
+// one oracle have Attack types, so the CLI, the challenge runner, and
+// benchmarks can run them alike. RecoverBEASTSecret and RecoverPOODLESecret
+// play a victim and a server, or a victim and an injected script, against
+// each other, so they need two oracles and don't.
+type Attack interface {
+	Name() string // A short name, such as "cbc-padding-oracle".
+
+	// Run runs the attack until it recovers the secret, the oracle returns
+	// an error, or ctx is done. It returns the first error it gets.
+	Run(ctx context.Context, oracle Oracle) (*AttackResult, error)
+}
+
+// An AttackResult is the outcome of running an Attack.
+type AttackResult struct {
+	Secret  []byte
+	Usage   Usage // Oracle queries the attack made.
+	Elapsed time.Duration
+}
+
+// withStop makes an attack stop, as it does once over budget, when stop
+// returns an error, and return that error.
+func withStop(stop func() error) Option {
+	return func(o *options) {
+		o.stop = stop
+	}
+}
+
+// runAttack runs f with a query function that stops it if oracle fails or ctx
+// is done, and measures it. Usage is also added to any WithUsage total in
+// opts.
+//
+// Once a query fails, query answers nil without calling oracle, and the
+// attack's meter reports the failure, so f returns it through its loops'
+// budget checks.
+func runAttack(ctx context.Context, oracle Oracle, opts []Option, f func(query func([]byte) []byte, opts []Option) ([]byte, error)) (*AttackResult, error) {
+	var usage Usage
+	defer func() {
+		if u := newOptions(opts).usage; u != nil {
+			u.Queries += usage.Queries
+			u.Bytes += usage.Bytes
+		}
+	}()
+
+	var failed error
+	query := func(input []byte) []byte {
+		if failed == nil {
+			failed = ctx.Err()
+		}
+		if failed != nil {
+			return nil
+		}
+		out, err := oracle.Query(input)
+		if err != nil {
+			failed = err
+			return nil
+		}
+		return out
+	}
+
+	start := time.Now()
+	opts = append(opts[:len(opts):len(opts)], WithUsage(&usage), withStop(func() error { return failed }))
+	secret, err := f(query, opts)
+	if failed != nil {
+		return nil, failed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &AttackResult{Secret: secret, Usage: usage, Elapsed: time.Since(start)}, nil
+}
+
+// isValid returns a validity oracle that reads query's answers as ValidityFunc
+// writes them.
+func isValid(query func([]byte) []byte) func([]byte) bool {
+	return func(input []byte) bool {
+		out := query(input)
+		return len(out) == 1 && out[0] == 1
+	}
+}
+
+// An ECBSuffixAttack runs RecoverECBSuffixOracleSecretErr against an
+// encryption oracle.
+type ECBSuffixAttack struct {
+	Options []Option
+}
+
+// Name returns "ecb-suffix".
+func (a *ECBSuffixAttack) Name() string { return "ecb-suffix" }
+
+// Run runs the attack.
+func (a *ECBSuffixAttack) Run(ctx context.Context, oracle Oracle) (*AttackResult, error) {
+	return runAttack(ctx, oracle, a.Options, func(query func([]byte) []byte, opts []Option) ([]byte, error) {
+		return RecoverECBSuffixOracleSecretErr(query, opts...)
+	})
+}
+
+// A CBCPaddingOracleAttack runs RecoverCBCPaddingOracleSecret against a
+// padding oracle, such as ValidityFunc(p.IsValid) or a
+// *RemotePaddingOracle.
+type CBCPaddingOracleAttack struct {
+	Ciphertext []byte // IV || ciphertext.
+	BlockSize  int
+	Options    []Option
+}
+
+// Name returns "cbc-padding-oracle".
+func (a *CBCPaddingOracleAttack) Name() string { return "cbc-padding-oracle" }
+
+// Run runs the attack.
+func (a *CBCPaddingOracleAttack) Run(ctx context.Context, oracle Oracle) (*AttackResult, error) {
+	return runAttack(ctx, oracle, a.Options, func(query func([]byte) []byte, opts []Option) ([]byte, error) {
+		return RecoverCBCPaddingOracleSecret(a.Ciphertext, a.BlockSize, isValid(query), opts...)
+	})
+}
+
+// A BleichenbacherAttack runs RecoverBleichenbacherPlaintext against a
+// PKCS #1 v1.5 conformance oracle, such as ValidityFunc(o.Conforms).
+type BleichenbacherAttack struct {
+	Pub        *RSAPublicKey
+	Ciphertext []byte
+	Options    []Option
+}
+
+// Name returns "bleichenbacher".
+func (a *BleichenbacherAttack) Name() string { return "bleichenbacher" }
+
+// Run runs the attack.
+func (a *BleichenbacherAttack) Run(ctx context.Context, oracle Oracle) (*AttackResult, error) {
+	return runAttack(ctx, oracle, a.Options, func(query func([]byte) []byte, opts []Option) ([]byte, error) {
+		return RecoverBleichenbacherPlaintext(a.Pub, a.Ciphertext, isValid(query), opts...)
+	})
+}
+
+// A MangerAttack runs RecoverMangerPlaintext against an OAEP oracle, such as
+// ValidityFunc(m.Conforms).
+type MangerAttack struct {
+	NewHash    func() hash.Hash
+	Pub        *RSAPublicKey
+	Ciphertext []byte
+	Label      []byte
+	Options    []Option
+}
+
+// Name returns "manger".
+func (a *MangerAttack) Name() string { return "manger" }
+
+// Run runs the attack.
+func (a *MangerAttack) Run(ctx context.Context, oracle Oracle) (*AttackResult, error) {
+	return runAttack(ctx, oracle, a.Options, func(query func([]byte) []byte, opts []Option) ([]byte, error) {
+		return RecoverMangerPlaintext(a.NewHash, a.Pub, a.Ciphertext, a.Label, isValid(query), opts...)
+	})
+}
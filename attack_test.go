@@ -0,0 +1,149 @@
+package cryptopals
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestAttacks(t *testing.T) {
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+	msg := []byte("kick it, CC")
+	ct, err := EncryptPKCS1v15(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("every attack runs the same way")
+	p := NewCBCPaddingOracle(secret)
+
+	tests := []struct {
+		attack Attack
+		oracle Oracle
+		want   []byte
+	}{
+		{&ECBSuffixAttack{}, OracleFunc(NewECBSuffixOracle(secret)), secret},
+		{&CBCPaddingOracleAttack{Ciphertext: p.Ciphertext(), BlockSize: aes.BlockSize}, ValidityFunc(p.IsValid), secret},
+		{&BleichenbacherAttack{Pub: pub, Ciphertext: ct}, ValidityFunc(NewBleichenbacherOracle(k).Conforms), msg},
+	}
+	for _, tt := range tests {
+		res, err := tt.attack.Run(context.Background(), tt.oracle)
+		if err != nil {
+			t.Errorf("%s: %v", tt.attack.Name(), err)
+			continue
+		}
+		if !bytes.Equal(res.Secret, tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.attack.Name(), tt.want, res.Secret)
+		}
+		if res.Usage.Queries == 0 {
+			t.Errorf("%s: want nonzero queries", tt.attack.Name())
+		}
+		t.Logf("%s: %d queries in %v", tt.attack.Name(), res.Usage.Queries, res.Elapsed)
+	}
+}
+
+func TestAttackErrors(t *testing.T) {
+	secret := []byte("stopped early")
+	oracle := OracleFunc(NewECBSuffixOracle(secret))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := (&ECBSuffixAttack{}).Run(ctx, oracle); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+
+	a := &ECBSuffixAttack{Options: []Option{WithBudget(Budget{Queries: 3})}}
+	if _, err := a.Run(context.Background(), oracle); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("want ErrBudgetExceeded, got %v", err)
+	}
+
+	errDown := errors.New("oracle down")
+	failing := errOracle(func([]byte) ([]byte, error) { return nil, errDown })
+	p := NewCBCPaddingOracle(secret)
+	b := &CBCPaddingOracleAttack{Ciphertext: p.Ciphertext(), BlockSize: aes.BlockSize}
+	if _, err := b.Run(context.Background(), failing); !errors.Is(err, errDown) {
+		t.Errorf("want the oracle's error, got %v", err)
+	}
+}
+
+// errOracle adapts a function that can fail to an Oracle.
+type errOracle func([]byte) ([]byte, error)
+
+func (f errOracle) Query(input []byte) ([]byte, error) { return f(input) }
+
+func TestBleichenbacherAttackRemote(t *testing.T) {
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+	msg := []byte("over the wire")
+	ct, err := EncryptPKCS1v15(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewBleichenbacherOracle(k)
+	addr := listen(t, func(l net.Listener) { ServeBleichenbacherOracle(l, o) })
+
+	r, err := DialBleichenbacherOracle(addr, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	res, err := (&BleichenbacherAttack{Pub: pub, Ciphertext: ct}).Run(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Secret, msg) {
+		t.Errorf("want %q, got %q", msg, res.Secret)
+	}
+}
+
+func TestAttackOracleFailsMidway(t *testing.T) {
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptPKCS1v15(&k.RSAPublicKey, []byte("kick it, CC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("the oracle goes down partway")
+	p := NewCBCPaddingOracle(secret)
+
+	tests := []struct {
+		attack Attack
+		oracle Oracle
+	}{
+		{&ECBSuffixAttack{}, OracleFunc(NewECBSuffixOracle(secret))},
+		{&CBCPaddingOracleAttack{Ciphertext: p.Ciphertext(), BlockSize: aes.BlockSize}, ValidityFunc(p.IsValid)},
+		{&BleichenbacherAttack{Pub: &k.RSAPublicKey, Ciphertext: ct}, ValidityFunc(NewBleichenbacherOracle(k).Conforms)},
+	}
+	errDown := errors.New("oracle down")
+	for _, tt := range tests {
+		// The oracle fails after 20 queries, and the attack should stop
+		// there rather than query a dead oracle.
+		var calls int
+		failing := errOracle(func(input []byte) ([]byte, error) {
+			calls++
+			if calls > 20 {
+				return nil, errDown
+			}
+			return tt.oracle.Query(input)
+		})
+		if _, err := tt.attack.Run(context.Background(), failing); !errors.Is(err, errDown) {
+			t.Errorf("%s: want the oracle's error, got %v", tt.attack.Name(), err)
+		}
+		if calls != 21 {
+			t.Errorf("%s: %d queries, want 21", tt.attack.Name(), calls)
+		}
+	}
+}
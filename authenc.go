@@ -0,0 +1,190 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"slices"
+	"time"
+)
+
+// ErrInvalidMAC is returned when a message fails authentication.
+var ErrInvalidMAC = errors.New("invalid mac")
+
+// An AuthCipher encrypts and authenticates messages.
+//
+// Seal returns a new slice. Open returns ErrInvalidMAC or ErrInvalidPadding
+// if the ciphertext was tampered with, depending on which check fails first.
+type AuthCipher interface {
+	Seal(pt []byte) []byte
+	Open(ct []byte) ([]byte, error)
+}
+
+// encryptThenMAC implements CBC encryption followed by an HMAC of the
+// ciphertext.
+type encryptThenMAC struct {
+	block  cipher.Block
+	macKey []byte
+	h      func() hash.Hash
+}
+
+// NewEncryptThenMAC returns an AuthCipher that encrypts under b in CBC mode
+// with PKCS #7 padding, then appends an HMAC of iv || ct using h and macKey.
+//
+// Open checks the MAC before decrypting, so tampered ciphertexts are rejected
+// with ErrInvalidMAC without revealing anything about their padding.
+func NewEncryptThenMAC(b cipher.Block, macKey []byte, h func() hash.Hash) AuthCipher {
+	return &encryptThenMAC{block: b, macKey: slices.Clone(macKey), h: h}
+}
+
+func (e *encryptThenMAC) mac(b []byte) []byte {
+	m := hmac.New(e.h, e.macKey)
+	m.Write(b)
+	return m.Sum(nil)
+}
+
+// Seal returns iv || ct || mac(iv || ct).
+func (e *encryptThenMAC) Seal(pt []byte) []byte {
+	ct := EncryptPadded(e.block, pt)
+	return append(ct, e.mac(ct)...)
+}
+
+func (e *encryptThenMAC) Open(ct []byte) ([]byte, error) {
+	n := e.h().Size()
+	if len(ct) < n {
+		return nil, ErrInvalidMAC
+	}
+
+	ct, tag := ct[:len(ct)-n], ct[len(ct)-n:]
+	if !hmac.Equal(tag, e.mac(ct)) {
+		return nil, ErrInvalidMAC
+	}
+
+	return DecryptPadded(e.block, ct)
+}
+
+// macThenEncrypt implements an HMAC of the plaintext followed by CBC
+// encryption of both, as in TLS before version 1.3.
+type macThenEncrypt struct {
+	block  cipher.Block
+	macKey []byte
+	h      func() hash.Hash
+}
+
+// NewMACThenEncrypt returns an AuthCipher that appends an HMAC of the
+// plaintext using h and macKey, then encrypts both under b in CBC mode with
+// PKCS #7 padding.
+//
+// Open has to decrypt and check the padding before it can check the MAC. It
+// returns early when the padding is invalid, so how long it takes reveals
+// whether the padding was valid. See RecoverMACThenEncryptSecret.
+func NewMACThenEncrypt(b cipher.Block, macKey []byte, h func() hash.Hash) AuthCipher {
+	return &macThenEncrypt{block: b, macKey: slices.Clone(macKey), h: h}
+}
+
+func (e *macThenEncrypt) mac(b []byte) []byte {
+	m := hmac.New(e.h, e.macKey)
+	m.Write(b)
+	return m.Sum(nil)
+}
+
+// Seal returns iv || encrypt(pad(pt || mac(pt))).
+func (e *macThenEncrypt) Seal(pt []byte) []byte {
+	return EncryptPadded(e.block, slices.Concat(pt, e.mac(pt)))
+}
+
+func (e *macThenEncrypt) Open(ct []byte) ([]byte, error) {
+	pt, err := DecryptPadded(e.block, ct)
+	if err != nil {
+		return nil, err
+	}
+
+	n := e.h().Size()
+	if len(pt) < n {
+		return nil, ErrInvalidMAC
+	}
+
+	pt, tag := pt[:len(pt)-n], pt[len(pt)-n:]
+	if !hmac.Equal(tag, e.mac(pt)) {
+		return nil, ErrInvalidMAC
+	}
+
+	return pt, nil
+}
+
+// timingSamples is how many times a timing attack repeats each measurement.
+// Taking the fastest sample filters out most scheduling noise.
+const timingSamples = 15
+
+// minDuration returns the shortest of n calls to f, timed with c.
+func minDuration(c clock, n int, f func()) time.Duration {
+	best := time.Duration(1<<63 - 1)
+	for range n {
+		start := c.Now()
+		f()
+		best = min(best, c.Now().Sub(start))
+	}
+	return best
+}
+
+// RecoverMACThenEncryptSecret decrypts iv || ct, a ciphertext sealed by a
+// MAC-then-encrypt AuthCipher, using only how long open takes to reject
+// forged ciphertexts. macSize is the size of the MAC in bytes.
+//
+// Like Lucky13, this turns the early return on invalid padding into a
+// padding oracle for RecoverCBCPaddingOracleSecret, even if open returns the
+// same error for every failure. Forged ciphertexts are prefixed with extra
+// blocks so that their plaintexts are long enough to hold a MAC, since
+// otherwise open would return early for that reason too.
+//
+// It returns the plaintext without the MAC. Timing is noisy, so it returns an
+// error if the recovered plaintext isn't validly padded.
+func RecoverMACThenEncryptSecret(ct []byte, blockSize, macSize int, open func([]byte) error, opts ...Option) ([]byte, error) {
+	if len(ct)%blockSize != 0 || len(ct) < 2*blockSize {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	o := newOptions(opts)
+
+	// With this prefix, forged plaintexts are at least macSize bytes after
+	// removing a full block of padding.
+	prefix := make([]byte, blockSize*(2+(macSize+blockSize-1)/blockSize))
+
+	forged := func(input []byte) []byte {
+		return slices.Concat(prefix, input)
+	}
+	elapsed := func(input []byte, n int) time.Duration {
+		b := forged(input)
+		return minDuration(o.clock, n, func() { open(b) })
+	}
+
+	// The last two blocks of ct have valid padding. Changing the last byte
+	// of the first one makes the padding invalid. Calibration is done once,
+	// so it can afford more samples.
+	tail := slices.Clone(ct[len(ct)-2*blockSize:])
+	slow := elapsed(tail, 10*timingSamples)
+
+	tail[blockSize-1] ^= 0xff
+	fast := elapsed(tail, 10*timingSamples)
+
+	if slow <= fast {
+		return nil, errors.New("no timing difference between valid and invalid padding")
+	}
+
+	threshold := (slow + fast) / 2
+	o.debug("calibrated timing", "valid", slow, "invalid", fast, "threshold", threshold)
+
+	valid := func(input []byte) bool {
+		return elapsed(input, timingSamples) >= threshold
+	}
+
+	pt, err := RecoverCBCPaddingOracleSecret(ct, blockSize, valid, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pt) < macSize {
+		return nil, ErrInvalidMAC
+	}
+	return pt[:len(pt)-macSize], nil
+}
@@ -0,0 +1,191 @@
+package cryptopals
+
+import "fmt"
+
+// A Base64Codec is a Base64 encoding written from scratch, in the spirit of
+// challenge 1, which asks you to operate on raw bytes rather than lean on a
+// library. It encodes and decodes as encoding/base64 does, except that it
+// doesn't skip newlines when decoding.
+type Base64Codec struct {
+	alphabet string
+	decode   [256]byte // Each byte's value, or 0xff if it's not in the alphabet.
+	padding  bool
+}
+
+// The codecs for the alphabets of RFC 4648, with and without "=" padding.
+var (
+	StdBase64Codec    = NewBase64Codec("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/", true)
+	URLBase64Codec    = NewBase64Codec("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_", true)
+	RawStdBase64Codec = NewBase64Codec(StdBase64Codec.alphabet, false)
+	RawURLBase64Codec = NewBase64Codec(URLBase64Codec.alphabet, false)
+)
+
+// WithScratchEncodings makes HexToBase64 use the codecs written from scratch
+// in this package, StdBase64Codec and LowerHexCodec, rather than the
+// standard library's.
+func WithScratchEncodings() Option {
+	return func(o *options) {
+		o.scratchEncodings = true
+	}
+}
+
+// NewBase64Codec returns a codec for the 64-byte alphabet, which pads
+// encodings to a multiple of 4 bytes with "=" if padding is set.
+//
+// It panics if the alphabet isn't 64 distinct bytes, or contains "=", "\r",
+// or "\n".
+func NewBase64Codec(alphabet string, padding bool) *Base64Codec {
+	if len(alphabet) != 64 {
+		panic("alphabet isn't 64 bytes")
+	}
+
+	c := &Base64Codec{alphabet: alphabet, padding: padding}
+	for i := range c.decode {
+		c.decode[i] = 0xff
+	}
+	for i := range len(alphabet) {
+		b := alphabet[i]
+		if b == '=' || b == '\r' || b == '\n' || c.decode[b] != 0xff {
+			panic("invalid alphabet")
+		}
+		c.decode[b] = byte(i)
+	}
+	return c
+}
+
+// EncodedLen returns the length of the encoding of n bytes.
+func (c *Base64Codec) EncodedLen(n int) int {
+	if c.padding {
+		return (n + 2) / 3 * 4
+	}
+	return (n*8 + 5) / 6
+}
+
+// DecodedLen returns the most bytes that n bytes of encoding decode to.
+func (c *Base64Codec) DecodedLen(n int) int {
+	if c.padding {
+		return n / 4 * 3
+	}
+	return n * 6 / 8
+}
+
+// Encode writes the encoding of src to dst, which must be at least
+// EncodedLen(len(src)) bytes.
+func (c *Base64Codec) Encode(dst, src []byte) {
+	// Each 3 bytes are 24 bits, which are 4 sextets.
+	for len(src) >= 3 {
+		v := uint(src[0])<<16 | uint(src[1])<<8 | uint(src[2])
+		dst[0] = c.alphabet[v>>18&0x3f]
+		dst[1] = c.alphabet[v>>12&0x3f]
+		dst[2] = c.alphabet[v>>6&0x3f]
+		dst[3] = c.alphabet[v&0x3f]
+		src, dst = src[3:], dst[4:]
+	}
+
+	// A last 1 or 2 bytes make 2 or 3 sextets, the last padded with zero
+	// bits.
+	if len(src) == 0 {
+		return
+	}
+	v := uint(src[0]) << 16
+	if len(src) == 2 {
+		v |= uint(src[1]) << 8
+	}
+	dst[0] = c.alphabet[v>>18&0x3f]
+	dst[1] = c.alphabet[v>>12&0x3f]
+	if len(src) == 2 {
+		dst[2] = c.alphabet[v>>6&0x3f]
+	} else if c.padding {
+		dst[2] = '='
+	}
+	if c.padding {
+		dst[3] = '='
+	}
+}
+
+// EncodeToString returns the encoding of src.
+func (c *Base64Codec) EncodeToString(src []byte) string {
+	dst := make([]byte, c.EncodedLen(len(src)))
+	c.Encode(dst, src)
+	return string(dst)
+}
+
+// A CorruptInputError is the offset of the first invalid byte in an
+// encoding, or the length of the encoding if it ends too soon.
+type CorruptInputError int64
+
+func (e CorruptInputError) Error() string {
+	return fmt.Sprintf("illegal encoded data at input byte %d", int64(e))
+}
+
+// Decode writes the bytes that src encodes to dst, which must be at least
+// DecodedLen(len(src)) bytes, and returns how many it wrote. It returns a
+// CorruptInputError if src isn't a valid encoding, such as if it has the
+// wrong padding.
+func (c *Base64Codec) Decode(dst, src []byte) (int, error) {
+	n := 0
+	for i := 0; i < len(src); i += 4 {
+		// Read up to 4 sextets, stopping at padding or the end.
+		var v uint
+		j := 0
+		for ; j < 4 && i+j < len(src); j++ {
+			b := src[i+j]
+			if b == '=' {
+				break
+			}
+			d := c.decode[b]
+			if d == 0xff {
+				return n, CorruptInputError(i + j)
+			}
+			v = v<<6 | uint(d)
+		}
+
+		if j == 4 {
+			dst[n], dst[n+1], dst[n+2] = byte(v>>16), byte(v>>8), byte(v)
+			n += 3
+			continue
+		}
+		if j < 2 {
+			// A sextet alone isn't a whole byte.
+			return n, CorruptInputError(i + j)
+		}
+
+		// A short group ends the encoding, with padding only if the codec
+		// pads.
+		end := i + j
+		if c.padding {
+			pad := 4 - j
+			if len(src) < end+pad {
+				return n, CorruptInputError(len(src))
+			}
+			for k := range pad {
+				if src[end+k] != '=' {
+					return n, CorruptInputError(end + k)
+				}
+			}
+			end += pad
+		}
+		if end < len(src) {
+			return n, CorruptInputError(end)
+		}
+
+		// 2 or 3 sextets hold 1 or 2 bytes, and the leftover bits are
+		// ignored.
+		v <<= 6 * uint(4-j)
+		dst[n] = byte(v >> 16)
+		n++
+		if j == 3 {
+			dst[n] = byte(v >> 8)
+			n++
+		}
+		return n, nil
+	}
+	return n, nil
+}
+
+// DecodeString returns the bytes that s encodes.
+func (c *Base64Codec) DecodeString(s string) ([]byte, error) {
+	dst := make([]byte, c.DecodedLen(len(s)))
+	n, err := c.Decode(dst, []byte(s))
+	return dst[:n], err
+}
@@ -0,0 +1,95 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// base64Codecs pairs each codec with the encoding/base64 encoding it matches.
+var base64Codecs = []struct {
+	codec *Base64Codec
+	std   *base64.Encoding
+}{
+	{StdBase64Codec, base64.StdEncoding},
+	{URLBase64Codec, base64.URLEncoding},
+	{RawStdBase64Codec, base64.RawStdEncoding},
+	{RawURLBase64Codec, base64.RawURLEncoding},
+}
+
+func TestBase64CodecRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, c := range base64Codecs {
+		for n := range 100 {
+			data := make([]byte, n)
+			for i := range data {
+				data[i] = byte(r.Uint32())
+			}
+
+			enc := c.codec.EncodeToString(data)
+			if want := c.std.EncodeToString(data); enc != want {
+				t.Fatalf("encode %x: want %q, got %q", data, want, enc)
+			}
+
+			got, err := c.codec.DecodeString(enc)
+			if err != nil {
+				t.Fatalf("decode %q: %v", enc, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("decode %q: want %x, got %x", enc, data, got)
+			}
+		}
+	}
+}
+
+func TestBase64CodecErrors(t *testing.T) {
+	tests := []struct {
+		codec *Base64Codec
+		in    string
+		off   int64
+	}{
+		{StdBase64Codec, "SGk", 3},      // Missing padding.
+		{StdBase64Codec, "SGk=SGk=", 4}, // Data after padding.
+		{StdBase64Codec, "S===", 1},     // One sextet.
+		{StdBase64Codec, "SG=x", 3},     // Bad padding.
+		{StdBase64Codec, "SG-_", 2},     // URL alphabet.
+		{RawStdBase64Codec, "SGk=", 3},  // Padding in a raw encoding.
+		{URLBase64Codec, "SG+/", 2},     // Standard alphabet.
+	}
+	for _, tt := range tests {
+		_, err := tt.codec.DecodeString(tt.in)
+		var cerr CorruptInputError
+		if !errors.As(err, &cerr) {
+			t.Errorf("%q: want a CorruptInputError, got %v", tt.in, err)
+			continue
+		}
+		if int64(cerr) != tt.off {
+			t.Errorf("%q: want offset %d, got %d", tt.in, tt.off, cerr)
+		}
+	}
+}
+
+func FuzzBase64Codec(f *testing.F) {
+	for _, s := range []string{"", "SGk=", "SGk", "SGVsbG8sIHdvcmxk", "S===", "-_-_", "+/+/"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		// encoding/base64 skips newlines, which the codecs don't.
+		if strings.ContainsAny(s, "\r\n") {
+			return
+		}
+		for _, c := range base64Codecs {
+			got, err := c.codec.DecodeString(s)
+			want, stdErr := c.std.DecodeString(s)
+			if (err == nil) != (stdErr == nil) {
+				t.Fatalf("%q: got error %v, encoding/base64 got %v", s, err, stdErr)
+			}
+			if err == nil && !bytes.Equal(got, want) {
+				t.Fatalf("%q: want %x, got %x", s, want, got)
+			}
+		}
+	})
+}
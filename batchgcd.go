@@ -0,0 +1,161 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	mrand "math/rand/v2"
+)
+
+// productTree returns the levels of the product tree of xs: the first level
+// is xs, each next level holds the products of adjacent pairs of the one
+// before, with an odd one out carried up, and the last holds the product of
+// them all.
+func productTree(xs []*big.Int) [][]*big.Int {
+	tree := [][]*big.Int{xs}
+	for level := xs; len(level) > 1; {
+		next := make([]*big.Int, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = new(big.Int).Mul(level[2*i], level[2*i+1])
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	return tree
+}
+
+// BatchGCD returns, for each of the moduli ns, its gcd with the product of
+// all the others, with Bernstein's product and remainder trees. A result of
+// 1 means the modulus shares no factor with any other; anything else is a
+// shared factor, or the modulus itself if all its factors are shared.
+//
+// Computing the gcd of every pair takes time quadratic in len(ns). Instead,
+// it multiplies the moduli up a product tree to get their product P, then
+// reduces P down the tree mod the square of each node, which leaves P mod
+// n^2 at each leaf n. (P mod n^2) / n is the product of the others mod n,
+// and its gcd with n is the result. That takes quasilinear time, which is
+// how surveys of millions of keys found the ones sharing primes.
+func BatchGCD(ns []*big.Int) []*big.Int {
+	if len(ns) == 0 {
+		return nil
+	}
+	tree := productTree(ns)
+
+	rems := tree[len(tree)-1]
+	for i := len(tree) - 2; i >= 0; i-- {
+		level := tree[i]
+		next := make([]*big.Int, len(level))
+		sq := new(big.Int)
+		for j, x := range level {
+			next[j] = new(big.Int).Mod(rems[j/2], sq.Mul(x, x))
+		}
+		rems = next
+	}
+
+	res := make([]*big.Int, len(ns))
+	for i, n := range ns {
+		z := new(big.Int).Quo(rems[i], n)
+		res[i] = z.GCD(nil, nil, z, n)
+	}
+	return res
+}
+
+// FactorSharedPrimes returns the private keys for those of keys whose
+// moduli share a prime with another's, found with BatchGCD, and nil for the
+// rest.
+func FactorSharedPrimes(keys []*RSAPublicKey) []*RSAPrivateKey {
+	ns := make([]*big.Int, len(keys))
+	for i, k := range keys {
+		ns[i] = k.N
+	}
+	gs := BatchGCD(ns)
+
+	one := big.NewInt(1)
+	res := make([]*RSAPrivateKey, len(keys))
+	for i, g := range gs {
+		n := ns[i]
+		if g.Cmp(one) == 0 {
+			continue
+		}
+		if g.Cmp(n) == 0 {
+			// Both primes are shared, perhaps with different keys, so
+			// look for one of them the slow way.
+			g = nil
+			for j, m := range ns {
+				if d := new(big.Int).GCD(nil, nil, n, m); j != i && d.Cmp(one) != 0 && d.Cmp(n) != 0 {
+					g = d
+					break
+				}
+			}
+			if g == nil {
+				continue
+			}
+		}
+		res[i] = rsaKeyFromFactors(keys[i], g, new(big.Int).Quo(n, g))
+	}
+	return res
+}
+
+// GenerateSharedPrimeCorpus returns count RSA keys with moduli of bits bits
+// and the public exponent e, of which shared have a prime in common with at
+// least one other, in random order, as devices that generate keys with
+// little entropy at boot do. The shared keys take their first prime from a
+// pool of shared/2 primes, two or three keys to each, and their second is
+// distinct. It returns an error if bits is less than 16, if e isn't odd and
+// at least 3, or if shared is 1 or more than count.
+func GenerateSharedPrimeCorpus(count, bits int, e int64, shared int) ([]*RSAPrivateKey, error) {
+	if bits < 16 {
+		return nil, errors.New("modulus too small")
+	}
+	if e < 3 || e%2 == 0 {
+		return nil, errors.New("invalid public exponent")
+	}
+	if shared == 1 || shared < 0 || shared > count {
+		return nil, errors.New("invalid number of shared keys")
+	}
+
+	// A pool prime p with gcd(e, p-1) > 1 would make every key that takes
+	// it invalid, so leave those out.
+	E := big.NewInt(e)
+	pool := make([]*big.Int, shared/2)
+	for i := range pool {
+		for pool[i] == nil {
+			p, err := rand.Prime(rand.Reader, (bits+1)/2)
+			if err != nil {
+				return nil, err
+			}
+			pm1 := new(big.Int).Sub(p, big.NewInt(1))
+			if new(big.Int).GCD(nil, nil, E, pm1).Cmp(big.NewInt(1)) == 0 {
+				pool[i] = p
+			}
+		}
+	}
+
+	keys := make([]*RSAPrivateKey, count)
+	for i := range keys {
+		for keys[i] == nil {
+			var p *big.Int
+			var err error
+			if i < shared {
+				p = pool[i%len(pool)]
+			} else if p, err = rand.Prime(rand.Reader, (bits+1)/2); err != nil {
+				return nil, err
+			}
+			q, err := rand.Prime(rand.Reader, bits/2)
+			if err != nil {
+				return nil, err
+			}
+			pub := &RSAPublicKey{N: new(big.Int).Mul(p, q), E: big.NewInt(e)}
+			if p.Cmp(q) == 0 || pub.N.BitLen() != bits {
+				continue
+			}
+			keys[i] = rsaKeyFromFactors(pub, p, q)
+		}
+	}
+	mrand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	return keys, nil
+}
@@ -0,0 +1,79 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBatchGCD(t *testing.T) {
+	ns := []*big.Int{
+		big.NewInt(3 * 5), big.NewInt(7 * 11), big.NewInt(5 * 13),
+		big.NewInt(17 * 19), big.NewInt(11 * 23),
+	}
+	want := []int64{5, 11, 5, 1, 11}
+	for i, g := range BatchGCD(ns) {
+		if g.Int64() != want[i] {
+			t.Errorf("modulus %v: got %v, want %d", ns[i], g, want[i])
+		}
+	}
+}
+
+func TestFactorSharedPrimes(t *testing.T) {
+	keys, err := GenerateSharedPrimeCorpus(300, 256, 65537, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Plant a key that shares both its primes, with two other keys.
+	var c *RSAPrivateKey
+	for i := 0; c == nil; i++ {
+		a, b := keys[i], keys[i+1]
+		c = rsaKeyFromFactors(&RSAPublicKey{N: new(big.Int).Mul(a.P, b.P), E: a.E}, a.P, b.P)
+	}
+	keys = append(keys, c)
+
+	pubs := make([]*RSAPublicKey, len(keys))
+	for i, k := range keys {
+		pubs[i] = &k.RSAPublicKey
+	}
+	found := FactorSharedPrimes(pubs)
+
+	shared := make(map[string]int)
+	for _, k := range keys {
+		shared[k.P.String()]++
+		shared[k.Q.String()]++
+	}
+	var n int
+	for i, k := range keys {
+		want := shared[k.P.String()] > 1 || shared[k.Q.String()] > 1
+		if got := found[i] != nil; got != want {
+			t.Errorf("key %d: factored %v, want %v", i, got, want)
+			continue
+		}
+		if found[i] != nil {
+			n++
+			if found[i].D.Cmp(k.D) != 0 {
+				t.Errorf("key %d: wrong private exponent", i)
+			}
+		}
+	}
+	if n < 10 {
+		t.Errorf("factored %d keys, want at least 10", n)
+	}
+}
+
+func TestGenerateSharedPrimeCorpusSmallExponent(t *testing.T) {
+	// With e = 3, about half of all primes p have 3 dividing p-1, so a pool
+	// prime like that would leave its keys impossible to generate.
+	for range 5 {
+		keys, err := GenerateSharedPrimeCorpus(8, 128, 3, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := big.NewInt(42)
+		for _, k := range keys {
+			if c := k.Decrypt(k.Encrypt(m)); c.Cmp(m) != 0 {
+				t.Errorf("got %v, want %v", c, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package cryptopals
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sync"
+)
+
+// BEASTOracle models a TLS 1.0 connection, where each CBC record uses the
+// last ciphertext block of the previous record as its IV.
+//
+// Request is a victim's browser that sends path || secret for a path the
+// attacker chooses. Inject sends a record holding plaintext of the
+// attacker's choice, such as from a script running in the victim's browser.
+// Both return the record as it appears on the wire.
+type BEASTOracle struct {
+	mu     sync.Mutex
+	mode   CBCMode
+	secret []byte
+	o      *options
+}
+
+// NewBEASTOracle returns a new BEAST oracle for secret under a random key and
+// initial IV. The key is 16 bytes unless set with WithKeySize, and WithCipher
+// replaces AES.
+func NewBEASTOracle(secret []byte, opts ...Option) *BEASTOracle {
+	o := newOptions(opts)
+	block := o.newBlock(randBytes(int64(o.keySize)))
+	return &BEASTOracle{
+		mode:   NewCBCEncrypter(block, randBytes(int64(block.BlockSize()))),
+		secret: secret,
+		o:      o,
+	}
+}
+
+// BlockSize returns the block size of the oracle's cipher.
+func (b *BEASTOracle) BlockSize() int {
+	return b.mode.BlockSize()
+}
+
+// record pads pt and encrypts it as the next record on the connection.
+func (b *BEASTOracle) record(pt []byte) []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := PadPKCS7(pt, b.mode.BlockSize())
+	b.mode.CryptBlocks(res, res)
+	return res
+}
+
+// Request returns the next record, holding path || secret.
+func (b *BEASTOracle) Request(path []byte) []byte {
+	b.o.debug("request sent", "path_len", len(path))
+	return b.record(slices.Concat(path, b.secret))
+}
+
+// Inject returns the next record, holding pt.
+func (b *BEASTOracle) Inject(pt []byte) []byte {
+	b.o.debug("plaintext injected", "len", len(pt))
+	return b.record(pt)
+}
+
+// RecoverBEASTSecret performs a BEAST attack to recover the secret that
+// request sends after a chosen path, using inject to send chosen plaintexts
+// on the same connection. Both must return whole records of the same CBC
+// connection, in the order they're sent.
+//
+// For each secret byte, the attack sizes the path so the byte ends a block
+// C[i] = E(C[i-1] ^ P[i]), where all of P[i] but that byte is known. The
+// next record's IV is the last block the attacker saw, so injecting
+// IV ^ C[i-1] ^ guess encrypts to C[i] exactly when the guess is right. It
+// takes up to 256 injections per byte.
+//
+// Use WithProgress or WithLogger to observe the attack as it runs.
+func RecoverBEASTSecret(request func(path []byte) []byte, inject func(pt []byte) []byte, blockSize int, opts ...Option) (_ []byte, err error) {
+	o := newOptions(opts)
+
+	queries := o.newMeter()
+	defer queries.finish(&err)
+	request = queries.oracle(request)
+	inject = queries.oracle(inject)
+
+	// Find the secret length from where the record length first grows. The
+	// last block of each record is the IV of the next.
+	base := request(nil)
+	if err := queries.err(); err != nil {
+		return nil, err
+	}
+	iv := slices.Clone(base[len(base)-blockSize:])
+
+	n := -1
+	for p := 1; p <= blockSize; p++ {
+		ct := request(make([]byte, p))
+		if err := queries.err(); err != nil {
+			return nil, err
+		}
+		iv = slices.Clone(ct[len(ct)-blockSize:])
+		if len(ct) > len(base) {
+			n = len(base) - p
+			break
+		}
+	}
+	if n < 0 {
+		return nil, errors.New("record length never changed")
+	}
+	o.debug("found secret length", "len", n)
+
+	res := make([]byte, n)
+	guess := make([]byte, blockSize)
+
+	for j := range res {
+		// Put secret byte j at the end of block i.
+		p := blockSize - 1 - j%blockSize
+		i := (p + j) / blockSize
+
+		pt := slices.Concat(make([]byte, p), res[:j+1])
+		known := pt[i*blockSize : (i+1)*blockSize]
+
+		ct := request(make([]byte, p))
+		if err := queries.err(); err != nil {
+			return nil, err
+		}
+		prev := iv
+		if i > 0 {
+			prev = ct[(i-1)*blockSize : i*blockSize]
+		}
+		target := slices.Clone(ct[i*blockSize : (i+1)*blockSize])
+		iv = slices.Clone(ct[len(ct)-blockSize:])
+
+		found := false
+		for g := range math.MaxUint8 + 1 {
+			known[blockSize-1] = byte(g)
+
+			for k := range guess {
+				guess[k] = iv[k] ^ prev[k] ^ known[k]
+			}
+
+			out := inject(guess)
+			if err := queries.err(); err != nil {
+				return nil, err
+			}
+			match := slices.Equal(out[:blockSize], target)
+			iv = slices.Clone(out[len(out)-blockSize:])
+
+			if match {
+				res[j] = byte(g)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.New("no guess matched")
+		}
+
+		o.debug("recovered byte", "index", j, "oracle_calls", queries.calls())
+		o.report(Progress{BytesRecovered: j + 1, OracleCalls: queries.calls()})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/des"
+	"testing"
+)
+
+func TestBEAST(t *testing.T) {
+	secret := []byte("Cookie: session=b3a5c0ffee; theme=dark")
+	b := NewBEASTOracle(secret)
+
+	got, err := RecoverBEASTSecret(b.Request, b.Inject, b.BlockSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
+
+func TestBEASTSecretLengths(t *testing.T) {
+	for n := range 2*des.BlockSize + 1 {
+		secret := bytes.Repeat([]byte{'s'}, n)
+		b := NewBEASTOracle(secret, WithCipher(des.NewCipher, 8))
+
+		got, err := RecoverBEASTSecret(b.Request, b.Inject, b.BlockSize())
+		if err != nil {
+			t.Fatalf("length %d: %v", n, err)
+		}
+		if !bytes.Equal(secret, got) {
+			t.Errorf("length %d: want %q, got %q", n, secret, got)
+		}
+	}
+}
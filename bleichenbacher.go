@@ -0,0 +1,568 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"slices"
+	"time"
+)
+
+// A BleichenbacherMode is what a Bleichenbacher oracle reveals about the
+// PKCS #1 v1.5 padding of a ciphertext.
+type BleichenbacherMode int
+
+const (
+	// BleichenbacherLoose reveals whether the encryption block starts with
+	// 00 02, as in challenges 47 and 48.
+	BleichenbacherLoose BleichenbacherMode = iota
+
+	// BleichenbacherStrict reveals whether the padding is entirely valid,
+	// with a message as long as a TLS pre-master secret, 48 bytes, as a
+	// careful TLS server once checked. Far fewer blocks pass, so the attack
+	// needs many more queries.
+	BleichenbacherStrict
+
+	// BleichenbacherTiming gives the same reply either way, as TLS servers
+	// do since the attack, but takes longer over blocks that start with
+	// 00 02, as when only those go on to use the secret.
+	BleichenbacherTiming
+)
+
+// preMasterSize is the length of a TLS pre-master secret.
+const preMasterSize = 48
+
+// WithBleichenbacherMode sets what a Bleichenbacher oracle reveals. For a
+// remote oracle's client, BleichenbacherTiming makes it judge replies by
+// how long they take. The default is BleichenbacherLoose.
+func WithBleichenbacherMode(m BleichenbacherMode) Option {
+	return func(o *options) {
+		o.bleichenbacherMode = m
+	}
+}
+
+// WithLeakDelay sets how much longer an oracle that only leaks through
+// timing takes on the path it leaks. The default is 2ms.
+func WithLeakDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.leakDelay = d
+	}
+}
+
+// A BleichenbacherOracle decrypts RSA-encrypted pre-master secrets with
+// PKCS #1 v1.5 padding, as a TLS server with RSA key exchange does, and
+// reveals whether their padding conforms.
+type BleichenbacherOracle struct {
+	key *RSAPrivateKey
+	o   *options
+}
+
+// NewBleichenbacherOracle returns an oracle for key. Use
+// WithBleichenbacherMode to choose what it reveals, and WithLeakDelay for
+// the timing difference in BleichenbacherTiming mode.
+func NewBleichenbacherOracle(key *RSAPrivateKey, opts ...Option) *BleichenbacherOracle {
+	return &BleichenbacherOracle{key: key, o: newOptions(opts)}
+}
+
+// PublicKey returns the oracle's public key.
+func (b *BleichenbacherOracle) PublicKey() *RSAPublicKey {
+	return &b.key.RSAPublicKey
+}
+
+// Conforms reports whether ct decrypts to an encryption block that passes
+// the oracle's check. In BleichenbacherTiming mode, that's the loose check,
+// after the delay if it passes.
+func (b *BleichenbacherOracle) Conforms(ct []byte) bool {
+	n := b.key.Size()
+	c := new(big.Int).SetBytes(ct)
+	if len(ct) != n || c.Cmp(b.key.N) >= 0 {
+		return false
+	}
+	em := rsaBytes(b.key.Decrypt(c), n)
+
+	if b.o.bleichenbacherMode == BleichenbacherStrict {
+		msg, ok := unpadPKCS1v15(em)
+		return ok && len(msg) == preMasterSize
+	}
+	ok := em[0] == 0 && em[1] == 2
+	if ok && b.o.bleichenbacherMode == BleichenbacherTiming {
+		b.o.clock.Sleep(b.o.leakDelay)
+	}
+	return ok
+}
+
+// Party returns the oracle as a server Party. Each request is a message of
+// one field, a ciphertext, and each reply is "ok" if it conforms or "error"
+// if it doesn't, or always "ok" in BleichenbacherTiming mode.
+func (b *BleichenbacherOracle) Party() Party {
+	return PartyFunc(func(ch Channel) error {
+		for {
+			m, err := ch.Receive()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(m) != 1 {
+				return errMalformedMessage
+			}
+
+			ok := b.Conforms(m[0])
+			b.o.debug("bleichenbacher check", "ok", ok)
+			b.o.clock.Sleep(b.o.latency)
+
+			status := "ok"
+			if !ok && b.o.bleichenbacherMode != BleichenbacherTiming {
+				status = "error"
+			}
+			if err := ch.Send(Message{[]byte(status)}); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// ServeBleichenbacherOracle accepts connections on l and runs b.Party() on
+// each, as ServeParty does.
+//
+// ServeBleichenbacherOracle returns when l.Accept fails, such as when l is
+// closed.
+func ServeBleichenbacherOracle(l net.Listener, b *BleichenbacherOracle, opts ...Option) error {
+	return ServeParty(l, b.Party(), opts...)
+}
+
+// leakCalibrations is how many certainly bad queries a timing client makes
+// to learn how fast a quick reply is.
+const leakCalibrations = 8
+
+// A RemoteBleichenbacherOracle queries a Bleichenbacher oracle over the
+// network, as served by ServeBleichenbacherOracle.
+type RemoteBleichenbacherOracle struct {
+	ch     Channel
+	size   int           // Ciphertext size in bytes.
+	timing bool          // Whether to judge replies by their timing.
+	delay  time.Duration // The timing difference to look for.
+	fast   time.Duration // The fastest reply seen.
+	timed  bool          // Whether fast is set.
+	clock  clock
+}
+
+// DialBleichenbacherOracle connects to the oracle at addr, for ciphertexts
+// under pub. With WithBleichenbacherMode(BleichenbacherTiming), it judges a
+// ciphertext as conforming if the reply takes half the WithLeakDelay delay
+// longer than the fastest one, after timing a few certainly bad queries.
+func DialBleichenbacherOracle(addr string, pub *RSAPublicKey, opts ...Option) (*RemoteBleichenbacherOracle, error) {
+	o := newOptions(opts)
+	ch, err := DialChannel(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r := &RemoteBleichenbacherOracle{
+		ch:     ch,
+		size:   pub.Size(),
+		timing: o.bleichenbacherMode == BleichenbacherTiming,
+		delay:  o.leakDelay,
+		clock:  o.clock,
+	}
+
+	if r.timing {
+		// Zero decrypts to zero, which never conforms.
+		zero := make([]byte, r.size)
+		for range leakCalibrations {
+			if _, err := r.conforms(zero); err != nil {
+				ch.Close()
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// conforms asks the server whether ct conforms.
+func (r *RemoteBleichenbacherOracle) conforms(ct []byte) (bool, error) {
+	start := r.clock.Now()
+	if err := r.ch.Send(Message{ct}); err != nil {
+		return false, err
+	}
+	m, err := r.ch.Receive()
+	if err != nil {
+		return false, noEOF(err)
+	}
+	elapsed := r.clock.Now().Sub(start)
+	if len(m) != 1 {
+		return false, errMalformedMessage
+	}
+
+	if !r.timing {
+		return string(m[0]) == "ok", nil
+	}
+	if !r.timed || elapsed < r.fast {
+		r.fast, r.timed = elapsed, true
+	}
+	return elapsed-r.fast >= r.delay/2, nil
+}
+
+// Query asks the server whether ct conforms, and answers as ValidityFunc
+// does, so r is an Oracle for BleichenbacherAttack.
+func (r *RemoteBleichenbacherOracle) Query(ct []byte) ([]byte, error) {
+	ok, err := r.conforms(ct)
+	if err != nil {
+		return nil, err
+	}
+	return validityAnswer(ok), nil
+}
+
+// Conforms is like Query, but reports whether ct conforms and panics if the
+// query fails. It can be passed to RecoverBleichenbacherPlaintext.
+func (r *RemoteBleichenbacherOracle) Conforms(ct []byte) bool {
+	ok, err := r.conforms(ct)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
+// Close closes the connection.
+func (r *RemoteBleichenbacherOracle) Close() error {
+	return r.ch.Close()
+}
+
+// An rsaInterval is a closed range of candidate encryption blocks.
+type rsaInterval struct {
+	a, b *big.Int
+}
+
+// cloneInt returns a copy of x, or nil if x is nil.
+func cloneInt(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+// ceilDiv returns x/y rounded up, for y > 0.
+func ceilDiv(x, y *big.Int) *big.Int {
+	q, m := new(big.Int).DivMod(x, y, new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// bleichenbacherNarrow returns the parts of the intervals in ms that can
+// hold m, given that m*s mod n is in [lo, hi], merged into disjoint
+// intervals.
+func bleichenbacherNarrow(ms []rsaInterval, s, n, lo, hi *big.Int) []rsaInterval {
+	var res []rsaInterval
+	t := new(big.Int)
+	for _, m := range ms {
+		// r ranges over the multiples of n that m*s can wrap by.
+		r := ceilDiv(t.Mul(m.a, s).Sub(t, hi), n)
+		rhi := t.Mul(m.b, s).Sub(t, lo)
+		rhi.Div(rhi, n)
+		for ; r.Cmp(rhi) <= 0; r.Add(r, big.NewInt(1)) {
+			rn := new(big.Int).Mul(r, n)
+			a := ceilDiv(new(big.Int).Add(lo, rn), s)
+			if a.Cmp(m.a) < 0 {
+				a.Set(m.a)
+			}
+			b := new(big.Int).Add(hi, rn)
+			b.Div(b, s)
+			if b.Cmp(m.b) > 0 {
+				b.Set(m.b)
+			}
+			if a.Cmp(b) <= 0 {
+				res = append(res, rsaInterval{a, b})
+			}
+		}
+	}
+
+	slices.SortFunc(res, func(x, y rsaInterval) int { return x.a.Cmp(y.a) })
+	var merged []rsaInterval
+	for _, m := range res {
+		if k := len(merged) - 1; k >= 0 && m.a.Cmp(merged[k].b) <= 0 {
+			if m.b.Cmp(merged[k].b) > 0 {
+				merged[k].b = m.b
+			}
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// A BleichenbacherState is a snapshot of RecoverBleichenbacherPlaintext's
+// progress: the blinding multiplier, the last conforming s, the intervals m
+// can still be in, and where a search for the next s had got to. The zero
+// value is a fresh start. It implements encoding.BinaryMarshaler, so it can
+// be saved with SaveAttackState and an interrupted attack resumed later.
+type BleichenbacherState struct {
+	ct      *big.Int // Nil for a fresh start.
+	s0      *big.Int // Nil until blinding succeeds.
+	s       *big.Int // Nil until the first step.
+	ms      []rsaInterval
+	steps   int
+	next    *big.Int // The next s an interrupted search tries, or nil.
+	r       *big.Int // The r an interrupted step 2c search was on, or nil.
+	queries int      // Across every run so far.
+}
+
+// bleichenbacherStateVersion is the first byte of a marshaled
+// BleichenbacherState.
+const bleichenbacherStateVersion = 2
+
+// MarshalBinary encodes the state.
+func (st *BleichenbacherState) MarshalBinary() ([]byte, error) {
+	w := &stateWriter{buf: []byte{bleichenbacherStateVersion}}
+	if st.ct == nil {
+		return w.buf, nil
+	}
+	w.int(st.ct)
+	w.optInt(st.s0)
+	w.optInt(st.s)
+	w.uint(uint64(st.steps))
+	w.uint(uint64(st.queries))
+	w.uint(uint64(len(st.ms)))
+	for _, m := range st.ms {
+		w.int(m.a)
+		w.int(m.b)
+	}
+	w.optInt(st.next)
+	w.optInt(st.r)
+	return w.buf, nil
+}
+
+// UnmarshalBinary decodes a state that MarshalBinary encoded.
+func (st *BleichenbacherState) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != bleichenbacherStateVersion {
+		return errBadState
+	}
+	r := &stateReader{buf: data[1:]}
+	if len(r.buf) == 0 {
+		*st = BleichenbacherState{}
+		return nil
+	}
+
+	var res BleichenbacherState
+	res.ct = r.int()
+	res.s0 = r.optInt()
+	res.s = r.optInt()
+	res.steps = int(r.uint())
+	res.queries = int(r.uint())
+	n := r.uint()
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		res.ms = append(res.ms, rsaInterval{r.int(), r.int()})
+	}
+	res.next = r.optInt()
+	res.r = r.optInt()
+	if err := r.done(); err != nil {
+		return err
+	}
+	if res.ct.Sign() == 0 ||
+		(res.s0 == nil) != (len(res.ms) == 0) ||
+		(res.steps > 0) != (res.s != nil) ||
+		(res.s0 == nil && res.next != nil) ||
+		(res.r != nil && res.next == nil) {
+		return errBadState
+	}
+	*st = res
+	return nil
+}
+
+// Queries returns the number of oracle queries the attack has made, across
+// every run that updated the state.
+func (st *BleichenbacherState) Queries() int {
+	return st.queries
+}
+
+// WithBleichenbacherState makes RecoverBleichenbacherPlaintext resume from
+// *st, and keep it up to date: after blinding, after each step, and with the
+// search position and queries so far when a step is interrupted, such as by
+// WithBudget. *st can then be saved and the attack resumed from where it
+// stopped.
+func WithBleichenbacherState(st *BleichenbacherState) Option {
+	return func(o *options) {
+		o.bleichenbacherState = st
+	}
+}
+
+// RecoverBleichenbacherPlaintext returns the message that ct, under pub with
+// PKCS #1 v1.5 padding, encrypts, with Bleichenbacher's 1998 attack. It
+// needs only conforms, which reports whether a ciphertext decrypts to a
+// block starting with 00 02, as from a BleichenbacherOracle.
+//
+// RSA is multiplicative: ct * s^e decrypts to m*s. Each s for which that
+// conforms puts m*s mod n in [2B, 3B), where B is 2^(8(k-2)) for a k-byte
+// modulus, which narrows the range m can be in, until one value is left.
+// Once the range is small, it takes a few queries per step, each about
+// halving it. If ct doesn't conform itself, it first blinds it with random
+// multipliers until it does.
+//
+// It reports progress with WithProgress as the range narrows, and can be
+// resumed with WithBleichenbacherState. It returns an error if the oracle's
+// answers contradict each other, if the plaintext has no valid padding, or
+// if the state is for another ciphertext.
+func RecoverBleichenbacherPlaintext(pub *RSAPublicKey, ct []byte, conforms func([]byte) bool, opts ...Option) (_ []byte, err error) {
+	o := newOptions(opts)
+
+	queries := o.newMeter()
+	defer queries.finish(&err)
+	conforms = queries.valid(conforms)
+
+	k, n := pub.Size(), pub.N
+	if k < 11 {
+		return nil, errors.New("modulus too small")
+	}
+	one := big.NewInt(1)
+	B := new(big.Int).Lsh(one, uint(8*(k-2)))
+	B2 := new(big.Int).Lsh(B, 1)
+	B3 := new(big.Int).Add(B2, B)
+	B3m1 := new(big.Int).Sub(B3, one)
+
+	st := o.bleichenbacherState
+	if st == nil {
+		st = new(BleichenbacherState)
+	}
+	ct0 := new(big.Int).SetBytes(ct)
+	if st.ct != nil && st.ct.Cmp(ct0) != 0 {
+		return nil, errors.New("state is for another ciphertext")
+	}
+	prior := st.queries
+
+	c0 := new(big.Int).Set(ct0)
+	try := func(s *big.Int) bool {
+		c := new(big.Int).Exp(s, pub.E, n)
+		c.Mul(c, c0).Mod(c, n)
+		return conforms(rsaBytes(c, k))
+	}
+
+	// last is the last conforming s, and next and r are where to resume an
+	// interrupted search for the next one.
+	var s0, last, next, r *big.Int
+	ms := []rsaInterval{{new(big.Int).Set(B2), new(big.Int).Set(B3m1)}}
+	steps := 0
+	if st.ct != nil {
+		s0, last, steps, next, r = st.s0, st.s, st.steps, st.next, st.r
+		if s0 != nil {
+			ms = st.ms
+		}
+		o.debug("bleichenbacher resumed", "steps", steps, "calls", prior)
+	}
+
+	// save records the progress so far in *st, with the search for the next s
+	// at next and, in step 2c, r.
+	save := func(next, r *big.Int) {
+		*st = BleichenbacherState{
+			ct:      ct0,
+			s0:      s0,
+			s:       last,
+			ms:      ms,
+			steps:   steps,
+			next:    cloneInt(next),
+			r:       cloneInt(r),
+			queries: prior + queries.calls(),
+		}
+	}
+
+	if s0 == nil {
+		// Step 1: blinding.
+		blind := big.NewInt(1)
+		for !try(blind) {
+			if err := queries.err(); err != nil {
+				save(nil, nil)
+				return nil, err
+			}
+			var err error
+			if blind, err = rand.Int(rand.Reader, n); err != nil {
+				return nil, err
+			}
+		}
+		s0 = blind
+		save(nil, nil)
+		o.debug("bleichenbacher blinded", "calls", queries.calls())
+	}
+	c0.Mul(c0, new(big.Int).Exp(s0, pub.E, n)).Mod(c0, n)
+
+	for {
+		var s *big.Int
+		switch {
+		case steps == 0:
+			// Step 2a: the smallest s that can conform.
+			s = ceilDiv(n, B3)
+			if next != nil {
+				s, next = next, nil
+			}
+			for !try(s) && queries.err() == nil {
+				s.Add(s, one)
+			}
+		case len(ms) > 1:
+			// Step 2b: the next s that conforms.
+			s = new(big.Int).Add(last, one)
+			if next != nil {
+				s, next = next, nil
+			}
+			for !try(s) && queries.err() == nil {
+				s.Add(s, one)
+			}
+		default:
+			// Step 2c: search s near where m*s wraps into [2B, 3B) again.
+			a, b := ms[0].a, ms[0].b
+			if r == nil {
+				r = new(big.Int).Mul(b, last)
+				r = ceilDiv(r.Sub(r, B2).Lsh(r, 1), n)
+			}
+		search:
+			for ; ; r.Add(r, one) {
+				rn := new(big.Int).Mul(r, n)
+				hi := new(big.Int).Add(B3, rn)
+				s = ceilDiv(new(big.Int).Add(B2, rn), b)
+				if next != nil {
+					s, next = next, nil
+				}
+				for ; new(big.Int).Mul(s, a).Cmp(hi) < 0; s.Add(s, one) {
+					if try(s) || queries.err() != nil {
+						break search
+					}
+				}
+			}
+		}
+
+		if err := queries.err(); err != nil {
+			save(s, r)
+			return nil, err
+		}
+
+		// Step 3: narrow the intervals.
+		ms = bleichenbacherNarrow(ms, s, n, B2, B3m1)
+		if len(ms) == 0 {
+			return nil, errors.New("inconsistent oracle answers")
+		}
+		last, r = s, nil
+		steps++
+		save(nil, nil)
+		width := new(big.Int).Sub(ms[0].b, ms[0].a)
+		o.report(Progress{
+			BytesRecovered: k - (width.BitLen()+7)/8,
+			OracleCalls:    st.queries,
+			Lo:             new(big.Int).Set(ms[0].a),
+			Hi:             new(big.Int).Set(ms[len(ms)-1].b),
+		})
+
+		// Step 4: one value left.
+		if len(ms) == 1 && width.Sign() == 0 {
+			m := new(big.Int).ModInverse(s0, n)
+			m.Mul(m, ms[0].a).Mod(m, n)
+			o.debug("bleichenbacher done", "calls", st.queries)
+
+			msg, ok := unpadPKCS1v15(rsaBytes(m, k))
+			if !ok {
+				return nil, fmt.Errorf("plaintext %x has invalid padding", rsaBytes(m, k))
+			}
+			return msg, nil
+		}
+	}
+}
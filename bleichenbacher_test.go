@@ -0,0 +1,290 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBleichenbacherOracleModes(t *testing.T) {
+	k, err := GenerateRSAKey(512, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+
+	preMaster, err := EncryptPKCS1v15(pub, make([]byte, preMasterSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	short, err := EncryptPKCS1v15(pub, []byte("short"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := make([]byte, k.Size())
+
+	tests := []struct {
+		mode BleichenbacherMode
+		want [3]bool // For preMaster, short, and bad.
+	}{
+		{BleichenbacherLoose, [3]bool{true, true, false}},
+		{BleichenbacherStrict, [3]bool{true, false, false}},
+		{BleichenbacherTiming, [3]bool{true, true, false}},
+	}
+	for _, tt := range tests {
+		o := NewBleichenbacherOracle(k, WithBleichenbacherMode(tt.mode), WithLeakDelay(time.Millisecond))
+		for i, ct := range [][]byte{preMaster, short, bad} {
+			if got := o.Conforms(ct); got != tt.want[i] {
+				t.Errorf("mode %d, ciphertext %d: got %v, want %v", tt.mode, i, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestRecoverBleichenbacherPlaintext(t *testing.T) {
+	// Challenge 48's 768-bit modulus takes seconds, so use a smaller one.
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("kick it, CC")
+	ct, err := EncryptPKCS1v15(&k.RSAPublicKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewBleichenbacherOracle(k)
+	var reports []Progress
+	got, err := RecoverBleichenbacherPlaintext(&k.RSAPublicKey, ct, o.Conforms, WithProgress(func(p Progress) {
+		reports = append(reports, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+	// ct conforms, so the attack doesn't blind it.
+	checkIntervalProgress(t, reports, k.Decrypt(new(big.Int).SetBytes(ct)))
+}
+
+// checkIntervalProgress checks that every report's interval holds m, and
+// that the last one is just m.
+func checkIntervalProgress(t *testing.T, reports []Progress, m *big.Int) {
+	t.Helper()
+	if len(reports) == 0 {
+		t.Fatal("no progress reports")
+	}
+	for i, p := range reports {
+		if p.Lo == nil || p.Hi == nil || p.Lo.Cmp(m) > 0 || p.Hi.Cmp(m) < 0 {
+			t.Fatalf("report %d: [%v, %v] doesn't hold %v", i, p.Lo, p.Hi, m)
+		}
+	}
+	if last := reports[len(reports)-1]; last.Lo.Cmp(last.Hi) != 0 {
+		t.Errorf("last report: [%v, %v] isn't one value", last.Lo, last.Hi)
+	}
+}
+
+func TestRemoteBleichenbacherOracle(t *testing.T) {
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+	ct, err := EncryptPKCS1v15(pub, []byte("pre-master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The timing mode shares a fake clock between the oracle and the client,
+	// so a conforming reply takes exactly the delay longer.
+	for _, opts := range [][]Option{
+		{WithBleichenbacherMode(BleichenbacherLoose)},
+		{WithBleichenbacherMode(BleichenbacherTiming), WithLeakDelay(2 * time.Millisecond), withClock(new(fakeClock))},
+	} {
+		o := NewBleichenbacherOracle(k, opts...)
+		addr := listen(t, func(l net.Listener) { ServeBleichenbacherOracle(l, o) })
+
+		r, err := DialBleichenbacherOracle(addr, pub, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok := r.Conforms(ct); !ok {
+			t.Errorf("%d options: ciphertext doesn't conform", len(opts))
+		}
+		if ok := r.Conforms(make([]byte, k.Size())); ok {
+			t.Errorf("%d options: zero conforms", len(opts))
+		}
+		r.Close()
+	}
+}
+
+func TestBleichenbacherStateResume(t *testing.T) {
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+	msg := []byte("resume me")
+	ct, err := EncryptPKCS1v15(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewBleichenbacherOracle(k)
+
+	// The attack is deterministic for a conforming ct, so a first run shows
+	// how many queries the first step takes.
+	var full Usage
+	var firstStep int
+	report := func(p Progress) {
+		if firstStep == 0 {
+			firstStep = p.OracleCalls
+		}
+	}
+	if _, err := RecoverBleichenbacherPlaintext(pub, ct, o.Conforms, WithUsage(&full), WithProgress(report)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop after the first step, as if the run timed out, and save the state.
+	var st BleichenbacherState
+	budget := Budget{Queries: (firstStep + full.Queries) / 2}
+	_, err = RecoverBleichenbacherPlaintext(pub, ct, o.Conforms, WithBleichenbacherState(&st), WithBudget(budget))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("want ErrBudgetExceeded, got %v", err)
+	}
+	name := filepath.Join(t.TempDir(), "bb98.state")
+	if err := SaveAttackState(name, &st); err != nil {
+		t.Fatal(err)
+	}
+
+	var resumed BleichenbacherState
+	if err := LoadAttackState(name, &resumed); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Queries() != st.Queries() || resumed.Queries() == 0 {
+		t.Errorf("want %d queries saved, got %d", st.Queries(), resumed.Queries())
+	}
+
+	got, err := RecoverBleichenbacherPlaintext(pub, ct, o.Conforms, WithBleichenbacherState(&resumed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+	if resumed.Queries() != full.Queries {
+		t.Errorf("want %d queries in total, got %d", full.Queries, resumed.Queries())
+	}
+
+	// The state doesn't apply to another ciphertext.
+	other, err := EncryptPKCS1v15(pub, []byte("another"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverBleichenbacherPlaintext(pub, other, o.Conforms, WithBleichenbacherState(&resumed)); err == nil {
+		t.Error("want an error resuming with another ciphertext")
+	}
+}
+
+func TestBleichenbacherStateResumeEarly(t *testing.T) {
+	k, err := GenerateRSAKey(256, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+	msg := []byte("resume me early")
+	ct, err := EncryptPKCS1v15(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewBleichenbacherOracle(k)
+
+	var full Usage
+	if _, err := RecoverBleichenbacherPlaintext(pub, ct, o.Conforms, WithUsage(&full)); err != nil {
+		t.Fatal(err)
+	}
+
+	// ct conforms, so blinding takes one query, and a budget of 5 stops the
+	// attack in step 2a. Resuming picks up the search where it stopped.
+	var st BleichenbacherState
+	_, err = RecoverBleichenbacherPlaintext(pub, ct, o.Conforms, WithBleichenbacherState(&st), WithBudget(Budget{Queries: 5}))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("want ErrBudgetExceeded, got %v", err)
+	}
+	if st.Queries() != 5 || st.s0 == nil || st.steps != 0 || st.next == nil {
+		t.Fatalf("state after 5 queries: %d queries, s0 %v, %d steps, next %v", st.Queries(), st.s0, st.steps, st.next)
+	}
+	data, err := st.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resumed BleichenbacherState
+	if err := resumed.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	got, err := RecoverBleichenbacherPlaintext(pub, ct, o.Conforms, WithBleichenbacherState(&resumed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+	if resumed.Queries() != full.Queries {
+		t.Errorf("want %d queries in total, got %d", full.Queries, resumed.Queries())
+	}
+
+	// A ciphertext that doesn't conform is stopped while blinding, and the
+	// queries spent there still count.
+	bad := make([]byte, k.Size())
+	bad[len(bad)-1] = 2
+	var blinding BleichenbacherState
+	for range 2 {
+		_, err := RecoverBleichenbacherPlaintext(pub, bad, o.Conforms, WithBleichenbacherState(&blinding), WithBudget(Budget{Queries: 3}))
+		if !errors.Is(err, ErrBudgetExceeded) {
+			t.Fatalf("want ErrBudgetExceeded, got %v", err)
+		}
+	}
+	if blinding.Queries() != 6 || blinding.s0 != nil {
+		t.Errorf("blinding: %d queries, s0 %v; want 6 queries and no s0", blinding.Queries(), blinding.s0)
+	}
+}
+
+func TestBleichenbacherStateUnmarshal(t *testing.T) {
+	var st BleichenbacherState
+	data, err := st.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UnmarshalBinary(data); err != nil {
+		t.Errorf("zero state: %v", err)
+	}
+
+	for _, data := range [][]byte{nil, {1}, {2, 5, 1}, {2, 0, 0, 0, 0, 0, 0, 0, 0}, {2, 1, 7, 0, 0, 0, 0, 0, 1, 1, 3, 0}} {
+		if err := st.UnmarshalBinary(data); err == nil {
+			t.Errorf("%x: want an error", data)
+		}
+	}
+}
+
+// fakeClock is a clock that only moves when something sleeps on it.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,181 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+// errPartialBlock is returned when unpadded data isn't a whole number of
+// blocks.
+var errPartialBlock = errors.New("input not full blocks")
+
+// blockModeWriter runs a block mode over what's written to it, a block at a
+// time, and pads the last block on Close.
+type blockModeWriter struct {
+	mode   cipher.BlockMode
+	padder Padder // Nil for no padding.
+	w      io.Writer
+	buf    []byte // A partial block not yet run through mode.
+	out    []byte // Scratch space for mode's output.
+	closed bool
+}
+
+// NewBlockModeWriter returns a writer that runs the data written to it
+// through mode and writes the result to w. It buffers data up to a block
+// boundary, so writes can be any size.
+//
+// On Close, it pads the last partial block with p and writes it, so with
+// PKCS7Padder and a CBC encrypter it writes what EncryptPadded would, less
+// the IV. If p is nil, nothing is padded and Close returns an error if the
+// data wasn't a whole number of blocks. Closing the writer doesn't close w.
+func NewBlockModeWriter(mode cipher.BlockMode, p Padder, w io.Writer) io.WriteCloser {
+	bs := mode.BlockSize()
+	return &blockModeWriter{
+		mode:   mode,
+		padder: p,
+		w:      w,
+		buf:    make([]byte, 0, bs),
+		out:    make([]byte, 2*bs+32*1024),
+	}
+}
+
+func (w *blockModeWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write after close")
+	}
+	bs, total := w.mode.BlockSize(), len(p)
+
+	// Finish the partial block first.
+	if len(w.buf) > 0 {
+		n := min(bs-len(w.buf), len(p))
+		w.buf, p = append(w.buf, p[:n]...), p[n:]
+		if len(w.buf) < bs {
+			return total, nil
+		}
+		if err := w.crypt(w.buf); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[:0]
+	}
+
+	for len(p) >= bs {
+		n := min(len(p)-len(p)%bs, len(w.out)-len(w.out)%bs)
+		if err := w.crypt(p[:n]); err != nil {
+			return total - len(p), err
+		}
+		p = p[n:]
+	}
+
+	w.buf = append(w.buf, p...)
+	return total, nil
+}
+
+// crypt runs whole blocks through the mode and writes them out.
+func (w *blockModeWriter) crypt(b []byte) error {
+	w.mode.CryptBlocks(w.out[:len(b)], b)
+	_, err := w.w.Write(w.out[:len(b)])
+	return err
+}
+
+func (w *blockModeWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if w.padder == nil {
+		if len(w.buf) > 0 {
+			return errPartialBlock
+		}
+		return nil
+	}
+	return w.crypt(w.padder.Pad(w.buf, w.mode.BlockSize()))
+}
+
+// blockModeReader runs a block mode over what it reads, holding back the
+// last block until EOF to remove its padding.
+type blockModeReader struct {
+	mode    cipher.BlockMode
+	padder  Padder // Nil for no padding.
+	r       io.Reader
+	in      []byte
+	pending int // Bytes at the front of in that don't make a whole block.
+	out     []byte
+	held    []byte // The last block of output, in out.
+	ready   []byte // Output that can be returned, in out.
+	err     error  // Sticky error, io.EOF once the input is finished.
+}
+
+// NewBlockModeReader returns a reader that reads data from r, runs it
+// through mode, and returns the result, a chunk at a time.
+//
+// It holds back the final block until r reaches EOF, and then removes its
+// padding with p, returning the Padder's error in place of io.EOF if the
+// padding is invalid. If p is nil, nothing is removed. Either way, it returns
+// an error at EOF if the input isn't a whole number of blocks.
+func NewBlockModeReader(mode cipher.BlockMode, p Padder, r io.Reader) io.Reader {
+	bs := mode.BlockSize()
+	in := make([]byte, bs+32*1024)
+	return &blockModeReader{
+		mode:   mode,
+		padder: p,
+		r:      r,
+		in:     in,
+		out:    make([]byte, len(in)+bs),
+	}
+}
+
+func (r *blockModeReader) Read(p []byte) (int, error) {
+	for len(r.ready) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.ready)
+	r.ready = r.ready[n:]
+	return n, nil
+}
+
+// fill reads more input and makes all but the last block of output ready.
+// It must only be called once r.ready is empty, since it reuses r.out.
+func (r *blockModeReader) fill() {
+	bs := r.mode.BlockSize()
+
+	n, err := r.r.Read(r.in[r.pending:])
+	m := r.pending + n
+	whole := m - m%bs
+
+	// The held block goes first, then the new whole blocks.
+	k := copy(r.out, r.held)
+	r.mode.CryptBlocks(r.out[k:k+whole], r.in[:whole])
+	total := k + whole
+	r.pending = copy(r.in, r.in[whole:m])
+	r.held = r.out[:total]
+
+	switch {
+	case err == io.EOF:
+		r.held = nil
+		switch {
+		case r.pending > 0:
+			r.err = errPartialBlock
+		case r.padder == nil:
+			r.ready, r.err = r.out[:total], io.EOF
+		case total == 0:
+			r.err = ErrInvalidPadding
+		default:
+			last, perr := r.padder.Unpad(r.out[total-bs:total], bs)
+			if perr != nil {
+				r.err = perr
+				return
+			}
+			r.ready, r.err = r.out[:total-bs+len(last)], io.EOF
+		}
+	case err != nil:
+		r.err = err
+	case total > bs:
+		r.ready = r.out[:total-bs]
+		r.held = r.out[total-bs : total]
+	}
+}
@@ -0,0 +1,104 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBlockModeWriterReader(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, aes.BlockSize)
+
+	for _, n := range []int{0, 1, 15, 16, 17, 100, 40000} {
+		pt := bytes.Repeat([]byte("stream me "), n/10+1)[:n]
+
+		// Write in awkward sizes.
+		var ct bytes.Buffer
+		w := NewBlockModeWriter(NewCBCEncrypter(block, iv), PKCS7Padder{}, &ct)
+		for rest := pt; len(rest) > 0; {
+			k := min(7, len(rest))
+			if _, err := w.Write(rest[:k]); err != nil {
+				t.Fatal(err)
+			}
+			rest = rest[k:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := PadPKCS7(pt, aes.BlockSize)
+		NewCBCEncrypter(block, iv).CryptBlocks(want, want)
+		if !bytes.Equal(ct.Bytes(), want) {
+			t.Fatalf("%d bytes: writer output differs from CBC", n)
+		}
+
+		r := NewBlockModeReader(NewCBCDecrypter(block, iv), PKCS7Padder{}, iotest.HalfReader(bytes.NewReader(ct.Bytes())))
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(got, pt) {
+			t.Errorf("%d bytes: round trip failed", n)
+		}
+	}
+}
+
+func TestBlockModeNoPadding(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := bytes.Repeat([]byte("YELLOW SUBMARINE"), 3)
+
+	var ct bytes.Buffer
+	w := NewBlockModeWriter(NewECBEncrypter(block), nil, &ct)
+	if _, err := w.Write(pt); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ct.Len() != len(pt) {
+		t.Errorf("want %d bytes, got %d", len(pt), ct.Len())
+	}
+
+	got, err := io.ReadAll(NewBlockModeReader(NewECBDecrypter(block), nil, &ct))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pt) {
+		t.Errorf("want %q, got %q", pt, got)
+	}
+
+	w = NewBlockModeWriter(NewECBEncrypter(block), nil, io.Discard)
+	w.Write([]byte("short"))
+	if err := w.Close(); err == nil {
+		t.Error("want an error closing on a partial block")
+	}
+}
+
+func TestBlockModeReaderErrors(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decrypting garbage gives invalid padding.
+	ct := make([]byte, 2*aes.BlockSize)
+	_, err = io.ReadAll(NewBlockModeReader(NewECBDecrypter(block), PKCS7Padder{}, bytes.NewReader(ct)))
+	if !errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("want ErrInvalidPadding, got %v", err)
+	}
+
+	_, err = io.ReadAll(NewBlockModeReader(NewECBDecrypter(block), PKCS7Padder{}, bytes.NewReader(ct[:20])))
+	if err == nil {
+		t.Error("want an error for a partial block")
+	}
+}
@@ -0,0 +1,40 @@
+package cryptopals
+
+import (
+	"iter"
+	"slices"
+)
+
+// Blocks returns an iterator over consecutive n-byte blocks of b. The last
+// block is shorter if len(b) isn't a multiple of n. The blocks are subslices
+// of b with their capacity clipped, so appending to one doesn't overwrite the
+// next. It panics if n < 1.
+func Blocks(b []byte, n int) iter.Seq[[]byte] {
+	return slices.Chunk(b, n)
+}
+
+// PaddedBlocks is like Blocks, but pads the last block with p so every block
+// is n bytes long. The padded blocks are new slices, and the rest are
+// subslices of b.
+//
+// Padders that always add padding, such as PKCS7Padder, yield an extra block
+// of padding when len(b) is a multiple of n.
+func PaddedBlocks(b []byte, n int, p Padder) iter.Seq[[]byte] {
+	if n < 1 {
+		panic("invalid block size")
+	}
+
+	whole := len(b) - len(b)%n
+	return func(yield func([]byte) bool) {
+		for block := range Blocks(b[:whole], n) {
+			if !yield(block) {
+				return
+			}
+		}
+		for block := range Blocks(p.Pad(b[whole:], n), n) {
+			if !yield(block) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cryptopals
+
+import (
+	"iter"
+	"slices"
+	"testing"
+)
+
+// collect returns the blocks from seq as strings.
+func collect(seq iter.Seq[[]byte]) []string {
+	var res []string
+	for b := range seq {
+		res = append(res, string(b))
+	}
+	return res
+}
+
+func TestBlocks(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want []string
+	}{
+		{"abcdef", 2, []string{"ab", "cd", "ef"}},
+		{"abcdefg", 3, []string{"abc", "def", "g"}},
+		{"ab", 4, []string{"ab"}},
+		{"", 4, nil},
+	}
+
+	for _, tc := range cases {
+		got := collect(Blocks([]byte(tc.in), tc.n))
+		if !slices.Equal(tc.want, got) {
+			t.Errorf("Blocks(%q, %d): want %q, got %q", tc.in, tc.n, tc.want, got)
+		}
+	}
+}
+
+func TestBlocksStop(t *testing.T) {
+	var n int
+	for range Blocks(make([]byte, 64), 16) {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d blocks, want 2", n)
+	}
+}
+
+func TestPaddedBlocks(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		p    Padder
+		want []string
+	}{
+		{"abcdefg", 4, PKCS7Padder{}, []string{"abcd", "efg\x01"}},
+		{"abcd", 4, PKCS7Padder{}, []string{"abcd", "\x04\x04\x04\x04"}},
+		{"abcd", 4, ZeroPadder{}, []string{"abcd"}},
+		{"abcde", 4, ZeroPadder{}, []string{"abcd", "e\x00\x00\x00"}},
+		{"", 2, PKCS7Padder{}, []string{"\x02\x02"}},
+	}
+
+	for _, tc := range cases {
+		got := collect(PaddedBlocks([]byte(tc.in), tc.n, tc.p))
+		if !slices.Equal(tc.want, got) {
+			t.Errorf("PaddedBlocks(%q, %d, %T): want %q, got %q", tc.in, tc.n, tc.p, tc.want, got)
+		}
+	}
+}
+
+func TestPaddedBlocksMatchesPad(t *testing.T) {
+	b := []byte("YELLOW SUBMARINE, YELLOW SUBMARINE")
+	want := PKCS7Padder{}.Pad(b, 16)
+
+	var got []byte
+	for block := range PaddedBlocks(b, 16, PKCS7Padder{}) {
+		if len(block) != 16 {
+			t.Fatalf("got %d-byte block", len(block))
+		}
+		got = append(got, block...)
+	}
+
+	if !slices.Equal(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,156 @@
+package cryptopals
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned by an attack, or an oracle wrapped with
+// BudgetOracle, that would go over its Budget.
+var ErrBudgetExceeded = errors.New("oracle budget exceeded")
+
+// A Budget limits the oracle queries an attack makes. A zero field means no
+// limit.
+type Budget struct {
+	Queries int // Most queries.
+	Bytes   int // Most bytes of input across all queries.
+}
+
+// Usage counts the oracle queries an attack made.
+type Usage struct {
+	Queries int
+	Bytes   int // Bytes of input across all queries.
+}
+
+// WithBudget makes an attack stop with ErrBudgetExceeded rather than query
+// its oracle beyond b, to test how few queries it can make do with. The
+// attacks that take it are those that report OracleCalls with WithProgress.
+// By default there's no limit.
+func WithBudget(b Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// WithUsage makes an attack add the queries it made to *u when it returns,
+// whether it succeeds or not. Solve reports the total in its Result.
+func WithUsage(u *Usage) Option {
+	return func(o *options) {
+		o.usage = u
+	}
+}
+
+// A meter counts queries against a budget.
+type meter struct {
+	mu     sync.Mutex
+	budget Budget
+	usage  Usage
+}
+
+// spend records a query with n bytes of input, and returns
+// ErrBudgetExceeded without recording it if that would go over budget.
+func (m *meter) spend(n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, b := m.usage.Queries+1, m.usage.Bytes+n
+	if (m.budget.Queries > 0 && q > m.budget.Queries) || (m.budget.Bytes > 0 && b > m.budget.Bytes) {
+		return ErrBudgetExceeded
+	}
+	m.usage = Usage{Queries: q, Bytes: b}
+	return nil
+}
+
+// calls returns the number of queries so far.
+func (m *meter) calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage.Queries
+}
+
+// BudgetOracle returns a wrapper around oracle that returns
+// ErrBudgetExceeded instead of calling it once a query would go over b, for
+// building oracles with a limit into services and exercises.
+func BudgetOracle(oracle func([]byte) []byte, b Budget) func([]byte) ([]byte, error) {
+	m := &meter{budget: b}
+	return func(input []byte) ([]byte, error) {
+		if err := m.spend(len(input)); err != nil {
+			return nil, err
+		}
+		return oracle(input), nil
+	}
+}
+
+// An attackMeter meters the oracles an attack queries, as set with
+// WithBudget and WithUsage. Once a query would go over budget, or the Attack
+// running it has to stop, its metered oracles stop calling the real ones and
+// answer with nothing, and err reports why, so the attack should check err in
+// every loop that queries them.
+type attackMeter struct {
+	meter
+	o        *options
+	exceeded bool
+}
+
+// newMeter returns a meter for an attack.
+func (o *options) newMeter() *attackMeter {
+	return &attackMeter{meter: meter{budget: o.budget}, o: o}
+}
+
+// spend is like meter.spend, but reports whether the query is within budget,
+// and remembers once one isn't.
+func (m *attackMeter) spend(n int) bool {
+	if m.err() != nil {
+		return false
+	}
+	if m.meter.spend(n) != nil {
+		m.o.debug("budget exceeded", "queries", m.budget.Queries, "bytes", m.budget.Bytes)
+		m.exceeded = true
+		return false
+	}
+	return true
+}
+
+// err returns ErrBudgetExceeded if a query has gone over budget, or the
+// error that stopped the Attack running the attack.
+func (m *attackMeter) err() error {
+	if m.exceeded {
+		return ErrBudgetExceeded
+	}
+	if m.o.stop != nil {
+		return m.o.stop()
+	}
+	return nil
+}
+
+// oracle returns a metered wrapper around oracle, which returns nil once
+// over budget.
+func (m *attackMeter) oracle(oracle func([]byte) []byte) func([]byte) []byte {
+	return func(input []byte) []byte {
+		if !m.spend(len(input)) {
+			return nil
+		}
+		return oracle(input)
+	}
+}
+
+// valid returns a metered wrapper around valid, which returns false once
+// over budget.
+func (m *attackMeter) valid(valid func([]byte) bool) func([]byte) bool {
+	return func(input []byte) bool {
+		return m.spend(len(input)) && valid(input)
+	}
+}
+
+// finish adds the attack's queries to the WithUsage total, and if the
+// attack went over budget or was stopped, sets *err to the error from err,
+// since whatever the attack made of the answers it didn't get is wrong. An
+// attack that uses the meter defers it.
+func (m *attackMeter) finish(err *error) {
+	if m.o.usage != nil {
+		m.o.usage.Queries += m.usage.Queries
+		m.o.usage.Bytes += m.usage.Bytes
+	}
+	if e := m.err(); e != nil {
+		*err = e
+	}
+}
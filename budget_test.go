@@ -0,0 +1,120 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestBudgetOracle(t *testing.T) {
+	echo := func(b []byte) []byte { return b }
+	oracle := BudgetOracle(echo, Budget{Queries: 2})
+
+	for range 2 {
+		if _, err := oracle([]byte("ok")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := oracle([]byte("ok")); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("want ErrBudgetExceeded, got %v", err)
+	}
+
+	oracle = BudgetOracle(echo, Budget{Bytes: 5})
+	if _, err := oracle([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oracle([]byte("abc")); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("want ErrBudgetExceeded, got %v", err)
+	}
+	if _, err := oracle([]byte("ab")); err != nil {
+		t.Errorf("want a query within budget to succeed, got %v", err)
+	}
+}
+
+func TestWithBudget(t *testing.T) {
+	secret := []byte("a budget stops the attack early")
+	p := NewCBCPaddingOracle(secret)
+	ct := p.Ciphertext()
+
+	var usage Usage
+	got, err := RecoverCBCPaddingOracleSecret(ct, aes.BlockSize, p.IsValid, WithUsage(&usage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(secret, got) {
+		t.Fatalf("want %q, got %q", secret, got)
+	}
+	if usage.Queries == 0 || usage.Bytes == 0 {
+		t.Fatalf("want nonzero usage, got %+v", usage)
+	}
+	t.Logf("usage: %+v", usage)
+
+	// The same attack fails with one query fewer.
+	var limited Usage
+	_, err = RecoverCBCPaddingOracleSecret(ct, aes.BlockSize, p.IsValid,
+		WithBudget(Budget{Queries: usage.Queries - 1}), WithUsage(&limited))
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("want ErrBudgetExceeded, got %v", err)
+	}
+	if limited.Queries != usage.Queries-1 {
+		t.Errorf("want %d queries, got %d", usage.Queries-1, limited.Queries)
+	}
+}
+
+func TestWithBudgetEveryAttack(t *testing.T) {
+	k, err := GenerateRSAKey(1024, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &k.RSAPublicKey
+	pkcs1, err := EncryptPKCS1v15(pub, []byte("kick it"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oaep, err := EncryptOAEP(sha256.New, pub, []byte("kick it"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("a budget stops every attack")
+	p := NewCBCPaddingOracle(secret)
+	beast := NewBEASTOracle(secret)
+	poodle := NewPOODLEOracle(secret)
+
+	// Every attack stops at each of these budgets rather than loop forever
+	// or crash on the answers it doesn't get.
+	for _, n := range []int{1, 2, 3, 20} {
+		opts := []Option{WithBudget(Budget{Queries: n})}
+		for name, attack := range map[string]func() error{
+			"ecb suffix": func() error {
+				_, err := RecoverECBSuffixOracleSecretErr(NewECBSuffixOracle(secret), opts...)
+				return err
+			},
+			"cbc padding oracle": func() error {
+				_, err := RecoverCBCPaddingOracleSecret(p.Ciphertext(), aes.BlockSize, p.IsValid, opts...)
+				return err
+			},
+			"beast": func() error {
+				_, err := RecoverBEASTSecret(beast.Request, beast.Inject, beast.BlockSize(), opts...)
+				return err
+			},
+			"poodle": func() error {
+				_, err := RecoverPOODLESecret(poodle.Encrypt, poodle.IsValid, aes.BlockSize, poodleMACSize, opts...)
+				return err
+			},
+			"bleichenbacher": func() error {
+				_, err := RecoverBleichenbacherPlaintext(pub, pkcs1, NewBleichenbacherOracle(k).Conforms, opts...)
+				return err
+			},
+			"manger": func() error {
+				_, err := RecoverMangerPlaintext(sha256.New, pub, oaep, nil, NewMangerOracle(k).Conforms, opts...)
+				return err
+			},
+		} {
+			if err := attack(); !errors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("%s, %d queries: want ErrBudgetExceeded, got %v", name, n, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"math/rand/v2"
+)
+
+// WithCacheLineSize sets the size in bytes of a cache line in a
+// CacheTimingAES's simulated cache, a power of two from 4 to 1024. The
+// default is 64. Larger lines hide more of each table index.
+func WithCacheLineSize(n int) Option {
+	return func(o *options) {
+		o.cacheLineSize = n
+	}
+}
+
+// WithTimingNoise sets the largest random delay, in simulated cycles, that a
+// CacheTimingAES or TimingRSA adds to each operation's time. The default is
+// 200, two cache misses.
+func WithTimingNoise(cycles int) Option {
+	return func(o *options) {
+		o.timingNoise = cycles
+	}
+}
+
+// Simulated cycle counts for a table lookup.
+const (
+	cacheHitCycles  = 4
+	cacheMissCycles = 100
+)
+
+// The tables a CacheTimingAES reads: aesTe[0] to aesTe[3] combine SubBytes,
+// ShiftRows, and MixColumns for all but the last round, which uses the S-box.
+const (
+	aesTables    = 5
+	aesSboxTable = 4
+)
+
+// aesTe are the AES encryption T-tables. aesTe[r][x] is the column that
+// MixColumns makes from S(x) in row r and zeros elsewhere, as a big-endian
+// word.
+var aesTe = func() (te [4][256]uint32) {
+	for x := range 256 {
+		s := aesSbox[x]
+		w := binary.BigEndian.Uint32([]byte{aesMul(s, 2), s, s, aesMul(s, 3)})
+		for r := range te {
+			te[r][x] = bits.RotateLeft32(w, -8*r)
+		}
+	}
+	return te
+}()
+
+// A CacheTimingAES is an AES encryption oracle implemented with T-tables, as
+// in older OpenSSL releases, running on a simulated data cache. Each table
+// lookup hits or misses the cache depending on whether its line was loaded,
+// and Encrypt returns the simulated time the encryption took. The index of
+// each lookup depends on the key, so the time leaks key bits to an attacker
+// who can evict lines, as another process sharing the cache can.
+//
+// Tables 0 to 3 are the 1 KiB T-tables of 32-bit words, table r indexed by
+// the bytes in row r of the state, and table 4 is the 256-byte S-box the last
+// round uses.
+type CacheTimingAES struct {
+	keys     [][aesBlockSize]byte
+	lineSize int
+	noise    int
+	rng      *rand.ChaCha8
+
+	cached [aesTables][]bool
+	counts [aesTables][]int
+}
+
+// NewCacheTimingAES returns a CacheTimingAES with key, which must be 16, 24,
+// or 32 bytes, and a cold cache. It panics if the key or the cache line size
+// is invalid. Use WithCacheLineSize and WithTimingNoise to change the cache
+// model, and WithSeed to make the noise deterministic.
+func NewCacheTimingAES(key []byte, opts ...Option) *CacheTimingAES {
+	o := newOptions(opts)
+	if o.cacheLineSize < 4 || o.cacheLineSize > 1024 || o.cacheLineSize&(o.cacheLineSize-1) != 0 {
+		panic("invalid cache line size")
+	}
+
+	c := &CacheTimingAES{
+		keys:     AESExpandKey(key, aesRounds(len(key))),
+		lineSize: o.cacheLineSize,
+		noise:    o.timingNoise,
+		rng:      o.newRand(),
+	}
+	for t := range aesTables {
+		n := c.Lines(t)
+		c.cached[t] = make([]bool, n)
+		c.counts[t] = make([]int, n)
+	}
+	return c
+}
+
+// tableSize returns the size in bytes of table t.
+func tableSize(t int) int {
+	if t == aesSboxTable {
+		return 256
+	}
+	return 1024
+}
+
+// LineSize returns the cache line size in bytes.
+func (c *CacheTimingAES) LineSize() int {
+	return c.lineSize
+}
+
+// Lines returns the number of cache lines table t spans.
+func (c *CacheTimingAES) Lines(t int) int {
+	return max(1, tableSize(t)/c.lineSize)
+}
+
+// Evict removes line of table t from the cache, so the next lookup in it
+// misses.
+func (c *CacheTimingAES) Evict(t, line int) {
+	c.cached[t][line] = false
+}
+
+// Flush evicts every line.
+func (c *CacheTimingAES) Flush() {
+	for t := range c.cached {
+		clear(c.cached[t])
+	}
+}
+
+// AccessCounts returns how many lookups each line of table t has had, over
+// every encryption.
+func (c *CacheTimingAES) AccessCounts(t int) []int {
+	return append([]int(nil), c.counts[t]...)
+}
+
+// lookup records a read of entry i of table t and returns its cost in
+// cycles.
+func (c *CacheTimingAES) lookup(t int, i byte) int {
+	entry := 4
+	if t == aesSboxTable {
+		entry = 1
+	}
+	line := int(i) * entry / c.lineSize
+	c.counts[t][line]++
+	if c.cached[t][line] {
+		return cacheHitCycles
+	}
+	c.cached[t][line] = true
+	return cacheMissCycles
+}
+
+// Encrypt encrypts the first block of src into dst, as AES, and returns the
+// simulated time it took in cycles.
+func (c *CacheTimingAES) Encrypt(dst, src []byte) int {
+	if len(src) < aesBlockSize || len(dst) < aesBlockSize {
+		panic("input not full block")
+	}
+
+	var s, t [4]uint32
+	for j := range s {
+		s[j] = binary.BigEndian.Uint32(src[4*j:]) ^ binary.BigEndian.Uint32(c.keys[0][4*j:])
+	}
+
+	var cycles int
+	n := len(c.keys) - 1
+	for round := 1; round < n; round++ {
+		for j := range t {
+			t[j] = binary.BigEndian.Uint32(c.keys[round][4*j:])
+			for r := range 4 {
+				// Row r of the output column comes from column j+r,
+				// after ShiftRows.
+				x := byte(s[(j+r)%4] >> (24 - 8*r))
+				cycles += c.lookup(r, x)
+				t[j] ^= aesTe[r][x]
+			}
+		}
+		s = t
+	}
+
+	for j := range t {
+		var w uint32
+		for r := range 4 {
+			x := byte(s[(j+r)%4] >> (24 - 8*r))
+			cycles += c.lookup(aesSboxTable, x)
+			w |= uint32(aesSbox[x]) << (24 - 8*r)
+		}
+		binary.BigEndian.PutUint32(dst[4*j:], w^binary.BigEndian.Uint32(c.keys[n][4*j:]))
+	}
+
+	if c.noise > 0 {
+		cycles += int(c.rng.Uint64() % uint64(c.noise+1))
+	}
+	return cycles
+}
+
+// RecoverCacheTimingKey recovers the high bits of each byte of c's key with
+// a first-round Evict+Time attack, using samples timings for each line of
+// the first four tables. It returns the key with the bits it can't see set
+// to zero, and how many high bits of each byte it recovered: with 64-byte
+// lines, which hold 16 table entries, that's 4.
+//
+// The first round looks up entry p[i] ^ k[i] of table i%4 for each
+// plaintext byte p[i] and key byte k[i]. The attack warms the cache with an
+// encryption, evicts one line, and times a second encryption of the same
+// random plaintext. It's slower whenever the line is read again, and always
+// when p[i] ^ k[i] falls in the line, while the other rounds read it only
+// most of the time. So for each byte, the candidate for k[i]'s high bits
+// that puts p[i] ^ k[i] in the evicted line most often when it's slow wins.
+// The other rounds' lookups depend on more than one key byte, which is what
+// a second-round attack uses to get the remaining bits.
+//
+// With the default cache model, 1600 samples recover every bit reliably,
+// and 800 occasionally get a byte wrong.
+func RecoverCacheTimingKey(c *CacheTimingAES, samples int, opts ...Option) (key []byte, known int) {
+	o := newOptions(opts)
+	rng := o.newRand()
+
+	// Each line holds 2^shift entries, so it reveals the top 8-shift bits of
+	// an index.
+	lines := c.Lines(0)
+	shift := 8 - (bits.Len(uint(lines)) - 1)
+
+	// sum[i][line][v] totals the times with line evicted from byte i's table
+	// and p[i] >> shift == v, and n counts them.
+	var sum, n [aesBlockSize][][]float64
+	for i := range sum {
+		sum[i] = make([][]float64, lines)
+		n[i] = make([][]float64, lines)
+		for l := range lines {
+			sum[i][l] = make([]float64, lines)
+			n[i][l] = make([]float64, lines)
+		}
+	}
+
+	pt, ct := make([]byte, aesBlockSize), make([]byte, aesBlockSize)
+	for t := range 4 {
+		for line := range lines {
+			for range samples {
+				for i := range pt {
+					pt[i] = byte(rng.Uint64())
+				}
+				c.Encrypt(ct, pt)
+				c.Evict(t, line)
+				d := float64(c.Encrypt(ct, pt))
+
+				for i := t; i < aesBlockSize; i += 4 {
+					v := pt[i] >> shift
+					sum[i][line][v] += d
+					n[i][line][v]++
+				}
+			}
+		}
+	}
+
+	// Candidate h puts p[i] ^ k[i] in line p[i]>>shift ^ h; score it by the
+	// mean time over those samples.
+	key = make([]byte, aesBlockSize)
+	for i := range key {
+		best, bestScore := 0, -1.0
+		for h := range lines {
+			var score float64
+			for v := range lines {
+				if cnt := n[i][v^h][v]; cnt > 0 {
+					score += sum[i][v^h][v] / cnt
+				}
+			}
+			if score > bestScore {
+				best, bestScore = h, score
+			}
+		}
+		key[i] = byte(best << shift)
+		o.debug("recovered key bits", "byte", i, "value", key[i])
+	}
+	return key, 8 - shift
+}
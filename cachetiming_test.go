@@ -0,0 +1,93 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCacheTimingAESEncrypt(t *testing.T) {
+	for _, size := range []int64{16, 24, 32} {
+		key := randBytes(size)
+		block, err := NewAES(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := NewCacheTimingAES(key)
+
+		for range 20 {
+			pt := randBytes(aesBlockSize)
+			got, want := make([]byte, aesBlockSize), make([]byte, aesBlockSize)
+			c.Encrypt(got, pt)
+			block.Encrypt(want, pt)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("key %x, plaintext %x: got %x, want %x", key, pt, got, want)
+			}
+		}
+	}
+}
+
+func TestCacheTimingAESCache(t *testing.T) {
+	c := NewCacheTimingAES(randBytes(16), WithTimingNoise(0))
+	pt, ct := randBytes(aesBlockSize), make([]byte, aesBlockSize)
+
+	// A cold cache misses at least once per line touched; a warm one hits
+	// every time.
+	cold := c.Encrypt(ct, pt)
+	warm := c.Encrypt(ct, pt)
+	if want := 160 * cacheHitCycles; warm != want {
+		t.Errorf("warm: got %d cycles, want %d", warm, want)
+	}
+	if cold <= warm {
+		t.Errorf("cold: got %d cycles, warm %d", cold, warm)
+	}
+
+	// Evicting a line the encryption reads costs one miss.
+	line := int(pt[0]^c.keys[0][0]) * 4 / c.LineSize()
+	c.Evict(0, line)
+	if got, want := c.Encrypt(ct, pt), warm+cacheMissCycles-cacheHitCycles; got != want {
+		t.Errorf("evicted: got %d cycles, want %d", got, want)
+	}
+
+	// AES-128 makes 144 T-table lookups and 16 S-box lookups.
+	var total int
+	for tbl := range 4 {
+		for _, n := range c.AccessCounts(tbl) {
+			total += n
+		}
+	}
+	if total != 3*144 {
+		t.Errorf("got %d T-table lookups, want %d", total, 3*144)
+	}
+	if lines := c.Lines(0); lines != 16 {
+		t.Errorf("got %d lines, want 16", lines)
+	}
+}
+
+func TestRecoverCacheTimingKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples int
+		opts    []Option
+		known   int
+	}{
+		{"default", 1600, nil, 4},
+		{"16-byte lines", 400, []Option{WithCacheLineSize(16), WithTimingNoise(0)}, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := []byte("YELLOW SUBMARINE")
+			c := NewCacheTimingAES(key, append(tt.opts, WithSeed(1))...)
+
+			got, known := RecoverCacheTimingKey(c, tt.samples, WithSeed(2))
+			if known != tt.known {
+				t.Fatalf("got %d known bits, want %d", known, tt.known)
+			}
+			mask := byte(0xff << (8 - known))
+			for i := range key {
+				if got[i] != key[i]&mask {
+					t.Errorf("byte %d: got %02x, want %02x", i, got[i], key[i]&mask)
+				}
+			}
+		})
+	}
+}
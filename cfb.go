@@ -0,0 +1,207 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// cfb implements full-block cipher feedback mode.
+type cfb struct {
+	b       cipher.Block
+	next    []byte // The ciphertext block being built, fed back into b.
+	out     []byte // Keystream for the current block.
+	used    int    // Keystream bytes used so far.
+	decrypt bool
+}
+
+func (c *cfb) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for len(src) > 0 {
+		if c.used == len(c.out) {
+			c.b.Encrypt(c.out, c.next)
+			c.used = 0
+		}
+
+		// The ciphertext is fed back, which is src when decrypting and dst
+		// when encrypting. Save src first in case it overlaps dst.
+		if c.decrypt {
+			copy(c.next[c.used:], src)
+		}
+		n := subtle.XORBytes(dst, src, c.out[c.used:])
+		if !c.decrypt {
+			copy(c.next[c.used:], dst[:n])
+		}
+
+		dst, src = dst[n:], src[n:]
+		c.used += n
+	}
+}
+
+func newCFB(b cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		panic("invalid iv length")
+	}
+	return &cfb{
+		b:       b,
+		next:    slices.Clone(iv),
+		out:     make([]byte, bs),
+		used:    bs,
+		decrypt: decrypt,
+	}
+}
+
+// NewCFBEncrypter returns a cipher.Stream which encrypts in full-block cipher
+// feedback mode.
+func NewCFBEncrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return newCFB(b, iv, false)
+}
+
+// NewCFBDecrypter returns a cipher.Stream which decrypts in full-block cipher
+// feedback mode.
+func NewCFBDecrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return newCFB(b, iv, true)
+}
+
+// cfb8 implements 8-bit cipher feedback mode, which encrypts the shift
+// register once per byte.
+type cfb8 struct {
+	b       cipher.Block
+	reg     []byte // The last block of ciphertext, starting with the IV.
+	out     []byte
+	decrypt bool
+}
+
+func (c *cfb8) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for i, v := range src {
+		c.b.Encrypt(c.out, c.reg)
+
+		res := v ^ c.out[0]
+
+		// Shift the ciphertext byte into the register.
+		ct := res
+		if c.decrypt {
+			ct = v
+		}
+		copy(c.reg, c.reg[1:])
+		c.reg[len(c.reg)-1] = ct
+
+		dst[i] = res
+	}
+}
+
+func newCFB8(b cipher.Block, iv []byte, decrypt bool) cipher.Stream {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		panic("invalid iv length")
+	}
+	return &cfb8{
+		b:       b,
+		reg:     slices.Clone(iv),
+		out:     make([]byte, bs),
+		decrypt: decrypt,
+	}
+}
+
+// NewCFB8Encrypter returns a cipher.Stream which encrypts in 8-bit cipher
+// feedback mode.
+func NewCFB8Encrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return newCFB8(b, iv, false)
+}
+
+// NewCFB8Decrypter returns a cipher.Stream which decrypts in 8-bit cipher
+// feedback mode.
+func NewCFB8Decrypter(b cipher.Block, iv []byte) cipher.Stream {
+	return newCFB8(b, iv, true)
+}
+
+// The fixed strings around user data, as in challenge 16.
+const (
+	userDataPrefix = "comment1=cooking%20MCs;userdata="
+	userDataSuffix = ";comment2=%20like%20a%20pound%20of%20bacon"
+)
+
+// quoteUserData escapes the characters that separate fields in user data.
+func quoteUserData(s string) string {
+	return strings.NewReplacer(";", url.QueryEscape(";"), "=", url.QueryEscape("=")).Replace(s)
+}
+
+// CFBUserData encrypts user data under AES-CFB with a random IV, in the
+// style of challenge 16.
+type CFBUserData struct {
+	block cipher.Block
+	o     *options
+}
+
+// NewCFBUserData returns a new CFBUserData. The key is 16 bytes unless set
+// with WithKeySize, and WithCipher replaces AES.
+func NewCFBUserData(opts ...Option) *CFBUserData {
+	o := newOptions(opts)
+	block := o.newBlock(randBytes(int64(o.keySize)))
+	return &CFBUserData{block: block, o: o}
+}
+
+// Encrypt returns iv || encrypt(prefix || quote(userData) || suffix), where
+// quoting escapes ";" and "=".
+func (u *CFBUserData) Encrypt(userData string) []byte {
+	pt := userDataPrefix + quoteUserData(userData) + userDataSuffix
+	u.o.debug("user data encrypted", "plaintext", pt)
+
+	iv := randBytes(int64(u.block.BlockSize()))
+
+	res := slices.Concat(iv, []byte(pt))
+	NewCFBEncrypter(u.block, iv).XORKeyStream(res[len(iv):], res[len(iv):])
+
+	return res
+}
+
+// IsAdmin decrypts iv || ct and reports whether the plaintext contains
+// ";admin=true;".
+func (u *CFBUserData) IsAdmin(ct []byte) bool {
+	bs := u.block.BlockSize()
+	if len(ct) < bs {
+		return false
+	}
+
+	pt := make([]byte, len(ct)-bs)
+	NewCFBDecrypter(u.block, ct[:bs]).XORKeyStream(pt, ct[bs:])
+
+	return strings.Contains(string(pt), ";admin=true;")
+}
+
+// NewCFBAdminCiphertext performs a CFB bitflipping attack to create a
+// ciphertext that u considers an admin's.
+//
+// In CFB mode, each plaintext block is its ciphertext block XOR a keystream
+// block derived from the previous ciphertext block. Flipping a ciphertext bit
+// flips the same plaintext bit, garbles the next block, and leaves every
+// block after that intact. The attack encrypts ":admin<true:", whose
+// characters each differ by one bit from ";admin=true;", and flips those bits
+// in place. The garbled block that follows falls in the suffix, which isn't
+// checked.
+//
+// NewCFBAdminCiphertext assumes u uses a block cipher with 16-byte blocks, so
+// that the payload fits in a single block.
+//
+// The same attack against CFB-8 garbles the 16 bytes after each flipped byte,
+// so it can't change bytes that are fewer than 17 apart.
+func NewCFBAdminCiphertext(u *CFBUserData) []byte {
+	const (
+		payload = ":admin<true:"
+		want    = ";admin=true;"
+	)
+
+	ct := u.Encrypt(payload)
+
+	// Skip the IV and the prefix.
+	start := u.block.BlockSize() + len(userDataPrefix)
+	XORInto(ct[start:start+len(payload)], ct[start:start+len(payload)], XOR([]byte(payload), []byte(want)))
+
+	return ct
+}
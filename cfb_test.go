@@ -0,0 +1,90 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+)
+
+// These test vectors are from NIST SP 800-38A, appendix F.3.
+var cfbTests = []struct {
+	name          string
+	newEnc        func(cipher.Block, []byte) cipher.Stream
+	newDec        func(cipher.Block, []byte) cipher.Stream
+	key, iv       string
+	plaintext, ct string
+}{
+	{
+		name:      "CFB128-AES128",
+		newEnc:    NewCFBEncrypter,
+		newDec:    NewCFBDecrypter,
+		key:       "2b7e151628aed2a6abf7158809cf4f3c",
+		iv:        "000102030405060708090a0b0c0d0e0f",
+		plaintext: "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710",
+		ct:        "3b3fd92eb72dad20333449f8e83cfb4ac8a64537a0b3a93fcde3cdad9f1ce58b26751f67a3cbb140b1808cf187a4f4dfc04b05357c5d1c0eeac4c66f9ff7f2e6",
+	},
+	{
+		name:      "CFB8-AES128",
+		newEnc:    NewCFB8Encrypter,
+		newDec:    NewCFB8Decrypter,
+		key:       "2b7e151628aed2a6abf7158809cf4f3c",
+		iv:        "000102030405060708090a0b0c0d0e0f",
+		plaintext: "6bc1bee22e409f96e93d7e117393172aae2d",
+		ct:        "3b79424c9c0dd436bace9e0ed4586a4f32b9",
+	},
+}
+
+func TestCFB(t *testing.T) {
+	for _, tc := range cfbTests {
+		t.Run(tc.name, func(t *testing.T) {
+			block, err := aes.NewCipher(decodeHex(t, tc.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			iv := decodeHex(t, tc.iv)
+			pt := decodeHex(t, tc.plaintext)
+			want := decodeHex(t, tc.ct)
+
+			got := make([]byte, len(pt))
+			tc.newEnc(block, iv).XORKeyStream(got, pt)
+			if !bytes.Equal(want, got) {
+				t.Errorf("encrypt: want %x, got %x", want, got)
+			}
+
+			// Decrypt in place and in uneven pieces.
+			dec := tc.newDec(block, iv)
+			for b := got; len(b) > 0; {
+				n := min(len(b), 7)
+				dec.XORKeyStream(b[:n], b[:n])
+				b = b[n:]
+			}
+			if !bytes.Equal(pt, got) {
+				t.Errorf("decrypt: want %x, got %x", pt, got)
+			}
+		})
+	}
+}
+
+func TestCFBAdminCiphertext(t *testing.T) {
+	u := NewCFBUserData()
+
+	if u.IsAdmin(u.Encrypt(";admin=true;")) {
+		t.Fatal("user data isn't quoted")
+	}
+
+	if !u.IsAdmin(NewCFBAdminCiphertext(u)) {
+		t.Error("not an admin ciphertext")
+	}
+}
+
+func TestCFBAdminCiphertextKeySizes(t *testing.T) {
+	for _, ks := range keySizes {
+		u := NewCFBUserData(WithKeySize(ks))
+
+		if !u.IsAdmin(NewCFBAdminCiphertext(u)) {
+			t.Errorf("key size %d: not an admin ciphertext", ks)
+		}
+	}
+}
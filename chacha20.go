@@ -0,0 +1,112 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/bits"
+)
+
+// ChaCha20 sizes in bytes.
+const (
+	ChaCha20KeySize   = 32
+	ChaCha20NonceSize = 12
+)
+
+// chachaConstants are the first four words of the ChaCha state,
+// "expand 32-byte k" in little-endian order.
+var chachaConstants = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+// chachaQuarterRound is the ChaCha quarter round from RFC 8439, section 2.1.
+func chachaQuarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d = bits.RotateLeft32(d^a, 16)
+	c += d
+	b = bits.RotateLeft32(b^c, 12)
+	a += b
+	d = bits.RotateLeft32(d^a, 8)
+	c += d
+	b = bits.RotateLeft32(b^c, 7)
+	return a, b, c, d
+}
+
+// ChaChaCore is the ChaCha block function with a configurable number of
+// rounds. It runs rounds rounds on in, alternating column and diagonal
+// rounds starting with a column round, and adds in to the result. ChaCha20
+// uses 20.
+func ChaChaCore(in *[16]uint32, rounds int) [16]uint32 {
+	x := *in
+	for i := range rounds {
+		if i%2 == 0 {
+			x[0], x[4], x[8], x[12] = chachaQuarterRound(x[0], x[4], x[8], x[12])
+			x[1], x[5], x[9], x[13] = chachaQuarterRound(x[1], x[5], x[9], x[13])
+			x[2], x[6], x[10], x[14] = chachaQuarterRound(x[2], x[6], x[10], x[14])
+			x[3], x[7], x[11], x[15] = chachaQuarterRound(x[3], x[7], x[11], x[15])
+		} else {
+			x[0], x[5], x[10], x[15] = chachaQuarterRound(x[0], x[5], x[10], x[15])
+			x[1], x[6], x[11], x[12] = chachaQuarterRound(x[1], x[6], x[11], x[12])
+			x[2], x[7], x[8], x[13] = chachaQuarterRound(x[2], x[7], x[8], x[13])
+			x[3], x[4], x[9], x[14] = chachaQuarterRound(x[3], x[4], x[9], x[14])
+		}
+	}
+	for i := range x {
+		x[i] += in[i]
+	}
+	return x
+}
+
+// chacha20State returns the initial ChaCha20 state for key, counter, and
+// nonce. It panics if key or nonce is the wrong size.
+func chacha20State(key []byte, counter uint32, nonce []byte) [16]uint32 {
+	if len(key) != ChaCha20KeySize {
+		panic("invalid key size")
+	}
+	if len(nonce) != ChaCha20NonceSize {
+		panic("invalid nonce size")
+	}
+
+	var s [16]uint32
+	copy(s[:4], chachaConstants[:])
+	for i := range 8 {
+		s[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	s[12] = counter
+	for i := range 3 {
+		s[13+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	return s
+}
+
+// ChaCha20Block returns the 64-byte ChaCha20 keystream block for key,
+// counter, and nonce, as specified in RFC 8439, section 2.3. It panics if key
+// isn't 32 bytes or nonce isn't 12.
+func ChaCha20Block(key []byte, counter uint32, nonce []byte) [arxBlockSize]byte {
+	s := chacha20State(key, counter, nonce)
+	return arxBytes(ChaChaCore(&s, 20))
+}
+
+// NewChaCha20 returns a cipher.Stream that encrypts and decrypts with the
+// ChaCha20 stream cipher from RFC 8439, implemented from the specification,
+// starting at block counter. It panics if key isn't 32 bytes or nonce isn't
+// 12, and XORKeyStream panics if the 32-bit block counter overflows.
+//
+// Reusing a key and nonce reuses the keystream. See RecoverReusedKeystream.
+func NewChaCha20(key, nonce []byte, counter uint32) cipher.Stream {
+	return NewChaCha(key, nonce, counter, 20)
+}
+
+// NewChaCha is like NewChaCha20, but with the given number of rounds, such as
+// 8 for ChaCha8, the fewest in common use. Fewer rounds are for experimenting
+// with attacks. It panics if rounds isn't positive.
+func NewChaCha(key, nonce []byte, counter uint32, rounds int) cipher.Stream {
+	checkARXRounds(rounds)
+	return &arxStream{
+		state:  chacha20State(key, counter, nonce),
+		core:   ChaChaCore,
+		rounds: rounds,
+		step: func(s *[16]uint32) bool {
+			s[12]++
+			return s[12] == 0
+		},
+		off: arxBlockSize,
+	}
+}
@@ -0,0 +1,70 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+// rfc8439Key is the key RFC 8439 uses in its examples, 00 01 02 ... 1f.
+var rfc8439Key = func() []byte {
+	k := make([]byte, 32)
+	for i := range k {
+		k[i] = byte(i)
+	}
+	return k
+}()
+
+func TestChaChaQuarterRound(t *testing.T) {
+	// RFC 8439, section 2.1.1.
+	a, b, c, d := chachaQuarterRound(0x11111111, 0x01020304, 0x9b8d6f43, 0x01234567)
+	if a != 0xea2a92f4 || b != 0xcb1cf8ce || c != 0x4581472e || d != 0x5881c4bb {
+		t.Errorf("got %08x %08x %08x %08x", a, b, c, d)
+	}
+}
+
+func TestChaCha20Block(t *testing.T) {
+	// RFC 8439, section 2.3.2.
+	nonce := decodeHex(t, "000000090000004a00000000")
+	want := decodeHex(t, "10f1e7e4d13b5915500fdd1fa32071c4c7d1f4c733c068030422aa9ac3d46c4ed2826446079faa0914c2d705d98b02a2b5129cd1de164eb9cbd083e8a2503c4e")
+
+	got := ChaCha20Block(rfc8439Key, 1, nonce)
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestChaCha20(t *testing.T) {
+	// RFC 8439, section 2.4.2.
+	nonce := decodeHex(t, "000000000000004a00000000")
+	pt := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	want := decodeHex(t, "6e2e359a2568f98041ba0728dd0d6981e97e7aec1d4360c20a27afccfd9fae0bf91b65c5524733ab8f593dabcd62b3571639d624e65152ab8f530c359f0861d807ca0dbf500d6a6156a38e088a22b65e52bc514d16ccf806818ce91ab77937365af90bbf74a35be6b40b8eedf2785e42874d")
+
+	ct := make([]byte, len(pt))
+	NewChaCha20(rfc8439Key, nonce, 1).XORKeyStream(ct, pt)
+	if !bytes.Equal(ct, want) {
+		t.Errorf("got %x, want %x", ct, want)
+	}
+
+	// Encrypting in pieces gives the same result.
+	s := NewChaCha20(rfc8439Key, nonce, 1)
+	for i := 0; i < len(pt); i += 7 {
+		j := min(i+7, len(pt))
+		s.XORKeyStream(ct[i:j], pt[i:j])
+	}
+	if !bytes.Equal(ct, want) {
+		t.Errorf("chunked: got %x, want %x", ct, want)
+	}
+}
+
+func TestChaCha20CounterOverflow(t *testing.T) {
+	s := NewChaCha20(rfc8439Key, make([]byte, 12), 1<<32-1)
+	buf := make([]byte, 64)
+	s.XORKeyStream(buf, buf)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	s.XORKeyStream(buf[:1], buf[:1])
+}
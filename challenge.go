@@ -0,0 +1,407 @@
+package cryptopals
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrUnsolved is returned by Solve for challenges without a solution yet.
+var ErrUnsolved = errors.New("challenge not solved yet")
+
+// A Challenge describes one of the Cryptopals challenges.
+type Challenge struct {
+	Number   int
+	Set      int
+	Title    string
+	Testdata []string // Files the solution reads, relative to the testdata directory.
+
+	solve func(fsys fs.FS, opts []Option) (string, error) // Nil if unsolved.
+}
+
+// Solved reports whether the challenge has a solution that Solve can run.
+func (c Challenge) Solved() bool {
+	return c.solve != nil
+}
+
+// A Result is the outcome of solving a challenge.
+type Result struct {
+	Challenge Challenge
+	Answer    string // A short summary, such as a recovered key or the first line of a plaintext.
+	Elapsed   time.Duration
+	Usage     Usage // Oracle queries the solution's attacks made.
+}
+
+// WithTestdata sets where Solve reads challenge data files from. The default
+// is the testdata directory in the current working directory.
+func WithTestdata(fsys fs.FS) Option {
+	return func(o *options) {
+		o.testdata = fsys
+	}
+}
+
+// Challenges returns every challenge in the registry, in order.
+func Challenges() []Challenge {
+	return append([]Challenge(nil), challenges...)
+}
+
+// LookupChallenge returns challenge n, if it's in the registry.
+func LookupChallenge(n int) (Challenge, bool) {
+	if n < 1 || n > len(challenges) {
+		return Challenge{}, false
+	}
+	return challenges[n-1], true
+}
+
+// Solve runs the solution to challenge n and checks its answer.
+//
+// It returns ErrUnsolved if there's no solution yet, and another error if the
+// solution fails or gets the wrong answer. Options are passed on to the
+// challenge's oracles and attacks, so WithLogger and WithProgress observe
+// them as they run, and WithBudget limits them. Use WithTestdata to choose
+// where data files are read from.
+func Solve(n int, opts ...Option) (*Result, error) {
+	c, ok := LookupChallenge(n)
+	if !ok {
+		return nil, fmt.Errorf("no challenge %d", n)
+	}
+	if !c.Solved() {
+		return nil, fmt.Errorf("challenge %d: %w", n, ErrUnsolved)
+	}
+
+	o := newOptions(opts)
+	fsys := o.testdata
+	if fsys == nil {
+		fsys = os.DirFS("testdata")
+	}
+
+	var usage Usage
+	start := time.Now()
+	answer, err := c.solve(fsys, append(opts[:len(opts):len(opts)], WithUsage(&usage)))
+	if err != nil {
+		return nil, fmt.Errorf("challenge %d: %w", n, err)
+	}
+
+	return &Result{Challenge: c, Answer: answer, Elapsed: time.Since(start), Usage: usage}, nil
+}
+
+// errWrongAnswer is returned by solutions that get a known answer wrong.
+var errWrongAnswer = errors.New("wrong answer")
+
+// challenges is the registry, indexed by challenge number minus one.
+var challenges = []Challenge{
+	{Number: 1, Set: 1, Title: "Convert hex to base64", solve: solveChallenge1},
+	{Number: 2, Set: 1, Title: "Fixed XOR", solve: solveChallenge2},
+	{Number: 3, Set: 1, Title: "Single-byte XOR cipher", solve: solveChallenge3},
+	{Number: 4, Set: 1, Title: "Detect single-character XOR", Testdata: []string{"4.txt"}, solve: solveChallenge4},
+	{Number: 5, Set: 1, Title: "Implement repeating-key XOR", solve: solveChallenge5},
+	{Number: 6, Set: 1, Title: "Break repeating-key XOR", Testdata: []string{"6.txt"}, solve: solveChallenge6},
+	{Number: 7, Set: 1, Title: "AES in ECB mode", Testdata: []string{"7.txt"}, solve: solveChallenge7},
+	{Number: 8, Set: 1, Title: "Detect AES in ECB mode", Testdata: []string{"8.txt"}, solve: solveChallenge8},
+	{Number: 9, Set: 2, Title: "Implement PKCS#7 padding", solve: solveChallenge9},
+	{Number: 10, Set: 2, Title: "Implement CBC mode", Testdata: []string{"10.txt"}, solve: solveChallenge10},
+	{Number: 11, Set: 2, Title: "An ECB/CBC detection oracle", solve: solveChallenge11},
+	{Number: 12, Set: 2, Title: "Byte-at-a-time ECB decryption (Simple)", solve: solveChallenge12},
+	{Number: 13, Set: 2, Title: "ECB cut-and-paste", solve: solveChallenge13},
+	{Number: 14, Set: 2, Title: "Byte-at-a-time ECB decryption (Harder)"},
+	{Number: 15, Set: 2, Title: "PKCS#7 padding validation", solve: solveChallenge15},
+	{Number: 16, Set: 2, Title: "CBC bitflipping attacks"},
+	{Number: 17, Set: 3, Title: "The CBC padding oracle", solve: solveChallenge17},
+	{Number: 18, Set: 3, Title: "Implement CTR, the stream cipher mode", solve: solveChallenge18},
+}
+
+// readBase64File reads and decodes a base64 file from fsys. Newlines are
+// ignored.
+func readBase64File(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(base64.NewDecoder(base64.StdEncoding, f))
+}
+
+// readHexLines reads a file from fsys with one hex string per line.
+func readHexLines(fsys fs.FS, name string) ([][]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res [][]byte
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		b, err := hex.DecodeString(s.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		res = append(res, b)
+	}
+
+	return res, s.Err()
+}
+
+// firstLine returns the first line of b without surrounding whitespace.
+func firstLine(b []byte) string {
+	line, _, _ := bytes.Cut(b, []byte("\n"))
+	return strings.TrimSpace(string(line))
+}
+
+func solveChallenge1(fs.FS, []Option) (string, error) {
+	got, err := HexToBase64("49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f69736f6e6f7573206d757368726f6f6d")
+	if err != nil {
+		return "", err
+	}
+	if got != "SSdtIGtpbGxpbmcgeW91ciBicmFpbiBsaWtlIGEgcG9pc29ub3VzIG11c2hyb29t" {
+		return "", errWrongAnswer
+	}
+	return got, nil
+}
+
+func solveChallenge2(fs.FS, []Option) (string, error) {
+	a, _ := hex.DecodeString("1c0111001f010100061a024b53535009181c")
+	b, _ := hex.DecodeString("686974207468652062756c6c277320657965")
+
+	got := hex.EncodeToString(XOR(a, b))
+	if got != "746865206b696420646f6e277420706c6179" {
+		return "", errWrongAnswer
+	}
+	return got, nil
+}
+
+func solveChallenge3(_ fs.FS, opts []Option) (string, error) {
+	ct, _ := hex.DecodeString("1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+
+	key := RecoverSingleByteXORKey(ct, opts...)
+	if key != 88 {
+		return "", errWrongAnswer
+	}
+
+	NewSingleByteXORCipher(key).XORKeyStream(ct, ct)
+	return string(ct), nil
+}
+
+func solveChallenge4(fsys fs.FS, opts []Option) (string, error) {
+	cts, err := readHexLines(fsys, "4.txt")
+	if err != nil {
+		return "", err
+	}
+
+	i := FindSingleByteXORCiphertext(cts, opts...)
+	if i != 170 {
+		return "", errWrongAnswer
+	}
+
+	ct := cts[i]
+	NewSingleByteXORCipher(RecoverSingleByteXORKey(ct, opts...)).XORKeyStream(ct, ct)
+	return firstLine(ct), nil
+}
+
+func solveChallenge5(fs.FS, []Option) (string, error) {
+	pt := []byte("Burning 'em, if you ain't quick and nimble\nI go crazy when I hear a cymbal")
+
+	NewRepeatingKeyXORCipher([]byte("ICE")).XORKeyStream(pt, pt)
+
+	got := hex.EncodeToString(pt)
+	if got != "0b3637272a2b2e63622c2e69692a23693a2a3c6324202d623d63343c2a26226324272765272a282b2f20430a652e2c652a3124333a653e2b2027630c692b20283165286326302e27282f" {
+		return "", errWrongAnswer
+	}
+	return got, nil
+}
+
+func solveChallenge6(fsys fs.FS, opts []Option) (string, error) {
+	ct, err := readBase64File(fsys, "6.txt")
+	if err != nil {
+		return "", err
+	}
+
+	key, err := RecoverRepeatingKeyXORKey(ct, opts...)
+	if err != nil {
+		return "", err
+	}
+	if string(key) != "Terminator X: Bring the noise" {
+		return "", errWrongAnswer
+	}
+	return string(key), nil
+}
+
+func solveChallenge7(fsys fs.FS, _ []Option) (string, error) {
+	ct, err := readBase64File(fsys, "7.txt")
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		return "", err
+	}
+	NewECBDecrypter(block).CryptBlocks(ct, ct)
+
+	got := firstLine(ct)
+	if got != "I'm back and I'm ringin' the bell" {
+		return "", errWrongAnswer
+	}
+	return got, nil
+}
+
+func solveChallenge8(fsys fs.FS, _ []Option) (string, error) {
+	cts, err := readHexLines(fsys, "8.txt")
+	if err != nil {
+		return "", err
+	}
+
+	i := FindMostECBLike(cts, aes.BlockSize)
+	if i != 132 {
+		return "", errWrongAnswer
+	}
+	return fmt.Sprintf("line %d", i+1), nil
+}
+
+func solveChallenge9(fs.FS, []Option) (string, error) {
+	got := PadPKCS7([]byte("YELLOW SUBMARINE"), 20)
+	if string(got) != "YELLOW SUBMARINE\x04\x04\x04\x04" {
+		return "", errWrongAnswer
+	}
+	return hex.EncodeToString(got), nil
+}
+
+func solveChallenge10(fsys fs.FS, _ []Option) (string, error) {
+	ct, err := readBase64File(fsys, "10.txt")
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		return "", err
+	}
+	NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(ct, ct)
+
+	got := firstLine(ct)
+	if got != "I'm back and I'm ringin' the bell" {
+		return "", errWrongAnswer
+	}
+	return got, nil
+}
+
+func solveChallenge11(_ fs.FS, opts []Option) (string, error) {
+	var nECB int
+	for range 100 {
+		if IsECBOracle(NewECBOrCBCPrefixSuffixOracle(opts...)) {
+			nECB++
+		}
+	}
+
+	// Within 4 standard deviations of a fair coin.
+	if nECB < 30 || nECB > 70 {
+		return "", fmt.Errorf("detected %d of 100 oracles as ECB", nECB)
+	}
+	return fmt.Sprintf("%d ECB, %d CBC", nECB, 100-nECB), nil
+}
+
+// challenge12Secret is the base64-encoded secret from challenge 12.
+const challenge12Secret = "Um9sbGluJyBpbiBteSA1LjAKV2l0aCBteSByYWctdG9wIGRvd24gc28gbXkgaGFpciBjYW4gYmxvdwpUaGUgZ2lybGllcyBvbiBzdGFuZGJ5IHdhdmluZyBqdXN0IHRvIHNheSBoaQpEaWQgeW91IHN0b3A/IE5vLCBJIGp1c3QgZHJvdmUgYnkK"
+
+func solveChallenge12(_ fs.FS, opts []Option) (string, error) {
+	secret, _ := base64.StdEncoding.DecodeString(challenge12Secret)
+
+	a := &ECBSuffixAttack{Options: opts}
+	res, err := a.Run(context.Background(), OracleFunc(NewECBSuffixOracle(secret, opts...)))
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(res.Secret, secret) {
+		return "", errWrongAnswer
+	}
+	return firstLine(res.Secret), nil
+}
+
+func solveChallenge13(_ fs.FS, opts []Option) (string, error) {
+	m := NewProfileManager(opts...)
+	if !m.IsAdmin(NewAdminProfile(m)) {
+		return "", errors.New("forged profile isn't an admin")
+	}
+	return "forged an admin profile", nil
+}
+
+func solveChallenge15(fs.FS, []Option) (string, error) {
+	got, err := PKCS7Padder{}.Unpad([]byte("ICE ICE BABY\x04\x04\x04\x04"), aes.BlockSize)
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range []string{"ICE ICE BABY\x05\x05\x05\x05", "ICE ICE BABY\x01\x02\x03\x04"} {
+		if _, err := (PKCS7Padder{}).Unpad([]byte(s), aes.BlockSize); !errors.Is(err, ErrInvalidPadding) {
+			return "", fmt.Errorf("accepted invalid padding %q", s)
+		}
+	}
+
+	return string(got), nil
+}
+
+// challenge17Secrets are the base64-encoded plaintexts from challenge 17.
+var challenge17Secrets = []string{
+	"MDAwMDAwTm93IHRoYXQgdGhlIHBhcnR5IGlzIGp1bXBpbmc=",
+	"MDAwMDAxV2l0aCB0aGUgYmFzcyBraWNrZWQgaW4gYW5kIHRoZSBWZWdhJ3MgYXJlIHB1bXBpbic=",
+	"MDAwMDAyUXVpY2sgdG8gdGhlIHBvaW50LCB0byB0aGUgcG9pbnQsIG5vIGZha2luZw==",
+	"MDAwMDAzQ29va2luZyBNQydzIGxpa2UgYSBwb3VuZCBvZiBiYWNvbg==",
+	"MDAwMDA0QnVybmluZyAnZW0sIGlmIHlvdSBhaW4ndCBxdWljayBhbmQgbmltYmxl",
+	"MDAwMDA1SSBnbyBjcmF6eSB3aGVuIEkgaGVhciBhIGN5bWJhbA==",
+	"MDAwMDA2QW5kIGEgaGlnaCBoYXQgd2l0aCBhIHNvdXBlZCB1cCB0ZW1wbw==",
+	"MDAwMDA3SSdtIG9uIGEgcm9sbCwgaXQncyB0aW1lIHRvIGdvIHNvbG8=",
+	"MDAwMDA4b2xsaW4nIGluIG15IGZpdmUgcG9pbnQgb2g=",
+	"MDAwMDA5aXRoIG15IHJhZy10b3AgZG93biBzbyBteSBoYWlyIGNhbiBibG93",
+}
+
+// Challenge17Secrets returns the plaintexts from challenge 17, for padding
+// oracles to encrypt. The slices are new on each call.
+func Challenge17Secrets() [][]byte {
+	res := make([][]byte, len(challenge17Secrets))
+	for i, s := range challenge17Secrets {
+		res[i], _ = base64.StdEncoding.DecodeString(s)
+	}
+	return res
+}
+
+func solveChallenge17(_ fs.FS, opts []Option) (string, error) {
+	secrets := Challenge17Secrets()
+	secret := secrets[randInt64(int64(len(secrets)))]
+
+	p := NewCBCPaddingOracle(secret, opts...)
+	a := &CBCPaddingOracleAttack{Ciphertext: p.Ciphertext(), BlockSize: p.BlockSize(), Options: opts}
+	res, err := a.Run(context.Background(), ValidityFunc(p.IsValid))
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(res.Secret, secret) {
+		return "", errWrongAnswer
+	}
+	return string(res.Secret), nil
+}
+
+func solveChallenge18(fs.FS, []Option) (string, error) {
+	ct, _ := base64.StdEncoding.DecodeString("L77na/nrFsKvynd6HzOoG7GHTLXsTVu9qvY/2syLXzhPweyyMTJULu/6/kXX0KSvoOLSFQ==")
+
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		return "", err
+	}
+	NewNonceCTR(block, 0).XORKeyStream(ct, ct)
+	if string(ct) != "Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby " {
+		return "", errWrongAnswer
+	}
+	return string(ct), nil
+}
@@ -0,0 +1,81 @@
+package cryptopals
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSolve(t *testing.T) {
+	for _, c := range Challenges() {
+		t.Run(fmt.Sprint(c.Number), func(t *testing.T) {
+			if !c.Solved() {
+				t.Skip("not solved yet")
+			}
+
+			res, err := Solve(c.Number)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Challenge.Number != c.Number {
+				t.Errorf("got result for challenge %d", res.Challenge.Number)
+			}
+
+			t.Logf("answer: %q", res.Answer)
+		})
+	}
+}
+
+func TestChallengesRegistry(t *testing.T) {
+	for i, c := range Challenges() {
+		if c.Number != i+1 {
+			t.Errorf("challenge at index %d has number %d", i, c.Number)
+		}
+		if c.Set != (c.Number+7)/8 {
+			t.Errorf("challenge %d is in set %d", c.Number, c.Set)
+		}
+		if c.Title == "" {
+			t.Errorf("challenge %d has no title", c.Number)
+		}
+	}
+}
+
+func TestLookupChallenge(t *testing.T) {
+	c, ok := LookupChallenge(6)
+	if !ok || c.Title != "Break repeating-key XOR" {
+		t.Errorf("got %+v, %t", c, ok)
+	}
+
+	for _, n := range []int{0, -1, 1000} {
+		if _, ok := LookupChallenge(n); ok {
+			t.Errorf("found challenge %d", n)
+		}
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	if _, err := Solve(14); !errors.Is(err, ErrUnsolved) {
+		t.Errorf("challenge 14: got %v, want %v", err, ErrUnsolved)
+	}
+
+	if _, err := Solve(1000); err == nil {
+		t.Error("challenge 1000: no error")
+	}
+
+	// Challenge 6 needs 6.txt, which isn't there.
+	if _, err := Solve(6, WithTestdata(fstest.MapFS{})); err == nil {
+		t.Error("challenge 6 without testdata: no error")
+	}
+}
+
+func TestSolveWithTestdata(t *testing.T) {
+	fsys := fstest.MapFS{
+		"8.txt": &fstest.MapFile{Data: []byte("00\n")},
+	}
+
+	// The only ciphertext isn't the right answer.
+	if _, err := Solve(8, WithTestdata(fsys)); !errors.Is(err, errWrongAnswer) {
+		t.Errorf("got %v, want %v", err, errWrongAnswer)
+	}
+}
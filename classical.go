@@ -0,0 +1,228 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"errors"
+	"sync"
+)
+
+// isLetter reports whether c is an ASCII letter.
+func isLetter(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
+}
+
+// letterIndex returns the position of the ASCII letter c in the alphabet,
+// ignoring case.
+func letterIndex(c byte) byte {
+	return (c | 0x20) - 'a'
+}
+
+// shiftLetter shifts the ASCII letter c forward by k places, wrapping around
+// and keeping its case.
+func shiftLetter(c, k byte) byte {
+	base := c & 0x20 // 0 for uppercase, 0x20 for lowercase.
+	return 'A' + base + (letterIndex(c)+k)%26
+}
+
+// vigenere is a Vigenère cipher. Its key is held as shifts from 0 to 25.
+type vigenere struct {
+	shifts []byte
+	i      int // Index into shifts of the next key letter.
+}
+
+func newVigenere(key []byte, decrypt bool) *vigenere {
+	if len(key) == 0 {
+		panic("empty key")
+	}
+
+	shifts := make([]byte, len(key))
+	for i, c := range key {
+		if !isLetter(c) {
+			panic("invalid key")
+		}
+		shifts[i] = letterIndex(c)
+		if decrypt {
+			shifts[i] = (26 - shifts[i]) % 26
+		}
+	}
+	return &vigenere{shifts: shifts}
+}
+
+// XORKeyStream shifts each letter of src by the next key letter. Other bytes
+// are copied unchanged and don't use up key letters.
+func (v *vigenere) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for i, c := range src {
+		if !isLetter(c) {
+			dst[i] = c
+			continue
+		}
+		dst[i] = shiftLetter(c, v.shifts[v.i])
+		v.i = (v.i + 1) % len(v.shifts)
+	}
+}
+
+// NewVigenereEncrypter returns a cipher.Stream that encrypts with the
+// alphabetic Vigenère cipher, which shifts each letter forward by the
+// matching key letter, where A shifts by 0 and Z by 25.
+//
+// Only ASCII letters are encrypted, and they keep their case. Other bytes pass
+// through unchanged and don't use up key letters. The key may be in either
+// case. It panics if key is empty or has bytes that aren't letters.
+func NewVigenereEncrypter(key []byte) cipher.Stream {
+	return newVigenere(key, false)
+}
+
+// NewVigenereDecrypter returns a cipher.Stream that decrypts what
+// NewVigenereEncrypter encrypts.
+func NewVigenereDecrypter(key []byte) cipher.Stream {
+	return newVigenere(key, true)
+}
+
+// caesar is a Caesar cipher.
+type caesar struct {
+	shift byte
+}
+
+// XORKeyStream shifts each letter of src. Other bytes are copied unchanged.
+func (c caesar) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for i, v := range src {
+		if isLetter(v) {
+			v = shiftLetter(v, c.shift)
+		}
+		dst[i] = v
+	}
+}
+
+// NewCaesarCipher returns a cipher.Stream that shifts each ASCII letter
+// forward by shift places, wrapping around and keeping its case. Other bytes
+// pass through unchanged. Shifts are taken mod 26, so NewCaesarCipher(-shift)
+// decrypts what NewCaesarCipher(shift) encrypts.
+func NewCaesarCipher(shift int) cipher.Stream {
+	return caesar{byte((shift%26 + 26) % 26)}
+}
+
+// ROT13 returns a new slice holding b with each ASCII letter shifted by 13
+// places. ROT13 is its own inverse.
+func ROT13(b []byte) []byte {
+	res := make([]byte, len(b))
+	NewCaesarCipher(13).XORKeyStream(res, b)
+	return res
+}
+
+// RecoverCaesarShift returns the most likely shift, from 0 to 25, for a Caesar
+// ciphertext. Decrypt with NewCaesarCipher(-shift).
+//
+// It assumes the plaintext is English. Use WithScorer to change how
+// plaintexts are scored; NewEnglishNGramScorer is more reliable for short
+// ciphertexts.
+func RecoverCaesarShift(ct []byte, opts ...Option) int {
+	o := newOptions(opts)
+
+	shift, _ := argmax(26, o.workers, o.threshold, func() func(int) float64 {
+		pt := make([]byte, len(ct))
+		return func(k int) float64 {
+			NewCaesarCipher(-k).XORKeyStream(pt, ct)
+			return o.scorer.Score(pt)
+		}
+	})
+	return shift
+}
+
+// englishLetters returns a scorer for English letter frequencies, ignoring
+// case, built from the embedded corpus.
+var englishLetters = sync.OnceValue(func() Scorer {
+	var d Distribution
+	for _, c := range englishText {
+		if isLetter(c) {
+			d['a'+letterIndex(c)]++
+		}
+	}
+	return d.Scorer()
+})
+
+// maxVigenereKeySize is the longest key RecoverVigenereKey tries.
+const maxVigenereKeySize = 20
+
+// englishIC is an index of coincidence that English letters usually reach,
+// and random letters don't. English letters score about 0.066 on average, and
+// random letters about 0.038.
+const englishIC = 0.063
+
+// vigenereColumnLetters is how many letters RecoverVigenereKey needs in each
+// column for it to try a key size. With fewer, the index of coincidence is too
+// noisy to compare key sizes.
+const vigenereColumnLetters = 20
+
+// RecoverVigenereKey returns the most likely key for an alphabetic Vigenère
+// ciphertext, as uppercase letters. Bytes that aren't letters are ignored.
+//
+// The key size is estimated from the index of coincidence of the letters in
+// each column, which is about as high as for English when every column is
+// encrypted with the same shift. Each column is then solved as a Caesar shift
+// by comparing its letter frequencies to English ones from the embedded
+// corpus.
+//
+// Key sizes up to 20 are tried, as long as each column gets at least 20
+// letters, so longer keys need longer ciphertexts. It returns an error if ct
+// has fewer than 40 letters. Use WithLogger to see the estimated key size.
+func RecoverVigenereKey(ct []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+
+	var letters []byte
+	for _, c := range ct {
+		if isLetter(c) {
+			letters = append(letters, letterIndex(c))
+		}
+	}
+
+	hi := min(maxVigenereKeySize, len(letters)/vigenereColumnLetters)
+	if hi < 2 {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	ics := make([]float64, hi+1)
+	best := 1
+	for ks := 1; ks <= hi; ks++ {
+		var sum float64
+		for _, col := range Transpose(letters, ks) {
+			sum += IndexOfCoincidence(col)
+		}
+		ics[ks] = sum / float64(ks)
+		if ics[ks] > ics[best] {
+			best = ks
+		}
+	}
+
+	// Multiples of the key size score as well as the key size itself, so
+	// prefer the smallest divisor of the best size that scores nearly as
+	// well, or as well as English does.
+	ks := 1
+	for best%ks != 0 || ics[ks] < min(0.9*ics[best], englishIC) {
+		ks++
+	}
+	o.debug("estimated key size", "key_size", ks, "ic", ics[ks])
+
+	key := make([]byte, ks)
+	scorer := englishLetters()
+	pt := make([]byte, 0, len(letters)/ks+1)
+
+	for i, col := range Transpose(letters, ks) {
+		bestScore := -1.0
+		for k := range byte(26) {
+			pt = pt[:0]
+			for _, c := range col {
+				pt = append(pt, 'a'+(c+26-k)%26)
+			}
+			if score := scorer.Score(pt); score > bestScore {
+				bestScore = score
+				key[i] = 'A' + k
+			}
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,119 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVigenere(t *testing.T) {
+	pt := []byte("Attack at dawn!")
+	key := []byte("LEMON")
+	want := []byte("Lxfopv ef rnhr!")
+
+	ct := make([]byte, len(pt))
+	NewVigenereEncrypter(key).XORKeyStream(ct, pt)
+	if !bytes.Equal(want, ct) {
+		t.Errorf("encrypt: want %q, got %q", want, ct)
+	}
+
+	// Decrypt in pieces to check that the key position carries over.
+	got := make([]byte, len(ct))
+	s := NewVigenereDecrypter([]byte("lemon"))
+	for i := 0; i < len(ct); i += 4 {
+		end := min(i+4, len(ct))
+		s.XORKeyStream(got[i:end], ct[i:end])
+	}
+	if !bytes.Equal(pt, got) {
+		t.Errorf("decrypt: want %q, got %q", pt, got)
+	}
+}
+
+func TestVigenereInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "KEY1", "k y"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: no panic", key)
+				}
+			}()
+			NewVigenereEncrypter([]byte(key))
+		}()
+	}
+}
+
+func TestRecoverVigenereKey(t *testing.T) {
+	pt := englishText[:2000]
+
+	for _, key := range []string{"K", "LEMON", "CRYPTOPALS", "VIGENERECIPHER"} {
+		ct := make([]byte, len(pt))
+		NewVigenereEncrypter([]byte(key)).XORKeyStream(ct, pt)
+
+		got, err := RecoverVigenereKey(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != key {
+			t.Errorf("want %q, got %q", key, got)
+		}
+	}
+}
+
+func TestRecoverVigenereKeyTooShort(t *testing.T) {
+	if _, err := RecoverVigenereKey(bytes.Repeat([]byte("ABC DEF 123 "), 4)); err == nil {
+		t.Error("want error for short ciphertext")
+	}
+}
+
+func TestCaesar(t *testing.T) {
+	pt := []byte("The quick brown fox, 1 lazy dog.")
+	want := []byte("Wkh txlfn eurzq ira, 1 odcb grj.")
+
+	ct := make([]byte, len(pt))
+	NewCaesarCipher(3).XORKeyStream(ct, pt)
+	if !bytes.Equal(want, ct) {
+		t.Errorf("encrypt: want %q, got %q", want, ct)
+	}
+
+	for _, shift := range []int{-3, 23, -29} {
+		got := make([]byte, len(ct))
+		NewCaesarCipher(shift).XORKeyStream(got, ct)
+		if !bytes.Equal(pt, got) {
+			t.Errorf("shift %d: want %q, got %q", shift, pt, got)
+		}
+	}
+}
+
+func TestROT13(t *testing.T) {
+	pt := []byte("Why did the chicken cross the road?")
+	want := []byte("Jul qvq gur puvpxra pebff gur ebnq?")
+
+	if got := ROT13(pt); !bytes.Equal(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got := ROT13(ROT13(pt)); !bytes.Equal(pt, got) {
+		t.Errorf("ROT13 isn't its own inverse: got %q", got)
+	}
+}
+
+func TestRecoverCaesarShift(t *testing.T) {
+	pt := []byte("Now that the party is jumping, with the bass kicked in and the Vega's are pumpin'")
+
+	for shift := range 26 {
+		ct := make([]byte, len(pt))
+		NewCaesarCipher(shift).XORKeyStream(ct, pt)
+
+		if got := RecoverCaesarShift(ct); got != shift {
+			t.Errorf("want shift %d, got %d", shift, got)
+		}
+	}
+}
+
+func TestRecoverCaesarShiftShort(t *testing.T) {
+	pt := []byte("attack at dawn")
+	ct := make([]byte, len(pt))
+	NewCaesarCipher(7).XORKeyStream(ct, pt)
+
+	if got := RecoverCaesarShift(ct, WithScorer(NewEnglishNGramScorer(3))); got != 7 {
+		t.Errorf("want shift 7, got %d", got)
+	}
+}
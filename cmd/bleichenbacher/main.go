@@ -0,0 +1,131 @@
+// Bleichenbacher serves a PKCS #1 v1.5 padding oracle for RSA-encrypted
+// pre-master secrets over TCP, and attacks it, for practicing challenges 47
+// and 48 against a real socket.
+//
+// Usage:
+//
+//	bleichenbacher serve [-addr addr] [-bits n] [-mode m] [-delay d]
+//	bleichenbacher attack [-addr addr] [-mode m] [-delay d] -n hex -e e -ct hex
+//
+// Serve generates a key, prints its modulus and exponent and an encrypted
+// pre-master secret, and answers queries. The mode is loose, strict, or
+// timing; see BleichenbacherMode. Attack recovers the plaintext of the
+// ciphertext through the server, and must be given the same mode, and for
+// timing, the same delay.
+//
+// See BleichenbacherOracle.Party for the protocol.
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/clfs/cryptopals"
+)
+
+var modes = map[string]cryptopals.BleichenbacherMode{
+	"loose":  cryptopals.BleichenbacherLoose,
+	"strict": cryptopals.BleichenbacherStrict,
+	"timing": cryptopals.BleichenbacherTiming,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: bleichenbacher serve|attack [flags]")
+	}
+
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	addr := fs.String("addr", "localhost:9047", "server address")
+	mode := fs.String("mode", "loose", "what the oracle reveals: loose, strict, or timing")
+	delay := fs.Duration("delay", 2*time.Millisecond, "timing difference in timing mode")
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		bits := fs.Int("bits", 768, "modulus size")
+		fs.Parse(os.Args[2:])
+		err = serve(*addr, *bits, *mode, *delay)
+	case "attack":
+		n := fs.String("n", "", "hex modulus")
+		e := fs.Int64("e", 3, "public exponent")
+		ct := fs.String("ct", "", "hex ciphertext")
+		fs.Parse(os.Args[2:])
+		err = attack(*addr, *mode, *delay, *n, *e, *ct)
+	default:
+		log.Fatalf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// options returns the options for a mode name and delay.
+func options(mode string, delay time.Duration) ([]cryptopals.Option, error) {
+	m, ok := modes[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown mode %q", mode)
+	}
+	return []cryptopals.Option{cryptopals.WithBleichenbacherMode(m), cryptopals.WithLeakDelay(delay)}, nil
+}
+
+func serve(addr string, bits int, mode string, delay time.Duration) error {
+	opts, err := options(mode, delay)
+	if err != nil {
+		return err
+	}
+	k, err := cryptopals.GenerateRSAKey(bits, 3)
+	if err != nil {
+		return err
+	}
+	ct, err := cryptopals.EncryptPKCS1v15(&k.RSAPublicKey, []byte("kick it, CC"))
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("serving a %s oracle on %s", mode, l.Addr())
+	fmt.Printf("-n %x -e %d -ct %x\n", k.N, k.E, ct)
+	return cryptopals.ServeBleichenbacherOracle(l, cryptopals.NewBleichenbacherOracle(k, opts...))
+}
+
+func attack(addr, mode string, delay time.Duration, n string, e int64, ct string) error {
+	opts, err := options(mode, delay)
+	if err != nil {
+		return err
+	}
+	N, ok := new(big.Int).SetString(n, 16)
+	if !ok {
+		return fmt.Errorf("invalid modulus %q", n)
+	}
+	c, err := hex.DecodeString(ct)
+	if err != nil {
+		return err
+	}
+	pub := &cryptopals.RSAPublicKey{N: N, E: big.NewInt(e)}
+
+	r, err := cryptopals.DialBleichenbacherOracle(addr, pub, opts...)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	opts = append(opts, cryptopals.WithProgress(func(p cryptopals.Progress) {
+		width := new(big.Int).Sub(p.Hi, p.Lo)
+		log.Printf("%d of %d bytes known after %d queries, %d-bit interval left", p.BytesRecovered, pub.Size(), p.OracleCalls, width.BitLen())
+	}))
+	msg, err := cryptopals.RecoverBleichenbacherPlaintext(pub, c, r.Conforms, opts...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%q\n", msg)
+	return nil
+}
@@ -0,0 +1,495 @@
+// Cryptopals runs the library's attacks and helpers on arbitrary data.
+//
+// Usage:
+//
+//	cryptopals <command> <subcommand> [flags] [file]
+//	cryptopals run [-testdata dir] [-fetch] [challenges]
+//
+// The commands are:
+//
+//	run           solve challenges, such as "run 1..16" or "run 3 6"
+//	convert       convert input from one encoding to another
+//	xor crack     recover a single-byte or repeating-key XOR key
+//	ecb detect    score how ECB-like a ciphertext is
+//	score english score how English a plaintext is
+//	pkcs7 pad     add PKCS #7 padding
+//	pkcs7 unpad   remove PKCS #7 padding
+//	hash extend   forge a secret-prefix MAC by length extension
+//
+// Input is read from the named file, or from standard input if there is
+// none. The -in and -out flags select how input and output bytes are
+// encoded: raw, hex, base64, or base64url.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clfs/cryptopals"
+)
+
+// A command runs a subcommand with its arguments.
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"run":           {"solve challenges, such as \"run 1..16\" or \"run 3 6\"", runChallenges},
+	"convert":       {"convert input from one encoding to another", convert},
+	"xor crack":     {"recover a single-byte or repeating-key XOR key", xorCrack},
+	"ecb detect":    {"score how ECB-like a ciphertext is", ecbDetect},
+	"score english": {"score how English a plaintext is", scoreEnglish},
+	"pkcs7 pad":     {"add PKCS #7 padding", pkcs7Pad},
+	"pkcs7 unpad":   {"remove PKCS #7 padding", pkcs7Unpad},
+	"hash extend":   {"forge a secret-prefix MAC by length extension", hashExtend},
+}
+
+// errUsage is returned for invalid command lines.
+var errUsage = errors.New("usage: cryptopals <command> <subcommand> [flags] [file]")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "cryptopals:", err)
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, usage())
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	if cmd, ok := commands[args[0]]; ok {
+		return cmd.run(args[1:], stdin, stdout)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	name := args[0] + " " + args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+
+	return cmd.run(args[2:], stdin, stdout)
+}
+
+// An encoding is how bytes are written as text.
+type encoding string
+
+func (e *encoding) String() string { return string(*e) }
+
+func (e *encoding) Set(s string) error {
+	if _, err := cryptopals.ParseEncoding(s); err != nil {
+		return err
+	}
+	*e = encoding(s)
+	return nil
+}
+
+// lib returns the library's form of e.
+func (e encoding) lib() cryptopals.Encoding {
+	enc, _ := cryptopals.ParseEncoding(string(e))
+	return enc
+}
+
+func (e encoding) decode(b []byte) ([]byte, error) {
+	return io.ReadAll(e.lib().NewDecoder(bytes.NewReader(b)))
+}
+
+func (e encoding) encode(b []byte) []byte {
+	if e == "raw" {
+		return b
+	}
+	var buf bytes.Buffer
+	w := e.lib().NewEncoder(&buf)
+	w.Write(b)
+	w.Close()
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// flags holds the flags shared by every subcommand.
+type flags struct {
+	*flag.FlagSet
+	in, out encoding
+}
+
+func newFlags(name string) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError), in: "raw", out: "raw"}
+	f.Var(&f.in, "in", "input `encoding`: raw, hex, base64, or base64url")
+	f.Var(&f.out, "out", "output `encoding`: raw, hex, base64, or base64url")
+	return f
+}
+
+// input reads the file named by the remaining arguments, or stdin.
+func (f *flags) input(stdin io.Reader) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch f.NArg() {
+	case 0:
+		b, err = io.ReadAll(stdin)
+	case 1:
+		b, err = os.ReadFile(f.Arg(0))
+	default:
+		return nil, fmt.Errorf("%w: too many arguments", errUsage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f.in.decode(b)
+}
+
+// lines reads the input like input, but decodes each nonempty line
+// separately.
+func (f *flags) lines(stdin io.Reader) ([][]byte, error) {
+	enc := f.in
+	f.in = "raw"
+	b, err := f.input(stdin)
+	f.in = enc
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]byte
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		line, err := enc.decode(s.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(res)+1, err)
+		}
+		res = append(res, line)
+	}
+	return res, s.Err()
+}
+
+// convert streams its input through the encodings, so it works on files
+// too large to read at once.
+func convert(args []string, stdin io.Reader, stdout io.Writer) error {
+	f := newFlags("convert")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	src := stdin
+	switch f.NArg() {
+	case 0:
+	case 1:
+		file, err := os.Open(f.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		src = file
+	default:
+		return fmt.Errorf("%w: too many arguments", errUsage)
+	}
+
+	if _, err := cryptopals.Transcode(stdout, src, f.in.lib(), f.out.lib()); err != nil {
+		return err
+	}
+	if f.out != "raw" {
+		_, err := io.WriteString(stdout, "\n")
+		return err
+	}
+	return nil
+}
+
+func xorCrack(args []string, stdin io.Reader, stdout io.Writer) error {
+	f := newFlags("xor crack")
+	repeating := f.Bool("repeating", false, "crack repeating-key XOR instead of single-byte XOR")
+	lines := f.Bool("lines", false, "find the one line that is single-byte XOR encrypted")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	var ct []byte
+
+	switch {
+	case *lines && *repeating:
+		return fmt.Errorf("%w: -lines and -repeating are exclusive", errUsage)
+	case *lines:
+		cts, err := f.lines(stdin)
+		if err != nil {
+			return err
+		}
+		i := cryptopals.FindSingleByteXORCiphertext(cts)
+		if i < 0 {
+			return errors.New("no input lines")
+		}
+		fmt.Fprintf(stdout, "line %d\n", i+1)
+		ct = cts[i]
+	default:
+		b, err := f.input(stdin)
+		if err != nil {
+			return err
+		}
+		ct = b
+	}
+
+	var key []byte
+	if *repeating {
+		k, err := cryptopals.RecoverRepeatingKeyXORKey(ct)
+		if err != nil {
+			return err
+		}
+		key = k
+	} else {
+		key = []byte{cryptopals.RecoverSingleByteXORKey(ct)}
+	}
+
+	pt := make([]byte, len(ct))
+	cryptopals.NewRepeatingKeyXORCipher(key).XORKeyStream(pt, ct)
+
+	fmt.Fprintf(stdout, "key %x\n", key)
+	_, err := stdout.Write(f.out.encode(pt))
+	return err
+}
+
+func ecbDetect(args []string, stdin io.Reader, stdout io.Writer) error {
+	f := newFlags("ecb detect")
+	blockSize := f.Int("block", 16, "block size in bytes")
+	lines := f.Bool("lines", false, "find the line most likely to be ECB encrypted")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if *blockSize < 1 {
+		return fmt.Errorf("%w: invalid block size %d", errUsage, *blockSize)
+	}
+
+	if *lines {
+		cts, err := f.lines(stdin)
+		if err != nil {
+			return err
+		}
+		i := cryptopals.FindMostECBLike(cts, *blockSize)
+		if i < 0 {
+			fmt.Fprintln(stdout, "no line has repeated blocks")
+			return nil
+		}
+		fmt.Fprintf(stdout, "line %d\nscore %g\n", i+1, cryptopals.ECBScore(cts[i], *blockSize))
+		return nil
+	}
+
+	ct, err := f.input(stdin)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "score %g\necb %t\n", cryptopals.ECBScore(ct, *blockSize), cryptopals.IsECBCiphertext(ct, *blockSize))
+	return nil
+}
+
+func scoreEnglish(args []string, stdin io.Reader, stdout io.Writer) error {
+	f := newFlags("score english")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	pt, err := f.input(stdin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "englishness %g\n", cryptopals.Englishness(pt))
+	fmt.Fprintf(stdout, "trigrams %g\n", cryptopals.NewEnglishNGramScorer(3).Score(pt))
+	fmt.Fprintf(stdout, "language %s\n", cryptopals.DetectLanguage(pt).Name)
+	return nil
+}
+
+func pkcs7Pad(args []string, stdin io.Reader, stdout io.Writer) error {
+	return pkcs7(args, stdin, stdout, false)
+}
+
+func pkcs7Unpad(args []string, stdin io.Reader, stdout io.Writer) error {
+	return pkcs7(args, stdin, stdout, true)
+}
+
+func pkcs7(args []string, stdin io.Reader, stdout io.Writer, unpad bool) error {
+	name := "pkcs7 pad"
+	if unpad {
+		name = "pkcs7 unpad"
+	}
+
+	f := newFlags(name)
+	blockSize := f.Int("block", 16, "block size in bytes, 1 to 255")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if *blockSize < 1 || *blockSize > 255 {
+		return fmt.Errorf("%w: invalid block size %d", errUsage, *blockSize)
+	}
+
+	b, err := f.input(stdin)
+	if err != nil {
+		return err
+	}
+
+	if unpad {
+		b, err = cryptopals.PKCS7Padder{}.Unpad(b, *blockSize)
+		if err != nil {
+			return err
+		}
+	} else {
+		b = cryptopals.PKCS7Padder{}.Pad(b, *blockSize)
+	}
+
+	_, err = stdout.Write(f.out.encode(b))
+	return err
+}
+
+// hashes are the hashes hash extend can extend.
+var hashes = map[string]cryptopals.ResumableHash{
+	"sha1":   cryptopals.SHA1(),
+	"sha256": cryptopals.SHA256(),
+	"md4":    cryptopals.MD4(),
+	"md5":    cryptopals.MD5(),
+}
+
+func hashExtend(args []string, stdin io.Reader, stdout io.Writer) error {
+	f := newFlags("hash extend")
+	name := f.String("hash", "sha1", "`hash`: sha1, sha256, md4, or md5")
+	tagHex := f.String("tag", "", "hex tag of the input message")
+	keyLen := f.Int("keylen", 16, "secret key length in bytes")
+	suffix := f.String("suffix", "", "data to append")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	h, ok := hashes[*name]
+	if !ok {
+		return fmt.Errorf("%w: unknown hash %q", errUsage, *name)
+	}
+	tag, err := hex.DecodeString(*tagHex)
+	if err != nil {
+		return fmt.Errorf("%w: bad tag: %v", errUsage, err)
+	}
+	if len(tag) != h.New().Size() {
+		return fmt.Errorf("%w: tag is %d bytes, want %d", errUsage, len(tag), h.New().Size())
+	}
+	if *keyLen < 0 {
+		return fmt.Errorf("%w: invalid key length %d", errUsage, *keyLen)
+	}
+
+	msg, err := f.input(stdin)
+	if err != nil {
+		return err
+	}
+
+	forged, forgedTag := cryptopals.Extend(h, tag, msg, []byte(*suffix), *keyLen)
+
+	fmt.Fprintf(stdout, "tag %x\n", forgedTag)
+	_, err = stdout.Write(f.out.encode(forged))
+	return err
+}
+
+func runChallenges(args []string, _ io.Reader, stdout io.Writer) error {
+	f := flag.NewFlagSet("run", flag.ContinueOnError)
+	dir := f.String("testdata", "testdata", "`directory` holding the challenge data files")
+	fetch := f.Bool("fetch", false, "download missing data files into the testdata directory")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	nums, err := parseChallenges(f.Args())
+	if err != nil {
+		return err
+	}
+
+	fsys := os.DirFS(*dir)
+	if *fetch {
+		fsys = cryptopals.NewTestdataFS(*dir)
+	}
+
+	var failed int
+	for _, n := range nums {
+		res, err := cryptopals.Solve(n, cryptopals.WithTestdata(fsys))
+		switch {
+		case errors.Is(err, cryptopals.ErrUnsolved):
+			fmt.Fprintf(stdout, "%-4d skip  not solved yet\n", n)
+		case err != nil:
+			fmt.Fprintf(stdout, "%-4d FAIL  %v\n", n, err)
+			failed++
+		default:
+			fmt.Fprintf(stdout, "%-4d ok    %-40.40s %v\n", n, res.Answer, res.Elapsed.Round(time.Millisecond))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d challenges failed", failed, len(nums))
+	}
+	return nil
+}
+
+// parseChallenges parses challenge numbers and ranges like "1..16". With no
+// arguments, it returns every challenge.
+func parseChallenges(args []string) ([]int, error) {
+	if len(args) == 0 {
+		var res []int
+		for _, c := range cryptopals.Challenges() {
+			res = append(res, c.Number)
+		}
+		return res, nil
+	}
+
+	var res []int
+	for _, arg := range args {
+		lo, hi, isRange := strings.Cut(arg, "..")
+		a, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad challenge %q", errUsage, arg)
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(hi); err != nil || b < a {
+				return nil, fmt.Errorf("%w: bad challenge range %q", errUsage, arg)
+			}
+		}
+		for n := a; n <= b; n++ {
+			if _, ok := cryptopals.LookupChallenge(n); !ok {
+				return nil, fmt.Errorf("no challenge %d", n)
+			}
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+// usage returns a summary of the commands, sorted by name.
+func usage() string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "  %-14s %s\n", name, commands[name].summary)
+	}
+	return sb.String()
+}
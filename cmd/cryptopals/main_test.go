@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/clfs/cryptopals"
+)
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		name   string
+		args   []string
+		stdin  string
+		want   string
+		prefix bool // Whether want is only the start of the output.
+	}{
+		{
+			"xor crack",
+			[]string{"xor", "crack", "-in", "hex"},
+			"1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736\n",
+			"key 58\nCooking MC's like a pound of bacon",
+			false,
+		},
+		{
+			"xor crack lines",
+			[]string{"xor", "crack", "-in", "hex", "-lines", "../../testdata/4.txt"},
+			"",
+			"line 171\nkey 35\nNow that the party is jumping\n",
+			false,
+		},
+		{
+			"xor crack repeating",
+			[]string{"xor", "crack", "-in", "base64", "-repeating", "../../testdata/6.txt"},
+			"",
+			"key 5465726d696e61746f7220583a204272696e6720746865206e6f697365\n",
+			true,
+		},
+		{
+			"ecb detect lines",
+			[]string{"ecb", "detect", "-in", "hex", "-lines", "../../testdata/8.txt"},
+			"",
+			"line 133\nscore 0.3333333333333333\n",
+			false,
+		},
+		{
+			"ecb detect",
+			[]string{"ecb", "detect"},
+			strings.Repeat("YELLOW SUBMARINE", 2),
+			"score 1\necb true\n",
+			false,
+		},
+		{
+			"score english",
+			[]string{"score", "english"},
+			"the quick brown fox jumps over the lazy dog",
+			"englishness 1.2093023255813953\ntrigrams -8.90411879307823\nlanguage english\n",
+			false,
+		},
+		{
+			"run",
+			[]string{"run", "-testdata", "../../testdata", "1..4", "9"},
+			"",
+			"1    ok",
+			true,
+		},
+		{
+			"pkcs7 pad",
+			[]string{"pkcs7", "pad", "-block", "20", "-out", "hex"},
+			"YELLOW SUBMARINE",
+			"59454c4c4f57205355424d4152494e4504040404\n",
+			false,
+		},
+		{
+			"convert",
+			[]string{"convert", "-in", "hex", "-out", "base64"},
+			"49276d206b696c6c696e6720796f757220627261696e\n206c696b65206120706f69736f6e6f7573206d757368726f6f6d\n",
+			"SSdtIGtpbGxpbmcgeW91ciBicmFpbiBsaWtlIGEgcG9pc29ub3VzIG11c2hyb29t\n",
+			false,
+		},
+		{
+			"pkcs7 unpad",
+			[]string{"pkcs7", "unpad", "-in", "hex"},
+			"49434520494345204241425904040404",
+			"ICE ICE BABY",
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := run(tc.args, strings.NewReader(tc.stdin), &stdout); err != nil {
+				t.Fatal(err)
+			}
+			got := stdout.String()
+			if tc.prefix && !strings.HasPrefix(got, tc.want) {
+				t.Errorf("got %q, want prefix %q", got, tc.want)
+			}
+			if !tc.prefix && got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunFailure(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "6.txt"), []byte("AAAA\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	if err := run([]string{"run", "-testdata", dir, "6"}, strings.NewReader(""), &stdout); err == nil {
+		t.Error("no error")
+	}
+	want := "6    FAIL  challenge 6: ciphertext too short: need 80 bytes, got 3\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+	}{
+		{"no command", nil, ""},
+		{"unknown command", []string{"xor", "frobnicate"}, ""},
+		{"bad encoding", []string{"xor", "crack", "-in", "rot13"}, ""},
+		{"bad hex", []string{"xor", "crack", "-in", "hex"}, "zz"},
+		{"bad padding", []string{"pkcs7", "unpad"}, "ICE ICE BABY\x05\x05\x05\x05"},
+		{"bad block size", []string{"pkcs7", "pad", "-block", "256"}, ""},
+		{"bad range", []string{"run", "5..2"}, ""},
+		{"missing challenge", []string{"run", "1000"}, ""},
+		{"missing testdata", []string{"run", "-testdata", "nowhere", "6"}, ""},
+		{"too many files", []string{"score", "english", "a", "b"}, ""},
+		{"unknown hash", []string{"hash", "extend", "-hash", "sha3", "-tag", "00"}, ""},
+		{"short tag", []string{"hash", "extend", "-tag", "0011"}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := run(tc.args, strings.NewReader(tc.stdin), &stdout); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestHashExtend(t *testing.T) {
+	key, msg := "YELLOW SUBMARINE", "user=alice"
+	tag := sha1.Sum([]byte(key + msg))
+
+	var stdout bytes.Buffer
+	args := []string{"hash", "extend", "-tag", hex.EncodeToString(tag[:]), "-keylen", "16", "-suffix", "&admin=1"}
+	if err := run(args, strings.NewReader(msg), &stdout); err != nil {
+		t.Fatal(err)
+	}
+
+	forged := msg + string(cryptopals.SHA1().Padding(uint64(len(key+msg)))) + "&admin=1"
+	forgedTag := sha1.Sum([]byte(key + forged))
+	want := "tag " + hex.EncodeToString(forgedTag[:]) + "\n" + forged
+	if got := stdout.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	err := run([]string{"ecb"}, strings.NewReader(""), new(bytes.Buffer))
+	if !errors.Is(err, errUsage) {
+		t.Errorf("got %v, want %v", err, errUsage)
+	}
+}
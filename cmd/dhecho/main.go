@@ -0,0 +1,73 @@
+// Dhecho runs the echo server and client from challenge 34 over TCP, for
+// practicing the parameter injection attack with dhmitm.
+//
+// Usage:
+//
+//	dhecho serve [-addr addr] [-latency d]
+//	dhecho send [-addr addr] message...
+//
+// Serve runs the server, Bob, which agrees on a key with each client and
+// echoes what it sends. Send connects as Alice and sends each message,
+// printing the echoes. Point send at a dhmitm proxy in front of the server
+// to see that nothing looks different.
+//
+// See ServeDHEcho for the protocol.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"github.com/clfs/cryptopals"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: dhecho serve|send [flags]")
+	}
+
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	addr := fs.String("addr", "localhost:9034", "server address")
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		latency := fs.Duration("latency", 0, "delay before each echo")
+		fs.Parse(os.Args[2:])
+
+		var l net.Listener
+		l, err = net.Listen("tcp", *addr)
+		if err == nil {
+			log.Printf("serving on %s", l.Addr())
+			err = cryptopals.ServeDHEcho(l, cryptopals.WithLatency(*latency))
+		}
+	case "send":
+		fs.Parse(os.Args[2:])
+		err = send(*addr, fs.Args())
+	default:
+		log.Fatalf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func send(addr string, msgs []string) error {
+	c, err := cryptopals.DialDHEcho(addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	for _, msg := range msgs {
+		echo, err := c.Echo([]byte(msg))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", echo)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Dhmitm sits between a dhecho client and server and performs the parameter
+// injection attack from challenge 34, printing every message it decrypts.
+//
+// Usage:
+//
+//	dhmitm [-addr addr] [-upstream addr]
+//
+// See ServeDHEchoMITM for how it works.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+
+	"github.com/clfs/cryptopals"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", "localhost:9035", "listen address")
+		upstream = flag.String("upstream", "localhost:9034", "echo server address")
+	)
+	flag.Parse()
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("relaying %s to %s", l.Addr(), *upstream)
+
+	log.Fatal(cryptopals.ServeDHEchoMITM(l, *upstream, func(dir cryptopals.Direction, msg []byte) {
+		log.Printf("%s: %q", dir, msg)
+	}))
+}
@@ -0,0 +1,72 @@
+// Paddingoracle serves a CBC padding oracle over HTTP and TCP, for practicing
+// the challenge 17 attack against a remote target.
+//
+// Usage:
+//
+//	paddingoracle [-http addr] [-tcp addr] [-latency d] [-flaky rate] [-verbose] [-secret s]
+//
+// See NewCBCPaddingOracleHandler and ServeCBCPaddingOracle for the
+// protocols.
+package main
+
+import (
+	"flag"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+
+	"github.com/clfs/cryptopals"
+)
+
+func main() {
+	var (
+		httpAddr = flag.String("http", "localhost:8017", "HTTP listen address, or empty to disable")
+		tcpAddr  = flag.String("tcp", "localhost:9017", "TCP listen address, or empty to disable")
+		latency  = flag.Duration("latency", 0, "delay before each response")
+		flaky    = flag.Float64("flaky", 0, "probability of a wrong answer about padding")
+		verbose  = flag.Bool("verbose", false, "say why ciphertexts are rejected")
+		secret   = flag.String("secret", "", "secret to encrypt (default: a random challenge 17 plaintext)")
+	)
+	flag.Parse()
+
+	if *httpAddr == "" && *tcpAddr == "" {
+		log.Fatal("nothing to serve: both -http and -tcp are empty")
+	}
+
+	s := []byte(*secret)
+	if len(s) == 0 {
+		secrets := cryptopals.Challenge17Secrets()
+		s = secrets[rand.IntN(len(secrets))]
+	}
+
+	opts := []cryptopals.Option{cryptopals.WithLatency(*latency)}
+	if *verbose {
+		opts = append(opts, cryptopals.WithVerboseErrors())
+	}
+
+	p := cryptopals.NewCBCPaddingOracle(s, cryptopals.WithFlakiness(*flaky))
+
+	errc := make(chan error, 2)
+
+	if *httpAddr != "" {
+		go func() {
+			log.Printf("serving HTTP on %s", *httpAddr)
+			errc <- http.ListenAndServe(*httpAddr, cryptopals.NewCBCPaddingOracleHandler(p, opts...))
+		}()
+	}
+
+	if *tcpAddr != "" {
+		go func() {
+			l, err := net.Listen("tcp", *tcpAddr)
+			if err != nil {
+				errc <- err
+				return
+			}
+			log.Printf("serving TCP on %s", l.Addr())
+			errc <- cryptopals.ServeCBCPaddingOracle(l, p, opts...)
+		}()
+	}
+
+	log.Fatal(<-errc)
+}
@@ -0,0 +1,187 @@
+// Srp serves and logs in to SRP over TCP, for practicing the challenge 37
+// and 38 attacks against a real socket.
+//
+// Usage:
+//
+//	srp serve [-addr addr] [-email e] [-password p] [-simple] [-verbose]
+//	srp login [-addr addr] [-email e] [-password p]
+//	srp zerokey [-addr addr] [-email e]
+//	srp crack [-addr addr] [-words file]
+//
+// Serve runs an honest server for one user, and login logs in to it. Zerokey
+// logs in without the password by sending A = 0. Crack runs a malicious
+// simplified SRP server, waits for one login, and searches for its password
+// in a word list, one per line. Point login at it, and at a serve -simple
+// server to see that the two look the same.
+//
+// See ServeSRP for the protocol.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/clfs/cryptopals"
+)
+
+// words is the word list crack uses by default.
+var words = []string{
+	"123456", "password", "12345678", "qwerty", "letmein", "dragon",
+	"monkey", "football", "iloveyou", "sunshine", "hunter2", "trustno1",
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: srp serve|login|zerokey|crack [flags]")
+	}
+
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	addr := fs.String("addr", "localhost:9036", "server address")
+	email := fs.String("email", "alice@example.com", "user email")
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		password := fs.String("password", "hunter2", "user password")
+		simple := fs.Bool("simple", false, "use simplified SRP")
+		verbose := fs.Bool("verbose", false, "say why logins fail")
+		fs.Parse(os.Args[2:])
+		err = serve(*addr, *email, *password, *simple, *verbose)
+	case "login":
+		password := fs.String("password", "hunter2", "user password")
+		fs.Parse(os.Args[2:])
+		err = login(*addr, *email, *password)
+	case "zerokey":
+		fs.Parse(os.Args[2:])
+		err = zeroKey(*addr, *email)
+	case "crack":
+		file := fs.String("words", "", "word list `file` (default: a few common passwords)")
+		fs.Parse(os.Args[2:])
+		err = crack(*addr, *file)
+	default:
+		log.Fatalf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(addr, email, password string, simple, verbose bool) error {
+	var opts []cryptopals.Option
+	if simple {
+		opts = append(opts, cryptopals.WithSimpleSRP())
+	}
+	if verbose {
+		opts = append(opts, cryptopals.WithVerboseErrors())
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("serving SRP for %s on %s", email, l.Addr())
+	return cryptopals.ServeSRP(l, cryptopals.NewSRPServer(email, password, opts...), opts...)
+}
+
+func login(addr, email, password string) error {
+	conn, err := cryptopals.DialSRP(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ok, err := conn.Login(cryptopals.NewSRPClient(email, password))
+	if err != nil {
+		return err
+	}
+	return report(ok)
+}
+
+func zeroKey(addr, email string) error {
+	conn, err := cryptopals.DialSRP(addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Hello(email, new(big.Int))
+	if err != nil {
+		return err
+	}
+	ok, err := conn.Prove(cryptopals.SRPZeroKeyProof(ch))
+	if err != nil {
+		return err
+	}
+	return report(ok)
+}
+
+// report prints the result of a login.
+func report(ok bool) error {
+	if !ok {
+		return errors.New("login rejected")
+	}
+	fmt.Println("logged in")
+	return nil
+}
+
+// notifier passes logins to a cracker, and signals on done after each one.
+type notifier struct {
+	*cryptopals.SimpleSRPCracker
+	done chan struct{}
+}
+
+func (n notifier) Hello(email string, A *big.Int) (cryptopals.SRPChallenge, func([]byte) bool, error) {
+	ch, verify, err := n.SimpleSRPCracker.Hello(email, A)
+	return ch, func(proof []byte) bool {
+		ok := verify(proof)
+		n.done <- struct{}{}
+		return ok
+	}, err
+}
+
+func crack(addr, file string) error {
+	list := words
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		list = nil
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if w := strings.TrimSpace(sc.Text()); w != "" {
+				list = append(list, w)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	log.Printf("waiting for a login on %s", l.Addr())
+
+	n := notifier{new(cryptopals.SimpleSRPCracker), make(chan struct{}, 1)}
+	go cryptopals.ServeSRP(l, n)
+	<-n.done
+
+	email, password, err := n.Crack(list)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s\n", email, password)
+	return nil
+}
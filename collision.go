@@ -0,0 +1,163 @@
+package cryptopals
+
+import "bytes"
+
+// collisionInputSize is the size in bytes of the inputs FindCollision tries.
+const collisionInputSize = 16
+
+// WithMaxEntries limits how many entries a birthday or meet-in-the-middle
+// search keeps in memory. When a birthday search's table fills up, it's
+// cleared and the search goes on, which costs more hashing. A
+// meet-in-the-middle search spills its tables to disk instead; see
+// MeetInTheMiddle. The default is no limit.
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// truncateDigest returns the first bits bits of d as a map key. It panics if
+// d is shorter than that.
+func truncateDigest(d []byte, bits int) string {
+	n := (bits + 7) / 8
+	if len(d) < n {
+		panic("digest shorter than outBits")
+	}
+	b := bytes.Clone(d[:n])
+	if r := bits % 8; r != 0 {
+		b[n-1] &= 0xff << (8 - r)
+	}
+	return string(b)
+}
+
+// FindCollision returns two distinct 16-byte inputs whose digests under h
+// agree in their first outBits bits.
+//
+// It's a birthday search: it hashes random inputs, remembering each truncated
+// digest in a table, until one repeats. That takes about 2^(outBits/2) calls
+// to h, and as many table entries, unless limited with WithMaxEntries. Use
+// WithSeed to make the search deterministic, and WithLogger to see how many
+// hashes it took.
+//
+// It panics if outBits < 1, or if h returns fewer than outBits bits.
+func FindCollision(h func([]byte) []byte, outBits int, opts ...Option) (a, b []byte) {
+	if outBits < 1 {
+		panic("invalid outBits")
+	}
+
+	o := newOptions(opts)
+	r := o.newRand()
+
+	seen := make(map[string][]byte)
+	for n := 1; ; n++ {
+		in := make([]byte, collisionInputSize)
+		r.Read(in)
+
+		k := truncateDigest(h(in), outBits)
+		if prev, ok := seen[k]; ok && !bytes.Equal(prev, in) {
+			o.debug("found collision", "hashes", n)
+			return prev, in
+		}
+
+		if o.maxEntries > 0 && len(seen) >= o.maxEntries {
+			clear(seen)
+		}
+		seen[k] = in
+	}
+}
+
+// NewIterationFunc returns h as a function from outBits-bit values to
+// outBits-bit values, for cycle finding: it hashes its input and keeps the
+// first outBits bits of the digest, as a slice of (outBits+7)/8 bytes with any
+// unused low bits zeroed. Inputs that collide under it collide in the first
+// outBits bits under h.
+//
+// It panics if outBits < 1.
+func NewIterationFunc(h func([]byte) []byte, outBits int) func([]byte) []byte {
+	if outBits < 1 {
+		panic("invalid outBits")
+	}
+	return func(b []byte) []byte {
+		return []byte(truncateDigest(h(b), outBits))
+	}
+}
+
+// FindCollisionFloyd is like FindCollision, but finds the collision with
+// Floyd's cycle detection on NewIterationFunc(h, outBits), using constant
+// memory. The inputs it returns are outBits-bit values from the iteration
+// function, as (outBits+7)/8 bytes.
+//
+// A random walk under the iteration function runs into a cycle after about
+// 2^(outBits/2) steps, and the two values that first lead into the cycle
+// collide. Finding them takes a few times more calls to h than FindCollision.
+func FindCollisionFloyd(h func([]byte) []byte, outBits int, opts ...Option) (a, b []byte) {
+	return findCollisionRho(h, outBits, opts, func(f func([]byte) []byte, x0 []byte) []byte {
+		// The tortoise moves one step at a time and the hare two. They meet
+		// inside the cycle, a multiple of its length from x0.
+		t, r := f(x0), f(f(x0))
+		for !bytes.Equal(t, r) {
+			t, r = f(t), f(f(r))
+		}
+		return r
+	})
+}
+
+// FindCollisionBrent is like FindCollisionFloyd, but uses Brent's cycle
+// detection, which calls h fewer times.
+func FindCollisionBrent(h func([]byte) []byte, outBits int, opts ...Option) (a, b []byte) {
+	return findCollisionRho(h, outBits, opts, func(f func([]byte) []byte, x0 []byte) []byte {
+		// The hare searches for the tortoise in windows of doubling size,
+		// which finds the cycle length lam.
+		power, lam := 1, 1
+		t, r := x0, f(x0)
+		for !bytes.Equal(t, r) {
+			if power == lam {
+				t, power, lam = r, 2*power, 0
+			}
+			r = f(r)
+			lam++
+		}
+
+		r = x0
+		for range lam {
+			r = f(r)
+		}
+		return r
+	})
+}
+
+// findCollisionRho finds a collision by walking from random starting points
+// under the iteration function. For a start x0, cycle returns a point a
+// multiple of the cycle length ahead of x0.
+func findCollisionRho(h func([]byte) []byte, outBits int, opts []Option, cycle func(f func([]byte) []byte, x0 []byte) []byte) (a, b []byte) {
+	o := newOptions(opts)
+	r := o.newRand()
+
+	var calls int
+	f := NewIterationFunc(func(b []byte) []byte {
+		calls++
+		return h(b)
+	}, outBits)
+
+	for {
+		x0 := make([]byte, (outBits+7)/8)
+		r.Read(x0)
+		x0 = []byte(truncateDigest(x0, outBits))
+
+		// Walking from x0 and from a multiple of the cycle length ahead, the
+		// two meet where the tail enters the cycle. The steps before that
+		// are a collision, unless x0 is already on the cycle.
+		t, u := x0, cycle(f, x0)
+		if bytes.Equal(t, u) {
+			continue
+		}
+		for {
+			ft, fu := f(t), f(u)
+			if bytes.Equal(ft, fu) {
+				o.debug("found collision", "hashes", calls)
+				return t, u
+			}
+			t, u = ft, fu
+		}
+	}
+}
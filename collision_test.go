@@ -0,0 +1,110 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func sha256Func(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	return sum[:]
+}
+
+func TestFindCollision(t *testing.T) {
+	for _, bits := range []int{8, 13, 24} {
+		a, b := FindCollision(sha256Func, bits)
+		if bytes.Equal(a, b) {
+			t.Fatalf("%d bits: inputs are equal", bits)
+		}
+		if truncateDigest(sha256Func(a), bits) != truncateDigest(sha256Func(b), bits) {
+			t.Errorf("%d bits: %x and %x don't collide", bits, a, b)
+		}
+	}
+}
+
+func TestFindCollisionToyMD(t *testing.T) {
+	h := NewToyMD(2)
+	hash := func(b []byte) []byte {
+		d := h.New()
+		d.Write(b)
+		return d.Sum(nil)
+	}
+
+	a, b := FindCollision(hash, 16)
+	if !bytes.Equal(hash(a), hash(b)) {
+		t.Errorf("%x and %x don't collide", a, b)
+	}
+}
+
+func TestFindCollisionSeed(t *testing.T) {
+	a1, b1 := FindCollision(sha256Func, 20, WithSeed(1))
+	a2, b2 := FindCollision(sha256Func, 20, WithSeed(1))
+	if !bytes.Equal(a1, a2) || !bytes.Equal(b1, b2) {
+		t.Error("same seed gave different collisions")
+	}
+}
+
+func TestFindCollisionMaxEntries(t *testing.T) {
+	var calls int
+	h := func(b []byte) []byte {
+		calls++
+		return sha256Func(b)
+	}
+
+	a, b := FindCollision(h, 16, WithMaxEntries(64), WithSeed(2))
+	if truncateDigest(sha256Func(a), 16) != truncateDigest(sha256Func(b), 16) {
+		t.Errorf("%x and %x don't collide", a, b)
+	}
+	if calls < 1000 {
+		t.Errorf("only %d calls with a small table", calls)
+	}
+}
+
+func TestTruncateDigest(t *testing.T) {
+	d := []byte{0xff, 0xff, 0xff}
+	if got := truncateDigest(d, 12); got != "\xff\xf0" {
+		t.Errorf("got %x", got)
+	}
+	if d[1] != 0xff {
+		t.Error("digest changed")
+	}
+}
+
+func TestFindCollisionRho(t *testing.T) {
+	finders := map[string]func(func([]byte) []byte, int, ...Option) ([]byte, []byte){
+		"Floyd": FindCollisionFloyd,
+		"Brent": FindCollisionBrent,
+	}
+	for name, find := range finders {
+		t.Run(name, func(t *testing.T) {
+			for _, bits := range []int{8, 13, 24, 32} {
+				a, b := find(sha256Func, bits)
+				if bytes.Equal(a, b) {
+					t.Fatalf("%d bits: inputs are equal", bits)
+				}
+				if truncateDigest(sha256Func(a), bits) != truncateDigest(sha256Func(b), bits) {
+					t.Errorf("%d bits: %x and %x don't collide", bits, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestFindCollisionRhoSeed(t *testing.T) {
+	a1, b1 := FindCollisionBrent(sha256Func, 20, WithSeed(3))
+	a2, b2 := FindCollisionBrent(sha256Func, 20, WithSeed(3))
+	if !bytes.Equal(a1, a2) || !bytes.Equal(b1, b2) {
+		t.Error("same seed gave different collisions")
+	}
+}
+
+func TestNewIterationFunc(t *testing.T) {
+	f := NewIterationFunc(sha256Func, 20)
+	got := f([]byte("hello"))
+	want := sha256Func([]byte("hello"))[:3]
+	want[2] &= 0xf0
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package cryptopals
+
+import (
+	"errors"
+	"math/big"
+)
+
+// GenerateCommonModulusKeys returns two RSA keys with the same random
+// modulus of bits bits and the public exponents e1 and e2, as a careless
+// organization might issue its users to save generating primes. Anyone who
+// sees a message encrypted under both can decrypt it: see
+// RecoverCommonModulusPlaintext. It returns an error if GenerateRSAKey
+// would, or if e1 and e2 aren't coprime.
+func GenerateCommonModulusKeys(bits int, e1, e2 int64) (*RSAPrivateKey, *RSAPrivateKey, error) {
+	if e2 < 3 || e2%2 == 0 {
+		return nil, nil, errors.New("invalid public exponent")
+	}
+	E2 := big.NewInt(e2)
+	if new(big.Int).GCD(nil, nil, big.NewInt(e1), E2).Cmp(big.NewInt(1)) != 0 {
+		return nil, nil, errors.New("public exponents aren't coprime")
+	}
+
+	one := big.NewInt(1)
+	for {
+		k1, err := GenerateRSAKey(bits, e1)
+		if err != nil {
+			return nil, nil, err
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(k1.P, one), new(big.Int).Sub(k1.Q, one))
+		d2 := new(big.Int).ModInverse(E2, phi)
+		if d2 == nil {
+			continue
+		}
+		k2 := &RSAPrivateKey{
+			RSAPublicKey: RSAPublicKey{N: k1.N, E: E2},
+			D:            d2,
+			P:            k1.P,
+			Q:            k1.Q,
+		}
+		return k1, k2, nil
+	}
+}
+
+// RecoverCommonModulusPlaintext returns the message m that c1 and c2
+// encrypt under pub1 and pub2, textbook RSA keys with the same modulus and
+// coprime public exponents, without either private key.
+//
+// The extended Euclidean algorithm gives a and b with a*e1 + b*e2 = 1, so
+// c1^a * c2^b = m^(a*e1 + b*e2) = m. One of a and b is negative, which
+// takes the inverse of its ciphertext.
+//
+// It returns an error if the moduli differ, if the exponents aren't
+// coprime, or if the ciphertext to invert shares a factor with the modulus.
+func RecoverCommonModulusPlaintext(pub1, pub2 *RSAPublicKey, c1, c2 *big.Int) (*big.Int, error) {
+	n := pub1.N
+	if n.Cmp(pub2.N) != 0 {
+		return nil, errors.New("different moduli")
+	}
+	a, b := new(big.Int), new(big.Int)
+	if new(big.Int).GCD(a, b, pub1.E, pub2.E).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("public exponents aren't coprime")
+	}
+
+	power := func(c, x *big.Int) *big.Int {
+		if x.Sign() >= 0 {
+			return new(big.Int).Exp(c, x, n)
+		}
+		inv := new(big.Int).ModInverse(c, n)
+		if inv == nil {
+			return nil
+		}
+		return inv.Exp(inv, new(big.Int).Neg(x), n)
+	}
+	x, y := power(c1, a), power(c2, b)
+	if x == nil || y == nil {
+		return nil, errors.New("ciphertext not invertible")
+	}
+	return x.Mul(x, y).Mod(x, n), nil
+}
@@ -0,0 +1,54 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRecoverCommonModulusPlaintext(t *testing.T) {
+	k1, k2, err := GenerateCommonModulusKeys(512, 3, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1.N.Cmp(k2.N) != 0 {
+		t.Fatal("different moduli")
+	}
+
+	m := new(big.Int).SetBytes([]byte("same message, same modulus"))
+	if got := k2.Decrypt(k2.Encrypt(m)); got.Cmp(m) != 0 {
+		t.Errorf("second key: got %x, want %x", got, m)
+	}
+
+	got, err := RecoverCommonModulusPlaintext(&k1.RSAPublicKey, &k2.RSAPublicKey, k1.Encrypt(m), k2.Encrypt(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(m) != 0 {
+		t.Errorf("got %x, want %x", got, m)
+	}
+}
+
+func TestCommonModulusErrors(t *testing.T) {
+	if _, _, err := GenerateCommonModulusKeys(512, 3, 9); err == nil {
+		t.Error("exponents 3 and 9: no error")
+	}
+
+	k1, k2, err := GenerateCommonModulusKeys(256, 5, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateRSAKey(256, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := big.NewInt(2)
+	if _, err := RecoverCommonModulusPlaintext(&k1.RSAPublicKey, &other.RSAPublicKey, c, c); err == nil {
+		t.Error("different moduli: no error")
+	}
+	if _, err := RecoverCommonModulusPlaintext(&k1.RSAPublicKey, &k1.RSAPublicKey, c, c); err == nil {
+		t.Error("same exponent: no error")
+	}
+	if _, err := RecoverCommonModulusPlaintext(&k1.RSAPublicKey, &k2.RSAPublicKey, k1.P, k1.P); err == nil {
+		t.Error("ciphertext sharing a factor: no error")
+	}
+}
@@ -0,0 +1,242 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// maxCoppersmithM is the largest multiplicity the small-roots attacks try,
+// and maxCoppersmithDegree the largest degree. Their lattices have
+// dimension about deg(f) * (m + 1), and LLL's exact arithmetic gets slow
+// past a few dozen.
+const (
+	maxCoppersmithM      = 8
+	maxCoppersmithDegree = 8
+)
+
+// intPolyMul returns f*g over the integers, lowest degree first.
+func intPolyMul(f, g []*big.Int) []*big.Int {
+	res := make([]*big.Int, len(f)+len(g)-1)
+	for i := range res {
+		res[i] = new(big.Int)
+	}
+	t := new(big.Int)
+	for i, a := range f {
+		for j, b := range g {
+			res[i+j].Add(res[i+j], t.Mul(a, b))
+		}
+	}
+	return res
+}
+
+// intPolyEval returns f(x) over the integers.
+func intPolyEval(f []*big.Int, x *big.Int) *big.Int {
+	res := new(big.Int)
+	for i := len(f) - 1; i >= 0; i-- {
+		res.Mul(res, x).Add(res, f[i])
+	}
+	return res
+}
+
+// coppersmithCandidates returns the integers r with |r| < x that are roots
+// over the integers of the short polynomial Howgrave-Graham's lattice gives
+// for the monic f mod a divisor of n, with multiplicity m and t extra
+// shifts.
+//
+// The lattice is spanned by the coefficient vectors of g(x*X) for the
+// polynomials x^j n^(m-i) f^i, for i < m and j < deg(f), and x^j f^m, for
+// j < t. Each is 0 mod b^m at a root of f mod b, for any divisor b of n, and
+// so is any integer combination of them. If LLL finds one with coefficients
+// small enough, its value at a root less than X is less than b^m, and so 0
+// over the integers. Its roots are then easy to find: they're among its
+// roots mod a prime larger than 2X.
+func coppersmithCandidates(f []*big.Int, n, x *big.Int, m, t int) []*big.Int {
+	delta := len(f) - 1
+	var polys [][]*big.Int
+	fi := []*big.Int{big.NewInt(1)}
+	for i := range m {
+		c := new(big.Int).Exp(n, big.NewInt(int64(m-i)), nil)
+		for j := range delta {
+			g := slices.Concat(make([]*big.Int, j), intPolyMul(fi, []*big.Int{c}))
+			polys = append(polys, g)
+		}
+		fi = intPolyMul(fi, f)
+	}
+	for j := range t {
+		polys = append(polys, slices.Concat(make([]*big.Int, j), fi))
+	}
+
+	dim := len(polys)
+	xPows := make([]*big.Int, dim)
+	xPows[0] = big.NewInt(1)
+	for k := 1; k < dim; k++ {
+		xPows[k] = new(big.Int).Mul(xPows[k-1], x)
+	}
+	basis := make([][]*big.Int, dim)
+	for i, g := range polys {
+		basis[i] = make([]*big.Int, dim)
+		for k := range basis[i] {
+			basis[i][k] = new(big.Int)
+			if k < len(g) && g[k] != nil {
+				basis[i][k].Mul(g[k], xPows[k])
+			}
+		}
+	}
+	LLL(basis)
+
+	h := make([]*big.Int, dim)
+	for k, c := range basis[0] {
+		h[k] = new(big.Int).Quo(c, xPows[k])
+	}
+
+	p, err := rand.Prime(rand.Reader, x.BitLen()+2)
+	if err != nil {
+		panic(err)
+	}
+	var res []*big.Int
+	for _, r := range polyRoots(newModPoly(p, h...), p) {
+		for _, c := range []*big.Int{r, new(big.Int).Sub(r, p)} {
+			if new(big.Int).Abs(c).Cmp(x) < 0 && intPolyEval(h, c).Sign() == 0 {
+				res = append(res, c)
+			}
+		}
+	}
+	return res
+}
+
+// coppersmith returns the roots r of f that coppersmithCandidates finds and
+// ok accepts, trying multiplicities from 1 to maxCoppersmithM until some
+// are found. t gives the number of extra shifts for each multiplicity.
+func coppersmith(f []*big.Int, n, x *big.Int, t func(m int) int, ok func(r *big.Int) bool) []*big.Int {
+	for m := 1; m <= maxCoppersmithM; m++ {
+		var res []*big.Int
+		for _, r := range coppersmithCandidates(f, n, x, m, t(m)) {
+			if ok(r) {
+				res = append(res, r)
+			}
+		}
+		if len(res) > 0 {
+			return res
+		}
+	}
+	return nil
+}
+
+// SmallRoots returns the roots r of the polynomial f mod n with |r| < bound,
+// with Coppersmith's method in Howgrave-Graham's formulation. f's
+// coefficients are lowest degree first, and n needn't be prime; its factors
+// are unknown, as for an RSA modulus, or there'd be easier ways.
+//
+// It finds the roots if bound is somewhat less than n^(1/d) for f of degree
+// d, building larger lattices, which are slower to reduce, as bound gets
+// closer to that. For a cubic mod a 512-bit n, the limit is 170 bits: 96-bit
+// roots take milliseconds, 128-bit ones a few seconds, and 144-bit ones over
+// ten. It returns an error if f's degree is 0 or more than 8, if its
+// leading coefficient isn't invertible mod n, or if it finds no roots.
+func SmallRoots(f []*big.Int, n, bound *big.Int) ([]*big.Int, error) {
+	g, ok := polyMonic(newModPoly(n, f...), n)
+	if !ok {
+		return nil, errors.New("leading coefficient not invertible")
+	}
+	if g.deg() < 1 || g.deg() > maxCoppersmithDegree {
+		return nil, errors.New("unsupported degree")
+	}
+
+	delta := g.deg()
+	roots := coppersmith(g, n, bound, func(int) int { return delta }, func(r *big.Int) bool {
+		return g.eval(r, n).Sign() == 0
+	})
+	if len(roots) == 0 {
+		return nil, errors.New("no small roots found")
+	}
+	return roots, nil
+}
+
+// RecoverStereotypedMessage returns the message of size bytes that c
+// encrypts under pub, textbook RSA with a small public exponent, given all
+// but its last bytes: prefix. Such messages come from templates, as in "the
+// password for today is: " followed by a few secret bytes.
+//
+// The unknown suffix is a small root of (prefix*2^(8u) + x)^e - c mod n, for
+// u unknown bytes, and SmallRoots finds it if 8u is somewhat less than the
+// modulus's bit length over e.
+func RecoverStereotypedMessage(pub *RSAPublicKey, c *big.Int, prefix []byte, size int) ([]byte, error) {
+	u := size - len(prefix)
+	if u <= 0 || size > pub.Size() {
+		return nil, errors.New("invalid message size")
+	}
+	n := pub.N
+	known := new(big.Int).Lsh(new(big.Int).SetBytes(prefix), uint(8*u))
+
+	// f = (known + x)^e - c, by the binomial theorem.
+	if !pub.E.IsInt64() || pub.E.Int64() > maxCoppersmithDegree {
+		return nil, errors.New("public exponent too large")
+	}
+	e := int(pub.E.Int64())
+	f := make([]*big.Int, e+1)
+	binom := big.NewInt(1)
+	for k := range f {
+		f[k] = new(big.Int).Exp(known, big.NewInt(int64(e-k)), n)
+		f[k].Mul(f[k], binom).Mod(f[k], n)
+		binom.Mul(binom, big.NewInt(int64(e-k))).Div(binom, big.NewInt(int64(k+1)))
+	}
+	f[0].Sub(f[0], c)
+
+	roots, err := SmallRoots(f, n, new(big.Int).Lsh(big.NewInt(1), uint(8*u)))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roots {
+		if r.Sign() < 0 {
+			continue
+		}
+		m := new(big.Int).Add(known, r)
+		if pub.Encrypt(m).Cmp(new(big.Int).Mod(c, n)) == 0 {
+			return m.FillBytes(make([]byte, size)), nil
+		}
+	}
+	return nil, errors.New("no small roots found")
+}
+
+// RecoverKeyFromHighBits returns the private key for pub given the high bits
+// of one of its primes, p >> unknownBits, as a side channel or a bad prime
+// generator might expose them.
+//
+// p - high * 2^unknownBits is a small root of x + high * 2^unknownBits mod
+// p, a divisor of n about n^(1/2) in size, and Howgrave-Graham's lattice
+// finds such roots if they're somewhat less than n^(1/4). So about half of
+// p's bits are enough: for a 512-bit modulus, 96 unknown bits of a 256-bit
+// prime take milliseconds, 110 take half a second, and 120 are too many.
+func RecoverKeyFromHighBits(pub *RSAPublicKey, high *big.Int, unknownBits int) (*RSAPrivateKey, error) {
+	n := pub.N
+	known := new(big.Int).Lsh(high, uint(unknownBits))
+	f := []*big.Int{new(big.Int).Mod(known, n), big.NewInt(1)}
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(unknownBits))
+
+	one := big.NewInt(1)
+	factor := func(r *big.Int) *big.Int {
+		p := new(big.Int).Add(known, r)
+		if p.Cmp(one) <= 0 {
+			return nil
+		}
+		if g := new(big.Int).GCD(nil, nil, p, n); g.Cmp(one) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+		return nil
+	}
+	roots := coppersmith(f, n, bound, func(m int) int { return m }, func(r *big.Int) bool {
+		return factor(r) != nil
+	})
+	if len(roots) == 0 {
+		return nil, errors.New("no factor found")
+	}
+
+	p := factor(roots[0])
+	k := rsaKeyFromFactors(pub, p, new(big.Int).Div(n, p))
+	if k == nil {
+		return nil, errors.New("public exponent not invertible")
+	}
+	return k, nil
+}
@@ -0,0 +1,65 @@
+package cryptopals
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestSmallRoots(t *testing.T) {
+	k, err := GenerateRSAKey(512, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := k.N
+
+	// (x - r)(x - R) for a small r and a large R has only r as a small
+	// root, whichever sign it has.
+	R := new(big.Int).Rsh(n, 1)
+	for _, r := range []*big.Int{big.NewInt(123456789), big.NewInt(-987654321)} {
+		f := polyMul(newModPoly(n, new(big.Int).Neg(r), big.NewInt(1)), newModPoly(n, new(big.Int).Neg(R), big.NewInt(1)), n)
+		roots, err := SmallRoots(f, n, big.NewInt(1<<32))
+		if err != nil {
+			t.Fatalf("root %v: %v", r, err)
+		}
+		if len(roots) != 1 || roots[0].Cmp(r) != 0 {
+			t.Errorf("got %v, want [%v]", roots, r)
+		}
+	}
+
+	if _, err := SmallRoots([]*big.Int{big.NewInt(5)}, n, big.NewInt(100)); err == nil {
+		t.Error("constant: no error")
+	}
+}
+
+func TestRecoverStereotypedMessage(t *testing.T) {
+	k, err := GenerateRSAKey(512, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := []byte("the password for today is: ")
+	msg := append(bytes.Clone(prefix), "open sesame!"...)
+	c := k.Encrypt(new(big.Int).SetBytes(msg))
+
+	got, err := RecoverStereotypedMessage(&k.RSAPublicKey, c, prefix, len(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestRecoverKeyFromHighBits(t *testing.T) {
+	k, err := GenerateRSAKey(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RecoverKeyFromHighBits(&k.RSAPublicKey, new(big.Int).Rsh(k.P, 96), 96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.D.Cmp(k.D) != 0 {
+		t.Errorf("got d = %x, want %x", got.D, k.D)
+	}
+}
@@ -0,0 +1,78 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"errors"
+	"slices"
+)
+
+// EncryptCBCCS3 returns iv || ct, where ct is pt encrypted under b in CBC
+// mode with ciphertext stealing, in the CS3 variant from NIST SP 800-38A's
+// addendum, under a random IV. It panics if pt is shorter than a block.
+//
+// Instead of padding, the last partial block is zero-filled and encrypted,
+// and the ciphertext block before it is cut short to make room, so ct is
+// exactly as long as pt. CS3 always swaps the last two ciphertext blocks,
+// even when pt is a whole number of blocks, as Kerberos does.
+func EncryptCBCCS3(b cipher.Block, pt []byte) []byte {
+	bs := b.BlockSize()
+	if len(pt) < bs {
+		panic("input shorter than a block")
+	}
+
+	iv := randBytes(int64(bs))
+	full := make([]byte, len(pt)+padLen(pt, bs)%bs)
+	copy(full, pt)
+	NewCBCEncrypter(b, iv).CryptBlocks(full, full)
+
+	if len(full) > bs {
+		swapStolenBlocks(full[len(full)-2*bs:], len(pt)-(len(full)-bs))
+	}
+	return slices.Concat(iv, full[:len(pt)])
+}
+
+// swapStolenBlocks swaps the two blocks of b, where the first is to be cut
+// to d bytes, leaving the cut one last.
+func swapStolenBlocks(b []byte, d int) {
+	bs := len(b) / 2
+	last := slices.Clone(b[bs:])
+	copy(b[bs:], b[:d])
+	copy(b, last)
+}
+
+// DecryptCBCCS3 decrypts iv || ct from EncryptCBCCS3 under b. It returns an
+// error if ct is shorter than a block, and never fails otherwise: with no
+// padding, every ciphertext decrypts to something, so there's no padding
+// oracle for RecoverCBCPaddingOracleSecret to use.
+func DecryptCBCCS3(b cipher.Block, ct []byte) ([]byte, error) {
+	bs := b.BlockSize()
+	if len(ct) < 2*bs {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	iv, ct := ct[:bs], ct[bs:]
+	n := len(ct)
+	if n == bs {
+		pt := make([]byte, bs)
+		NewCBCDecrypter(b, iv).CryptBlocks(pt, ct)
+		return pt, nil
+	}
+
+	// The second to last block is the encrypted last block, whose plaintext
+	// was zero-filled, so decrypting it gives the stolen bytes of the block
+	// before it, XOR zeros.
+	d := n - (n-1)/bs*bs
+	head := n - bs - d // Bytes before the swapped blocks.
+	z := make([]byte, bs)
+	b.Decrypt(z, ct[head:head+bs])
+
+	full := make([]byte, head+2*bs)
+	copy(full, ct[:head])
+	copy(full[head:], ct[head+bs:])
+	copy(full[head+d:], z[d:])
+	copy(full[head+bs:], ct[head:head+bs])
+
+	pt := make([]byte, len(full))
+	NewCBCDecrypter(b, iv).CryptBlocks(pt, full)
+	return pt[:n], nil
+}
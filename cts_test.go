@@ -0,0 +1,86 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"slices"
+	"testing"
+)
+
+func TestDecryptCBCCS3(t *testing.T) {
+	// RFC 3962, appendix B, with a zero IV.
+	b, err := aes.NewCipher([]byte("chicken teriyaki"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("I would like the General Gau's Chicken, please, and wonton soup.")
+
+	tests := []struct {
+		n  int
+		ct string
+	}{
+		{17, "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{31, "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+		{32, "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+	}
+	for _, tt := range tests {
+		ct := slices.Concat(make([]byte, 16), decodeHex(t, tt.ct))
+		got, err := DecryptCBCCS3(b, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := msg[:tt.n]; !bytes.Equal(got, want) {
+			t.Errorf("DecryptCBCCS3(%d bytes) = %q, want %q", tt.n, got, want)
+		}
+	}
+}
+
+func TestCBCCS3(t *testing.T) {
+	b, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 16; n <= 64; n++ {
+		pt := randBytes(int64(n))
+		ct := EncryptCBCCS3(b, pt)
+		if len(ct) != 16+n {
+			t.Errorf("EncryptCBCCS3(%d bytes) is %d bytes, want %d", n, len(ct), 16+n)
+		}
+		got, err := DecryptCBCCS3(b, ct)
+		if err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("DecryptCBCCS3(EncryptCBCCS3(%d bytes)) = %x, %v, want %x", n, got, err, pt)
+		}
+	}
+
+	if _, err := DecryptCBCCS3(b, randBytes(31)); err == nil {
+		t.Errorf("DecryptCBCCS3 accepted a ciphertext shorter than a block")
+	}
+}
+
+func TestCBCCS3NoPaddingOracle(t *testing.T) {
+	b, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := []byte("the secret, which isn't a whole number of blocks")
+	ct := EncryptCBCCS3(b, pt)
+
+	// Every forgery a padding oracle attack tries decrypts without error.
+	valid := func(ct []byte) bool {
+		_, err := DecryptCBCCS3(b, ct)
+		return err == nil
+	}
+	forged := slices.Clone(ct)
+	for i := range 256 {
+		forged[len(forged)-17] = byte(i)
+		if !valid(forged) {
+			t.Fatalf("forged ciphertext rejected for guess %d", i)
+		}
+	}
+
+	// So the attack learns nothing, whole blocks or not.
+	whole := ct[:len(ct)-len(ct)%16]
+	if got, err := RecoverCBCPaddingOracleSecret(whole, 16, valid); err == nil && bytes.HasPrefix(pt, got) && len(got) > 0 {
+		t.Errorf("RecoverCBCPaddingOracleSecret recovered %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// desBlockSize is the DES block and key size in bytes.
+const desBlockSize = 8
+
+// The DES permutation tables from FIPS 46-3. Entries are 1-based input bit
+// positions, counting from the most significant bit.
+var (
+	desIP = [64]byte{
+		58, 50, 42, 34, 26, 18, 10, 2,
+		60, 52, 44, 36, 28, 20, 12, 4,
+		62, 54, 46, 38, 30, 22, 14, 6,
+		64, 56, 48, 40, 32, 24, 16, 8,
+		57, 49, 41, 33, 25, 17, 9, 1,
+		59, 51, 43, 35, 27, 19, 11, 3,
+		61, 53, 45, 37, 29, 21, 13, 5,
+		63, 55, 47, 39, 31, 23, 15, 7,
+	}
+
+	// desFP is the final permutation, the inverse of desIP.
+	desFP = func() (fp [64]byte) {
+		for i, p := range desIP {
+			fp[p-1] = byte(i + 1)
+		}
+		return fp
+	}()
+
+	// desE expands the 32-bit half block to 48 bits.
+	desE = [48]byte{
+		32, 1, 2, 3, 4, 5,
+		4, 5, 6, 7, 8, 9,
+		8, 9, 10, 11, 12, 13,
+		12, 13, 14, 15, 16, 17,
+		16, 17, 18, 19, 20, 21,
+		20, 21, 22, 23, 24, 25,
+		24, 25, 26, 27, 28, 29,
+		28, 29, 30, 31, 32, 1,
+	}
+
+	// desP permutes the S-box outputs.
+	desP = [32]byte{
+		16, 7, 20, 21, 29, 12, 28, 17,
+		1, 15, 23, 26, 5, 18, 31, 10,
+		2, 8, 24, 14, 32, 27, 3, 9,
+		19, 13, 30, 6, 22, 11, 4, 25,
+	}
+
+	// desPC1 selects the 56 key bits that aren't parity bits.
+	desPC1 = [56]byte{
+		57, 49, 41, 33, 25, 17, 9,
+		1, 58, 50, 42, 34, 26, 18,
+		10, 2, 59, 51, 43, 35, 27,
+		19, 11, 3, 60, 52, 44, 36,
+		63, 55, 47, 39, 31, 23, 15,
+		7, 62, 54, 46, 38, 30, 22,
+		14, 6, 61, 53, 45, 37, 29,
+		21, 13, 5, 28, 20, 12, 4,
+	}
+
+	// desPC2 selects each round's 48 subkey bits.
+	desPC2 = [48]byte{
+		14, 17, 11, 24, 1, 5,
+		3, 28, 15, 6, 21, 10,
+		23, 19, 12, 4, 26, 8,
+		16, 7, 27, 20, 13, 2,
+		41, 52, 31, 37, 47, 55,
+		30, 40, 51, 45, 33, 48,
+		44, 49, 39, 56, 34, 53,
+		46, 42, 50, 36, 29, 32,
+	}
+
+	// desShifts are how far each round rotates the key halves.
+	desShifts = [16]int{1, 1, 2, 2, 2, 2, 2, 2, 1, 2, 2, 2, 2, 2, 2, 1}
+
+	// desSboxes map 6 bits to 4: the outer bits select a row, the inner four
+	// a column.
+	desSboxes = [8][4][16]byte{
+		{
+			{14, 4, 13, 1, 2, 15, 11, 8, 3, 10, 6, 12, 5, 9, 0, 7},
+			{0, 15, 7, 4, 14, 2, 13, 1, 10, 6, 12, 11, 9, 5, 3, 8},
+			{4, 1, 14, 8, 13, 6, 2, 11, 15, 12, 9, 7, 3, 10, 5, 0},
+			{15, 12, 8, 2, 4, 9, 1, 7, 5, 11, 3, 14, 10, 0, 6, 13},
+		},
+		{
+			{15, 1, 8, 14, 6, 11, 3, 4, 9, 7, 2, 13, 12, 0, 5, 10},
+			{3, 13, 4, 7, 15, 2, 8, 14, 12, 0, 1, 10, 6, 9, 11, 5},
+			{0, 14, 7, 11, 10, 4, 13, 1, 5, 8, 12, 6, 9, 3, 2, 15},
+			{13, 8, 10, 1, 3, 15, 4, 2, 11, 6, 7, 12, 0, 5, 14, 9},
+		},
+		{
+			{10, 0, 9, 14, 6, 3, 15, 5, 1, 13, 12, 7, 11, 4, 2, 8},
+			{13, 7, 0, 9, 3, 4, 6, 10, 2, 8, 5, 14, 12, 11, 15, 1},
+			{13, 6, 4, 9, 8, 15, 3, 0, 11, 1, 2, 12, 5, 10, 14, 7},
+			{1, 10, 13, 0, 6, 9, 8, 7, 4, 15, 14, 3, 11, 5, 2, 12},
+		},
+		{
+			{7, 13, 14, 3, 0, 6, 9, 10, 1, 2, 8, 5, 11, 12, 4, 15},
+			{13, 8, 11, 5, 6, 15, 0, 3, 4, 7, 2, 12, 1, 10, 14, 9},
+			{10, 6, 9, 0, 12, 11, 7, 13, 15, 1, 3, 14, 5, 2, 8, 4},
+			{3, 15, 0, 6, 10, 1, 13, 8, 9, 4, 5, 11, 12, 7, 2, 14},
+		},
+		{
+			{2, 12, 4, 1, 7, 10, 11, 6, 8, 5, 3, 15, 13, 0, 14, 9},
+			{14, 11, 2, 12, 4, 7, 13, 1, 5, 0, 15, 10, 3, 9, 8, 6},
+			{4, 2, 1, 11, 10, 13, 7, 8, 15, 9, 12, 5, 6, 3, 0, 14},
+			{11, 8, 12, 7, 1, 14, 2, 13, 6, 15, 0, 9, 10, 4, 5, 3},
+		},
+		{
+			{12, 1, 10, 15, 9, 2, 6, 8, 0, 13, 3, 4, 14, 7, 5, 11},
+			{10, 15, 4, 2, 7, 12, 9, 5, 6, 1, 13, 14, 0, 11, 3, 8},
+			{9, 14, 15, 5, 2, 8, 12, 3, 7, 0, 4, 10, 1, 13, 11, 6},
+			{4, 3, 2, 12, 9, 5, 15, 10, 11, 14, 1, 7, 6, 0, 8, 13},
+		},
+		{
+			{4, 11, 2, 14, 15, 0, 8, 13, 3, 12, 9, 7, 5, 10, 6, 1},
+			{13, 0, 11, 7, 4, 9, 1, 10, 14, 3, 5, 12, 2, 15, 8, 6},
+			{1, 4, 11, 13, 12, 3, 7, 14, 10, 15, 6, 8, 0, 5, 9, 2},
+			{6, 11, 13, 8, 1, 4, 10, 7, 9, 5, 0, 15, 14, 2, 3, 12},
+		},
+		{
+			{13, 2, 8, 4, 6, 15, 11, 1, 10, 9, 3, 14, 5, 0, 12, 7},
+			{1, 15, 13, 8, 10, 3, 7, 4, 12, 5, 6, 11, 0, 14, 9, 2},
+			{7, 11, 4, 1, 9, 12, 14, 2, 0, 6, 10, 13, 15, 3, 5, 8},
+			{2, 1, 14, 7, 4, 10, 8, 13, 15, 12, 9, 0, 3, 5, 6, 11},
+		},
+	}
+)
+
+// desPermute returns the bits of x, an n-bit value, selected by table, with
+// the first entry's bit as the most significant of the result.
+func desPermute(x uint64, n int, table []byte) uint64 {
+	var res uint64
+	for _, p := range table {
+		res = res<<1 | x>>(n-int(p))&1
+	}
+	return res
+}
+
+// DESKeySchedule returns the sixteen 48-bit DES subkeys for an 8-byte key,
+// in the low bits of each word. The low bit of each key byte is a parity bit
+// that DES ignores. It panics if key isn't 8 bytes.
+func DESKeySchedule(key []byte) [16]uint64 {
+	if len(key) != desBlockSize {
+		panic("invalid key size")
+	}
+
+	cd := desPermute(binary.BigEndian.Uint64(key), 64, desPC1[:])
+	c, d := uint32(cd>>28), uint32(cd&(1<<28-1))
+	rot := func(x uint32, n int) uint32 {
+		return (x<<n | x>>(28-n)) & (1<<28 - 1)
+	}
+
+	var keys [16]uint64
+	for i, n := range desShifts {
+		c, d = rot(c, n), rot(d, n)
+		keys[i] = desPermute(uint64(c)<<28|uint64(d), 56, desPC2[:])
+	}
+	return keys
+}
+
+// desF is the DES round function: it expands the half block r to 48 bits,
+// adds the subkey k, and passes each 6 bits through an S-box.
+func desF(r uint32, k uint64) uint32 {
+	x := desPermute(uint64(r), 32, desE[:]) ^ k
+	var out uint32
+	for i, s := range desSboxes {
+		b := x >> (42 - 6*i) & 0x3f
+		out = out<<4 | uint32(s[b>>4&2|b&1][b>>1&0xf])
+	}
+	return uint32(desPermute(uint64(out), 32, desP[:]))
+}
+
+// desCipher is DES with its subkeys.
+type desCipher struct {
+	keys [16]uint64
+}
+
+// NewDES returns a cipher.Block for DES, implemented from FIPS 46-3 rather
+// than with crypto/des, with the round steps exposed through DESKeySchedule.
+// It's slow and not constant time. It has the signature of des.NewCipher, so
+// WithCipher(NewDES, 8) selects it.
+//
+// DES has four weak keys, for which encryption and decryption are the same,
+// and encrypting the complement of a block under the complement of a key
+// gives the complement of the ciphertext.
+func NewDES(key []byte) (cipher.Block, error) {
+	if len(key) != desBlockSize {
+		return nil, errors.New("invalid key size")
+	}
+	return &desCipher{keys: DESKeySchedule(key)}, nil
+}
+
+func (c *desCipher) BlockSize() int {
+	return desBlockSize
+}
+
+// crypt runs the sixteen rounds on src with the subkeys in the given order.
+func (c *desCipher) crypt(dst, src []byte, decrypt bool) {
+	if len(src) < desBlockSize || len(dst) < desBlockSize {
+		panic("input not full block")
+	}
+
+	x := desPermute(binary.BigEndian.Uint64(src), 64, desIP[:])
+	l, r := uint32(x>>32), uint32(x)
+	for i := range c.keys {
+		k := c.keys[i]
+		if decrypt {
+			k = c.keys[15-i]
+		}
+		l, r = r, l^desF(r, k)
+	}
+	// The halves aren't swapped after the last round.
+	x = desPermute(uint64(r)<<32|uint64(l), 64, desFP[:])
+	binary.BigEndian.PutUint64(dst, x)
+}
+
+func (c *desCipher) Encrypt(dst, src []byte) {
+	c.crypt(dst, src, false)
+}
+
+func (c *desCipher) Decrypt(dst, src []byte) {
+	c.crypt(dst, src, true)
+}
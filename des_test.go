@@ -0,0 +1,109 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/des"
+	"testing"
+)
+
+func TestDES(t *testing.T) {
+	// The worked example from "The DES Algorithm Illustrated".
+	block, err := NewDES(decodeHex(t, "133457799bbcdff1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, want := decodeHex(t, "0123456789abcdef"), decodeHex(t, "85e813540f0ab405")
+
+	got := make([]byte, desBlockSize)
+	block.Encrypt(got, pt)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	block.Decrypt(got, got)
+	if !bytes.Equal(got, pt) {
+		t.Errorf("decrypted to %x, want %x", got, pt)
+	}
+
+	if _, err := NewDES(make([]byte, 7)); err == nil {
+		t.Error("7-byte key: no error")
+	}
+}
+
+func TestDESMatchesStdlib(t *testing.T) {
+	for range 200 {
+		key, pt := randBytes(desBlockSize), randBytes(desBlockSize)
+		ours, err := NewDES(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		theirs, err := des.NewCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, want := make([]byte, desBlockSize), make([]byte, desBlockSize)
+		ours.Encrypt(got, pt)
+		theirs.Encrypt(want, pt)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("key %x, plaintext %x: got %x, want %x", key, pt, got, want)
+		}
+	}
+}
+
+func TestDESWeakKeys(t *testing.T) {
+	weak := []string{"0101010101010101", "fefefefefefefefe", "e0e0e0e0f1f1f1f1", "1f1f1f1f0e0e0e0e"}
+	for _, k := range weak {
+		key := decodeHex(t, k)
+
+		// Every subkey is the same, so decryption, which uses them in
+		// reverse, is encryption.
+		keys := DESKeySchedule(key)
+		for i, sk := range keys {
+			if sk != keys[0] {
+				t.Errorf("key %s: subkey %d differs from subkey 0", k, i)
+			}
+		}
+
+		block, _ := NewDES(key)
+		pt := randBytes(desBlockSize)
+		ct := make([]byte, desBlockSize)
+		block.Encrypt(ct, pt)
+		block.Encrypt(ct, ct)
+		if !bytes.Equal(ct, pt) {
+			t.Errorf("key %s: encrypting twice gives %x, want %x", k, ct, pt)
+		}
+	}
+
+	// Other keys don't have the property.
+	block, _ := NewDES(randBytes(desBlockSize))
+	pt := randBytes(desBlockSize)
+	ct := make([]byte, desBlockSize)
+	block.Encrypt(ct, pt)
+	block.Encrypt(ct, ct)
+	if bytes.Equal(ct, pt) {
+		t.Error("random key is weak")
+	}
+}
+
+func TestDESComplementation(t *testing.T) {
+	not := func(b []byte) []byte {
+		res := make([]byte, len(b))
+		for i := range b {
+			res[i] = ^b[i]
+		}
+		return res
+	}
+
+	for range 20 {
+		key, pt := randBytes(desBlockSize), randBytes(desBlockSize)
+		a, _ := NewDES(key)
+		b, _ := NewDES(not(key))
+
+		ct, ct2 := make([]byte, desBlockSize), make([]byte, desBlockSize)
+		a.Encrypt(ct, pt)
+		b.Encrypt(ct2, not(pt))
+		if !bytes.Equal(ct2, not(ct)) {
+			t.Errorf("key %x, plaintext %x: got %x, want %x", key, pt, ct2, not(ct))
+		}
+	}
+}
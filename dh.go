@@ -0,0 +1,212 @@
+package cryptopals
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+)
+
+// dhP and dhG are the group from challenge 33: the 1536-bit MODP prime from
+// RFC 3526 and the generator 2.
+var (
+	dhP = func() *big.Int {
+		p, _ := new(big.Int).SetString("ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd1"+
+			"29024e088a67cc74020bbea63b139b22514a08798e3404dd"+
+			"ef9519b3cd3a431b302b0a6df25f14374fe1356d6d51c245"+
+			"e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed"+
+			"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3d"+
+			"c2007cb8a163bf0598da48361c55d39a69163fa8fd24cf5f"+
+			"83655d23dca3ad961c62f356208552bb9ed529077096966d"+
+			"670c354e4abc9804f1746c08ca237327ffffffffffffffff", 16)
+		return p
+	}()
+	dhG = big.NewInt(2)
+)
+
+// dhExponent returns a random private exponent.
+func dhExponent() *big.Int {
+	n, err := rand.Int(rand.Reader, dhP)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// dhCipher returns the AES-128 cipher challenge 34 keys from the shared
+// secret s, with the first 16 bytes of SHA1(s).
+func dhCipher(s *big.Int) cipher.Block {
+	k := sha1.Sum(s.Bytes())
+	b, _ := aes.NewCipher(k[:16])
+	return b
+}
+
+// NewDHEchoServer returns Bob from challenge 34 as a Party. See ServeDHEcho
+// for the messages.
+func NewDHEchoServer(opts ...Option) Party {
+	o := newOptions(opts)
+
+	return PartyFunc(func(ch Channel) error {
+		hello, err := ch.Receive()
+		if err != nil {
+			return noEOF(err)
+		}
+		if len(hello) != 3 {
+			return errMalformedMessage
+		}
+		p := new(big.Int).SetBytes(hello[0])
+		g := new(big.Int).SetBytes(hello[1])
+		A := new(big.Int).SetBytes(hello[2])
+		if p.Sign() == 0 {
+			return errors.New("zero modulus")
+		}
+
+		b := dhExponent()
+		if err := ch.Send(Message{new(big.Int).Exp(g, b, p).Bytes()}); err != nil {
+			return err
+		}
+		block := dhCipher(new(big.Int).Exp(A, b, p))
+		o.debug("dh handshake")
+
+		for {
+			m, err := ch.Receive()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(m) != 1 {
+				return errMalformedMessage
+			}
+			msg, err := DecryptPadded(block, m[0])
+			o.debug("dh echo", "err", err)
+			if err != nil {
+				return err
+			}
+
+			time.Sleep(o.latency)
+
+			if err := ch.Send(Message{EncryptPadded(block, msg)}); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// dhEchoHandshake runs Alice's side of the handshake on ch, over the group
+// from challenge 33, and returns the cipher for the key.
+func dhEchoHandshake(ch Channel) (cipher.Block, error) {
+	a := dhExponent()
+	A := new(big.Int).Exp(dhG, a, dhP)
+	if err := ch.Send(Message{dhP.Bytes(), dhG.Bytes(), A.Bytes()}); err != nil {
+		return nil, err
+	}
+	m, err := ch.Receive()
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	if len(m) != 1 {
+		return nil, errMalformedMessage
+	}
+	return dhCipher(new(big.Int).Exp(new(big.Int).SetBytes(m[0]), a, dhP)), nil
+}
+
+// dhEcho sends msg on ch encrypted under b, and returns the decrypted echo.
+func dhEcho(ch Channel, b cipher.Block, msg []byte) ([]byte, error) {
+	if err := ch.Send(Message{EncryptPadded(b, msg)}); err != nil {
+		return nil, err
+	}
+	m, err := ch.Receive()
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	if len(m) != 1 {
+		return nil, errMalformedMessage
+	}
+	return DecryptPadded(b, m[0])
+}
+
+// A DHEchoClient is Alice from challenge 34 as a Party: it sends each of
+// Messages to an echo server and keeps the replies in Echoes.
+type DHEchoClient struct {
+	Messages [][]byte
+	Echoes   [][]byte
+}
+
+// Run implements Party.
+func (c *DHEchoClient) Run(ch Channel) error {
+	b, err := dhEchoHandshake(ch)
+	if err != nil {
+		return err
+	}
+	for _, msg := range c.Messages {
+		echo, err := dhEcho(ch, b, msg)
+		if err != nil {
+			return err
+		}
+		c.Echoes = append(c.Echoes, echo)
+	}
+	return nil
+}
+
+// NewDHParameterInjection returns an interceptor for one connection between
+// a DHEchoClient and an echo server that performs the parameter injection
+// attack from challenge 34. It passes each message's plaintext to intercept.
+//
+// It replaces both public values in the handshake with p, so each side's
+// shared secret is p^x mod p = 0, and it knows the key without either
+// private value. Neither side can tell, since their messages still decrypt.
+func NewDHParameterInjection(intercept func(dir Direction, msg []byte)) Interceptor {
+	var (
+		shook [2]bool // Whether each direction's handshake message has passed.
+
+		mu sync.Mutex
+		p  []byte // Set before the server's handshake message arrives.
+	)
+	b := dhCipher(new(big.Int))
+
+	return InterceptorFunc(func(dir Direction, m Message, send func(Direction, Message) error) error {
+		if !shook[dir] {
+			shook[dir] = true
+			switch {
+			case dir == ToServer && len(m) == 3:
+				mu.Lock()
+				p = m[0]
+				mu.Unlock()
+				return send(dir, Message{m[0], m[1], m[0]})
+			case dir == ToClient && len(m) == 1:
+				mu.Lock()
+				pub := p
+				mu.Unlock()
+				return send(dir, Message{pub})
+			}
+			return errMalformedMessage
+		}
+
+		if len(m) == 1 {
+			if msg, err := DecryptPadded(b, m[0]); err == nil {
+				intercept(dir, msg)
+			}
+		}
+		return send(dir, m)
+	})
+}
+
+// ServeDHEchoMITM accepts connections on l and relays each to the echo
+// server at upstream, as served by ServeDHEcho, through
+// NewDHParameterInjection(intercept). It may call intercept from several
+// goroutines at once.
+//
+// ServeDHEchoMITM returns when l.Accept fails, such as when l is closed.
+func ServeDHEchoMITM(l net.Listener, upstream string, intercept func(dir Direction, msg []byte), opts ...Option) error {
+	return ServeMITM(l, upstream, func() Interceptor {
+		return NewDHParameterInjection(intercept)
+	}, opts...)
+}
@@ -0,0 +1,74 @@
+package cryptopals
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+)
+
+func TestDHEcho(t *testing.T) {
+	addr := listen(t, func(l net.Listener) { ServeDHEcho(l) })
+
+	c, err := DialDHEcho(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for _, msg := range []string{"hello, bob", "", "a message longer than one block"} {
+		got, err := c.Echo([]byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != msg {
+			t.Errorf("got %q, want %q", got, msg)
+		}
+	}
+}
+
+func TestDHEchoMITM(t *testing.T) {
+	bob := listen(t, func(l net.Listener) { ServeDHEcho(l) })
+
+	var (
+		mu  sync.Mutex
+		got [][]byte
+	)
+	mitm := listen(t, func(l net.Listener) {
+		ServeDHEchoMITM(l, bob, func(dir Direction, msg []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, msg)
+		})
+	})
+
+	c, err := DialDHEcho(mitm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	msgs := []string{"attack at dawn", "bring snacks"}
+	for _, msg := range msgs {
+		echo, err := c.Echo([]byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(echo) != msg {
+			t.Errorf("echo: got %q, want %q", echo, msg)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := [][]byte{[]byte(msgs[0]), []byte(msgs[0]), []byte(msgs[1]), []byte(msgs[1])}
+	if len(got) != len(want) {
+		t.Fatalf("intercepted %q, want %q", got, want)
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("intercepted %q, want %q", got, want)
+			break
+		}
+	}
+}
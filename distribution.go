@@ -0,0 +1,104 @@
+package cryptopals
+
+import "math"
+
+// A Distribution assigns a weight to each byte value, such as a count or a
+// probability.
+type Distribution [256]float64
+
+// ByteCounts returns the distribution of byte counts in b.
+func ByteCounts(b []byte) Distribution {
+	var d Distribution
+	for _, v := range b {
+		d[v]++
+	}
+	return d
+}
+
+// Distribution returns the distribution of byte counts in c.
+func (c *Corpus) Distribution() Distribution {
+	return ByteCounts(c.text)
+}
+
+// Total returns the sum of the weights in d.
+func (d Distribution) Total() float64 {
+	var sum float64
+	for _, v := range d {
+		sum += v
+	}
+	return sum
+}
+
+// Merge returns the sum of d and e. Merging counts from two samples gives the
+// counts for both samples combined.
+func (d Distribution) Merge(e Distribution) Distribution {
+	for i := range d {
+		d[i] += e[i]
+	}
+	return d
+}
+
+// Normalize returns d scaled so that its weights sum to 1.
+//
+// If d has a total weight of 0, Normalize returns d unchanged.
+func (d Distribution) Normalize() Distribution {
+	total := d.Total()
+	if total == 0 {
+		return d
+	}
+	for i := range d {
+		d[i] /= total
+	}
+	return d
+}
+
+// BhattacharyyaCoefficient returns the overlap between d and e after
+// normalizing both. It is between 0 and 1 inclusive, and is 1 if and only if
+// the normalized distributions are equal.
+func (d Distribution) BhattacharyyaCoefficient(e Distribution) float64 {
+	d, e = d.Normalize(), e.Normalize()
+
+	var bc float64
+	for i := range d {
+		bc += math.Sqrt(d[i] * e[i])
+	}
+	return bc
+}
+
+// BhattacharyyaDistance returns the Bhattacharyya distance between d and e
+// after normalizing both. Lower means more similar.
+//
+// If d and e share no byte values, BhattacharyyaDistance returns +Inf.
+func (d Distribution) BhattacharyyaDistance(e Distribution) float64 {
+	return -math.Log(d.BhattacharyyaCoefficient(e))
+}
+
+// KLDivergence returns the Kullback-Leibler divergence of e from d, in nats,
+// after normalizing both. Lower means more similar.
+//
+// KLDivergence is not symmetric, and it returns +Inf if some byte value has
+// weight in d but not in e.
+func (d Distribution) KLDivergence(e Distribution) float64 {
+	d, e = d.Normalize(), e.Normalize()
+
+	var kl float64
+	for i := range d {
+		if d[i] == 0 {
+			continue
+		}
+		if e[i] == 0 {
+			return math.Inf(1)
+		}
+		kl += d[i] * math.Log(d[i]/e[i])
+	}
+	return kl
+}
+
+// Scorer returns a scorer that scores plaintexts by the Bhattacharyya
+// coefficient between their byte counts and d.
+func (d Distribution) Scorer() Scorer {
+	d = d.Normalize()
+	return ScorerFunc(func(b []byte) float64 {
+		return ByteCounts(b).BhattacharyyaCoefficient(d)
+	})
+}
@@ -0,0 +1,69 @@
+package cryptopals
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistributionNormalize(t *testing.T) {
+	d := ByteCounts([]byte("aab"))
+
+	if got := d.Total(); got != 3 {
+		t.Errorf("total: want 3, got %v", got)
+	}
+
+	p := d.Normalize()
+	if math.Abs(p['a']-2.0/3) > 1e-12 || math.Abs(p['b']-1.0/3) > 1e-12 {
+		t.Errorf("want a=2/3 and b=1/3, got a=%v and b=%v", p['a'], p['b'])
+	}
+
+	var zero Distribution
+	if zero.Normalize() != zero {
+		t.Error("normalizing an empty distribution should be a no-op")
+	}
+}
+
+func TestDistributionMerge(t *testing.T) {
+	got := ByteCounts([]byte("ab")).Merge(ByteCounts([]byte("bc")))
+	want := ByteCounts([]byte("abbc"))
+
+	if want != got {
+		t.Error("merged counts differ from combined counts")
+	}
+}
+
+func TestDistributionDistance(t *testing.T) {
+	english := EnglishCorpus().Distribution()
+	german := GermanCorpus().Distribution()
+	sample := ByteCounts([]byte("It was the best of times, it was the worst of times."))
+
+	if got := english.BhattacharyyaDistance(english); math.Abs(got) > 1e-12 {
+		t.Errorf("distance to self: want 0, got %v", got)
+	}
+	if got := english.KLDivergence(english); math.Abs(got) > 1e-12 {
+		t.Errorf("divergence from self: want 0, got %v", got)
+	}
+
+	if sample.BhattacharyyaDistance(english) >= sample.BhattacharyyaDistance(german) {
+		t.Error("english sample is closer to german than english")
+	}
+
+	disjoint := ByteCounts([]byte("zzz"))
+	if got := ByteCounts([]byte("aaa")).BhattacharyyaDistance(disjoint); !math.IsInf(got, 1) {
+		t.Errorf("disjoint distance: want +Inf, got %v", got)
+	}
+	if got := ByteCounts([]byte("az")).KLDivergence(disjoint); !math.IsInf(got, 1) {
+		t.Errorf("divergence with missing support: want +Inf, got %v", got)
+	}
+}
+
+func TestDistributionScorer(t *testing.T) {
+	ct := decodeHex(t, "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+	want := byte(88)
+
+	s := EnglishCorpus().Distribution().Scorer()
+
+	if got := RecoverSingleByteXORKey(ct, WithScorer(s)); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,124 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// NewToyCipher returns a block cipher with a key of only bits bits, small
+// enough to search: AES-128 keyed by key as a big-endian number padded with
+// zeros. It panics if bits isn't from 1 to 64 or key doesn't fit in it.
+func NewToyCipher(key uint64, bits int) cipher.Block {
+	if bits < 1 || bits > 64 {
+		panic("invalid key size")
+	}
+	if bits < 64 && key>>bits != 0 {
+		panic("key too large")
+	}
+
+	var k [aes.BlockSize]byte
+	binary.BigEndian.PutUint64(k[:], key)
+	c, _ := aes.NewCipher(k[:])
+	return c
+}
+
+// doubleCipher encrypts with one block cipher, then another.
+type doubleCipher struct {
+	inner, outer cipher.Block
+}
+
+// NewDoubleCipher returns a block cipher that encrypts with inner and then
+// outer, as 2DES does with two DES keys. They must have the same block size.
+//
+// Two keys of k bits each don't give 2k bits of security: see
+// RecoverDoubleKeys.
+func NewDoubleCipher(inner, outer cipher.Block) cipher.Block {
+	if inner.BlockSize() != outer.BlockSize() {
+		panic("block sizes differ")
+	}
+	return &doubleCipher{inner, outer}
+}
+
+func (c *doubleCipher) BlockSize() int {
+	return c.inner.BlockSize()
+}
+
+func (c *doubleCipher) Encrypt(dst, src []byte) {
+	c.inner.Encrypt(dst, src)
+	c.outer.Encrypt(dst, dst)
+}
+
+func (c *doubleCipher) Decrypt(dst, src []byte) {
+	c.outer.Decrypt(dst, src)
+	c.inner.Decrypt(dst, dst)
+}
+
+// RecoverDoubleKeys returns the inner and outer keys of
+// NewDoubleCipher(newCipher(k1), newCipher(k2)), where keys have bits bits,
+// from known plaintext and ciphertext block pairs.
+//
+// It's a meet-in-the-middle attack with MeetInTheMiddle: the encryption of
+// the first plaintext under the inner key equals the decryption of its
+// ciphertext under the outer key. So it takes about 2^bits encryptions,
+// decryptions, and table entries, rather than the 2^(2 bits) encryptions of
+// a search over both keys. Each match is checked against the other pairs;
+// false matches are likely unless the pairs have more bits than the two keys
+// together. Use WithMaxEntries to limit the memory it uses.
+//
+// It returns an error if bits isn't from 1 to 63, if there are no pairs or
+// one isn't a single block, or if no key pair fits every pair.
+func RecoverDoubleKeys(newCipher func(key uint64) cipher.Block, bits int, pairs [][2][]byte, opts ...Option) (k1, k2 uint64, stats MITMStats, err error) {
+	if bits < 1 || bits > 63 {
+		return 0, 0, stats, errors.New("invalid key size")
+	}
+	if len(pairs) == 0 {
+		return 0, 0, stats, errors.New("no plaintext and ciphertext pairs")
+	}
+	bs := newCipher(0).BlockSize()
+	for _, p := range pairs {
+		if len(p[0]) != bs || len(p[1]) != bs {
+			return 0, 0, stats, errors.New("pair isn't a single block")
+		}
+	}
+	pt, ct := pairs[0][0], pairs[0][1]
+	mid, buf := make([]byte, len(pt)), make([]byte, len(pt))
+
+	m := &MeetInTheMiddle{
+		NumForward:  1 << bits,
+		NumBackward: 1 << bits,
+		Forward: func(k uint64) []byte {
+			newCipher(k).Encrypt(mid, pt)
+			return mid
+		},
+		Backward: func(k uint64) []byte {
+			newCipher(k).Decrypt(mid, ct)
+			return mid
+		},
+		Match: func(k1, k2 uint64) bool {
+			return doubleMatches(NewDoubleCipher(newCipher(k1), newCipher(k2)), pairs, buf)
+		},
+	}
+	k1, k2, stats, err = m.Search(opts...)
+	if err == errNoMITMMatch {
+		err = errors.New("no key pair fits")
+	}
+	if err != nil {
+		return 0, 0, stats, err
+	}
+	return k1, k2, stats, nil
+}
+
+// doubleMatches reports whether c encrypts every plaintext in pairs to its
+// ciphertext, using buf as scratch space.
+func doubleMatches(c cipher.Block, pairs [][2][]byte, buf []byte) bool {
+	for _, p := range pairs {
+		c.Encrypt(buf, p[0])
+		if !bytes.Equal(buf, p[1]) {
+			return false
+		}
+	}
+	return true
+}
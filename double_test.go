@@ -0,0 +1,115 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"testing"
+)
+
+func TestDoubleCipher(t *testing.T) {
+	a, _ := des.NewCipher(randBytes(8))
+	b, _ := des.NewCipher(randBytes(8))
+	c := NewDoubleCipher(a, b)
+
+	pt := randBytes(8)
+	want, got := make([]byte, 8), make([]byte, 8)
+	a.Encrypt(want, pt)
+	b.Encrypt(want, want)
+	c.Encrypt(got, pt)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+	c.Decrypt(got, got)
+	if !bytes.Equal(got, pt) {
+		t.Errorf("decrypted to %x, want %x", got, pt)
+	}
+}
+
+func TestRecoverDoubleKeys(t *testing.T) {
+	const bits = 16
+	newCipher := func(k uint64) cipher.Block { return NewToyCipher(k, bits) }
+
+	k1, k2 := uint64(randInt64(1<<bits)), uint64(randInt64(1<<bits))
+	c := NewDoubleCipher(newCipher(k1), newCipher(k2))
+	var pairs [][2][]byte
+	for range 2 {
+		pt := randBytes(16)
+		ct := make([]byte, 16)
+		c.Encrypt(ct, pt)
+		pairs = append(pairs, [2][]byte{pt, ct})
+	}
+
+	gotK1, gotK2, stats, err := RecoverDoubleKeys(newCipher, bits, pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotK1 != k1 || gotK2 != k2 {
+		t.Errorf("got keys %x, %x, want %x, %x", gotK1, gotK2, k1, k2)
+	}
+
+	// About 2^bits work, not 2^(2 bits).
+	if stats.TableEntries != 1<<bits || stats.Lookups != int(k2)+1 {
+		t.Errorf("stats %+v, want %d entries and %d lookups", stats, 1<<bits, k2+1)
+	}
+}
+
+func TestRecoverDoubleKeysNoMatch(t *testing.T) {
+	const bits = 8
+	newCipher := func(k uint64) cipher.Block { return NewToyCipher(k, bits) }
+
+	pairs := [][2][]byte{{randBytes(16), randBytes(16)}}
+	if _, _, _, err := RecoverDoubleKeys(newCipher, bits, pairs); err == nil {
+		t.Error("no error")
+	}
+}
+
+func TestRecoverDoubleKeysBadInput(t *testing.T) {
+	const bits = 8
+	newCipher := func(k uint64) cipher.Block { return NewToyCipher(k, bits) }
+	pair := [2][]byte{randBytes(16), randBytes(16)}
+
+	for name, tt := range map[string]struct {
+		bits  int
+		pairs [][2][]byte
+	}{
+		"zero bits":  {0, [][2][]byte{pair}},
+		"64 bits":    {64, [][2][]byte{pair}},
+		"no pairs":   {bits, nil},
+		"short pair": {bits, [][2][]byte{pair, {randBytes(15), randBytes(16)}}},
+	} {
+		if _, _, _, err := RecoverDoubleKeys(newCipher, tt.bits, tt.pairs); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestToyCipher(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	NewToyCipher(1<<8, 8)
+}
+
+func TestRecoverDoubleKeysSpilled(t *testing.T) {
+	const bits = 12
+	newCipher := func(k uint64) cipher.Block { return NewToyCipher(k, bits) }
+
+	k1, k2 := uint64(randInt64(1<<bits)), uint64(randInt64(1<<bits))
+	c := NewDoubleCipher(newCipher(k1), newCipher(k2))
+	pt, ct := randBytes(16), make([]byte, 16)
+	c.Encrypt(ct, pt)
+
+	gotK1, gotK2, stats, err := RecoverDoubleKeys(newCipher, bits, [][2][]byte{{pt, ct}}, WithMaxEntries(1<<8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotK1 != k1 || gotK2 != k2 {
+		t.Errorf("got keys %x, %x, want %x, %x", gotK1, gotK2, k1, k2)
+	}
+	if stats.TableEntries > 1<<8 || stats.SpilledBytes == 0 {
+		t.Errorf("stats %+v", stats)
+	}
+}
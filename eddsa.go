@@ -0,0 +1,297 @@
+package cryptopals
+
+import (
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// Edwards25519 constants: the field prime p = 2^255 - 19, the curve's d,
+// the base point's order L, and the base point itself, with y = 4/5.
+var (
+	edP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	edD = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), edP)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, edP)
+	}()
+	edL, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	edB    = func() edPoint {
+		y := new(big.Int).ModInverse(big.NewInt(5), edP)
+		y.Mul(y, big.NewInt(4)).Mod(y, edP)
+		b, _ := edDecode(edEncodeY(y, false))
+		return b
+	}()
+)
+
+// An edPoint is a point on the twisted Edwards curve -x^2 + y^2 = 1 +
+// d x^2 y^2 over GF(p), in affine coordinates. Every operation inverts,
+// which is slow but simple.
+type edPoint struct {
+	x, y *big.Int
+}
+
+// edIdentity returns the neutral point, (0, 1).
+func edIdentity() edPoint {
+	return edPoint{big.NewInt(0), big.NewInt(1)}
+}
+
+// edAdd returns a + b, with the complete twisted Edwards addition law.
+func edAdd(a, b edPoint) edPoint {
+	x1y2 := new(big.Int).Mul(a.x, b.y)
+	y1x2 := new(big.Int).Mul(a.y, b.x)
+	x1x2 := new(big.Int).Mul(a.x, b.x)
+	y1y2 := new(big.Int).Mul(a.y, b.y)
+	t := new(big.Int).Mul(x1x2, y1y2)
+	t.Mul(t, edD).Mod(t, edP)
+
+	// x3 = (x1y2 + y1x2) / (1 + d x1x2y1y2), y3 = (y1y2 + x1x2) / (1 - d ...).
+	den := new(big.Int).Add(big.NewInt(1), t)
+	x := x1y2.Add(x1y2, y1x2)
+	x.Mul(x, den.ModInverse(den, edP)).Mod(x, edP)
+	den = new(big.Int).Sub(big.NewInt(1), t)
+	den.Mod(den, edP)
+	y := y1y2.Add(y1y2, x1x2)
+	y.Mul(y, den.ModInverse(den, edP)).Mod(y, edP)
+	return edPoint{x, y}
+}
+
+// edMul returns k*a, by double-and-add.
+func edMul(k *big.Int, a edPoint) edPoint {
+	res := edIdentity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		res = edAdd(res, res)
+		if k.Bit(i) == 1 {
+			res = edAdd(res, a)
+		}
+	}
+	return res
+}
+
+// edEncodeY returns the 32-byte encoding of a point with coordinate y: y
+// little-endian, with the top bit set if x is odd.
+func edEncodeY(y *big.Int, odd bool) []byte {
+	b := y.FillBytes(make([]byte, 32))
+	slices.Reverse(b)
+	if odd {
+		b[31] |= 0x80
+	}
+	return b
+}
+
+// encode returns the 32-byte encoding of a.
+func (a edPoint) encode() []byte {
+	return edEncodeY(a.y, a.x.Bit(0) == 1)
+}
+
+// edDecode returns the point b encodes, and reports whether it's on the
+// curve and canonically encoded.
+func edDecode(b []byte) (edPoint, bool) {
+	if len(b) != 32 {
+		return edPoint{}, false
+	}
+	le := slices.Clone(b)
+	odd := le[31]&0x80 != 0
+	le[31] &= 0x7f
+	slices.Reverse(le)
+	y := new(big.Int).SetBytes(le)
+	if y.Cmp(edP) >= 0 {
+		return edPoint{}, false
+	}
+
+	// x^2 = (y^2 - 1) / (d y^2 + 1).
+	y2 := new(big.Int).Mul(y, y)
+	num := new(big.Int).Sub(y2, big.NewInt(1))
+	den := y2.Mul(y2, edD).Add(y2, big.NewInt(1)).Mod(y2, edP)
+	num.Mul(num, den.ModInverse(den, edP)).Mod(num, edP)
+	x := new(big.Int).ModSqrt(num, edP)
+	if x == nil || (x.Sign() == 0 && odd) {
+		return edPoint{}, false
+	}
+	if (x.Bit(0) == 1) != odd {
+		x.Sub(edP, x)
+	}
+	return edPoint{x, y}, true
+}
+
+// clampScalar returns the scalar X25519 and Ed25519 use for the 32 bytes k:
+// k little-endian, with the low 3 bits cleared, so it's a multiple of the
+// cofactor 8, and bit 254 set and bit 255 cleared, so every scalar takes
+// the same number of ladder steps.
+func clampScalar(k []byte) *big.Int {
+	le := slices.Clone(k)
+	le[0] &= 248
+	le[31] &= 127
+	le[31] |= 64
+	slices.Reverse(le)
+	return new(big.Int).SetBytes(le)
+}
+
+// edScalar returns the little-endian number b mod L.
+func edScalar(b []byte) *big.Int {
+	be := slices.Clone(b)
+	slices.Reverse(be)
+	s := new(big.Int).SetBytes(be)
+	return s.Mod(s, edL)
+}
+
+// edScalarBytes returns the 32-byte little-endian encoding of s, less than L.
+func edScalarBytes(s *big.Int) []byte {
+	b := s.FillBytes(make([]byte, 32))
+	slices.Reverse(b)
+	return b
+}
+
+// edHash returns SHA-512 of the concatenated parts, as a scalar.
+func edHash(parts ...[]byte) *big.Int {
+	h := sha512.Sum512(slices.Concat(parts...))
+	return edScalar(h[:])
+}
+
+// WithRandomNonces makes an EdDSAKey draw each signature's nonce at random
+// instead of deriving it from the key and message, as ECDSA and Schnorr
+// signatures did before EdDSA. The nonces come from the source WithSeed
+// seeds, so with a fixed seed, two signers repeat each other's nonces, as
+// two devices with the same badly seeded generator do.
+func WithRandomNonces() Option {
+	return func(o *options) {
+		o.randomNonces = true
+	}
+}
+
+// An EdDSAKey is an Ed25519 private key, implemented from RFC 8032 with
+// big.Int arithmetic, so it's slow and not constant time. Its signatures
+// verify with crypto/ed25519.
+//
+// The nonce for a signature is hash(prefix || msg), for a secret prefix
+// derived from the seed, so signing the same message always uses the same
+// nonce. That's safe only as long as the rest of the signature is the same
+// too: see SignWithPublicKey.
+type EdDSAKey struct {
+	scalar *big.Int
+	prefix []byte
+	pub    []byte
+	nonces func() *big.Int // Nil for deterministic nonces.
+}
+
+// NewEdDSAKey returns the key for the 32-byte seed, as crypto/ed25519's
+// NewKeyFromSeed does. Use WithRandomNonces for random nonces. It panics if
+// seed isn't 32 bytes.
+func NewEdDSAKey(seed []byte, opts ...Option) *EdDSAKey {
+	if len(seed) != 32 {
+		panic("invalid seed size")
+	}
+	o := newOptions(opts)
+
+	h := sha512.Sum512(seed)
+	k := &EdDSAKey{scalar: clampScalar(h[:32]), prefix: slices.Clone(h[32:])}
+	k.pub = edMul(k.scalar, edB).encode()
+
+	if o.randomNonces {
+		rng := o.newRand()
+		k.nonces = func() *big.Int {
+			b := make([]byte, 64)
+			rng.Read(b)
+			return edScalar(b)
+		}
+	}
+	return k
+}
+
+// PublicKey returns the 32-byte public key.
+func (k *EdDSAKey) PublicKey() []byte {
+	return slices.Clone(k.pub)
+}
+
+// Sign returns the 64-byte signature R || S of msg, where R = rB for the
+// nonce r and S = r + hash(R || A || msg) * a mod L for the public key A and
+// secret scalar a.
+func (k *EdDSAKey) Sign(msg []byte) []byte {
+	return k.SignWithPublicKey(k.pub, msg)
+}
+
+// SignWithPublicKey is like Sign, but hashes pub into the signature instead
+// of k's own public key, as some Ed25519 libraries allow, to save deriving
+// it. That's a misuse: the nonce depends only on msg, so two signatures of
+// the same message under different public keys share a nonce and differ in
+// the hash, which gives away the secret scalar. See RecoverEdDSAScalar. A
+// fault in computing the hash does the same.
+func (k *EdDSAKey) SignWithPublicKey(pub, msg []byte) []byte {
+	var r *big.Int
+	if k.nonces != nil {
+		r = k.nonces()
+	} else {
+		r = edHash(k.prefix, msg)
+	}
+	return edSign(k.scalar, r, pub, msg)
+}
+
+// edSign returns the signature of msg with the secret scalar a, the nonce
+// r, and the public key pub.
+func edSign(a, r *big.Int, pub, msg []byte) []byte {
+	R := edMul(r, edB).encode()
+	s := edHash(R, pub, msg)
+	s.Mul(s, a).Add(s, r).Mod(s, edL)
+	return slices.Concat(R, edScalarBytes(s))
+}
+
+// VerifyEdDSA reports whether sig is a valid Ed25519 signature of msg under
+// pub: whether SB = R + hash(R || A || msg) * A.
+func VerifyEdDSA(pub, msg, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	A, ok := edDecode(pub)
+	if !ok {
+		return false
+	}
+	R, ok := edDecode(sig[:32])
+	if !ok {
+		return false
+	}
+	sb := slices.Clone(sig[32:])
+	slices.Reverse(sb)
+	s := new(big.Int).SetBytes(sb)
+	if s.Cmp(edL) >= 0 {
+		return false
+	}
+
+	want := edAdd(R, edMul(edHash(sig[:32], pub, msg), A))
+	got := edMul(s, edB)
+	return got.x.Cmp(want.x) == 0 && got.y.Cmp(want.y) == 0
+}
+
+// RecoverEdDSAScalar returns the secret scalar of a key from two of its
+// signatures that share a nonce, with the message and public key each was
+// hashed with: the same message signed with the wrong public key by
+// SignWithPublicKey, or a fault, or two messages signed with the same
+// random nonce.
+//
+// With S1 = r + h1*a and S2 = r + h2*a, a = (S1 - S2) / (h1 - h2) mod L.
+// The scalar is all ForgeEdDSA needs to sign anything.
+//
+// It returns an error if the signatures don't share a nonce, or share their
+// hash too.
+func RecoverEdDSAScalar(pub1, msg1, sig1, pub2, msg2, sig2 []byte) (*big.Int, error) {
+	if len(sig1) != 64 || len(sig2) != 64 {
+		return nil, errors.New("invalid signature size")
+	}
+	if !slices.Equal(sig1[:32], sig2[:32]) {
+		return nil, errors.New("signatures don't share a nonce")
+	}
+	h := new(big.Int).Sub(edHash(sig1[:32], pub1, msg1), edHash(sig2[:32], pub2, msg2))
+	if h.Mod(h, edL).Sign() == 0 {
+		return nil, errors.New("signatures share a hash")
+	}
+	s := new(big.Int).Sub(edScalar(sig1[32:]), edScalar(sig2[32:]))
+	s.Mul(s, h.ModInverse(h, edL))
+	return s.Mod(s, edL), nil
+}
+
+// ForgeEdDSA returns a signature of msg with the secret scalar a, valid
+// under the public key aB, with a nonce derived from a and msg.
+func ForgeEdDSA(a *big.Int, msg []byte) []byte {
+	pub := edMul(a, edB).encode()
+	return edSign(a, edHash(edScalarBytes(new(big.Int).Mod(a, edL)), msg), pub, msg)
+}
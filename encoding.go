@@ -0,0 +1,175 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Base64ToHex converts a Base64-encoded string to a hex-encoded string.
+func Base64ToHex(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// EncodeBase64URL returns the unpadded URL-safe Base64 encoding of b, as used
+// in URLs and cookies, where "+", "/", and "=" would need escaping.
+func EncodeBase64URL(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBase64URL decodes URL-safe Base64, with or without padding.
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// An Encoding is how bytes are written as text.
+type Encoding int
+
+const (
+	RawEncoding       Encoding = iota // The bytes themselves.
+	HexEncoding                       // Lowercase hex.
+	Base64Encoding                    // Standard Base64, with padding.
+	Base64URLEncoding                 // URL-safe Base64, without padding.
+)
+
+var encodingNames = [...]string{RawEncoding: "raw", HexEncoding: "hex", Base64Encoding: "base64", Base64URLEncoding: "base64url"}
+
+func (e Encoding) String() string {
+	if e < 0 || int(e) >= len(encodingNames) {
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+	return encodingNames[e]
+}
+
+// ParseEncoding returns the Encoding that String names s.
+func ParseEncoding(s string) (Encoding, error) {
+	for e, name := range encodingNames {
+		if s == name {
+			return Encoding(e), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown encoding %q", s)
+}
+
+// NewEncoder returns a writer that encodes what's written to it and writes
+// it to w. Close flushes any partial Base64 block; it doesn't close w.
+func (e Encoding) NewEncoder(w io.Writer) io.WriteCloser {
+	switch e {
+	case HexEncoding:
+		return nopCloser{hex.NewEncoder(w)}
+	case Base64Encoding:
+		return base64.NewEncoder(base64.StdEncoding, w)
+	case Base64URLEncoding:
+		return base64.NewEncoder(base64.RawURLEncoding, w)
+	default:
+		return nopCloser{w}
+	}
+}
+
+// NewDecoder returns a reader that decodes what it reads from r. For the text
+// encodings, it skips whitespace, so data split over lines decodes as a
+// whole, and for Base64URLEncoding it also skips padding.
+func (e Encoding) NewDecoder(r io.Reader) io.Reader {
+	switch e {
+	case HexEncoding:
+		return hex.NewDecoder(&skipReader{r: r})
+	case Base64Encoding:
+		return base64.NewDecoder(base64.StdEncoding, &skipReader{r: r})
+	case Base64URLEncoding:
+		return base64.NewDecoder(base64.RawURLEncoding, &skipReader{r: r, padding: true})
+	default:
+		return r
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// A skipReader reads from r, leaving out whitespace and, if padding is set,
+// "=".
+type skipReader struct {
+	r       io.Reader
+	padding bool
+}
+
+func (s *skipReader) Read(p []byte) (int, error) {
+	for {
+		n, err := s.r.Read(p)
+		j := 0
+		for _, c := range p[:n] {
+			switch {
+			case c == ' ', c == '\t', c == '\n', c == '\r':
+			case c == '=' && s.padding:
+			default:
+				p[j] = c
+				j++
+			}
+		}
+		// Don't return 0, nil for a read that was all whitespace.
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+// Transcode copies src, in the from encoding, to dst, in the to encoding,
+// a chunk at a time rather than all at once. It returns the number of
+// decoded bytes copied.
+func Transcode(dst io.Writer, src io.Reader, from, to Encoding) (int64, error) {
+	w := to.NewEncoder(dst)
+	n, err := io.Copy(w, from.NewDecoder(src))
+	if err != nil {
+		return n, err
+	}
+	return n, w.Close()
+}
+
+// NewTranscoder returns a reader that reads r, in the from encoding, and
+// returns it in the to encoding, a chunk at a time.
+func NewTranscoder(r io.Reader, from, to Encoding) io.Reader {
+	t := &transcoder{src: from.NewDecoder(r), chunk: make([]byte, 32*1024)}
+	t.enc = to.NewEncoder(&t.buf)
+	return t
+}
+
+// A transcoder decodes a chunk from src whenever buf, which enc writes to,
+// runs out.
+type transcoder struct {
+	src   io.Reader
+	enc   io.WriteCloser
+	chunk []byte
+	buf   bytes.Buffer
+	err   error // From src, once it's done.
+}
+
+func (t *transcoder) Read(p []byte) (int, error) {
+	for t.buf.Len() == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+		n, err := t.src.Read(t.chunk)
+		if _, werr := t.enc.Write(t.chunk[:n]); werr != nil {
+			return 0, werr
+		}
+		switch {
+		case err == io.EOF:
+			if cerr := t.enc.Close(); cerr != nil {
+				return 0, cerr
+			}
+			t.err = io.EOF
+		case err != nil:
+			t.err = err
+		}
+	}
+	return t.buf.Read(p)
+}
@@ -0,0 +1,107 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBase64ToHex(t *testing.T) {
+	in := "SSdtIGtpbGxpbmcgeW91ciBicmFpbiBsaWtlIGEgcG9pc29ub3VzIG11c2hyb29t"
+	want := "49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f69736f6e6f7573206d757368726f6f6d"
+
+	got, err := Base64ToHex(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestBase64URL(t *testing.T) {
+	data := []byte{0xfb, 0xff, 0xfe, 0x01}
+	s := EncodeBase64URL(data)
+	if s != "-__-AQ" {
+		t.Errorf("want %q, got %q", "-__-AQ", s)
+	}
+
+	for _, in := range []string{s, s + "=="} {
+		got, err := DecodeBase64URL(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%q: want %x, got %x", in, data, got)
+		}
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	for _, e := range []Encoding{RawEncoding, HexEncoding, Base64Encoding, Base64URLEncoding} {
+		got, err := ParseEncoding(e.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != e {
+			t.Errorf("want %v, got %v", e, got)
+		}
+	}
+	if _, err := ParseEncoding("base32"); err == nil {
+		t.Error("want an error for an unknown encoding")
+	}
+}
+
+func TestTranscode(t *testing.T) {
+	data := bytes.Repeat([]byte("transcode me \x00\xff"), 5000)
+	encoded := map[Encoding]string{
+		RawEncoding:       string(data),
+		HexEncoding:       hex.EncodeToString(data),
+		Base64Encoding:    base64.StdEncoding.EncodeToString(data),
+		Base64URLEncoding: base64.RawURLEncoding.EncodeToString(data),
+	}
+
+	for from, in := range encoded {
+		for to, want := range encoded {
+			var out bytes.Buffer
+			n, err := Transcode(&out, strings.NewReader(in), from, to)
+			if err != nil {
+				t.Fatalf("%v to %v: %v", from, to, err)
+			}
+			if n != int64(len(data)) {
+				t.Errorf("%v to %v: want %d bytes, got %d", from, to, len(data), n)
+			}
+			if out.String() != want {
+				t.Errorf("%v to %v: wrong output", from, to)
+			}
+
+			got, err := io.ReadAll(NewTranscoder(iotest.OneByteReader(strings.NewReader(in)), from, to))
+			if err != nil {
+				t.Fatalf("%v to %v: %v", from, to, err)
+			}
+			if string(got) != want {
+				t.Errorf("%v to %v: NewTranscoder gave wrong output", from, to)
+			}
+		}
+	}
+}
+
+func TestTranscodeWhitespace(t *testing.T) {
+	// Challenge data files wrap Base64 over lines.
+	in := "SSdtIGtpbGxp\nbmcgeW91ciBi\r\ncmFpbg==\n"
+	var out bytes.Buffer
+	if _, err := Transcode(&out, strings.NewReader(in), Base64Encoding, RawEncoding); err != nil {
+		t.Fatal(err)
+	}
+	if want := "I'm killing your brain"; out.String() != want {
+		t.Errorf("want %q, got %q", want, out.String())
+	}
+
+	if _, err := Transcode(io.Discard, strings.NewReader("abc"), HexEncoding, RawEncoding); err == nil {
+		t.Error("want an error for odd-length hex")
+	}
+}
@@ -0,0 +1,107 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// GenerateCloseRSAKey returns an RSA key like GenerateRSAKey's, except that
+// it picks one random prime p and takes q as the first prime after p plus
+// a random offset of at most about a quarter of the modulus's bits, as a
+// generator that searches upward from one random starting point might.
+// FactorFermat factors such moduli at once. It returns an error if bits is
+// less than 16 or e isn't odd and at least 3.
+func GenerateCloseRSAKey(bits int, e int64) (*RSAPrivateKey, error) {
+	if bits < 16 {
+		return nil, errors.New("modulus too small")
+	}
+	if e < 3 || e%2 == 0 {
+		return nil, errors.New("invalid public exponent")
+	}
+
+	// p is between sqrt(2^(bits-1)) and sqrt(2^bits), so that p^2 has bits
+	// bits. rand.Prime(bits/2) would be too small for odd sizes, and
+	// rand.Prime((bits+1)/2) too large, since q is about the same size as p.
+	lo := new(big.Int).Sqrt(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)))
+	hi := new(big.Int).Sqrt(new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	span := new(big.Int).Sub(hi, lo)
+
+	pub := RSAPublicKey{E: big.NewInt(e)}
+	for {
+		p, err := rand.Int(rand.Reader, span)
+		if err != nil {
+			return nil, err
+		}
+		p.Add(p, lo).SetBit(p, 0, 1)
+		for !p.ProbablyPrime(20) {
+			p.Add(p, big.NewInt(2))
+		}
+		q, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(bits/4)))
+		if err != nil {
+			return nil, err
+		}
+		q.Add(q, p).SetBit(q, 0, 1)
+		for q.Cmp(p) == 0 || !q.ProbablyPrime(20) {
+			q.Add(q, big.NewInt(2))
+		}
+
+		pub.N = new(big.Int).Mul(p, q)
+		if pub.N.BitLen() != bits {
+			continue
+		}
+		if k := rsaKeyFromFactors(&pub, p, q); k != nil {
+			return k, nil
+		}
+	}
+}
+
+// FactorFermat returns the factors p <= q of the odd number n with Fermat's
+// method, which is fast when they're close together.
+//
+// If n = p*q with a = (p+q)/2 and b = (q-p)/2, then n = a^2 - b^2. Starting
+// from a = ceil(sqrt(n)), it steps a up until a^2 - n is a square. That
+// takes about (q-p)^2 / (8 sqrt(n)) steps, so one step if the primes are
+// within n^(1/4) of each other and hopelessly many for independently chosen
+// primes.
+//
+// It returns an error if n is even or if maxSteps steps don't find a
+// factorization other than 1 * n.
+func FactorFermat(n *big.Int, maxSteps int) (p, q *big.Int, err error) {
+	if n.Bit(0) == 0 {
+		return nil, nil, errors.New("even modulus")
+	}
+	a := new(big.Int).Sqrt(n)
+	if new(big.Int).Mul(a, a).Cmp(n) != 0 {
+		a.Add(a, big.NewInt(1))
+	}
+
+	b2, b := new(big.Int), new(big.Int)
+	for range maxSteps {
+		b2.Mul(a, a).Sub(b2, n)
+		b.Sqrt(b2)
+		if new(big.Int).Mul(b, b).Cmp(b2) == 0 {
+			p, q = new(big.Int).Sub(a, b), new(big.Int).Add(a, b)
+			if p.Cmp(big.NewInt(1)) == 0 {
+				break
+			}
+			return p, q, nil
+		}
+		a.Add(a, big.NewInt(1))
+	}
+	return nil, nil, errors.New("no close factors")
+}
+
+// RecoverFermatKey returns the private key for pub by factoring its modulus
+// with FactorFermat.
+func RecoverFermatKey(pub *RSAPublicKey, maxSteps int) (*RSAPrivateKey, error) {
+	p, q, err := FactorFermat(pub.N, maxSteps)
+	if err != nil {
+		return nil, err
+	}
+	k := rsaKeyFromFactors(pub, p, q)
+	if k == nil {
+		return nil, errors.New("public exponent not invertible")
+	}
+	return k, nil
+}
@@ -0,0 +1,69 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRecoverFermatKey(t *testing.T) {
+	k, err := GenerateCloseRSAKey(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RecoverFermatKey(&k.RSAPublicKey, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.D.Cmp(k.D) != 0 {
+		t.Errorf("got d = %x, want %x", got.D, k.D)
+	}
+	m := big.NewInt(42)
+	if c := got.Decrypt(k.Encrypt(m)); c.Cmp(m) != 0 {
+		t.Errorf("got %v, want %v", c, m)
+	}
+
+	// Independently chosen primes are far too far apart.
+	k, err = GenerateRSAKey(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverFermatKey(&k.RSAPublicKey, 10000); err == nil {
+		t.Error("factored an ordinary key")
+	}
+}
+
+func TestGenerateCloseRSAKeySizes(t *testing.T) {
+	for _, bits := range []int{256, 257, 511} {
+		k, err := GenerateCloseRSAKey(bits, 65537)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.N.BitLen() != bits {
+			t.Errorf("%d bits: got a %d-bit modulus", bits, k.N.BitLen())
+		}
+		if _, err := RecoverFermatKey(&k.RSAPublicKey, 10); err != nil {
+			t.Errorf("%d bits: %v", bits, err)
+		}
+	}
+}
+
+func TestFactorFermat(t *testing.T) {
+	for _, tt := range []struct{ n, p, q int64 }{
+		{5959, 59, 101},
+		{9, 3, 3},
+		{1073 * 1061, 1061, 1073},
+	} {
+		p, q, err := FactorFermat(big.NewInt(tt.n), 100)
+		if err != nil {
+			t.Errorf("%d: %v", tt.n, err)
+			continue
+		}
+		if p.Int64() != tt.p || q.Int64() != tt.q {
+			t.Errorf("%d: got %v * %v, want %d * %d", tt.n, p, q, tt.p, tt.q)
+		}
+	}
+
+	if _, _, err := FactorFermat(big.NewInt(101), 100); err == nil {
+		t.Error("factored a prime")
+	}
+}
@@ -0,0 +1,64 @@
+package cryptopals
+
+import (
+	"errors"
+	"math/big"
+)
+
+// maxFranklinReiterExponent is the largest public exponent
+// RecoverRelatedMessages tries. Its polynomials have degree e, and the gcd
+// takes time quadratic in that.
+const maxFranklinReiterExponent = 1 << 10
+
+// RecoverRelatedMessages returns the messages m1 and m2 = a*m1 + b mod n
+// that c1 and c2 encrypt under pub, textbook RSA with a small public
+// exponent, given the relation but neither message, with Franklin and
+// Reiter's related-message attack.
+//
+// m1 is a root of both x^e - c1 and (a*x + b)^e - c2 mod n. The two rarely
+// share any other root, so their gcd is x - m1. That's Euclid's algorithm
+// on degree-e polynomials, which is quick for e = 3 and slow for e = 65537.
+//
+// It returns an error if e is larger than 1024, or if the gcd isn't
+// linear, as when the messages aren't related as claimed. Stumbling on a
+// coefficient that isn't invertible mod n would factor n, but is too
+// unlikely to bother with, so that's an error too.
+func RecoverRelatedMessages(pub *RSAPublicKey, a, b, c1, c2 *big.Int) (m1, m2 *big.Int, err error) {
+	if !pub.E.IsInt64() || pub.E.Int64() > maxFranklinReiterExponent {
+		return nil, nil, errors.New("public exponent too large")
+	}
+	n, e := pub.N, int(pub.E.Int64())
+
+	// f1 = x^e - c1.
+	f1 := make(modPoly, e+1)
+	for i := range f1 {
+		f1[i] = new(big.Int)
+	}
+	f1[0].Neg(c1).Mod(f1[0], n)
+	f1[e].SetInt64(1)
+
+	// f2 = (a*x + b)^e - c2.
+	lin := newModPoly(n, b, a)
+	f2 := modPoly{big.NewInt(1)}
+	for range e {
+		f2 = polyMul(f2, lin, n)
+	}
+	f2 = polySub(f2, newModPoly(n, c2), n)
+
+	g, ok := polyGCD(f1, f2, n)
+	if !ok {
+		return nil, nil, errors.New("coefficient not invertible")
+	}
+	if g.deg() != 1 {
+		return nil, nil, errors.New("messages not related as given")
+	}
+
+	// g is monic, x + g[0].
+	m1 = new(big.Int).Neg(g[0])
+	m1.Mod(m1, n)
+	m2 = lin.eval(m1, n)
+	if pub.Encrypt(m1).Cmp(new(big.Int).Mod(c1, n)) != 0 {
+		return nil, nil, errors.New("messages not related as given")
+	}
+	return m1, m2, nil
+}
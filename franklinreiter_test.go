@@ -0,0 +1,43 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRecoverRelatedMessages(t *testing.T) {
+	for _, e := range []int64{3, 17} {
+		k, err := GenerateRSAKey(1024, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := &k.RSAPublicKey
+
+		// A message and its resend with a new counter, m2 = 256*m1 + 2.
+		m1 := new(big.Int).SetBytes([]byte("wire $1000 to account 12345, attempt 1"))
+		a, b := big.NewInt(256), big.NewInt(2)
+		m2 := new(big.Int).Mul(a, m1)
+		m2.Add(m2, b)
+
+		g1, g2, err := RecoverRelatedMessages(pub, a, b, pub.Encrypt(m1), pub.Encrypt(m2))
+		if err != nil {
+			t.Fatalf("e = %d: %v", e, err)
+		}
+		if g1.Cmp(m1) != 0 || g2.Cmp(m2) != 0 {
+			t.Errorf("e = %d: got %x and %x, want %x and %x", e, g1, g2, m1, m2)
+		}
+
+		if _, _, err := RecoverRelatedMessages(pub, a, big.NewInt(3), pub.Encrypt(m1), pub.Encrypt(m2)); err == nil {
+			t.Errorf("e = %d: wrong relation: no error", e)
+		}
+	}
+
+	k, err := GenerateRSAKey(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := big.NewInt(1)
+	if _, _, err := RecoverRelatedMessages(&k.RSAPublicKey, one, one, one, one); err == nil {
+		t.Error("e = 65537: no error")
+	}
+}
@@ -0,0 +1,100 @@
+package cryptopals
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// A BitVector is a vector over GF(2), with bit i in bit i%64 of word i/64.
+type BitVector []uint64
+
+// NewBitVector returns a zero BitVector of n bits.
+func NewBitVector(n int) BitVector {
+	return make(BitVector, (n+63)/64)
+}
+
+// Bit returns bit i of v.
+func (v BitVector) Bit(i int) uint {
+	return uint(v[i/64]>>(i%64)) & 1
+}
+
+// SetBit sets bit i of v to b, which must be 0 or 1.
+func (v BitVector) SetBit(i int, b uint) {
+	v[i/64] = v[i/64]&^(1<<(i%64)) | uint64(b)<<(i%64)
+}
+
+// Xor adds w to v, in place. They must be the same length.
+func (v BitVector) Xor(w BitVector) {
+	for i := range v {
+		v[i] ^= w[i]
+	}
+}
+
+// Dot returns the inner product of v and w over GF(2).
+func (v BitVector) Dot(w BitVector) uint {
+	var n int
+	for i := range v {
+		n += bits.OnesCount64(v[i] & w[i])
+	}
+	return uint(n & 1)
+}
+
+// Clone returns a copy of v.
+func (v BitVector) Clone() BitVector {
+	return append(BitVector(nil), v...)
+}
+
+// SolveGF2 solves the linear system a x = b over GF(2) for the n-bit vector
+// x, where each a[i] holds the coefficients of equation i and bit i of b its
+// right-hand side. It doesn't change a or b.
+//
+// It returns an error if the system has no solution, or more than one, in
+// which case it needs more independent equations.
+func SolveGF2(a []BitVector, b BitVector, n int) (BitVector, error) {
+	rows := make([]BitVector, len(a))
+	rhs := make([]uint, len(a))
+	for i := range a {
+		rows[i] = a[i].Clone()
+		rhs[i] = b.Bit(i)
+	}
+
+	// Gaussian elimination, keeping the pivot row for each column.
+	pivots := make([]int, n)
+	r := 0
+	for col := range n {
+		p := -1
+		for i := r; i < len(rows); i++ {
+			if rows[i].Bit(col) == 1 {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			return nil, errors.New("underdetermined system")
+		}
+		rows[r], rows[p] = rows[p], rows[r]
+		rhs[r], rhs[p] = rhs[p], rhs[r]
+
+		for i := range rows {
+			if i != r && rows[i].Bit(col) == 1 {
+				rows[i].Xor(rows[r])
+				rhs[i] ^= rhs[r]
+			}
+		}
+		pivots[col] = r
+		r++
+	}
+
+	// Rows past the pivots have no variables left, so must be 0 = 0.
+	for i := r; i < len(rows); i++ {
+		if rhs[i] != 0 {
+			return nil, errors.New("inconsistent system")
+		}
+	}
+
+	x := NewBitVector(n)
+	for col, p := range pivots {
+		x.SetBit(col, rhs[p])
+	}
+	return x, nil
+}
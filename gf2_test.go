@@ -0,0 +1,65 @@
+package cryptopals
+
+import "testing"
+
+func TestSolveGF2(t *testing.T) {
+	// x0 ^ x1 = 1, x1 ^ x2 = 0, x0 ^ x2 = 1, x2 = 1, repeating the third
+	// equation.
+	rows := [][]int{{0, 1}, {1, 2}, {0, 2}, {2}, {0, 2}}
+	rhs := []uint{1, 0, 1, 1, 1}
+
+	a := make([]BitVector, len(rows))
+	b := NewBitVector(len(rows))
+	for i, row := range rows {
+		a[i] = NewBitVector(3)
+		for _, j := range row {
+			a[i].SetBit(j, 1)
+		}
+		b.SetBit(i, rhs[i])
+	}
+
+	x, err := SolveGF2(a, b, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []uint{0, 1, 1} {
+		if x.Bit(i) != want {
+			t.Errorf("x%d = %d, want %d", i, x.Bit(i), want)
+		}
+	}
+	for i := range a {
+		if a[i].Dot(x) != b.Bit(i) {
+			t.Errorf("equation %d unsatisfied", i)
+		}
+	}
+}
+
+func TestSolveGF2Errors(t *testing.T) {
+	vec := func(bits ...int) BitVector {
+		v := NewBitVector(3)
+		for _, i := range bits {
+			v.SetBit(i, 1)
+		}
+		return v
+	}
+
+	// x0 ^ x1 = 0 alone has two solutions.
+	if _, err := SolveGF2([]BitVector{vec(0, 1)}, vec(), 2); err == nil {
+		t.Error("underdetermined: no error")
+	}
+
+	// x0 = 0, x1 = 0, and x0 ^ x1 = 1 has none.
+	if _, err := SolveGF2([]BitVector{vec(0), vec(1), vec(0, 1)}, vec(2), 2); err == nil {
+		t.Error("inconsistent: no error")
+	}
+}
+
+func TestBitVector(t *testing.T) {
+	v := NewBitVector(130)
+	v.SetBit(129, 1)
+	v.SetBit(3, 1)
+	v.SetBit(3, 0)
+	if v.Bit(129) != 1 || v.Bit(3) != 0 || len(v) != 3 {
+		t.Errorf("got %x", v)
+	}
+}
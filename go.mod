@@ -1,5 +1,5 @@
 module github.com/clfs/cryptopals
 
-go 1.22.4
+go 1.23.0
 
 require github.com/google/uuid v1.6.0
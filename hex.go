@@ -0,0 +1,81 @@
+package cryptopals
+
+// A HexCodec is a hex encoding written from scratch, like Base64Codec. It
+// decodes either case, whichever case it encodes.
+type HexCodec struct {
+	digits string
+}
+
+// The codecs that encode with lowercase and uppercase digits.
+var (
+	LowerHexCodec = &HexCodec{digits: "0123456789abcdef"}
+	UpperHexCodec = &HexCodec{digits: "0123456789ABCDEF"}
+)
+
+// EncodedLen returns the length of the encoding of n bytes.
+func (c *HexCodec) EncodedLen(n int) int { return n * 2 }
+
+// DecodedLen returns the number of bytes that n bytes of encoding decode to.
+func (c *HexCodec) DecodedLen(n int) int { return n / 2 }
+
+// Encode writes the encoding of src to dst, which must be at least
+// EncodedLen(len(src)) bytes.
+func (c *HexCodec) Encode(dst, src []byte) {
+	for i, b := range src {
+		dst[2*i] = c.digits[b>>4]
+		dst[2*i+1] = c.digits[b&0x0f]
+	}
+}
+
+// EncodeToString returns the encoding of src.
+func (c *HexCodec) EncodeToString(src []byte) string {
+	dst := make([]byte, c.EncodedLen(len(src)))
+	c.Encode(dst, src)
+	return string(dst)
+}
+
+// hexValue returns the value of the hex digit b, in either case.
+func hexValue(b byte) (byte, bool) {
+	switch {
+	case '0' <= b && b <= '9':
+		return b - '0', true
+	case 'a' <= b && b <= 'f':
+		return b - 'a' + 10, true
+	case 'A' <= b && b <= 'F':
+		return b - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// Decode writes the bytes that src encodes to dst, which must be at least
+// DecodedLen(len(src)) bytes, and returns how many it wrote. It returns a
+// CorruptInputError with the offset of the first byte that isn't a hex
+// digit, or the length of src if it has an odd length.
+func (c *HexCodec) Decode(dst, src []byte) (int, error) {
+	n := 0
+	for ; 2*n+1 < len(src); n++ {
+		hi, ok := hexValue(src[2*n])
+		if !ok {
+			return n, CorruptInputError(2 * n)
+		}
+		lo, ok := hexValue(src[2*n+1])
+		if !ok {
+			return n, CorruptInputError(2*n + 1)
+		}
+		dst[n] = hi<<4 | lo
+	}
+	if len(src)%2 == 1 {
+		if _, ok := hexValue(src[len(src)-1]); !ok {
+			return n, CorruptInputError(len(src) - 1)
+		}
+		return n, CorruptInputError(len(src))
+	}
+	return n, nil
+}
+
+// DecodeString returns the bytes that s encodes.
+func (c *HexCodec) DecodeString(s string) ([]byte, error) {
+	dst := make([]byte, c.DecodedLen(len(s)))
+	n, err := c.Decode(dst, []byte(s))
+	return dst[:n], err
+}
@@ -0,0 +1,75 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHexCodec(t *testing.T) {
+	data := []byte("\x00\x01\xab\xcd\xef\xff hex")
+
+	if got, want := LowerHexCodec.EncodeToString(data), hex.EncodeToString(data); got != want {
+		t.Errorf("lower: want %q, got %q", want, got)
+	}
+	upper := UpperHexCodec.EncodeToString(data)
+	if want := strings.ToUpper(hex.EncodeToString(data)); upper != want {
+		t.Errorf("upper: want %q, got %q", want, upper)
+	}
+
+	// Either codec decodes either case, or a mix.
+	for _, s := range []string{upper, strings.ToLower(upper), "0001AbCdEfFf20686578"} {
+		got, err := LowerHexCodec.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%q: want %x, got %x", s, data, got)
+		}
+	}
+}
+
+func TestHexCodecErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		off int64
+	}{
+		{"0g", 1},
+		{"g0", 0},
+		{"abc", 3}, // Odd length.
+		{"abz", 2}, // An invalid last byte takes precedence.
+		{"ab cd", 2},
+	}
+	for _, tt := range tests {
+		_, err := LowerHexCodec.DecodeString(tt.in)
+		var cerr CorruptInputError
+		if !errors.As(err, &cerr) {
+			t.Errorf("%q: want a CorruptInputError, got %v", tt.in, err)
+			continue
+		}
+		if int64(cerr) != tt.off {
+			t.Errorf("%q: want offset %d, got %d", tt.in, tt.off, cerr)
+		}
+	}
+}
+
+func FuzzHexCodec(f *testing.F) {
+	for _, s := range []string{"", "00", "abCD", "abc", "0g", "ffff"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		got, err := LowerHexCodec.DecodeString(s)
+		want, stdErr := hex.DecodeString(s)
+		if (err == nil) != (stdErr == nil) {
+			t.Fatalf("%q: got error %v, encoding/hex got %v", s, err, stdErr)
+		}
+		if err == nil && !bytes.Equal(got, want) {
+			t.Fatalf("%q: want %x, got %x", s, want, got)
+		}
+		if err == nil && LowerHexCodec.EncodeToString(got) != hex.EncodeToString(want) {
+			t.Fatalf("%q: encodings differ", s)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// InsecureCompare returns a function that reports whether a and b are equal
+// by comparing them chunkSize bytes at a time, sleeping for delay after each
+// chunk that matches and returning at the first that doesn't. How long it
+// takes gives away how long a prefix of a matches b, chunk by chunk.
+// Challenge 31's server compares HMACs a byte at a time with a 50ms delay,
+// and challenge 32's with a 5ms delay. It panics if chunkSize is less than
+// 1.
+func InsecureCompare(delay time.Duration, chunkSize int) func(a, b []byte) bool {
+	if chunkSize < 1 {
+		panic("invalid chunk size")
+	}
+	return func(a, b []byte) bool {
+		for i := 0; i < len(a) && i < len(b); i += chunkSize {
+			j := min(i+chunkSize, len(a), len(b))
+			if !bytes.Equal(a[i:j], b[i:j]) {
+				return false
+			}
+			time.Sleep(delay)
+		}
+		return len(a) == len(b)
+	}
+}
+
+// A RequestField gets the value of part of an HTTP request, such as a query
+// parameter or a header, for InsecureCompareMiddleware to check.
+type RequestField func(r *http.Request) string
+
+// QueryField returns the RequestField for the query parameter name.
+func QueryField(name string) RequestField {
+	return func(r *http.Request) string {
+		return r.URL.Query().Get(name)
+	}
+}
+
+// HeaderField returns the RequestField for the header name.
+func HeaderField(name string) RequestField {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// InsecureCompareMiddleware returns middleware that only lets a request
+// through to the handler it wraps if field, hex-decoded, matches want(r) by
+// compare, as challenge 31's server checks a file's HMAC before serving it.
+// It responds with 500 Internal Server Error to a mismatch, as that server
+// does, and 400 Bad Request if field isn't hex.
+//
+// With compare from InsecureCompare, the wrapped handler is open to a timing
+// attack on the expected value, whatever it serves.
+func InsecureCompareMiddleware(field RequestField, want func(r *http.Request) []byte, compare func(a, b []byte) bool, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, err := hex.DecodeString(field(r))
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+			ok := compare(got, want(r))
+			o.debug("compared", "remote", r.RemoteAddr, "match", ok)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
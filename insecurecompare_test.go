@@ -0,0 +1,96 @@
+package cryptopals
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInsecureCompare(t *testing.T) {
+	eq := InsecureCompare(0, 3)
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"", "", true},
+		{"abcdefg", "abcdefg", true},
+		{"abcdefg", "abcdefx", false},
+		{"abcdef", "abcdefg", false},
+		{"xbcdefg", "abcdefg", false},
+	}
+	for _, tt := range tests {
+		if got := eq([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Errorf("%q, %q: got %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	// Each matching chunk takes a delay longer.
+	eq = InsecureCompare(2*time.Millisecond, 2)
+	want := []byte("0123456789")
+	start := time.Now()
+	eq([]byte("0123xxxxxx"), want)
+	if d := time.Since(start); d < 4*time.Millisecond {
+		t.Errorf("2 matching chunks took %v", d)
+	}
+}
+
+func TestInsecureCompareMiddleware(t *testing.T) {
+	// Challenge 31's server: serve a file if its signature parameter is
+	// its name's HMAC.
+	key := randBytes(16)
+	sign := func(file string) []byte {
+		m := hmac.New(sha1.New, key)
+		m.Write([]byte(file))
+		return m.Sum(nil)
+	}
+	files := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "contents of ", r.URL.Query().Get("file"))
+	})
+
+	for name, field := range map[string]RequestField{
+		"query":  QueryField("signature"),
+		"header": HeaderField("X-Signature"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mw := InsecureCompareMiddleware(field, func(r *http.Request) []byte {
+				return sign(r.URL.Query().Get("file"))
+			}, InsecureCompare(0, 1))
+			srv := httptest.NewServer(mw(files))
+			defer srv.Close()
+
+			get := func(sig string) int {
+				t.Helper()
+				req, err := http.NewRequest("GET", srv.URL+"/test?file=foo", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if name == "query" {
+					req.URL.RawQuery += "&signature=" + sig
+				} else {
+					req.Header.Set("X-Signature", sig)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+
+			if code := get(hex.EncodeToString(sign("foo"))); code != http.StatusOK {
+				t.Errorf("right signature: got %d", code)
+			}
+			if code := get(hex.EncodeToString(sign("bar"))); code != http.StatusInternalServerError {
+				t.Errorf("wrong signature: got %d", code)
+			}
+			if code := get("zz"); code != http.StatusBadRequest {
+				t.Errorf("malformed signature: got %d", code)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package cryptopals
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// A Message is one protocol message: a sequence of fields.
+type Message [][]byte
+
+// A Channel carries messages between two parties, in order.
+type Channel interface {
+	// Send sends m to the other party. It may be called from several
+	// goroutines at once.
+	Send(m Message) error
+
+	// Receive returns the next message from the other party, or io.EOF if
+	// it has closed its end.
+	Receive() (Message, error)
+
+	// Close closes the channel in both directions.
+	Close() error
+}
+
+// A Party runs its side of a protocol over a channel, such as a client
+// making requests or a server answering them until the client closes its
+// end. A Party passed to ServeParty or RunMITM runs once per channel, so it
+// may run on several channels at once.
+type Party interface {
+	Run(ch Channel) error
+}
+
+// PartyFunc adapts a function to Party.
+type PartyFunc func(ch Channel) error
+
+func (f PartyFunc) Run(ch Channel) error {
+	return f(ch)
+}
+
+// A Direction is which way a message is going between a client and server.
+type Direction int
+
+const (
+	ToServer Direction = iota
+	ToClient
+)
+
+func (d Direction) String() string {
+	if d == ToServer {
+		return "client -> server"
+	}
+	return "server -> client"
+}
+
+// An Interceptor sits between a client and server, as a man in the middle.
+type Interceptor interface {
+	// Intercept is called with each message m going in direction dir. It
+	// delivers messages with send: m to pass it on, a changed copy to
+	// modify it, or other messages in either direction to inject them.
+	// Not calling send drops m. An error ends the connection.
+	//
+	// Each direction's messages arrive in order from one goroutine, but the
+	// two directions may be intercepted at once.
+	Intercept(dir Direction, m Message, send func(Direction, Message) error) error
+}
+
+// InterceptorFunc adapts a function to Interceptor.
+type InterceptorFunc func(dir Direction, m Message, send func(Direction, Message) error) error
+
+func (f InterceptorFunc) Intercept(dir Direction, m Message, send func(Direction, Message) error) error {
+	return f(dir, m, send)
+}
+
+// Observe returns an interceptor that passes every message on unchanged,
+// after calling f with it.
+func Observe(f func(dir Direction, m Message)) Interceptor {
+	return InterceptorFunc(func(dir Direction, m Message, send func(Direction, Message) error) error {
+		f(dir, m)
+		return send(dir, m)
+	})
+}
+
+// intercept relays messages between the client and server channels through
+// i until either side is done, then closes both. A nil i passes messages on
+// unchanged.
+func intercept(client, server Channel, i Interceptor) error {
+	if i == nil {
+		i = Observe(func(Direction, Message) {})
+	}
+	send := func(dir Direction, m Message) error {
+		if dir == ToServer {
+			return server.Send(m)
+		}
+		return client.Send(m)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for k, from := range []Channel{client, server} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer client.Close()
+			defer server.Close()
+
+			for {
+				m, err := from.Receive()
+				if err != nil {
+					if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+						errs[k] = err
+					}
+					return
+				}
+				if err := i.Intercept(Direction(k), m, send); err != nil {
+					errs[k] = err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// pipe carries messages one way between goroutines.
+type pipe struct {
+	msgs   chan Message
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipe() *pipe {
+	return &pipe{msgs: make(chan Message), closed: make(chan struct{})}
+}
+
+func (p *pipe) close() {
+	p.once.Do(func() { close(p.closed) })
+}
+
+// pipeChannel is one end of an in-process channel.
+type pipeChannel struct {
+	in, out *pipe
+}
+
+// newPipeChannel returns the two ends of an in-process channel.
+func newPipeChannel() (a, b *pipeChannel) {
+	ab, ba := newPipe(), newPipe()
+	return &pipeChannel{in: ba, out: ab}, &pipeChannel{in: ab, out: ba}
+}
+
+func (c *pipeChannel) Send(m Message) error {
+	select {
+	case c.out.msgs <- m:
+		return nil
+	case <-c.out.closed:
+		return io.ErrClosedPipe
+	}
+}
+
+func (c *pipeChannel) Receive() (Message, error) {
+	select {
+	case m := <-c.in.msgs:
+		return m, nil
+	case <-c.in.closed:
+		return nil, io.EOF
+	}
+}
+
+func (c *pipeChannel) Close() error {
+	c.in.close()
+	c.out.close()
+	return nil
+}
+
+// RunMITM runs client and server in process, with i between them, and
+// returns when both are done. A nil i passes messages on unchanged. It
+// returns the errors from the parties and the interceptor, joined.
+func RunMITM(client, server Party, i Interceptor) error {
+	c, mc := newPipeChannel()
+	s, ms := newPipeChannel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for k, p := range []struct {
+		party Party
+		ch    Channel
+	}{{client, c}, {server, s}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[k] = p.party.Run(p.ch)
+			p.ch.Close()
+		}()
+	}
+	errs[2] = intercept(mc, ms, i)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// maxFields and maxFieldSize limit the messages a connChannel accepts.
+const (
+	maxFields    = 16
+	maxFieldSize = 1 << 16
+)
+
+// connChannel carries messages over a network connection. Each message is
+// its number of fields, then each field, all with 4-byte big-endian length
+// prefixes. Sends may come from several goroutines, as when an interceptor
+// injects messages in both directions.
+type connChannel struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	wmu     sync.Mutex // Guards w and the write deadline.
+	w       *bufio.Writer
+	timeout time.Duration // Per send or receive, or 0 for none.
+}
+
+func newConnChannel(conn net.Conn, timeout time.Duration) *connChannel {
+	return &connChannel{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		w:       bufio.NewWriter(conn),
+		timeout: timeout,
+	}
+}
+
+func (c *connChannel) Send(m Message) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.timeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	}
+	if err := binary.Write(c.w, binary.BigEndian, uint32(len(m))); err != nil {
+		return err
+	}
+	for _, f := range m {
+		if err := binary.Write(c.w, binary.BigEndian, uint32(len(f))); err != nil {
+			return err
+		}
+		if _, err := c.w.Write(f); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+func (c *connChannel) Receive() (Message, error) {
+	if c.timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	var n uint32
+	if err := binary.Read(c.r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if n > maxFields {
+		return nil, errors.New("too many fields")
+	}
+
+	m := make(Message, n)
+	for i := range m {
+		var size uint32
+		if err := binary.Read(c.r, binary.BigEndian, &size); err != nil {
+			return nil, noEOF(err)
+		}
+		if size > maxFieldSize {
+			return nil, errors.New("field too long")
+		}
+		m[i] = make([]byte, size)
+		if _, err := io.ReadFull(c.r, m[i]); err != nil {
+			return nil, noEOF(err)
+		}
+	}
+	return m, nil
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF, for a message cut short.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (c *connChannel) Close() error {
+	return c.conn.Close()
+}
+
+// DialChannel connects to the server at addr, as served by ServeParty, and
+// returns a channel to it. Each send and receive has to finish within the
+// timeout set with WithTimeout.
+func DialChannel(addr string, opts ...Option) (Channel, error) {
+	o := newOptions(opts)
+	conn, err := net.DialTimeout("tcp", addr, o.timeout)
+	if err != nil {
+		return nil, err
+	}
+	return newConnChannel(conn, o.timeout), nil
+}
+
+// ServeParty accepts connections on l and runs p on each, in its own
+// goroutine, closing the connection when p returns. Messages are framed as
+// a count of fields and then the fields, each with a 4-byte big-endian
+// length prefix.
+//
+// ServeParty returns when l.Accept fails, such as when l is closed.
+func ServeParty(l net.Listener, p Party, opts ...Option) error {
+	o := newOptions(opts)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			ch := newConnChannel(conn, 0)
+			defer ch.Close()
+			err := p.Run(ch)
+			o.debug("party done", "remote", conn.RemoteAddr(), "err", err)
+		}()
+	}
+}
+
+// ServeMITM accepts connections on l and relays each to a new connection to
+// the server at upstream, as served by ServeParty, through the interceptor
+// newInterceptor returns for it.
+//
+// ServeMITM returns when l.Accept fails, such as when l is closed.
+func ServeMITM(l net.Listener, upstream string, newInterceptor func() Interceptor, opts ...Option) error {
+	o := newOptions(opts)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			client := newConnChannel(conn, 0)
+			defer client.Close()
+
+			up, err := net.DialTimeout("tcp", upstream, o.timeout)
+			if err != nil {
+				o.debug("mitm dial", "upstream", upstream, "err", err)
+				return
+			}
+			err = intercept(client, newConnChannel(up, 0), newInterceptor())
+			o.debug("mitm done", "remote", conn.RemoteAddr(), "err", err)
+		}()
+	}
+}
@@ -0,0 +1,242 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"testing"
+)
+
+// echoParty answers each message with itself until the client is done.
+var echoParty = PartyFunc(func(ch Channel) error {
+	for {
+		m, err := ch.Receive()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := ch.Send(m); err != nil {
+			return err
+		}
+	}
+})
+
+// sendParty sends each message, keeping the first field of each reply.
+type sendParty struct {
+	msgs    []string
+	replies []string
+}
+
+func (p *sendParty) Run(ch Channel) error {
+	for _, msg := range p.msgs {
+		if err := ch.Send(Message{[]byte(msg)}); err != nil {
+			return err
+		}
+		m, err := ch.Receive()
+		if err != nil {
+			return err
+		}
+		p.replies = append(p.replies, string(m[0]))
+	}
+	return nil
+}
+
+func TestRunMITM(t *testing.T) {
+	tests := []struct {
+		name string
+		i    Interceptor
+		want []string
+	}{
+		{"nil", nil, []string{"a", "b", "c"}},
+		{"modify", InterceptorFunc(func(dir Direction, m Message, send func(Direction, Message) error) error {
+			if dir == ToClient {
+				m = Message{append([]byte("!"), m[0]...)}
+			}
+			return send(dir, m)
+		}), []string{"!a", "!b", "!c"}},
+		{"drop and inject", InterceptorFunc(func(dir Direction, m Message, send func(Direction, Message) error) error {
+			if string(m[0]) == "b" {
+				return send(ToClient, Message{[]byte("not b")})
+			}
+			return send(dir, m)
+		}), []string{"a", "not b", "c"}},
+	}
+	for _, tt := range tests {
+		client := &sendParty{msgs: []string{"a", "b", "c"}}
+		if err := RunMITM(client, echoParty, tt.i); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !slices.Equal(client.replies, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, client.replies, tt.want)
+		}
+	}
+}
+
+func TestRunMITMErrors(t *testing.T) {
+	errBoom := errors.New("boom")
+	boom := InterceptorFunc(func(Direction, Message, func(Direction, Message) error) error {
+		return errBoom
+	})
+	err := RunMITM(&sendParty{msgs: []string{"a"}}, echoParty, boom)
+	if !errors.Is(err, errBoom) {
+		t.Errorf("got %v, want %v", err, errBoom)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	var got []Direction
+	client := &sendParty{msgs: []string{"a", "b"}}
+	err := RunMITM(client, echoParty, Observe(func(dir Direction, m Message) {
+		got = append(got, dir)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Direction{ToServer, ToClient, ToServer, ToClient}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDHParameterInjection(t *testing.T) {
+	var got [][]byte
+	client := &DHEchoClient{Messages: [][]byte{[]byte("attack at dawn")}}
+	err := RunMITM(client, NewDHEchoServer(), NewDHParameterInjection(func(dir Direction, msg []byte) {
+		got = append(got, msg)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Echoes) != 1 || !bytes.Equal(client.Echoes[0], client.Messages[0]) {
+		t.Errorf("echoes %q", client.Echoes)
+	}
+	if len(got) != 2 || !bytes.Equal(got[0], client.Messages[0]) || !bytes.Equal(got[1], client.Messages[0]) {
+		t.Errorf("intercepted %q", got)
+	}
+}
+
+func TestSRPMITM(t *testing.T) {
+	s := NewSRPServer("alice@example.com", "hunter2", WithSimpleSRP())
+
+	// Without an interceptor, the login goes through.
+	l := &SRPLogin{Client: NewSRPClient("alice@example.com", "hunter2")}
+	if err := RunMITM(l, SRPParty(s), nil); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Accepted {
+		t.Error("login rejected")
+	}
+
+	// The cracker answers in the server's place, over the network too.
+	var c SimpleSRPCracker
+	server := listen(t, func(l net.Listener) { ServeSRP(l, s) })
+	mitm := listen(t, func(l net.Listener) { ServeMITM(l, server, c.Interceptor) })
+
+	conn, err := DialSRP(mitm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if ok, err := conn.Login(NewSRPClient("alice@example.com", "hunter2")); err != nil || ok {
+		t.Fatalf("got %v, %v", ok, err)
+	}
+	_, password, err := c.Crack([]string{"password", "hunter2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "hunter2" {
+		t.Errorf("got %q, want %q", password, "hunter2")
+	}
+}
+
+func TestServeParty(t *testing.T) {
+	addr := listen(t, func(l net.Listener) { ServeParty(l, echoParty) })
+
+	for range 2 {
+		ch, err := DialChannel(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Message{[]byte("two"), nil, []byte("fields and an empty one")}
+		if err := ch.Send(want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ch.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) || !bytes.Equal(got[2], want[2]) || len(got[1]) != 0 {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		ch.Close()
+	}
+}
+
+func TestServeMITMInjectBothWays(t *testing.T) {
+	const n = 200
+	field := bytes.Repeat([]byte("x"), 1000)
+
+	// The server and the client both send n messages at once, and each
+	// client message is bounced straight back to it, so both relay
+	// goroutines send to the client at the same time.
+	flood := PartyFunc(func(ch Channel) error {
+		for range n {
+			if err := ch.Send(Message{field}); err != nil {
+				return err
+			}
+		}
+		for range n {
+			if _, err := ch.Receive(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	bounce := InterceptorFunc(func(dir Direction, m Message, send func(Direction, Message) error) error {
+		if dir == ToServer {
+			if err := send(ToClient, Message{[]byte("bounced"), m[0]}); err != nil {
+				return err
+			}
+		}
+		return send(dir, m)
+	})
+	server := listen(t, func(l net.Listener) { ServeParty(l, flood) })
+	mitm := listen(t, func(l net.Listener) { ServeMITM(l, server, func() Interceptor { return bounce }) })
+
+	ch, err := DialChannel(mitm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ch.Close()
+
+	go func() {
+		for range n {
+			if err := ch.Send(Message{field}); err != nil {
+				return
+			}
+		}
+	}()
+
+	var sent, bounced int
+	for range 2 * n {
+		m, err := ch.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case len(m) == 1 && bytes.Equal(m[0], field):
+			sent++
+		case len(m) == 2 && string(m[0]) == "bounced" && bytes.Equal(m[1], field):
+			bounced++
+		default:
+			t.Fatalf("malformed message with %d fields", len(m))
+		}
+	}
+	if sent != n || bounced != n {
+		t.Errorf("got %d sent and %d bounced, want %d each", sent, bounced, n)
+	}
+}
@@ -0,0 +1,292 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math/bits"
+	"slices"
+)
+
+// keccakStateSize is the Keccak-f[1600] state size in bytes.
+const keccakStateSize = 200
+
+// keccakRC holds the Keccak-f[1600] round constants.
+var keccakRC = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRho and keccakPi hold the rotation amounts and lane order of the rho
+// and pi steps, following the lanes visited from lane 1.
+var (
+	keccakRho = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakPi  = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// keccakF applies the Keccak-f[1600] permutation to the state.
+func keccakF(b *[keccakStateSize]byte) {
+	var a [25]uint64
+	for i := range a {
+		a[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+
+	var c [5]uint64
+	for _, rc := range keccakRC {
+		// Theta.
+		for x := range 5 {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := range 5 {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+
+		// Rho and pi.
+		t := a[1]
+		for i, j := range keccakPi {
+			a[j], t = bits.RotateLeft64(t, keccakRho[i]), a[j]
+		}
+
+		// Chi.
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], a[y:y+5])
+			for x := range 5 {
+				a[y+x] ^= ^c[(x+1)%5] & c[(x+2)%5]
+			}
+		}
+
+		// Iota.
+		a[0] ^= rc
+	}
+
+	for i, v := range a {
+		binary.LittleEndian.PutUint64(b[8*i:], v)
+	}
+}
+
+// A Sponge is the sponge construction over the Keccak-f[1600] permutation,
+// padded as SHA-3 pads. Input is absorbed into the first rate bytes of the
+// state, and output squeezed from them. The remaining capacity bytes are
+// never output directly, which is what keeps the state secret.
+//
+// SHA3-256 is a sponge with a capacity of 64 bytes. With a tiny capacity,
+// the state can be guessed from a long enough output, and then extended. See
+// ExtendSponge.
+type Sponge struct {
+	state     [keccakStateSize]byte
+	rate      int
+	n         int // Bytes absorbed or squeezed in the current block.
+	squeezing bool
+}
+
+// NewSponge returns a Sponge with the given capacity in bytes. It panics if
+// capacity isn't from 1 to 199.
+func NewSponge(capacity int) *Sponge {
+	if capacity < 1 || capacity >= keccakStateSize {
+		panic("invalid capacity")
+	}
+	return &Sponge{rate: keccakStateSize - capacity}
+}
+
+// Rate returns the number of bytes absorbed or squeezed per permutation.
+func (s *Sponge) Rate() int {
+	return s.rate
+}
+
+// Absorb mixes p into the state. It panics if s is already squeezing.
+func (s *Sponge) Absorb(p []byte) {
+	if s.squeezing {
+		panic("absorb after squeeze")
+	}
+	for _, c := range p {
+		s.state[s.n] ^= c
+		s.n++
+		if s.n == s.rate {
+			keccakF(&s.state)
+			s.n = 0
+		}
+	}
+}
+
+// Squeeze fills b with output. The first call pads the input absorbed so far,
+// and later calls continue the output.
+func (s *Sponge) Squeeze(b []byte) {
+	if !s.squeezing {
+		s.state[s.n] ^= 0x06
+		s.state[s.rate-1] ^= 0x80
+		keccakF(&s.state)
+		s.n = 0
+		s.squeezing = true
+	}
+	for i := range b {
+		if s.n == s.rate {
+			keccakF(&s.state)
+			s.n = 0
+		}
+		b[i] = s.state[s.n]
+		s.n++
+	}
+}
+
+// spongePadding returns the bytes Squeeze pads an n-byte input with, for a
+// sponge with the given rate.
+func spongePadding(n, rate int) []byte {
+	pad := make([]byte, rate-n%rate)
+	pad[0] ^= 0x06
+	pad[len(pad)-1] ^= 0x80
+	return pad
+}
+
+// spongeHash is a Sponge as a hash.Hash.
+type spongeHash struct {
+	s        *Sponge
+	capacity int
+	size     int
+}
+
+// NewSpongeHash returns a hash.Hash that absorbs its input into a Sponge with
+// the given capacity, and squeezes size bytes from it. It panics if capacity
+// isn't from 1 to 199, or size < 1.
+func NewSpongeHash(capacity, size int) hash.Hash {
+	if size < 1 {
+		panic("invalid size")
+	}
+	return &spongeHash{s: NewSponge(capacity), capacity: capacity, size: size}
+}
+
+// NewSHA3_256 returns a new SHA3-256 hash.Hash, implemented from FIPS 202.
+func NewSHA3_256() hash.Hash {
+	return NewSpongeHash(64, 32)
+}
+
+func (h *spongeHash) Write(p []byte) (int, error) {
+	h.s.Absorb(p)
+	return len(p), nil
+}
+
+// Sum appends the digest of the data written so far to b. It doesn't change
+// the state.
+func (h *spongeHash) Sum(b []byte) []byte {
+	s := *h.s
+	out := make([]byte, h.size)
+	s.Squeeze(out)
+	return append(b, out...)
+}
+
+func (h *spongeHash) Reset() { h.s = NewSponge(h.capacity) }
+
+func (h *spongeHash) Size() int { return h.size }
+
+func (h *spongeHash) BlockSize() int { return h.s.rate }
+
+// maxSpongeGuess is the largest capacity in bytes ExtendSponge guesses.
+const maxSpongeGuess = 3
+
+// spongeCheckBytes is how many more tag bytes than capacity bytes
+// ExtendSponge needs to check guesses, so that wrong guesses are unlikely to
+// match.
+const spongeCheckBytes = 4
+
+// ExtendSponge performs a length extension on a secret-prefix tag from
+// NewSpongeHash(capacity, len(tag)). Given tag = hash(key || message) for
+// some keyLen-byte key, it returns message || glue || suffix and its tag,
+// where glue is the padding the sponge added to key || message.
+//
+// The tag of a sponge is only part of its state, so this guesses the rest:
+// the first rate bytes of the tag are the state after absorbing, and each
+// guess at the capacity bytes is checked against the remaining tag bytes.
+// It returns an error if capacity is over 3 bytes, since guessing would take
+// too long, or if the tag isn't at least capacity+4 bytes longer than the
+// rate, since wrong guesses could match. Real sponges are safe on both
+// counts.
+func ExtendSponge(capacity int, tag, message, suffix []byte, keyLen int) (forged, forgedTag []byte, err error) {
+	forgedTag, err = extendSpongeTag(capacity, tag, suffix)
+	if err != nil {
+		return nil, nil, err
+	}
+	glue := spongePadding(keyLen+len(message), keccakStateSize-capacity)
+	return slices.Concat(message, glue, suffix), forgedTag, nil
+}
+
+// ForgeSpongeMAC forges a message ending in suffix, and a tag for it, from msg
+// and its secret-prefix tag under NewSpongeHash(capacity, len(tag)), as
+// ExtendSponge does. The forged tag doesn't depend on the key length, so the
+// state is only guessed once, and each key length from 0 to 64 bytes is tried
+// until verify accepts the forgery.
+//
+// It returns an error if ExtendSponge would, or if verify rejects every
+// forgery. Use WithLogger to see the key length that works.
+func ForgeSpongeMAC(capacity int, msg, tag, suffix []byte, verify func(msg, tag []byte) bool, opts ...Option) (forged, forgedTag []byte, err error) {
+	o := newOptions(opts)
+
+	forgedTag, err = extendSpongeTag(capacity, tag, suffix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for keyLen := range maxSecretPrefixKeySize + 1 {
+		glue := spongePadding(keyLen+len(msg), keccakStateSize-capacity)
+		forged := slices.Concat(msg, glue, suffix)
+		if verify(forged, forgedTag) {
+			o.debug("found key length", "key_len", keyLen)
+			return forged, forgedTag, nil
+		}
+	}
+	return nil, nil, errors.New("no key length worked")
+}
+
+// extendSpongeTag returns the tag of the message tag was computed for,
+// padded and followed by suffix.
+func extendSpongeTag(capacity int, tag, suffix []byte) ([]byte, error) {
+	rate := keccakStateSize - capacity
+	switch {
+	case capacity < 1 || capacity > maxSpongeGuess:
+		return nil, errors.New("capacity too large to guess")
+	case len(tag) < rate+capacity+spongeCheckBytes:
+		return nil, errors.New("tag too short to check guesses")
+	}
+
+	s, ok := guessSpongeState(tag, rate)
+	if !ok {
+		return nil, errors.New("no state matches the tag")
+	}
+
+	// The state is now that after absorbing the padded message, so keep
+	// absorbing from there.
+	s.Absorb(suffix)
+
+	forgedTag := make([]byte, len(tag))
+	s.Squeeze(forgedTag)
+	return forgedTag, nil
+}
+
+// guessSpongeState returns the sponge state that squeezes tag, trying every
+// value of the capacity bytes.
+func guessSpongeState(tag []byte, rate int) (*Sponge, bool) {
+	s := &Sponge{rate: rate}
+	copy(s.state[:], tag[:rate])
+
+	// The next permutation gives the rest of the tag.
+	check := tag[rate:min(len(tag), 2*rate)]
+
+	var guess [4]byte
+	for g := range 1 << (8 * (keccakStateSize - rate)) {
+		binary.LittleEndian.PutUint32(guess[:], uint32(g))
+		copy(s.state[rate:], guess[:])
+
+		t := s.state
+		keccakF(&t)
+		if bytes.Equal(t[:len(check)], check) {
+			return s, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,144 @@
+package cryptopals
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash"
+	"slices"
+	"testing"
+)
+
+func TestSHA3_256(t *testing.T) {
+	cases := []struct {
+		msg, want string
+	}{
+		{"", "a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a"},
+		{"abc", "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532"},
+		{"abcdbcdecdefdefgefghfghighijhijkijkljklmklmnlmnomnopnopq", "41c0dba2a9d6240849100376a8235e2c82e1b9998a999e21db32dd97496d3376"},
+	}
+
+	for _, tc := range cases {
+		h := NewSHA3_256()
+		h.Write([]byte(tc.msg))
+		if got := hex.EncodeToString(h.Sum(nil)); got != tc.want {
+			t.Errorf("%q: want %s, got %s", tc.msg, tc.want, got)
+		}
+	}
+}
+
+func TestSpongeChunks(t *testing.T) {
+	msg := englishText[:1000]
+
+	h := NewSHA3_256()
+	h.Write(msg)
+	want := h.Sum(nil)
+
+	s := NewSponge(64)
+	for i, n := 0, 1; i < len(msg); i, n = i+n, n+13 {
+		s.Absorb(msg[i:min(i+n, len(msg))])
+	}
+
+	// Squeezing in pieces gives the same output as all at once.
+	got := make([]byte, 32)
+	s.Squeeze(got[:5])
+	s.Squeeze(got[5:])
+	if !bytes.Equal(got, want) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+}
+
+func TestSpongeLongOutput(t *testing.T) {
+	// Output longer than the rate takes several permutations.
+	a, b := NewSponge(64), NewSponge(64)
+	a.Absorb([]byte("hello"))
+	b.Absorb([]byte("hello"))
+
+	long := make([]byte, 3*a.Rate()+7)
+	a.Squeeze(long)
+
+	got := make([]byte, 0, len(long))
+	for range len(long) {
+		var c [1]byte
+		b.Squeeze(c[:])
+		got = append(got, c[0])
+	}
+	if !bytes.Equal(got, long) {
+		t.Error("byte-at-a-time output differs")
+	}
+}
+
+func TestSpongeResistsExtension(t *testing.T) {
+	// Treating a SHA3-256 tag as the state, as length extension does for
+	// Merkle-Damgård hashes, doesn't work: the tag is only part of the rate,
+	// and the capacity is never output.
+	m := NewSecretPrefixMAC(NewSHA3_256)
+	msg := []byte("user=alice")
+	tag := m.Sign(msg)
+
+	if _, _, err := ExtendSponge(64, tag, msg, []byte("&admin=1"), 16); err == nil {
+		t.Error("no error extending SHA3-256")
+	}
+
+	s := NewSponge(64)
+	copy(s.state[:], tag)
+	for keyLen := range maxSecretPrefixKeySize + 1 {
+		forged := slices.Concat(msg, spongePadding(keyLen+len(msg), s.Rate()), []byte("&admin=1"))
+
+		resumed := *s
+		resumed.Absorb([]byte("&admin=1"))
+		forgedTag := make([]byte, len(tag))
+		resumed.Squeeze(forgedTag)
+
+		if m.Verify(forged, forgedTag) {
+			t.Fatalf("key length %d: SHA3-256 extended", keyLen)
+		}
+	}
+}
+
+func TestExtendSponge(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	msg := []byte("user=alice")
+	suffix := []byte("&admin=1")
+
+	// The tag has to be longer than the rate to check guesses at the
+	// capacity.
+	h := NewSpongeHash(2, keccakStateSize+8)
+	h.Write(slices.Concat(key, msg))
+
+	forged, tag, err := ExtendSponge(2, h.Sum(nil), msg, suffix, len(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Reset()
+	h.Write(slices.Concat(key, forged))
+	if want := h.Sum(nil); !bytes.Equal(tag, want) {
+		t.Errorf("want %x, got %x", want, tag)
+	}
+}
+
+func TestForgeSpongeMAC(t *testing.T) {
+	msg := []byte("comment1=cooking%20MCs;userdata=foo")
+	suffix := []byte(";admin=true")
+
+	for _, capacity := range []int{1, 2} {
+		m := NewSecretPrefixMAC(func() hash.Hash { return NewSpongeHash(capacity, keccakStateSize+8) })
+
+		forged, tag, err := ForgeSpongeMAC(capacity, msg, m.Sign(msg), suffix, m.Verify)
+		if err != nil {
+			t.Fatalf("capacity %d: %v", capacity, err)
+		}
+		if !m.Verify(forged, tag) {
+			t.Errorf("capacity %d: forged tag doesn't verify", capacity)
+		}
+	}
+}
+
+func TestExtendSpongeErrors(t *testing.T) {
+	if _, _, err := ExtendSponge(4, make([]byte, 300), nil, nil, 0); err == nil {
+		t.Error("capacity 4: no error")
+	}
+	if _, _, err := ExtendSponge(2, make([]byte, 200), nil, nil, 0); err == nil {
+		t.Error("short tag: no error")
+	}
+}
@@ -0,0 +1,166 @@
+package cryptopals
+
+import (
+	"cmp"
+	"slices"
+)
+
+// A KeySizeEstimator is a method of estimating the key size of a
+// repeating-key XOR ciphertext.
+type KeySizeEstimator int
+
+const (
+	// HammingKeySizes ranks key sizes by the normalized Hamming distance
+	// between adjacent key-sized chunks. See RankRepeatingKeyXORKeySizes.
+	HammingKeySizes KeySizeEstimator = iota
+
+	// CoincidenceKeySizes ranks key sizes by the average index of
+	// coincidence of the columns from Transpose. Columns encrypted with one
+	// key byte keep the index of the plaintext, and mixed columns have a
+	// lower one. Multiples of the key size rank just after it.
+	CoincidenceKeySizes
+
+	// KasiskiKeySizes ranks key sizes by how much more often than chance they
+	// divide the distances between repeated trigrams. Repeats are usually
+	// the same plaintext under the same key bytes, so their distances are
+	// multiples of the key size.
+	KasiskiKeySizes
+
+	// VotedKeySizes ranks key sizes by combining their ranks under
+	// CoincidenceKeySizes and KasiskiKeySizes, which is more reliable than
+	// either one on short ciphertexts. HammingKeySizes doesn't vote, since it
+	// often ranks multiples of the key size first.
+	VotedKeySizes
+)
+
+// WithKeySizeEstimator sets how an attack estimates the key size of a
+// repeating-key XOR ciphertext. The default is HammingKeySizes.
+func WithKeySizeEstimator(e KeySizeEstimator) Option {
+	return func(o *options) {
+		o.keySizeEstimator = e
+	}
+}
+
+// IndexOfCoincidence returns the probability that two bytes of b chosen at
+// random, without replacement, are equal. English text scores about 0.06,
+// and uniformly random bytes about 1/256.
+//
+// If len(b) < 2, IndexOfCoincidence returns 0.
+func IndexOfCoincidence(b []byte) float64 {
+	if len(b) < 2 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, v := range b {
+		counts[v]++
+	}
+
+	var sum int
+	for _, n := range counts {
+		sum += n * (n - 1)
+	}
+	return float64(sum) / float64(len(b)*(len(b)-1))
+}
+
+// rankKeySizes returns every key size from lo to hi, ordered by e from most
+// to least likely. Ties go to the smaller key size.
+func rankKeySizes(ct []byte, lo, hi int, e KeySizeEstimator) []int {
+	switch e {
+	case CoincidenceKeySizes:
+		return rankKeySizesByCoincidence(ct, lo, hi)
+	case KasiskiKeySizes:
+		return rankKeySizesByKasiski(ct, lo, hi)
+	case VotedKeySizes:
+		return rankKeySizesByVote(ct, lo, hi)
+	default:
+		candidates, _ := RankRepeatingKeyXORKeySizes(ct, lo, hi, hi-lo+1)
+		res := make([]int, len(candidates))
+		for i, c := range candidates {
+			res[i] = c.KeySize
+		}
+		return res
+	}
+}
+
+// sortKeySizesByScore returns the key sizes from lo to hi sorted by score,
+// from highest to lowest, with ties going to the smaller key size. score is
+// indexed by key size.
+func sortKeySizesByScore(lo, hi int, score []float64) []int {
+	res := make([]int, 0, hi-lo+1)
+	for ks := lo; ks <= hi; ks++ {
+		res = append(res, ks)
+	}
+	slices.SortStableFunc(res, func(a, b int) int {
+		return cmp.Compare(score[b], score[a])
+	})
+	return res
+}
+
+func rankKeySizesByCoincidence(ct []byte, lo, hi int) []int {
+	ics := make([]float64, hi+1)
+	for ks := lo; ks <= hi; ks++ {
+		var sum float64
+		for _, col := range Transpose(ct, ks) {
+			sum += IndexOfCoincidence(col)
+		}
+		ics[ks] = sum / float64(ks)
+	}
+
+	// A multiple of a key size that scores nearly as well gets the same
+	// score, so it ranks just after the smaller size.
+	score := slices.Clone(ics)
+	for ks := lo; ks <= hi; ks++ {
+		for d := lo; d < ks; d++ {
+			if ks%d == 0 && ics[d] >= 0.9*ics[ks] {
+				score[ks] = min(score[ks], score[d])
+			}
+		}
+	}
+
+	return sortKeySizesByScore(lo, hi, score)
+}
+
+func rankKeySizesByKasiski(ct []byte, lo, hi int) []int {
+	// Find the distance from each trigram to its previous occurrence.
+	var (
+		last      = make(map[[3]byte]int)
+		distances []int
+	)
+	for i := 0; i+3 <= len(ct); i++ {
+		tri := [3]byte(ct[i : i+3])
+		if j, ok := last[tri]; ok {
+			distances = append(distances, i-j)
+		}
+		last[tri] = i
+	}
+
+	score := make([]float64, hi+1)
+	if len(distances) > 0 {
+		for ks := lo; ks <= hi; ks++ {
+			var n int
+			for _, d := range distances {
+				if d%ks == 0 {
+					n++
+				}
+			}
+			// By chance, a fraction 1/ks of distances divide evenly.
+			score[ks] = float64(n)/float64(len(distances)) - 1/float64(ks)
+		}
+	}
+
+	return sortKeySizesByScore(lo, hi, score)
+}
+
+func rankKeySizesByVote(ct []byte, lo, hi int) []int {
+	// Each estimator gives a key size 1/r points for ranking it r-th, so
+	// sizes that some estimator ranks first count for the most.
+	score := make([]float64, hi+1)
+	for _, e := range []KeySizeEstimator{CoincidenceKeySizes, KasiskiKeySizes} {
+		for i, ks := range rankKeySizes(ct, lo, hi, e) {
+			score[ks] += 1 / float64(i+1)
+		}
+	}
+
+	return sortKeySizesByScore(lo, hi, score)
+}
@@ -0,0 +1,93 @@
+package cryptopals
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+var keySizeEstimators = []KeySizeEstimator{HammingKeySizes, CoincidenceKeySizes, KasiskiKeySizes, VotedKeySizes}
+
+func TestIndexOfCoincidence(t *testing.T) {
+	cases := []struct {
+		in   string
+		want float64
+	}{
+		{"", 0},
+		{"a", 0},
+		{"aa", 1},
+		{"ab", 0},
+		{"aab", 1.0 / 3},
+		{"abcd", 0},
+	}
+
+	for _, tc := range cases {
+		if got := IndexOfCoincidence([]byte(tc.in)); got != tc.want {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestKeySizeEstimatorsChallenge6(t *testing.T) {
+	in := decodeBase64FromFile(t, "testdata/6.txt")
+
+	for _, e := range keySizeEstimators {
+		got, err := RecoverRepeatingKeyXORKeySize(in, 2, 40, WithKeySizeEstimator(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != 29 {
+			t.Errorf("estimator %d: want 29, got %d", e, got)
+		}
+	}
+}
+
+func TestKeySizeEstimators(t *testing.T) {
+	// The Hamming distance often prefers multiples of the key size for
+	// ciphertexts this short, so it's left out.
+	pt := englishText[:600]
+
+	for _, key := range []string{"ICE", "seven!!", "a longer key here"} {
+		ct := make([]byte, len(pt))
+		NewRepeatingKeyXORCipher([]byte(key)).XORKeyStream(ct, pt)
+
+		for _, e := range []KeySizeEstimator{CoincidenceKeySizes, KasiskiKeySizes, VotedKeySizes} {
+			t.Run(fmt.Sprintf("%s/%d", key, e), func(t *testing.T) {
+				got, err := RecoverRepeatingKeyXORKeySize(ct, 2, 40, WithKeySizeEstimator(e))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != len(key) {
+					t.Errorf("want %d, got %d", len(key), got)
+				}
+			})
+		}
+	}
+}
+
+func TestKeySizeEstimatorsRankEverySize(t *testing.T) {
+	ct := bytes.Repeat([]byte("0123456789"), 10)
+
+	for _, e := range keySizeEstimators {
+		got, err := RecoverRepeatingKeyXORKeySizes(ct, 3, 12, 100, WithKeySizeEstimator(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 10 {
+			t.Errorf("estimator %d: want 10 sizes, got %v", e, got)
+		}
+	}
+}
+
+func TestRecoverRepeatingKeyXORKeyVoted(t *testing.T) {
+	in := decodeBase64FromFile(t, "testdata/6.txt")
+	want := []byte("Terminator X: Bring the noise")
+
+	got, err := RecoverRepeatingKeyXORKey(in, WithKeySizeEstimator(VotedKeySizes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,77 @@
+package cryptopals
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// RecoverKeystream returns the keystream that encrypts bytes offset through
+// offset+len(knownPt) of a stream cipher ciphertext ct, given that they
+// decrypt to knownPt. It returns an error if knownPt doesn't fit in ct at
+// offset.
+//
+// Any other ciphertext encrypted with the same key and nonce, or IV, uses
+// the same keystream, so ApplyKeystream decrypts the same bytes of it.
+func RecoverKeystream(ct, knownPt []byte, offset int) ([]byte, error) {
+	if offset < 0 || offset > len(ct) || len(knownPt) > len(ct)-offset {
+		return nil, errors.New("known plaintext out of range")
+	}
+
+	ks := make([]byte, len(knownPt))
+	subtle.XORBytes(ks, ct[offset:], knownPt)
+	return ks, nil
+}
+
+// ApplyKeystream returns the bytes of ct from offset on XORed with ks, the
+// keystream segment from RecoverKeystream for that offset. The result is
+// only as long as both reach, and empty if offset is past the end of ct.
+// Since encrypting and decrypting are the same, it also encrypts.
+func ApplyKeystream(ct, ks []byte, offset int) []byte {
+	if offset < 0 {
+		panic("negative offset")
+	}
+	if offset >= len(ct) {
+		return []byte{}
+	}
+
+	res := make([]byte, min(len(ct)-offset, len(ks)))
+	subtle.XORBytes(res, ct[offset:], ks)
+	return res
+}
+
+// RecoverReusedKeystream returns the most likely keystream shared by
+// ciphertexts encrypted with the same stream cipher key and nonce, or IV,
+// such as ChaCha20, or OFB or CTR mode with a reused IV. It's as long as the
+// longest ciphertext. ApplyKeystream(ct, ks, 0) decrypts any of them.
+//
+// Each keystream byte encrypts the byte at that position of every plaintext,
+// so it's recovered as a single-byte XOR key across the ciphertexts long
+// enough to have that position, as in challenge 20. It assumes the
+// plaintexts are English; use WithScorer to change how they're scored. Bytes
+// covered by only a few ciphertexts are unreliable.
+//
+// With a known plaintext, use RecoverKeystream instead.
+func RecoverReusedKeystream(cts [][]byte, opts ...Option) []byte {
+	var n int
+	for _, ct := range cts {
+		n = max(n, len(ct))
+	}
+	if n == 0 {
+		return []byte{}
+	}
+
+	// Transposing a ciphertext into n columns puts byte i, if it has one,
+	// in column i.
+	cols := make([][]byte, n)
+	for _, ct := range cts {
+		for i, c := range Transpose(ct, n) {
+			cols[i] = append(cols[i], c...)
+		}
+	}
+
+	ks := make([]byte, n)
+	for i, col := range cols {
+		ks[i] = RecoverSingleByteXORKey(col, opts...)
+	}
+	return ks
+}
@@ -0,0 +1,94 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+func TestRecoverKeystream(t *testing.T) {
+	block, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypt := func(pt []byte) []byte {
+		ct := make([]byte, len(pt))
+		NewNonceCTR(block, 7).XORKeyStream(ct, pt)
+		return ct
+	}
+
+	known := []byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\n")
+	secret := []byte("POST /login HTTP/1.1\r\nCookie: session=1234\r\n\r\n")
+
+	ks, err := RecoverKeystream(encrypt(known), known[4:], 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ApplyKeystream(encrypt(secret), ks, 4)
+	if want := secret[4:len(known)]; !bytes.Equal(got, want) {
+		t.Errorf("ApplyKeystream() = %q, want %q", got, want)
+	}
+
+	// The result stops at the end of the shorter input.
+	if got := ApplyKeystream(encrypt(secret[:10]), ks, 4); !bytes.Equal(got, secret[4:10]) {
+		t.Errorf("ApplyKeystream() on a short ciphertext = %q, want %q", got, secret[4:10])
+	}
+	if got := ApplyKeystream(encrypt(secret[:3]), ks, 4); len(got) != 0 {
+		t.Errorf("ApplyKeystream() past the end = %q, want empty", got)
+	}
+}
+
+func TestRecoverKeystreamOutOfRange(t *testing.T) {
+	ct := make([]byte, 10)
+	for _, tt := range []struct {
+		n, offset int
+	}{
+		{11, 0},
+		{5, 6},
+		{1, 10},
+		{1, -1},
+	} {
+		if _, err := RecoverKeystream(ct, make([]byte, tt.n), tt.offset); err == nil {
+			t.Errorf("RecoverKeystream(%d bytes at %d) succeeded", tt.n, tt.offset)
+		}
+	}
+	if _, err := RecoverKeystream(ct, nil, 10); err != nil {
+		t.Errorf("RecoverKeystream(empty at end) failed: %v", err)
+	}
+}
+
+func TestRecoverReusedKeystream(t *testing.T) {
+	key, nonce := randBytes(32), randBytes(12)
+
+	// Sixty 60-byte pieces of English.
+	var pts, cts [][]byte
+	for i := range 60 {
+		pt := englishText[100*i : 100*i+60]
+		ct := make([]byte, len(pt))
+		NewChaCha20(key, nonce, 0).XORKeyStream(ct, pt)
+		pts, cts = append(pts, pt), append(cts, ct)
+	}
+
+	ks := RecoverReusedKeystream(cts)
+
+	var wrong int
+	for i, ct := range cts {
+		for j, c := range XOR(ct, ks) {
+			if c != pts[i][j] {
+				wrong++
+			}
+		}
+	}
+	if frac := float64(wrong) / float64(60*len(cts)); frac > 0.05 {
+		t.Errorf("%.1f%% of bytes wrong", 100*frac)
+	}
+}
+
+func TestRecoverReusedKeystreamLengths(t *testing.T) {
+	if ks := RecoverReusedKeystream([][]byte{{1}, {1, 2, 3}, nil}); len(ks) != 3 {
+		t.Errorf("got %d bytes, want 3", len(ks))
+	}
+	if ks := RecoverReusedKeystream(nil); len(ks) != 0 {
+		t.Errorf("got %d bytes, want 0", len(ks))
+	}
+}
@@ -0,0 +1,179 @@
+package cryptopals
+
+import "math/big"
+
+// lllDelta is the Lovász condition parameter LLL uses.
+var lllDelta = big.NewRat(3, 4)
+
+// dot returns the dot product of a and b as a rational.
+func dot(a, b []*big.Rat) *big.Rat {
+	sum, t := new(big.Rat), new(big.Rat)
+	for i := range a {
+		sum.Add(sum, t.Mul(a[i], b[i]))
+	}
+	return sum
+}
+
+// ratVector returns v as rationals.
+func ratVector(v []*big.Int) []*big.Rat {
+	res := make([]*big.Rat, len(v))
+	for i, x := range v {
+		res[i] = new(big.Rat).SetInt(x)
+	}
+	return res
+}
+
+// roundRat returns x rounded to the nearest integer, with halves rounding up.
+func roundRat(x *big.Rat) *big.Int {
+	t := new(big.Rat).Add(x, big.NewRat(1, 2))
+	// Floor division, since big.Int.Div rounds toward negative infinity for
+	// positive divisors.
+	return new(big.Int).Div(t.Num(), t.Denom())
+}
+
+// gramSchmidt returns the Gram-Schmidt orthogonalization of the rows of b,
+// unnormalized, along with the coefficients mu[i][j] = <b[i], bs[j]> /
+// <bs[j], bs[j]> and the squared norms of bs.
+func gramSchmidt(b [][]*big.Int) (bs [][]*big.Rat, mu [][]*big.Rat, norms []*big.Rat) {
+	n := len(b)
+	bs = make([][]*big.Rat, n)
+	mu = make([][]*big.Rat, n)
+	norms = make([]*big.Rat, n)
+
+	t := new(big.Rat)
+	for i := range n {
+		bi := ratVector(b[i])
+		bs[i] = ratVector(b[i])
+		mu[i] = make([]*big.Rat, n)
+		for j := range i {
+			mu[i][j] = new(big.Rat)
+			if norms[j].Sign() != 0 {
+				mu[i][j].Quo(dot(bi, bs[j]), norms[j])
+			}
+			for k := range bs[i] {
+				bs[i][k].Sub(bs[i][k], t.Mul(mu[i][j], bs[j][k]))
+			}
+		}
+		norms[i] = dot(bs[i], bs[i])
+	}
+	return bs, mu, norms
+}
+
+// LLL reduces the lattice basis b in place with the Lenstra-Lenstra-Lovász
+// algorithm, where the rows of b are the basis vectors. The reduced basis
+// spans the same lattice with short, nearly orthogonal vectors, the first of
+// which is within a factor 2^((n-1)/2) of the shortest nonzero vector.
+//
+// The rows must be linearly independent. The arithmetic is exact, in the
+// integral form of Cohen's Algorithm 2.6.7, which keeps the Gram-Schmidt
+// coefficients as integers scaled by d[j+1], the product of the squared
+// norms of the first j+1 orthogonalized vectors, and updates them in place
+// on each swap. It's still meant for the small lattices in attacks, with
+// dozens of dimensions at most.
+func LLL(b [][]*big.Int) {
+	n := len(b)
+	if n == 0 {
+		return
+	}
+
+	// lambda[i][j] = d[j+1] * mu[i][j], for j < i.
+	d := make([]*big.Int, n+1)
+	d[0] = big.NewInt(1)
+	lambda := make([][]*big.Int, n)
+	t := new(big.Int)
+	for k := range n {
+		lambda[k] = make([]*big.Int, k)
+		for j := 0; j <= k; j++ {
+			u := new(big.Int)
+			for i := range b[k] {
+				u.Add(u, t.Mul(b[k][i], b[j][i]))
+			}
+			for i := range j {
+				u.Mul(u, d[i+1]).Sub(u, t.Mul(lambda[k][i], lambda[j][i])).Quo(u, d[i])
+			}
+			if j < k {
+				lambda[k][j] = u
+			} else {
+				d[k+1] = u
+			}
+		}
+	}
+
+	num := new(big.Int).Set(lllDelta.Num())
+	den := new(big.Int).Set(lllDelta.Denom())
+	lhs, rhs := new(big.Int), new(big.Int)
+	for k := 1; k < n; {
+		// Size-reduce b[k] against the earlier vectors, keeping lambda in
+		// step. q is lambda/d[j+1] rounded, with halves rounding up.
+		for j := k - 1; j >= 0; j-- {
+			q := new(big.Int).Lsh(lambda[k][j], 1)
+			q.Add(q, d[j+1]).Div(q, t.Lsh(d[j+1], 1))
+			if q.Sign() == 0 {
+				continue
+			}
+			for i := range b[k] {
+				b[k][i].Sub(b[k][i], t.Mul(q, b[j][i]))
+			}
+			for i := range j {
+				lambda[k][i].Sub(lambda[k][i], t.Mul(q, lambda[j][i]))
+			}
+			lambda[k][j].Sub(lambda[k][j], t.Mul(q, d[j+1]))
+		}
+
+		// Check the Lovász condition, den*d[k+1]*d[k-1] >= num*d[k]^2 -
+		// den*lambda[k][k-1]^2, and swap if it fails.
+		l := lambda[k][k-1]
+		lhs.Mul(d[k+1], d[k-1]).Mul(lhs, den)
+		rhs.Mul(d[k], d[k]).Mul(rhs, num).Sub(rhs, t.Mul(l, l).Mul(t, den))
+		if lhs.Cmp(rhs) >= 0 {
+			k++
+			continue
+		}
+
+		b[k], b[k-1] = b[k-1], b[k]
+		for j := range k - 1 {
+			lambda[k][j], lambda[k-1][j] = lambda[k-1][j], lambda[k][j]
+		}
+		B := new(big.Int).Mul(d[k-1], d[k+1])
+		B.Add(B, t.Mul(l, l)).Quo(B, d[k])
+		for i := k + 1; i < n; i++ {
+			v := lambda[i][k]
+			nk := new(big.Int).Mul(d[k+1], lambda[i][k-1])
+			nk.Sub(nk, t.Mul(l, v)).Quo(nk, d[k])
+			nk1 := new(big.Int).Mul(B, v)
+			nk1.Add(nk1, t.Mul(l, nk)).Quo(nk1, d[k+1])
+			lambda[i][k], lambda[i][k-1] = nk, nk1
+		}
+		d[k] = B
+		k = max(k-1, 1)
+	}
+}
+
+// ClosestVector returns a vector of the lattice with basis b that is close to
+// target, using Babai's nearest plane algorithm. The result is within a
+// factor 2^(n/2) of the closest, if b is LLL-reduced; reduce it first with
+// LLL.
+func ClosestVector(b [][]*big.Int, target []*big.Int) []*big.Int {
+	bs, _, norms := gramSchmidt(b)
+
+	v := make([]*big.Int, len(target))
+	for i, x := range target {
+		v[i] = new(big.Int).Set(x)
+	}
+
+	// Subtract the nearest multiple of each basis vector, from the last, so
+	// that v ends up as target's offset from the lattice.
+	t := new(big.Int)
+	for i := len(b) - 1; i >= 0; i-- {
+		c := roundRat(new(big.Rat).Quo(dot(ratVector(v), bs[i]), norms[i]))
+		for j := range v {
+			v[j].Sub(v[j], t.Mul(c, b[i][j]))
+		}
+	}
+
+	res := make([]*big.Int, len(target))
+	for i := range res {
+		res[i] = new(big.Int).Sub(target[i], v[i])
+	}
+	return res
+}
@@ -0,0 +1,78 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func intMatrix(rows [][]int64) [][]*big.Int {
+	res := make([][]*big.Int, len(rows))
+	for i, row := range rows {
+		for _, x := range row {
+			res[i] = append(res[i], big.NewInt(x))
+		}
+	}
+	return res
+}
+
+func TestLLL(t *testing.T) {
+	// The example from Wikipedia's article on the LLL algorithm. It rounds
+	// a coefficient of 1/2 down rather than up, and so ends with (-1, 0, 2),
+	// which is just as reduced.
+	b := intMatrix([][]int64{{1, 1, 1}, {-1, 0, 2}, {3, 5, 6}})
+	LLL(b)
+
+	want := intMatrix([][]int64{{0, 1, 0}, {1, 0, 1}, {-2, 0, 1}})
+	for i := range want {
+		for j := range want[i] {
+			if b[i][j].Cmp(want[i][j]) != 0 {
+				t.Fatalf("got %v, want %v", b, want)
+			}
+		}
+	}
+}
+
+func TestLLLReduced(t *testing.T) {
+	// A knapsack-style basis with one short vector hidden in it.
+	b := intMatrix([][]int64{
+		{1, 0, 0, 0, 3812},
+		{0, 1, 0, 0, 7391},
+		{0, 0, 1, 0, 9247},
+		{0, 0, 0, 1, 1354},
+		{0, 0, 0, 0, -(3812 + 9247 + 1354)},
+	})
+	LLL(b)
+
+	_, mu, norms := gramSchmidt(b)
+	half := big.NewRat(1, 2)
+	for i := range b {
+		for j := range i {
+			if new(big.Rat).Abs(mu[i][j]).Cmp(half) > 0 {
+				t.Errorf("mu[%d][%d] = %v isn't size-reduced", i, j, mu[i][j])
+			}
+		}
+		if i > 0 {
+			bound := new(big.Rat).Mul(mu[i][i-1], mu[i][i-1])
+			bound.Sub(lllDelta, bound)
+			bound.Mul(bound, norms[i-1])
+			if norms[i].Cmp(bound) < 0 {
+				t.Errorf("Lovász condition fails at %d", i)
+			}
+		}
+	}
+
+	// The subset {1, 3, 4} sums to the target, giving (1, 0, 1, 1, 0).
+	if n := dot(ratVector(b[0]), ratVector(b[0])); n.Cmp(big.NewRat(3, 1)) > 0 {
+		t.Errorf("first vector %v is longer than the hidden one", b[0])
+	}
+}
+
+func TestClosestVector(t *testing.T) {
+	b := intMatrix([][]int64{{5, 0}, {0, 7}})
+	target := []*big.Int{big.NewInt(12), big.NewInt(-4)}
+
+	got := ClosestVector(b, target)
+	if got[0].Int64() != 10 || got[1].Int64() != -7 {
+		t.Errorf("got %v, want [10 -7]", got)
+	}
+}
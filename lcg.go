@@ -0,0 +1,253 @@
+package cryptopals
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+	"slices"
+)
+
+// LCGParams are the parameters of a linear congruential generator, which
+// steps its state as x' = (A*x + C) mod M.
+type LCGParams struct {
+	A, C, M uint64
+}
+
+// Parameters of well-known LCGs.
+var (
+	// GlibcLCG is the TYPE_0 generator behind glibc's rand, which outputs
+	// its whole state.
+	GlibcLCG = LCGParams{A: 1103515245, C: 12345, M: 1 << 31}
+
+	// JavaLCG is the generator behind java.util.Random. Java only outputs
+	// the high bits of its state.
+	JavaLCG = LCGParams{A: 0x5deece66d, C: 11, M: 1 << 48}
+)
+
+// An LCG is a linear congruential generator. It's fast, and completely
+// predictable from a few outputs. See RecoverLCG.
+type LCG struct {
+	LCGParams
+	state uint64
+}
+
+// NewLCG returns an LCG with parameters p and state seed mod p.M. It panics
+// if p.M < 2.
+//
+// Real generators often scramble their seeds first; Java XORs it with A.
+// NewLCG uses the seed as is.
+func NewLCG(p LCGParams, seed uint64) *LCG {
+	if p.M < 2 {
+		panic("invalid modulus")
+	}
+	return &LCG{LCGParams: p, state: seed % p.M}
+}
+
+// Next steps the generator and returns its new state.
+func (l *LCG) Next() uint64 {
+	hi, lo := bits.Mul64(l.A%l.M, l.state)
+	lo, carry := bits.Add64(lo, l.C%l.M, 0)
+	l.state = bits.Rem64(hi+carry, lo, l.M)
+	return l.state
+}
+
+// State returns the current state, which was the last output of Next.
+func (l *LCG) State() uint64 {
+	return l.state
+}
+
+// RecoverLCGModulus returns the modulus of an LCG from consecutive outputs of
+// Next.
+//
+// With differences t[i] = x[i+1] - x[i], each t[i+2]*t[i] - t[i+1]^2 is a
+// multiple of the modulus, so their GCD is the modulus or a small multiple of
+// it. It needs at least 4 outputs, and more make a multiple less likely. It
+// returns an error if the outputs don't fit in an LCG.
+func RecoverLCGModulus(outputs []uint64) (uint64, error) {
+	if len(outputs) < 4 {
+		return 0, errors.New("need at least 4 outputs")
+	}
+
+	t := make([]*big.Int, len(outputs)-1)
+	for i := range t {
+		t[i] = new(big.Int).Sub(new(big.Int).SetUint64(outputs[i+1]), new(big.Int).SetUint64(outputs[i]))
+	}
+
+	m := new(big.Int)
+	u, sq := new(big.Int), new(big.Int)
+	for i := 0; i+2 < len(t); i++ {
+		u.Mul(t[i+2], t[i])
+		u.Sub(u, sq.Mul(t[i+1], t[i+1]))
+		m.GCD(nil, nil, m, u.Abs(u))
+	}
+
+	if !m.IsUint64() || m.Uint64() < 2 {
+		return 0, errors.New("outputs aren't from an LCG")
+	}
+	return m.Uint64(), nil
+}
+
+// RecoverLCGMultiplier returns the multiplier of an LCG with modulus m from
+// consecutive outputs of Next, which satisfy t[i+1] = A*t[i] mod m for the
+// differences t[i] = x[i+1] - x[i]. It needs at least 3 outputs, and returns
+// an error if no difference is invertible mod m.
+func RecoverLCGMultiplier(outputs []uint64, m uint64) (uint64, error) {
+	if len(outputs) < 3 {
+		return 0, errors.New("need at least 3 outputs")
+	}
+
+	bm := new(big.Int).SetUint64(m)
+	diff := func(i int) *big.Int {
+		d := new(big.Int).Sub(new(big.Int).SetUint64(outputs[i+1]), new(big.Int).SetUint64(outputs[i]))
+		return d.Mod(d, bm)
+	}
+
+	for i := 0; i+2 < len(outputs); i++ {
+		inv := new(big.Int).ModInverse(diff(i), bm)
+		if inv == nil {
+			continue
+		}
+		a := inv.Mul(inv, diff(i+1))
+		return a.Mod(a, bm).Uint64(), nil
+	}
+	return 0, errors.New("no invertible difference")
+}
+
+// RecoverLCGIncrement returns the increment of an LCG with modulus m and
+// multiplier a from two consecutive outputs of Next.
+func RecoverLCGIncrement(x0, x1, a, m uint64) uint64 {
+	hi, lo := bits.Mul64(a%m, x0%m)
+	ax := bits.Rem64(hi, lo, m)
+	x := x1 % m
+	if x >= ax {
+		return x - ax
+	}
+	return m - (ax - x)
+}
+
+// maxLCGCofactor is the largest multiple of the modulus RecoverLCG undoes.
+const maxLCGCofactor = 64
+
+// RecoverLCG returns an LCG that continues from consecutive outputs of Next,
+// with its parameters and state recovered: its next output is the one that
+// would have followed. It needs at least 4 outputs.
+//
+// The modulus from RecoverLCGModulus can be a small multiple of the real
+// one, especially a power of two, so it tries the divisors larger than every
+// output, smallest first, and keeps the first whose parameters reproduce the
+// outputs. That picks too small a modulus only if every output is below it,
+// so about 16 outputs make it reliable. It returns an error if no divisor
+// works, as when the outputs aren't from an LCG.
+func RecoverLCG(outputs []uint64) (*LCG, error) {
+	m, err := RecoverLCGModulus(outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	largest := slices.Max(outputs)
+	for k := uint64(maxLCGCofactor); k >= 1; k-- {
+		if m%k != 0 || m/k <= largest {
+			continue
+		}
+		if l, ok := recoverLCG(outputs, m/k); ok {
+			return l, nil
+		}
+	}
+	return nil, errors.New("recovered parameters don't match the outputs")
+}
+
+// recoverLCG returns an LCG with modulus m that continues from outputs, if
+// there's one that reproduces them.
+func recoverLCG(outputs []uint64, m uint64) (*LCG, bool) {
+	a, err := RecoverLCGMultiplier(outputs, m)
+	if err != nil {
+		return nil, false
+	}
+	c := RecoverLCGIncrement(outputs[0], outputs[1], a, m)
+
+	l := NewLCG(LCGParams{A: a, C: c, M: m}, outputs[0])
+	for _, x := range outputs[1:] {
+		if l.Next() != x {
+			return nil, false
+		}
+	}
+	return l, true
+}
+
+// RecoverTruncatedLCG returns an LCG with parameters p that continues from
+// consecutive truncated outputs, each the high bits of the state, state >>
+// shift, as java.util.Random outputs the top 32 bits of its 48-bit state.
+// Its next output of Next is the full state after the last one.
+//
+// With w[i] = x[i] - c[i], where c[i] is the part of x[i] due to the
+// increment, w[i] = A^i * w[0] mod M, so w is in the lattice spanned by (1,
+// A, ..., A^(n-1)) and M times each unit vector. The outputs put w within
+// 2^shift of a known target in every coordinate, so LLL and ClosestVector
+// find it when enough bits are known: about 4 outputs of Java's 32 bits
+// suffice.
+//
+// It returns an error if no state matches the outputs, which means it needs
+// more of them.
+func RecoverTruncatedLCG(p LCGParams, outputs []uint64, shift uint) (*LCG, error) {
+	n := len(outputs)
+	if n < 2 {
+		return nil, errors.New("need at least 2 outputs")
+	}
+	if p.M < 2 || shift >= 64 {
+		panic("invalid parameters")
+	}
+
+	m := new(big.Int).SetUint64(p.M)
+	a := new(big.Int).SetUint64(p.A)
+
+	// The basis, with rows (1, A, A^2, ...) and M times the other unit
+	// vectors.
+	basis := make([][]*big.Int, n)
+	for i := range basis {
+		basis[i] = make([]*big.Int, n)
+		for j := range basis[i] {
+			basis[i][j] = new(big.Int)
+		}
+	}
+	pow := big.NewInt(1)
+	for j := range n {
+		basis[0][j].Set(pow)
+		pow = new(big.Int).Mod(new(big.Int).Mul(pow, a), m)
+	}
+	for i := 1; i < n; i++ {
+		basis[i][i].Set(m)
+	}
+	LLL(basis)
+
+	// The target is the middle of the range each w[i] can be in.
+	target := make([]*big.Int, n)
+	l := NewLCG(p, 0)
+	half := new(big.Int).Lsh(big.NewInt(1), shift)
+	half.Rsh(half, 1)
+	for i, y := range outputs {
+		// With a zero state, the generator's states are the c[i].
+		var c uint64
+		if i > 0 {
+			c = l.Next()
+		}
+		t := new(big.Int).Lsh(new(big.Int).SetUint64(y), shift)
+		t.Add(t, half)
+		t.Sub(t, new(big.Int).SetUint64(c))
+		target[i] = t.Mod(t, m)
+	}
+
+	w := ClosestVector(basis, target)
+	x0 := new(big.Int).Mod(w[0], m).Uint64()
+
+	// Check the state against every output, and step past them.
+	if x0>>shift != outputs[0] {
+		return nil, errors.New("no state matches the outputs")
+	}
+	res := NewLCG(p, x0)
+	for _, y := range outputs[1:] {
+		if res.Next()>>shift != y {
+			return nil, errors.New("no state matches the outputs")
+		}
+	}
+	return res, nil
+}
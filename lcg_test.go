@@ -0,0 +1,120 @@
+package cryptopals
+
+import "testing"
+
+func TestLCG(t *testing.T) {
+	// glibc's TYPE_0 rand from seed 1.
+	l := NewLCG(GlibcLCG, 1)
+	want := []uint64{1103527590, 377401575, 662824084, 1147902781, 2035015474}
+	for i, w := range want {
+		if got := l.Next(); got != w {
+			t.Errorf("output %d: got %d, want %d", i, got, w)
+		}
+	}
+}
+
+func TestLCGLargeModulus(t *testing.T) {
+	// The product overflows 64 bits.
+	p := LCGParams{A: 6364136223846793005, C: 1442695040888963407, M: 1<<63 + 25}
+	l := NewLCG(p, 12345)
+	x := l.Next()
+	if x >= p.M {
+		t.Fatalf("output %d not reduced", x)
+	}
+}
+
+func TestRecoverLCGIncrementLargeModulus(t *testing.T) {
+	// x1 + m - a*x0 overflows 64 bits for a modulus this close to 2^64.
+	p := LCGParams{A: 6364136223846793005, C: 1442695040888963407, M: 1<<64 - 59}
+	for seed := range uint64(20) {
+		l := NewLCG(p, seed)
+		x0 := l.Next()
+		x1 := l.Next()
+		if got := RecoverLCGIncrement(x0, x1, p.A, p.M); got != p.C {
+			t.Errorf("seed %d: got %d, want %d", seed, got, p.C)
+		}
+	}
+}
+
+func TestRecoverLCG(t *testing.T) {
+	params := map[string]LCGParams{
+		"glibc": GlibcLCG,
+		"java":  JavaLCG,
+		"prime": {A: 48271, C: 0, M: 1<<31 - 1},
+		"odd":   {A: 123456789, C: 987654321, M: 1_000_000_007},
+	}
+
+	for name, p := range params {
+		t.Run(name, func(t *testing.T) {
+			l := NewLCG(p, uint64(randInt64(1<<62)))
+			outputs := make([]uint64, 16)
+			for i := range outputs {
+				outputs[i] = l.Next()
+			}
+
+			r, err := RecoverLCG(outputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.M != p.M {
+				t.Errorf("modulus %d, want %d", r.M, p.M)
+			}
+			for i := range 20 {
+				if got, want := r.Next(), l.Next(); got != want {
+					t.Fatalf("prediction %d: got %d, want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverLCGErrors(t *testing.T) {
+	if _, err := RecoverLCG([]uint64{1, 2, 3}); err == nil {
+		t.Error("3 outputs: no error")
+	}
+	if _, err := RecoverLCG([]uint64{5, 5, 5, 5, 5}); err == nil {
+		t.Error("constant outputs: no error")
+	}
+}
+
+func TestRecoverTruncatedLCG(t *testing.T) {
+	cases := []struct {
+		name  string
+		p     LCGParams
+		shift uint
+		n     int
+	}{
+		{"java nextInt", JavaLCG, 16, 4},
+		{"java 24 bits", JavaLCG, 24, 8},
+		{"glibc 16 bits", GlibcLCG, 15, 8},
+		{"prime", LCGParams{A: 48271, C: 0, M: 1<<31 - 1}, 12, 6},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for range 3 {
+				l := NewLCG(tc.p, uint64(randInt64(1<<62)))
+				outputs := make([]uint64, tc.n)
+				for i := range outputs {
+					outputs[i] = l.Next() >> tc.shift
+				}
+
+				r, err := RecoverTruncatedLCG(tc.p, outputs, tc.shift)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := range 10 {
+					if got, want := r.Next(), l.Next(); got != want {
+						t.Fatalf("prediction %d: got %d, want %d", i, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverTruncatedLCGErrors(t *testing.T) {
+	if _, err := RecoverTruncatedLCG(JavaLCG, []uint64{1}, 16); err == nil {
+		t.Error("1 output: no error")
+	}
+}
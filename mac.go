@@ -0,0 +1,71 @@
+package cryptopals
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"slices"
+)
+
+// maxSecretPrefixKeySize is the longest key a SecretPrefixMAC uses, and so
+// the longest one attacks on it try.
+const maxSecretPrefixKeySize = 64
+
+// A SecretPrefixMAC authenticates messages as hash(key || message). This is
+// insecure for Merkle-Damgård hashes such as SHA-1 and SHA-256, since anyone
+// who knows a tag can extend the message. See ForgeSecretPrefixMAC.
+type SecretPrefixMAC struct {
+	newHash func() hash.Hash
+	key     []byte
+	o       *options
+}
+
+// NewSecretPrefixMAC returns a SecretPrefixMAC using newHash, under a random
+// key of a random length from 1 to 64 bytes.
+func NewSecretPrefixMAC(newHash func() hash.Hash, opts ...Option) *SecretPrefixMAC {
+	return &SecretPrefixMAC{
+		newHash: newHash,
+		key:     randBytes(1 + randInt64(maxSecretPrefixKeySize)),
+		o:       newOptions(opts),
+	}
+}
+
+// Sign returns the tag hash(key || msg).
+func (m *SecretPrefixMAC) Sign(msg []byte) []byte {
+	h := m.newHash()
+	h.Write(slices.Concat(m.key, msg))
+	return h.Sum(nil)
+}
+
+// Verify reports whether tag is the tag for msg.
+func (m *SecretPrefixMAC) Verify(msg, tag []byte) bool {
+	ok := hmac.Equal(tag, m.Sign(msg))
+	m.o.debug("tag checked", "msg_len", len(msg), "valid", ok)
+	return ok
+}
+
+// ForgeSecretPrefixMAC forges a message ending in suffix, and a tag for it,
+// from msg and its secret-prefix tag under h. It tries Extend with each key
+// length from 0 to 64 bytes until verify accepts the forgery.
+//
+// It returns an error if verify rejects every forgery. Use WithLogger to see
+// the key length that works.
+func ForgeSecretPrefixMAC(h ResumableHash, msg, tag, suffix []byte, verify func(msg, tag []byte) bool, opts ...Option) (forged, forgedTag []byte, err error) {
+	o := newOptions(opts)
+
+	for keyLen := range maxSecretPrefixKeySize + 1 {
+		forged, forgedTag := Extend(h, tag, msg, suffix, keyLen)
+		if verify(forged, forgedTag) {
+			o.debug("found key length", "key_len", keyLen)
+			return forged, forgedTag, nil
+		}
+	}
+	return nil, nil, errors.New("no key length worked")
+}
+
+// ForgeSHA256SecretPrefixMAC forges a message ending in suffix, and a tag
+// for it, from msg and its SHA-256 secret-prefix tag. It's
+// ForgeSecretPrefixMAC with SHA256.
+func ForgeSHA256SecretPrefixMAC(msg, tag, suffix []byte, verify func(msg, tag []byte) bool, opts ...Option) (forged, forgedTag []byte, err error) {
+	return ForgeSecretPrefixMAC(sha256MD, msg, tag, suffix, verify, opts...)
+}
@@ -0,0 +1,86 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
+	"testing"
+)
+
+func TestSecretPrefixMAC(t *testing.T) {
+	m := NewSecretPrefixMAC(sha256.New)
+	msg := []byte("hello")
+
+	tag := m.Sign(msg)
+	if !m.Verify(msg, tag) {
+		t.Error("valid tag rejected")
+	}
+	if m.Verify([]byte("hellp"), tag) {
+		t.Error("tag for another message accepted")
+	}
+	if m.Verify(msg, tag[1:]) {
+		t.Error("truncated tag accepted")
+	}
+}
+
+func TestForgeSecretPrefixMAC(t *testing.T) {
+	msg := []byte("comment1=cooking%20MCs;userdata=foo;comment2=%20like%20a%20pound%20of%20bacon")
+	suffix := []byte(";admin=true")
+
+	// The forgery works whether the MAC uses these hashes or the standard
+	// ones.
+	cases := []struct {
+		name    string
+		h       ResumableHash
+		newHash func() hash.Hash
+	}{
+		{"SHA-1", SHA1(), NewSHA1},
+		{"SHA-256", SHA256(), NewSHA256},
+		{"MD4", MD4(), NewMD4},
+		{"MD5", MD5(), NewMD5},
+		{"crypto/sha1", SHA1(), sha1.New},
+		{"crypto/sha256", SHA256(), sha256.New},
+		{"crypto/md5", MD5(), md5.New},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for range 5 {
+				m := NewSecretPrefixMAC(tc.newHash)
+
+				forged, tag, err := ForgeSecretPrefixMAC(tc.h, msg, m.Sign(msg), suffix, m.Verify)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.HasPrefix(forged, msg) || !bytes.HasSuffix(forged, suffix) {
+					t.Errorf("bad forged message %q", forged)
+				}
+				if !m.Verify(forged, tag) {
+					t.Error("forged tag doesn't verify")
+				}
+			}
+		})
+	}
+}
+
+func TestForgeSecretPrefixMACFails(t *testing.T) {
+	reject := func(msg, tag []byte) bool { return false }
+	if _, _, err := ForgeSecretPrefixMAC(SHA256(), []byte("a"), make([]byte, 32), []byte("b"), reject); err == nil {
+		t.Error("no error")
+	}
+}
+
+func TestForgeSHA256SecretPrefixMAC(t *testing.T) {
+	msg := []byte("user=alice")
+	m := NewSecretPrefixMAC(sha256.New)
+
+	forged, tag, err := ForgeSHA256SecretPrefixMAC(msg, m.Sign(msg), []byte("&admin=1"), m.Verify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Verify(forged, tag) {
+		t.Error("forged tag doesn't verify")
+	}
+}
@@ -0,0 +1,251 @@
+package cryptopals
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"hash"
+	"slices"
+)
+
+// A LengthEncoding is how a Merkle-Damgård hash pads its input.
+type LengthEncoding int
+
+const (
+	// BigEndianLength pads with a 0x80 byte, zeros, and the message length
+	// in bits as a big-endian uint64, as SHA-1 and SHA-256 do.
+	BigEndianLength LengthEncoding = iota
+
+	// LittleEndianLength is like BigEndianLength, but with a little-endian
+	// length, as MD4 and MD5 do.
+	LittleEndianLength
+
+	// ZeroPadding pads with zeros to a whole block, without the length. Such
+	// hashes are trivially extendable, since a message and its padded form
+	// hash the same.
+	ZeroPadding
+)
+
+// A MerkleDamgard is a hash built by the Merkle-Damgård construction: the
+// padded message is split into blocks, and each block is mixed into the state
+// with a compression function. The final state is the digest.
+//
+// Attacks written against a MerkleDamgard work on any hash it describes.
+type MerkleDamgard struct {
+	// BlockSize is the block size in bytes.
+	BlockSize int
+
+	// IV is the initial state. Its length is the digest size.
+	IV []byte
+
+	// Compress mixes one block into state, in place.
+	Compress func(state, block []byte)
+
+	// Length is how messages are padded.
+	Length LengthEncoding
+}
+
+// A ResumableHash is a hash that can continue from a digest, such as a
+// MerkleDamgard. Hashes like these are open to length extension. See Extend.
+type ResumableHash interface {
+	// New returns a hash.Hash computing the hash.
+	New() hash.Hash
+
+	// NewFromState returns a hash.Hash that continues from state, as if n
+	// bytes had already been hashed.
+	NewFromState(state []byte, n uint64) hash.Hash
+
+	// Padding returns the bytes the hash appends to an n-byte message.
+	Padding(n uint64) []byte
+}
+
+// SHA1 returns SHA-1 as a ResumableHash, implemented from its specification.
+func SHA1() ResumableHash { return sha1MD.clone() }
+
+// SHA256 returns SHA-256 as a ResumableHash, implemented from its
+// specification.
+func SHA256() ResumableHash { return sha256MD.clone() }
+
+// MD4 returns MD4 as a ResumableHash, implemented from its specification.
+func MD4() ResumableHash { return md4MD.clone() }
+
+// MD5 returns MD5 as a ResumableHash, implemented from its specification.
+func MD5() ResumableHash { return md5MD.clone() }
+
+// Extend performs a length extension on a secret-prefix tag. Given tag =
+// hash(key || message) for some keyLen-byte key, it returns message || glue ||
+// suffix and its tag, where glue is the padding h added to key || message. It
+// doesn't need the key.
+//
+// It panics if tag isn't a digest of h.
+func Extend(h ResumableHash, tag, message, suffix []byte, keyLen int) (forged, forgedTag []byte) {
+	n := uint64(keyLen + len(message))
+	glue := h.Padding(n)
+
+	d := h.NewFromState(tag, n+uint64(len(glue)))
+	d.Write(suffix)
+
+	return slices.Concat(message, glue, suffix), d.Sum(nil)
+}
+
+// encodeWords returns the words w encoded in order.
+func encodeWords(order binary.AppendByteOrder, w ...uint32) []byte {
+	b := make([]byte, 0, 4*len(w))
+	for _, v := range w {
+		b = order.AppendUint32(b, v)
+	}
+	return b
+}
+
+// decodeWords returns the 32-bit words encoded in b, whose length must be a
+// multiple of 4.
+func decodeWords(order binary.ByteOrder, b []byte) []uint32 {
+	w := make([]uint32, len(b)/4)
+	for i := range w {
+		w[i] = order.Uint32(b[4*i:])
+	}
+	return w
+}
+
+// New returns a hash.Hash computing the hash.
+func (m *MerkleDamgard) New() hash.Hash {
+	return m.NewFromState(m.IV, 0)
+}
+
+// NewFromState returns a hash.Hash that continues from state, as if n bytes
+// had already been hashed. Since the digest is the final state, this resumes
+// hashing from a digest.
+//
+// It panics if state isn't len(m.IV) bytes, or if n isn't a multiple of the
+// block size, since the state only changes at block boundaries. Reset returns
+// to this state.
+func (m *MerkleDamgard) NewFromState(state []byte, n uint64) hash.Hash {
+	if len(state) != len(m.IV) {
+		panic("invalid state length")
+	}
+	if n%uint64(m.BlockSize) != 0 {
+		panic("length not a multiple of the block size")
+	}
+	d := &mdDigest{
+		m:       m,
+		h:       make([]byte, len(state)),
+		x:       make([]byte, m.BlockSize),
+		start:   append([]byte(nil), state...),
+		lenBase: n,
+	}
+	d.Reset()
+	return d
+}
+
+// clone returns a copy of m, so callers can't change the hashes this package
+// defines.
+func (m *MerkleDamgard) clone() *MerkleDamgard {
+	c := *m
+	c.IV = slices.Clone(m.IV)
+	return &c
+}
+
+// Padding returns the bytes the hash appends to an n-byte message, so that
+// the padded message is a whole number of blocks.
+func (m *MerkleDamgard) Padding(n uint64) []byte {
+	bs := uint64(m.BlockSize)
+	if m.Length == ZeroPadding {
+		return make([]byte, (bs-n%bs)%bs)
+	}
+
+	// One 0x80 byte and an 8-byte length, with zeros to fill the last block.
+	pad := make([]byte, 1+(2*bs-9-n%bs)%bs+8)
+	pad[0] = 0x80
+	if m.Length == LittleEndianLength {
+		binary.LittleEndian.PutUint64(pad[len(pad)-8:], n*8)
+	} else {
+		binary.BigEndian.PutUint64(pad[len(pad)-8:], n*8)
+	}
+	return pad
+}
+
+// mdDigest is a running MerkleDamgard hash.
+type mdDigest struct {
+	m   *MerkleDamgard
+	h   []byte
+	x   []byte // Buffered input that doesn't fill a block yet.
+	nx  int
+	len uint64 // Bytes hashed so far, including any before the state was set.
+
+	start   []byte // State to reset to.
+	lenBase uint64
+}
+
+func (d *mdDigest) Reset() {
+	copy(d.h, d.start)
+	d.nx = 0
+	d.len = d.lenBase
+}
+
+func (d *mdDigest) Size() int { return len(d.h) }
+
+func (d *mdDigest) BlockSize() int { return d.m.BlockSize }
+
+func (d *mdDigest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		p = p[c:]
+		if d.nx < len(d.x) {
+			return n, nil
+		}
+		d.m.Compress(d.h, d.x)
+		d.nx = 0
+	}
+
+	for len(p) >= len(d.x) {
+		d.m.Compress(d.h, p[:len(d.x)])
+		p = p[len(d.x):]
+	}
+
+	d.nx = copy(d.x, p)
+	return n, nil
+}
+
+// Sum appends the digest of the data written so far to b. It doesn't change
+// the state.
+func (d *mdDigest) Sum(b []byte) []byte {
+	c := *d
+	c.h = append([]byte(nil), d.h...)
+	c.x = append([]byte(nil), d.x...)
+	c.Write(d.m.Padding(c.len))
+	return append(b, c.h...)
+}
+
+// NewToyMD returns the weak Merkle-Damgård hash from challenges 52 to 54,
+// with a digest of size bytes. Its compression function encrypts the block
+// with AES-128, keyed by the state padded with zeros, and keeps the first
+// size bytes. Its blocks are 16 bytes, and it pads like SHA-256.
+//
+// Digests of 2 or 3 bytes make collision attacks cheap to run. It panics if
+// size isn't from 1 to 16.
+func NewToyMD(size int) *MerkleDamgard {
+	if size < 1 || size > aes.BlockSize {
+		panic("invalid digest size")
+	}
+
+	iv := make([]byte, size)
+	for i := range iv {
+		iv[i] = byte(0x12 + 0x22*i)
+	}
+
+	return &MerkleDamgard{
+		BlockSize: aes.BlockSize,
+		IV:        iv,
+		Compress: func(state, block []byte) {
+			var key, out [aes.BlockSize]byte
+			copy(key[:], state)
+			c, _ := aes.NewCipher(key[:])
+			c.Encrypt(out[:], block)
+			copy(state, out[:])
+		},
+		Length: BigEndianLength,
+	}
+}
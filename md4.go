@@ -0,0 +1,64 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// md4MD is MD4 as specified in RFC 1320.
+var md4MD = &MerkleDamgard{
+	BlockSize: 64,
+	IV:        encodeWords(binary.LittleEndian, 0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476),
+	Compress:  md4Block,
+	Length:    LittleEndianLength,
+}
+
+// md4Rounds holds the order MD4 reads message words in, and its rotation
+// amounts, for each round.
+var md4Rounds = [3]struct {
+	order [16]int
+	s     [4]int
+	k     uint32
+}{
+	{[16]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, [4]int{3, 7, 11, 19}, 0},
+	{[16]int{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15}, [4]int{3, 5, 9, 13}, 0x5a827999},
+	{[16]int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}, [4]int{3, 9, 11, 15}, 0x6ed9eba1},
+}
+
+// NewMD4 returns a new MD4 hash.Hash. The standard library has no MD4, and
+// its state can be set through MD4().
+func NewMD4() hash.Hash {
+	return md4MD.New()
+}
+
+// md4Block runs the compression function on one 64-byte block, updating the
+// registers encoded in state.
+func md4Block(state, p []byte) {
+	h := decodeWords(binary.LittleEndian, state)
+	x := decodeWords(binary.LittleEndian, p)
+
+	a, b, c, d := h[0], h[1], h[2], h[3]
+	for r, round := range md4Rounds {
+		for i, j := range round.order {
+			var f uint32
+			switch r {
+			case 0:
+				f = b&c | ^b&d
+			case 1:
+				f = b&c | b&d | c&d
+			default:
+				f = b ^ c ^ d
+			}
+			// The registers rotate after each step, so the next step
+			// updates the one before.
+			a, b, c, d = d, bits.RotateLeft32(a+f+x[j]+round.k, round.s[i%4]), b, c
+		}
+	}
+
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+	copy(state, encodeWords(binary.LittleEndian, h...))
+}
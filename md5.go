@@ -0,0 +1,67 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"hash"
+	"math"
+	"math/bits"
+)
+
+// md5MD is MD5 as specified in RFC 1321.
+var md5MD = &MerkleDamgard{
+	BlockSize: 64,
+	IV:        encodeWords(binary.LittleEndian, 0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476),
+	Compress:  md5Block,
+	Length:    LittleEndianLength,
+}
+
+// md5K holds the MD5 round constants, the first 32 bits of the fractional
+// parts of |sin(i)| for i from 1 to 64.
+var md5K = func() (k [64]uint32) {
+	for i := range k {
+		k[i] = uint32(math.Abs(math.Sin(float64(i+1))) * (1 << 32))
+	}
+	return k
+}()
+
+// md5S holds the MD5 rotation amounts for each round.
+var md5S = [4][4]int{{7, 12, 17, 22}, {5, 9, 14, 20}, {4, 11, 16, 23}, {6, 10, 15, 21}}
+
+// NewMD5 returns a new MD5 hash.Hash, implemented from the specification
+// rather than with crypto/md5, so its state can be set through MD5.
+func NewMD5() hash.Hash {
+	return md5MD.New()
+}
+
+// md5Block runs the compression function on one 64-byte block, updating the
+// registers encoded in state.
+func md5Block(state, p []byte) {
+	h := decodeWords(binary.LittleEndian, state)
+	x := decodeWords(binary.LittleEndian, p)
+
+	a, b, c, d := h[0], h[1], h[2], h[3]
+	for i := range 64 {
+		var (
+			f uint32
+			g int
+		)
+		switch {
+		case i < 16:
+			f, g = b&c|^b&d, i
+		case i < 32:
+			f, g = d&b|^d&c, (5*i+1)%16
+		case i < 48:
+			f, g = b^c^d, (3*i+5)%16
+		default:
+			f, g = c^(b|^d), 7*i%16
+		}
+		f += a + md5K[i] + x[g]
+		a, d, c, b = d, c, b, b+bits.RotateLeft32(f, md5S[i/16][i%4])
+	}
+
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+	copy(state, encodeWords(binary.LittleEndian, h...))
+}
@@ -0,0 +1,21 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/md5"
+	"testing"
+)
+
+func TestMD5(t *testing.T) {
+	for n := range 300 {
+		msg := englishText[:n]
+
+		h := NewMD5()
+		h.Write(msg)
+
+		want := md5.Sum(msg)
+		if got := h.Sum(nil); !bytes.Equal(want[:], got) {
+			t.Errorf("%d bytes: want %x, got %x", n, want, got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+func TestMerkleDamgardPadding(t *testing.T) {
+	for _, bs := range []int{16, 64} {
+		for _, e := range []LengthEncoding{BigEndianLength, LittleEndianLength} {
+			m := &MerkleDamgard{BlockSize: bs, Length: e}
+			for n := range uint64(3 * bs) {
+				pad := m.Padding(n)
+				if (n+uint64(len(pad)))%uint64(bs) != 0 {
+					t.Fatalf("block size %d, %d bytes: padded length isn't whole blocks", bs, n)
+				}
+				if len(pad) < 9 || len(pad) > bs+8 || pad[0] != 0x80 {
+					t.Fatalf("block size %d, %d bytes: bad padding %x", bs, n, pad)
+				}
+
+				var bits uint64
+				if e == LittleEndianLength {
+					bits = binary.LittleEndian.Uint64(pad[len(pad)-8:])
+				} else {
+					bits = binary.BigEndian.Uint64(pad[len(pad)-8:])
+				}
+				if bits != 8*n {
+					t.Fatalf("block size %d, %d bytes: encoded length %d", bs, n, bits)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleDamgardZeroPadding(t *testing.T) {
+	m := &MerkleDamgard{BlockSize: 16, Length: ZeroPadding}
+	for n := range uint64(40) {
+		pad := m.Padding(n)
+		if (n+uint64(len(pad)))%16 != 0 || len(pad) >= 16 {
+			t.Errorf("%d bytes: %d bytes of padding", n, len(pad))
+		}
+		if !bytes.Equal(pad, make([]byte, len(pad))) {
+			t.Errorf("%d bytes: padding %x isn't zeros", n, pad)
+		}
+	}
+}
+
+func TestToyMD(t *testing.T) {
+	m := NewToyMD(2)
+	msg := englishText[:100]
+
+	whole := m.New()
+	whole.Write(msg)
+	want := whole.Sum(nil)
+	if len(want) != 2 {
+		t.Fatalf("digest length %d", len(want))
+	}
+
+	// Byte-at-a-time writes hash the same, and Sum doesn't change the state.
+	h := m.New()
+	for _, c := range msg {
+		h.Write([]byte{c})
+		h.Sum(nil)
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+
+	h.Reset()
+	h.Write(msg)
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("after Reset: want %x, got %x", want, got)
+	}
+}
+
+func TestMerkleDamgardFromState(t *testing.T) {
+	m := NewToyMD(3)
+	msg := englishText[:100]
+
+	whole := m.New()
+	whole.Write(msg)
+	want := whole.Sum(nil)
+
+	// Hash the first four blocks by running the compression function
+	// directly.
+	state := append([]byte(nil), m.IV...)
+	for block := range Blocks(msg[:64], m.BlockSize) {
+		m.Compress(state, block)
+	}
+
+	h := m.NewFromState(state, 64)
+	h.Write(msg[64:])
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+}
+
+func TestMerkleDamgardFromStatePanics(t *testing.T) {
+	m := NewToyMD(2)
+	tests := map[string]func(){
+		"bad state":  func() { m.NewFromState(make([]byte, 3), 0) },
+		"bad length": func() { m.NewFromState(m.IV, 5) },
+		"bad size":   func() { NewToyMD(17) },
+	}
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func TestExtend(t *testing.T) {
+	key := []byte("YELLOW SUBMARINE")
+	msg := []byte("user=alice")
+	suffix := []byte("&admin=1")
+
+	for name, h := range map[string]ResumableHash{"SHA-1": SHA1(), "SHA-256": SHA256(), "MD4": MD4(), "MD5": MD5(), "toy": NewToyMD(3)} {
+		t.Run(name, func(t *testing.T) {
+			d := h.New()
+			d.Write(slices.Concat(key, msg))
+
+			forged, tag := Extend(h, d.Sum(nil), msg, suffix, len(key))
+
+			d.Reset()
+			d.Write(slices.Concat(key, forged))
+			if want := d.Sum(nil); !bytes.Equal(tag, want) {
+				t.Errorf("want %x, got %x", want, tag)
+			}
+		})
+	}
+}
+
+func TestSHA1IsACopy(t *testing.T) {
+	SHA1().(*MerkleDamgard).IV[0] ^= 1
+	if want := sha1.Sum(nil); !bytes.Equal(NewSHA1().Sum(nil), want[:]) {
+		t.Error("changing SHA1() changed SHA-1")
+	}
+}
@@ -0,0 +1,299 @@
+package cryptopals
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// WithSpillDir sets the directory a meet-in-the-middle search writes its
+// temporary files to when its tables don't fit in memory. The default is
+// os.TempDir.
+func WithSpillDir(dir string) Option {
+	return func(o *options) {
+		o.spillDir = dir
+	}
+}
+
+// A MeetInTheMiddle is a meet-in-the-middle search: for forward candidates i
+// and backward candidates j, it finds a pair whose values Forward(i) and
+// Backward(j) are equal and that Match confirms. It computes each value
+// about once, so the cost is NumForward + NumBackward, rather than their
+// product.
+//
+// RecoverDoubleKeys uses it with keys as candidates and the middle value of
+// a double encryption as the value.
+type MeetInTheMiddle struct {
+	NumForward, NumBackward uint64
+
+	// Forward and Backward return the values of candidates. The search
+	// doesn't keep the slices they return past the next call.
+	Forward, Backward func(i uint64) []byte
+
+	// Match reports whether a pair with equal values is the one wanted, as
+	// when other known data has to fit too. If it's nil, every pair with
+	// equal values is.
+	Match func(i, j uint64) bool
+}
+
+// MITMStats describes the work a meet-in-the-middle search did.
+type MITMStats struct {
+	TableEntries int // Most entries in memory at once.
+	TableBytes   int // Bytes those entries take, not counting the map's overhead.
+	SpilledBytes int // Bytes written to disk.
+	Lookups      int // Lookups into the table.
+	Candidates   int // Pairs with equal values passed to Match.
+}
+
+// errNoMITMMatch means no pair of candidates matched.
+var errNoMITMMatch = errors.New("no match")
+
+// mitmEntrySize is the size in bytes of a table entry: a value's hash and
+// its candidate.
+const mitmEntrySize = 16
+
+// Search returns the first pair it finds, and how much work it did.
+//
+// It keeps a table of the forward values' hashes in memory, and looks up
+// each backward value in it. With WithMaxEntries smaller than NumForward,
+// it instead writes both sides' hashes to partitions on disk, see
+// WithSpillDir, and joins them a partition at a time, each with a table of
+// about half the limit. It writes at most 256 partitions at once, and splits
+// any that are still too big again, so a small limit costs more passes over
+// the disk rather than more open files. The pair it finds may then differ,
+// when more than one matches.
+//
+// It returns an error if no pair matches, or if spilling fails.
+func (m *MeetInTheMiddle) Search(opts ...Option) (i, j uint64, stats MITMStats, err error) {
+	o := newOptions(opts)
+	seed := maphash.MakeSeed()
+
+	if o.maxEntries <= 0 || m.NumForward <= uint64(o.maxEntries) {
+		table := make(map[uint64][]uint64)
+		for i := range m.NumForward {
+			h := maphash.Bytes(seed, m.Forward(i))
+			table[h] = append(table[h], i)
+		}
+		stats.TableEntries = int(m.NumForward)
+		stats.TableBytes = stats.TableEntries * mitmEntrySize
+
+		for j := range m.NumBackward {
+			v := bytes.Clone(m.Backward(j))
+			stats.Lookups++
+			if i, ok := m.join(table[maphash.Bytes(seed, v)], j, v, &stats); ok {
+				return i, j, stats, nil
+			}
+		}
+		return 0, 0, stats, errNoMITMMatch
+	}
+
+	i, j, err = m.spill(seed, o, &stats)
+	return i, j, stats, err
+}
+
+// join checks forward candidates whose hashes equal that of v, the value
+// of backward candidate j, and returns the first that matches.
+func (m *MeetInTheMiddle) join(is []uint64, j uint64, v []byte, stats *MITMStats) (uint64, bool) {
+	for _, i := range is {
+		// Hashes can collide, so compare the values.
+		if !bytes.Equal(m.Forward(i), v) {
+			continue
+		}
+		stats.Candidates++
+		if m.Match == nil || m.Match(i, j) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// maxMITMPartitions is the most partition files a spilled search writes at
+// once, so it stays well within limits on open files.
+const maxMITMPartitions = 256
+
+// spill runs the search through partitions on disk. Both sides go to the
+// partition picked by their hash, so matches are always in the same one. A
+// partition that is still over the limit is split again, by other digits of
+// the hash, until it fits or its hashes are all equal.
+func (m *MeetInTheMiddle) spill(seed maphash.Seed, o *options, stats *MITMStats) (i, j uint64, err error) {
+	limit := uint64(o.maxEntries)
+
+	// fanout returns how many partitions to split n entries into.
+	// Partitions of about half the limit leave room for uneven ones.
+	fanout := func(n uint64) uint64 {
+		return min((2*n+limit-1)/limit, maxMITMPartitions)
+	}
+
+	dir, err := os.MkdirTemp(o.spillDir, "mitm")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	partName := func(name string, p uint64) string {
+		return fmt.Sprintf("%s.%d", name, p)
+	}
+
+	// split writes the entries that each yields to parts files, picking
+	// digit (h/div)%parts of each hash, and returns how many went to each.
+	split := func(name string, parts, div uint64, each func(f func(h, i uint64) error) error) ([]uint64, error) {
+		files := make([]*os.File, parts)
+		ws := make([]*bufio.Writer, parts)
+		for p := range files {
+			f, err := os.Create(filepath.Join(dir, partName(name, uint64(p))))
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			files[p], ws[p] = f, bufio.NewWriter(f)
+		}
+
+		counts := make([]uint64, parts)
+		var rec [mitmEntrySize]byte
+		err := each(func(h, i uint64) error {
+			p := h / div % parts
+			binary.LittleEndian.PutUint64(rec[:], h)
+			binary.LittleEndian.PutUint64(rec[8:], i)
+			if _, err := ws[p].Write(rec[:]); err != nil {
+				return err
+			}
+			counts[p]++
+			stats.SpilledBytes += mitmEntrySize
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for p, w := range ws {
+			if err := w.Flush(); err != nil {
+				return nil, err
+			}
+			if err := files[p].Close(); err != nil {
+				return nil, err
+			}
+		}
+		return counts, nil
+	}
+
+	// values yields the hashes of n candidates' values.
+	values := func(n uint64, value func(uint64) []byte) func(f func(h, i uint64) error) error {
+		return func(f func(h, i uint64) error) error {
+			for i := range n {
+				if err := f(maphash.Bytes(seed, value(i)), i); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	// read calls f with each entry in a partition file, until f returns
+	// false or an error.
+	read := func(name string, f func(h, i uint64) (bool, error)) error {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		r := bufio.NewReader(file)
+		var rec [mitmEntrySize]byte
+		for {
+			if _, err := io.ReadFull(r, rec[:]); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			ok, err := f(binary.LittleEndian.Uint64(rec[:]), binary.LittleEndian.Uint64(rec[8:]))
+			if !ok || err != nil {
+				return err
+			}
+		}
+	}
+
+	// entries yields the entries in a partition file.
+	entries := func(name string) func(f func(h, i uint64) error) error {
+		return func(f func(h, i uint64) error) error {
+			return read(name, func(h, i uint64) (bool, error) {
+				return true, f(h, i)
+			})
+		}
+	}
+
+	// join searches the partition in files fwd and bwd, which has n forward
+	// entries and was picked by the hash digits below div.
+	var join func(fwd, bwd string, n, div uint64) (bool, error)
+	join = func(fwd, bwd string, n, div uint64) (bool, error) {
+		if parts := fanout(n); n > limit && div <= math.MaxUint64/parts {
+			counts, err := split(fwd, parts, div, entries(fwd))
+			if err != nil {
+				return false, err
+			}
+			if _, err := split(bwd, parts, div, entries(bwd)); err != nil {
+				return false, err
+			}
+			os.Remove(filepath.Join(dir, fwd))
+			os.Remove(filepath.Join(dir, bwd))
+
+			for p := range parts {
+				found, err := join(partName(fwd, p), partName(bwd, p), counts[p], div*parts)
+				if found || err != nil {
+					return found, err
+				}
+			}
+			return false, nil
+		}
+
+		table := make(map[uint64][]uint64)
+		err := read(fwd, func(h, i uint64) (bool, error) {
+			table[h] = append(table[h], i)
+			return true, nil
+		})
+		if err != nil {
+			return false, err
+		}
+		stats.TableEntries = max(stats.TableEntries, int(n))
+		stats.TableBytes = stats.TableEntries * mitmEntrySize
+
+		var found bool
+		err = read(bwd, func(h, jj uint64) (bool, error) {
+			stats.Lookups++
+			is := table[h]
+			if len(is) == 0 {
+				return true, nil
+			}
+			if ii, ok := m.join(is, jj, bytes.Clone(m.Backward(jj)), stats); ok {
+				i, j, found = ii, jj, true
+				return false, nil
+			}
+			return true, nil
+		})
+		return found, err
+	}
+
+	parts := fanout(m.NumForward)
+	counts, err := split("f", parts, 1, values(m.NumForward, m.Forward))
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := split("b", parts, 1, values(m.NumBackward, m.Backward)); err != nil {
+		return 0, 0, err
+	}
+	for p := range parts {
+		found, err := join(partName("f", p), partName("b", p), counts[p], parts)
+		if err != nil {
+			return 0, 0, err
+		}
+		if found {
+			return i, j, nil
+		}
+	}
+	return 0, 0, errNoMITMMatch
+}
@@ -0,0 +1,105 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+)
+
+// mitmSquares is a search for i^2 mod 2^32 equal to j^3 + c mod 2^32.
+func mitmSquares(n uint64, c uint32) *MeetInTheMiddle {
+	fwd, bwd := make([]byte, 4), make([]byte, 4)
+	return &MeetInTheMiddle{
+		NumForward:  n,
+		NumBackward: n,
+		Forward: func(i uint64) []byte {
+			binary.BigEndian.PutUint32(fwd, uint32(i*i))
+			return fwd
+		},
+		Backward: func(j uint64) []byte {
+			binary.BigEndian.PutUint32(bwd, uint32(j*j*j)+c)
+			return bwd
+		},
+	}
+}
+
+func TestMeetInTheMiddle(t *testing.T) {
+	// 1000^2 = 100^3, and 1234^2 = 56^3 + 1347140. Match rules out 0 and 1.
+	tests := []struct {
+		c    uint32
+		want func(i, j uint64) bool
+	}{
+		{0, func(i, j uint64) bool { return i*i == j*j*j }},
+		{1234*1234 - 56*56*56, func(i, j uint64) bool { return i*i == j*j*j+1234*1234-56*56*56 }},
+	}
+	for _, tt := range tests {
+		for _, opts := range [][]Option{nil, {WithMaxEntries(100)}} {
+			m := mitmSquares(5000, tt.c)
+			m.Match = func(i, j uint64) bool { return i > 1 }
+
+			i, j, stats, err := m.Search(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want(i, j) || i <= 1 {
+				t.Errorf("c = %d, %d options: got %d, %d", tt.c, len(opts), i, j)
+			}
+			if opts == nil && (stats.TableEntries != 5000 || stats.SpilledBytes != 0) {
+				t.Errorf("in memory: stats %+v", stats)
+			}
+			if opts != nil && (stats.TableEntries > 100 || stats.SpilledBytes != 2*5000*mitmEntrySize) {
+				t.Errorf("spilled: stats %+v", stats)
+			}
+		}
+	}
+}
+
+func TestMeetInTheMiddleSpillDir(t *testing.T) {
+	dir := t.TempDir()
+	m := mitmSquares(1000, 0)
+	m.Match = func(i, j uint64) bool { return i > 1 }
+	if _, _, _, err := m.Search(WithMaxEntries(50), WithSpillDir(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The temporary files are gone afterwards.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d entries left in the spill directory", len(entries))
+	}
+
+	if _, _, _, err := m.Search(WithMaxEntries(50), WithSpillDir(dir+"/missing")); err == nil {
+		t.Error("missing spill directory: no error")
+	}
+}
+
+func TestMeetInTheMiddleManyPartitions(t *testing.T) {
+	// 20000 entries with a limit of 10 would take 4000 partitions at once,
+	// so they're split again instead.
+	m := mitmSquares(20000, 0)
+	m.Match = func(i, j uint64) bool { return i > 1 }
+
+	i, j, stats, err := m.Search(WithMaxEntries(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint32(i*i) != uint32(j*j*j) || i <= 1 {
+		t.Errorf("got %d, %d", i, j)
+	}
+	if stats.TableEntries > 10 || stats.SpilledBytes <= 2*20000*mitmEntrySize {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestMeetInTheMiddleNoMatch(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithMaxEntries(10)}} {
+		m := mitmSquares(100, 0)
+		m.Match = func(i, j uint64) bool { return false }
+		if _, _, _, err := m.Search(opts...); err != errNoMITMMatch {
+			t.Errorf("%d options: got error %v", len(opts), err)
+		}
+	}
+}
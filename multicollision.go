@@ -0,0 +1,90 @@
+package cryptopals
+
+import (
+	"iter"
+	"slices"
+)
+
+// A Multicollision is 2^k messages of k blocks each with the same
+// Merkle-Damgård digest, found as in Joux's attack. At each block, either of
+// a pair of colliding blocks leads to the same state, so any choice of one
+// block from each pair does too.
+type Multicollision struct {
+	// Pairs holds the two colliding blocks at each position.
+	Pairs [][2][]byte
+
+	// States holds the state before each position and, last, the state every
+	// message leads to. States[0] is the IV.
+	States [][]byte
+}
+
+// FindMulticollision returns a 2^k-way multicollision under h, found with k
+// calls to FindCollision on the compression function. That costs about k
+// times a single collision, rather than 2^k times. It passes opts to
+// FindCollision, with a seed for each call drawn from the WithSeed seed, so
+// no two calls search the same candidates.
+//
+// The messages are all the same length, so they pad the same and have the
+// same digest. It panics if k isn't from 1 to 63, or if h has blocks under
+// 16 bytes.
+func FindMulticollision(h *MerkleDamgard, k int, opts ...Option) *Multicollision {
+	if k < 1 || k > 63 {
+		panic("invalid k")
+	}
+	if h.BlockSize < collisionInputSize {
+		panic("block size too small")
+	}
+
+	o := newOptions(opts)
+	seeds := o.newRand()
+
+	// FindCollision's inputs are padded with zeros to whole blocks.
+	block := func(in []byte) []byte {
+		b := make([]byte, h.BlockSize)
+		copy(b, in)
+		return b
+	}
+
+	m := &Multicollision{States: [][]byte{slices.Clone(h.IV)}}
+	for i := range k {
+		state := m.States[i]
+		compress := func(in []byte) []byte {
+			s := slices.Clone(state)
+			h.Compress(s, block(in))
+			return s
+		}
+
+		stageOpts := append(opts[:len(opts):len(opts)], WithSeed(seeds.Uint64()))
+		a, b := FindCollision(compress, 8*len(state), stageOpts...)
+		m.Pairs = append(m.Pairs, [2][]byte{block(a), block(b)})
+		m.States = append(m.States, compress(a))
+		o.debug("found colliding pair", "pair", i+1, "of", k)
+	}
+	return m
+}
+
+// Len returns the number of messages, 2^k.
+func (m *Multicollision) Len() int {
+	return 1 << len(m.Pairs)
+}
+
+// Message returns the i-th message, with bit j of i choosing the block at
+// position j.
+func (m *Multicollision) Message(i uint64) []byte {
+	var msg []byte
+	for j, pair := range m.Pairs {
+		msg = append(msg, pair[i>>j&1]...)
+	}
+	return msg
+}
+
+// Messages returns an iterator over the messages, in the order of Message.
+func (m *Multicollision) Messages() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for i := uint64(0); i>>len(m.Pairs) == 0; i++ {
+			if !yield(m.Message(i)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFindMulticollision(t *testing.T) {
+	h := NewToyMD(2)
+	m := FindMulticollision(h, 5, WithSeed(1))
+
+	if m.Len() != 32 {
+		t.Fatalf("Len() = %d, want 32", m.Len())
+	}
+
+	d := h.New()
+	d.Write(m.Message(0))
+	want := d.Sum(nil)
+
+	seen := make(map[string]bool)
+	for msg := range m.Messages() {
+		if len(msg) != 5*h.BlockSize {
+			t.Fatalf("message is %d bytes", len(msg))
+		}
+		seen[string(msg)] = true
+
+		d.Reset()
+		d.Write(msg)
+		if got := d.Sum(nil); !bytes.Equal(got, want) {
+			t.Errorf("message %x: digest %x, want %x", msg, got, want)
+		}
+	}
+	if len(seen) != 32 {
+		t.Errorf("%d distinct messages, want 32", len(seen))
+	}
+}
+
+func TestFindMulticollisionStates(t *testing.T) {
+	h := NewToyMD(3)
+	m := FindMulticollision(h, 2)
+
+	if !bytes.Equal(m.States[0], h.IV) {
+		t.Errorf("States[0] = %x, want the IV %x", m.States[0], h.IV)
+	}
+	for i, pair := range m.Pairs {
+		for _, block := range pair {
+			s := bytes.Clone(m.States[i])
+			h.Compress(s, block)
+			if !bytes.Equal(s, m.States[i+1]) {
+				t.Errorf("pair %d: block leads to %x, want %x", i, s, m.States[i+1])
+			}
+		}
+	}
+}
+
+func TestFindMulticollisionInvalidK(t *testing.T) {
+	for _, k := range []int{0, 64} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("FindMulticollision(h, %d) didn't panic", k)
+				}
+			}()
+			FindMulticollision(NewToyMD(2), k)
+		}()
+	}
+}
+
+func TestFindMulticollisionSeeded(t *testing.T) {
+	h := NewToyMD(2)
+	a := FindMulticollision(h, 3, WithSeed(7))
+	b := FindMulticollision(h, 3, WithSeed(7))
+	for i := range a.Pairs {
+		for j := range a.Pairs[i] {
+			if !bytes.Equal(a.Pairs[i][j], b.Pairs[i][j]) {
+				t.Fatalf("pair %d differs between runs with the same seed", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestOAEP(t *testing.T) {
+	k, err := GenerateRSAKey(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	std := &rsa.PublicKey{N: k.N, E: int(k.E.Int64())}
+	msg, label := []byte("attack at dawn"), []byte("orders")
+
+	ct, err := EncryptOAEP(sha256.New, &k.RSAPublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptOAEP(sha256.New, k, ct, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+	if _, err := DecryptOAEP(sha256.New, k, ct, []byte("other")); err == nil {
+		t.Error("decrypted under the wrong label")
+	}
+
+	// The standard library's ciphertexts decrypt too.
+	ct, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, std, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = DecryptOAEP(sha256.New, k, ct, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("standard library: got %q, want %q", got, msg)
+	}
+
+	// And the standard library decrypts ours.
+	priv := &rsa.PrivateKey{PublicKey: *std, D: k.D, Primes: []*big.Int{k.P, k.Q}}
+	priv.Precompute()
+	ct, err = EncryptOAEP(sha256.New, &k.RSAPublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = rsa.DecryptOAEP(sha256.New(), nil, priv, ct, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("to the standard library: got %q, want %q", got, msg)
+	}
+
+	if _, err := EncryptOAEP(sha256.New, &k.RSAPublicKey, make([]byte, k.Size()-2*32-1), nil); err == nil {
+		t.Error("encrypted an overlong message")
+	}
+}
+
+func TestRecoverMangerPlaintext(t *testing.T) {
+	k, err := GenerateRSAKey(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, label := []byte("that's why I found you don't play around"), []byte("manger")
+	ct, err := EncryptOAEP(sha256.New, &k.RSAPublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewMangerOracle(k)
+	var reports []Progress
+	got, err := RecoverMangerPlaintext(sha256.New, &k.RSAPublicKey, ct, label, o.Conforms, WithProgress(func(p Progress) {
+		reports = append(reports, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+	checkIntervalProgress(t, reports, k.Decrypt(new(big.Int).SetBytes(ct)))
+}
@@ -0,0 +1,65 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"slices"
+)
+
+// ofb implements output feedback mode.
+type ofb struct {
+	b    cipher.Block
+	out  []byte // Keystream for the current block, fed back into b.
+	used int    // Keystream bytes used so far.
+}
+
+func (x *ofb) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for len(src) > 0 {
+		if x.used == len(x.out) {
+			x.b.Encrypt(x.out, x.out)
+			x.used = 0
+		}
+
+		n := subtle.XORBytes(dst, src, x.out[x.used:])
+		dst, src = dst[n:], src[n:]
+		x.used += n
+	}
+}
+
+// NewOFB returns a cipher.Stream which encrypts or decrypts in output feedback
+// mode.
+func NewOFB(b cipher.Block, iv []byte) cipher.Stream {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		panic("invalid iv length")
+	}
+	return &ofb{b: b, out: slices.Clone(iv), used: bs}
+}
+
+// NewOFBFixedIVOracle returns an encryption oracle that encrypts every input
+// under AES-OFB with the same random key and IV, so every ciphertext is XORed
+// with the same keystream. The key is 16 bytes unless set with WithKeySize,
+// and WithCipher replaces AES. WithStreamMode(CTRStream) encrypts in CTR
+// mode instead, which reuses the keystream the same way.
+func NewOFBFixedIVOracle(opts ...Option) func([]byte) []byte {
+	o := newOptions(opts)
+
+	var (
+		block = o.newBlock(randBytes(int64(o.keySize)))
+		iv    = randBytes(int64(block.BlockSize()))
+		mode  = OFBStream
+	)
+	if o.streamMode != nil {
+		mode = *o.streamMode
+	}
+
+	return func(input []byte) []byte {
+		o.debug("oracle called", "input_len", len(input))
+
+		res := make([]byte, len(input))
+		NewStreamEncrypter(mode, block, iv, opts...).XORKeyStream(res, input)
+		return res
+	}
+}
@@ -0,0 +1,82 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+)
+
+func TestOFB(t *testing.T) {
+	// This test vector is from NIST SP 800-38A, appendix F.4.1.
+	block, err := aes.NewCipher(decodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iv := decodeHex(t, "000102030405060708090a0b0c0d0e0f")
+	pt := decodeHex(t, "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+	want := decodeHex(t, "3b3fd92eb72dad20333449f8e83cfb4a7789508d16918f03f53c52dac54ed8259740051e9c5fecf64344f7a82260edcc304c6528f659c77866a510d9c1d6ae5e")
+
+	got := make([]byte, len(pt))
+	NewOFB(block, iv).XORKeyStream(got, pt)
+	if !bytes.Equal(want, got) {
+		t.Errorf("encrypt: want %x, got %x", want, got)
+	}
+
+	// Decrypt in place and in uneven pieces.
+	dec := NewOFB(block, iv)
+	for b := got; len(b) > 0; {
+		n := min(len(b), 7)
+		dec.XORKeyStream(b[:n], b[:n])
+		b = b[n:]
+	}
+	if !bytes.Equal(pt, got) {
+		t.Errorf("decrypt: want %x, got %x", pt, got)
+	}
+}
+
+func TestOFBFixedIVKnownPlaintext(t *testing.T) {
+	oracle := NewOFBFixedIVOracle()
+
+	known := []byte("a message the attacker wrote or guessed")
+	secret := []byte("a secret under the same key and iv")
+
+	// The known plaintext reveals the keystream, which decrypts the secret.
+	ks := XOR(known, oracle(known))
+	ct := oracle(secret)
+
+	got := XOR(ct, ks[:len(ct)])
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
+
+func TestRecoverReusedKeystreamOFB(t *testing.T) {
+	oracle := NewOFBFixedIVOracle()
+
+	var pts, cts [][]byte
+	for _, line := range bytes.Split(englishText, []byte(". ")) {
+		if len(line) < 40 {
+			continue
+		}
+		pts = append(pts, line[:40])
+		cts = append(cts, oracle(line[:40]))
+	}
+
+	ks := RecoverReusedKeystream(cts, WithScorer(EnglishCorpus().Distribution().Scorer()))
+
+	var wrong int
+	for i, ct := range cts {
+		got := XOR(ct, ks[:len(ct)])
+		for j := range got {
+			if got[j] != pts[i][j] {
+				wrong++
+			}
+		}
+	}
+
+	// Allow a few bytes to be wrong, since the attack is statistical.
+	if total := 40 * len(cts); wrong*20 > total {
+		t.Errorf("%d of %d bytes wrong", wrong, total)
+	}
+}
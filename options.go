@@ -0,0 +1,230 @@
+package cryptopals
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"io/fs"
+	"log/slog"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"time"
+)
+
+// An Option configures an oracle or an attack. Options that don't apply to
+// what they're passed to are ignored.
+type Option func(*options)
+
+// options holds the configuration shared by oracles and attacks.
+type options struct {
+	newCipher func(key []byte) (cipher.Block, error)
+	keySize   int // Key size in bytes.
+	progress  func(Progress)
+	logger    *slog.Logger
+	scorer    Scorer
+	workers   int
+	threshold float64
+	latency   time.Duration
+	verbose   bool
+	timeout   time.Duration
+	retries   int
+	inFlight  int // Maximum concurrent requests, or 0 for no limit.
+
+	profileFormat ProfileFormat
+	sanitization  Sanitization
+	authProfiles  bool
+	uidCounter    bool
+	uidStart      int64
+
+	keySizeEstimator KeySizeEstimator
+
+	testdata    fs.FS // Nil for the testdata directory.
+	testdataURL string
+
+	seed       *uint64 // Nil for a random seed.
+	maxEntries int     // Maximum table entries, or 0 for no limit.
+	spillDir   string  // Empty for os.TempDir.
+
+	cacheLineSize int // Bytes.
+	timingNoise   int // Cycles.
+	blinding      bool
+
+	simpleSRP bool
+
+	bleichenbacherMode  BleichenbacherMode
+	leakDelay           time.Duration
+	clock               clock
+	bleichenbacherState *BleichenbacherState // Nil to not save progress.
+
+	randomNonces    bool
+	pointValidation bool
+
+	flakiness float64 // Probability of a wrong answer.
+	votes     int
+
+	budget Budget
+	usage  *Usage       // Nil to not report usage.
+	stop   func() error // Nil unless run by an Attack; why to stop early.
+
+	scratchEncodings bool
+
+	streamMode      *StreamMode // Nil for the oracle's default.
+	counterEncoding CounterEncoding
+}
+
+// newOptions returns the default configuration with opts applied.
+func newOptions(opts []Option) *options {
+	o := &options{
+		newCipher: aes.NewCipher,
+		keySize:   16,
+		scorer:    ScorerFunc(Englishness),
+		workers:   1,
+		threshold: math.Inf(1),
+		timeout:   10 * time.Second,
+		retries:   2,
+		uidStart:  10,
+
+		cacheLineSize: 64,
+		timingNoise:   200,
+
+		leakDelay: 2 * time.Millisecond,
+		clock:     systemClock{},
+
+		votes: 1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// newBlock returns a block cipher for key. It panics if key is invalid.
+func (o *options) newBlock(key []byte) cipher.Block {
+	block, err := o.newCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	return block
+}
+
+// WithKeySize sets the size in bytes of the random key an oracle uses.
+//
+// For AES, valid sizes are 16, 24, and 32, selecting AES-128, AES-192, or
+// AES-256. The default is 16.
+func WithKeySize(n int) Option {
+	return func(o *options) {
+		o.keySize = n
+	}
+}
+
+// WithCipher sets the block cipher an oracle uses in place of AES.
+//
+// The oracle calls newCipher with random keys of keySize bytes. For example,
+// WithCipher(des.NewCipher, 8) selects DES and WithCipher(des.NewTripleDESCipher,
+// 24) selects 3DES.
+func WithCipher(newCipher func(key []byte) (cipher.Block, error), keySize int) Option {
+	return func(o *options) {
+		o.newCipher = newCipher
+		o.keySize = keySize
+	}
+}
+
+// Progress describes how far an attack has gotten.
+type Progress struct {
+	BytesRecovered int // Bytes of the secret recovered so far.
+	OracleCalls    int // Oracle calls made so far.
+
+	// Lo and Hi bound the interval that attacks which narrow one down, such
+	// as Bleichenbacher's and Manger's, have left for the plaintext as a
+	// number. Bleichenbacher's bounds are for the blinded plaintext if the
+	// ciphertext had to be blinded. They're nil for other attacks.
+	Lo, Hi *big.Int
+}
+
+// WithProgress sets a function that an attack calls with its progress each
+// time it recovers a byte.
+func WithProgress(f func(Progress)) Option {
+	return func(o *options) {
+		o.progress = f
+	}
+}
+
+// report calls the progress function, if any.
+func (o *options) report(p Progress) {
+	if o.progress != nil {
+		o.progress(p)
+	}
+}
+
+// WithLogger sets a logger that oracles and attacks use to log their decisions
+// at debug level, such as an attack finding the block size or recovering a
+// byte. By default nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// debug logs a message at debug level, if there's a logger.
+func (o *options) debug(msg string, args ...any) {
+	if o.logger != nil {
+		o.logger.Debug(msg, args...)
+	}
+}
+
+// WithSeed makes a randomized search draw its candidates deterministically
+// from seed, so it gives the same result each run. By default the seed is
+// random.
+func WithSeed(seed uint64) Option {
+	return func(o *options) {
+		o.seed = &seed
+	}
+}
+
+// newRand returns a fast random source for search candidates, seeded as set
+// with WithSeed. It isn't for keys.
+func (o *options) newRand() *rand.ChaCha8 {
+	var seed [32]byte
+	if o.seed != nil {
+		binary.LittleEndian.PutUint64(seed[:], *o.seed)
+	} else {
+		copy(seed[:], randBytes(32))
+	}
+	return rand.NewChaCha8(seed)
+}
+
+// WithPointValidation sets whether an elliptic-curve oracle validates the
+// points it's sent. It applies to both X25519 and secp256k1 ECDH: with
+// validation on, an X25519Responder rejects low-order public keys and
+// all-zero shared secrets, and a Secp256k1Key rejects peer keys that aren't
+// on the curve. By default it's off, so the same oracle can be run in the
+// vulnerable mode an invalid-curve or small-subgroup attack needs and the
+// hardened mode it should fail against.
+func WithPointValidation(on bool) Option {
+	return func(o *options) {
+		o.pointValidation = on
+	}
+}
+
+// A clock tells the time and waits. Tests replace the system clock with a
+// fake one, so that timing leaks don't depend on the scheduler.
+type clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the real clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// withClock sets the clock that oracles wait on and timing attacks measure
+// with.
+func withClock(c clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
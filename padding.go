@@ -0,0 +1,335 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"slices"
+)
+
+// ErrInvalidPadding is returned when padding fails validation.
+var ErrInvalidPadding = errors.New("invalid padding")
+
+// A Padder pads data to a multiple of a block size and removes that padding.
+//
+// Pad returns a new slice. Unpad returns a subslice of its input, or
+// ErrInvalidPadding if the padding is malformed or if the input isn't a whole
+// number of blocks.
+type Padder interface {
+	Pad(b []byte, blockSize int) []byte
+	Unpad(b []byte, blockSize int) ([]byte, error)
+}
+
+// PKCS7Padder implements PKCS #7 padding, where every padding byte holds the
+// padding length: ... 04 04 04 04.
+type PKCS7Padder struct{}
+
+// ANSIX923Padder implements ANSI X9.23 padding, where the padding is zeros
+// followed by the padding length: ... 00 00 00 04.
+type ANSIX923Padder struct{}
+
+// ISO7816Padder implements ISO/IEC 7816-4 padding, where the padding is 0x80
+// followed by zeros: ... 80 00 00 00.
+type ISO7816Padder struct{}
+
+// SSL3Padder implements SSL 3.0 padding, where the padding is arbitrary bytes
+// followed by the number of them: ... xx xx xx 03. Pad uses zeros.
+//
+// Unpad only checks the last byte, as SSL 3.0 does, which is what makes
+// POODLE possible.
+type SSL3Padder struct{}
+
+// ZeroPadder implements zero padding, where the data is followed by as few
+// zeros as needed to fill a block: ... 00 00 00.
+//
+// Zero padding adds nothing to data that fills its last block, and Unpad
+// can't tell padding from trailing zeros in the data, so it only round-trips
+// data that doesn't end in a zero byte.
+type ZeroPadder struct{}
+
+// checkBlockSize panics unless n is a valid block size for length-byte
+// padding schemes.
+func checkBlockSize(n int) {
+	if n < 1 || n > math.MaxUint8 {
+		panic("invalid block size")
+	}
+}
+
+// padLen returns the number of padding bytes needed to pad b to a multiple of
+// n, always adding at least one byte.
+func padLen(b []byte, n int) int {
+	return n - len(b)%n
+}
+
+// wholeBlocks reports whether b is a nonempty whole number of n-byte blocks.
+func wholeBlocks(b []byte, n int) bool {
+	return len(b) > 0 && len(b)%n == 0
+}
+
+// PadPKCS7 returns a new slice that concatenates b with PKCS #7 padding to
+// guarantee block size n.
+func PadPKCS7(b []byte, n int) []byte {
+	checkBlockSize(n)
+
+	p := padLen(b, n)
+	padding := bytes.Repeat([]byte{byte(p)}, p)
+
+	return slices.Concat(b, padding)
+}
+
+// UnpadPKCS7 returns a subslice of b with PKCS #7 padding removed.
+//
+// Deprecated: UnpadPKCS7 trusts the last byte without checking the rest of
+// the padding. Use PKCS7Padder.Unpad, which checks it.
+func UnpadPKCS7(b []byte) []byte {
+	n := int(b[len(b)-1])
+	return b[:len(b)-n]
+}
+
+// Pad returns PadPKCS7(b, blockSize).
+func (PKCS7Padder) Pad(b []byte, blockSize int) []byte {
+	return PadPKCS7(b, blockSize)
+}
+
+// Unpad removes PKCS #7 padding from b.
+func (PKCS7Padder) Unpad(b []byte, blockSize int) ([]byte, error) {
+	checkBlockSize(blockSize)
+	if !wholeBlocks(b, blockSize) {
+		return nil, ErrInvalidPadding
+	}
+
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize {
+		return nil, ErrInvalidPadding
+	}
+	for _, v := range b[len(b)-n:] {
+		if int(v) != n {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return b[:len(b)-n], nil
+}
+
+// Pad returns a new slice that concatenates b with ANSI X9.23 padding.
+func (ANSIX923Padder) Pad(b []byte, blockSize int) []byte {
+	checkBlockSize(blockSize)
+
+	n := padLen(b, blockSize)
+	padding := make([]byte, n)
+	padding[n-1] = byte(n)
+
+	return slices.Concat(b, padding)
+}
+
+// Unpad removes ANSI X9.23 padding from b.
+func (ANSIX923Padder) Unpad(b []byte, blockSize int) ([]byte, error) {
+	checkBlockSize(blockSize)
+	if !wholeBlocks(b, blockSize) {
+		return nil, ErrInvalidPadding
+	}
+
+	n := int(b[len(b)-1])
+	if n == 0 || n > blockSize {
+		return nil, ErrInvalidPadding
+	}
+	for _, v := range b[len(b)-n : len(b)-1] {
+		if v != 0 {
+			return nil, ErrInvalidPadding
+		}
+	}
+	return b[:len(b)-n], nil
+}
+
+// Pad returns a new slice that concatenates b with ISO/IEC 7816-4 padding.
+func (ISO7816Padder) Pad(b []byte, blockSize int) []byte {
+	if blockSize < 1 {
+		panic("invalid block size")
+	}
+
+	padding := make([]byte, padLen(b, blockSize))
+	padding[0] = 0x80
+
+	return slices.Concat(b, padding)
+}
+
+// Unpad removes ISO/IEC 7816-4 padding from b.
+func (ISO7816Padder) Unpad(b []byte, blockSize int) ([]byte, error) {
+	if blockSize < 1 {
+		panic("invalid block size")
+	}
+	if !wholeBlocks(b, blockSize) {
+		return nil, ErrInvalidPadding
+	}
+
+	// The marker must be the last nonzero byte, and in the last block.
+	i := len(b) - 1
+	for i >= len(b)-blockSize && b[i] == 0 {
+		i--
+	}
+	if i < len(b)-blockSize || b[i] != 0x80 {
+		return nil, ErrInvalidPadding
+	}
+	return b[:i], nil
+}
+
+// Pad returns a new slice that concatenates b with SSL 3.0 padding.
+func (SSL3Padder) Pad(b []byte, blockSize int) []byte {
+	checkBlockSize(blockSize)
+
+	n := padLen(b, blockSize)
+	padding := make([]byte, n)
+	padding[n-1] = byte(n - 1)
+
+	return slices.Concat(b, padding)
+}
+
+// Unpad removes SSL 3.0 padding from b.
+func (SSL3Padder) Unpad(b []byte, blockSize int) ([]byte, error) {
+	checkBlockSize(blockSize)
+	if !wholeBlocks(b, blockSize) {
+		return nil, ErrInvalidPadding
+	}
+
+	n := int(b[len(b)-1]) + 1
+	if n > blockSize {
+		return nil, ErrInvalidPadding
+	}
+	return b[:len(b)-n], nil
+}
+
+// Pad returns a new slice that concatenates b with zero padding.
+func (ZeroPadder) Pad(b []byte, blockSize int) []byte {
+	if blockSize < 1 {
+		panic("invalid block size")
+	}
+
+	n := padLen(b, blockSize) % blockSize
+
+	return slices.Concat(b, make([]byte, n))
+}
+
+// Unpad removes up to blockSize-1 trailing zeros from b.
+func (ZeroPadder) Unpad(b []byte, blockSize int) ([]byte, error) {
+	if blockSize < 1 {
+		panic("invalid block size")
+	}
+	if len(b)%blockSize != 0 {
+		return nil, ErrInvalidPadding
+	}
+
+	n := 0
+	for n < blockSize-1 && n < len(b) && b[len(b)-1-n] == 0 {
+		n++
+	}
+	return b[:len(b)-n], nil
+}
+
+// pkcs7Writer passes data through and writes PKCS #7 padding on Close.
+type pkcs7Writer struct {
+	w         io.Writer
+	blockSize int
+	n         int // Bytes written so far, modulo blockSize.
+	closed    bool
+}
+
+// NewPKCS7Writer returns a writer that writes data to w unchanged and, on
+// Close, writes the PKCS #7 padding for everything written. Closing the
+// writer doesn't close w.
+func NewPKCS7Writer(w io.Writer, blockSize int) io.WriteCloser {
+	checkBlockSize(blockSize)
+	return &pkcs7Writer{w: w, blockSize: blockSize}
+}
+
+func (w *pkcs7Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write after close")
+	}
+	n, err := w.w.Write(p)
+	w.n = (w.n + n) % w.blockSize
+	return n, err
+}
+
+func (w *pkcs7Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	p := byte(w.blockSize - w.n)
+	_, err := w.w.Write(bytes.Repeat([]byte{p}, int(p)))
+	return err
+}
+
+// pkcs7Reader holds back the last block of its input until EOF, then strips
+// its PKCS #7 padding.
+type pkcs7Reader struct {
+	r         io.Reader
+	blockSize int
+	buf       []byte
+	held      []byte // Bytes read but not yet ready, in buf.
+	ready     []byte // Bytes that can be returned, in buf.
+	total     int    // Bytes read from r so far.
+	err       error  // Sticky error, io.EOF once the input is finished.
+}
+
+// NewPKCS7Reader returns a reader that reads PKCS #7 padded data from r and
+// returns it with the padding removed.
+//
+// The reader holds back the final block until r reaches EOF, and then returns
+// ErrInvalidPadding in place of io.EOF if the input isn't validly padded.
+func NewPKCS7Reader(r io.Reader, blockSize int) io.Reader {
+	checkBlockSize(blockSize)
+	return &pkcs7Reader{
+		r:         r,
+		blockSize: blockSize,
+		buf:       make([]byte, blockSize+32*1024),
+	}
+}
+
+func (r *pkcs7Reader) Read(p []byte) (int, error) {
+	for len(r.ready) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.ready)
+	r.ready = r.ready[n:]
+	return n, nil
+}
+
+// fill reads more input and makes everything but the last block ready. It
+// must only be called once r.ready is empty, since it reuses r.buf.
+func (r *pkcs7Reader) fill() {
+	bs := r.blockSize
+
+	// Move the held bytes to the front of buf and read after them.
+	m := copy(r.buf, r.held)
+	n, err := r.r.Read(r.buf[m:])
+	m += n
+	r.total += n
+	r.held = r.buf[:m]
+
+	switch {
+	case err == io.EOF:
+		// Since at least a block is always held, the last block is in buf.
+		if r.total == 0 || r.total%bs != 0 {
+			r.err = ErrInvalidPadding
+			return
+		}
+		unpadded, err := PKCS7Padder{}.Unpad(r.held[m-bs:], bs)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.ready = r.held[:m-bs+len(unpadded)]
+		r.held = nil
+		r.err = io.EOF
+	case err != nil:
+		r.err = err
+	case m > bs:
+		r.ready = r.buf[:m-bs]
+		r.held = r.buf[m-bs : m]
+	}
+}
@@ -0,0 +1,143 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPadderExamples(t *testing.T) {
+	in := []byte("YELLOW SUBMARINE")
+
+	cases := []struct {
+		p    Padder
+		want string
+	}{
+		{PKCS7Padder{}, "YELLOW SUBMARINE\x04\x04\x04\x04"},
+		{ANSIX923Padder{}, "YELLOW SUBMARINE\x00\x00\x00\x04"},
+		{ISO7816Padder{}, "YELLOW SUBMARINE\x80\x00\x00\x00"},
+		{SSL3Padder{}, "YELLOW SUBMARINE\x00\x00\x00\x03"},
+		{ZeroPadder{}, "YELLOW SUBMARINE\x00\x00\x00\x00"},
+	}
+
+	for _, tc := range cases {
+		got := tc.p.Pad(in, 20)
+		if !bytes.Equal([]byte(tc.want), got) {
+			t.Errorf("%T: want %q, got %q", tc.p, tc.want, got)
+		}
+	}
+}
+
+func TestPadderRoundTrip(t *testing.T) {
+	padders := []Padder{PKCS7Padder{}, ANSIX923Padder{}, ISO7816Padder{}, SSL3Padder{}, ZeroPadder{}}
+
+	for _, p := range padders {
+		for _, bs := range []int{1, 8, 16} {
+			for n := range 3 * bs {
+				t.Run(fmt.Sprintf("%T/%d/%d", p, bs, n), func(t *testing.T) {
+					// Avoid trailing zeros, which zero padding can't round-trip.
+					in := bytes.Repeat([]byte{0xc2}, n)
+
+					padded := p.Pad(in, bs)
+					if len(padded)%bs != 0 {
+						t.Fatalf("padded length %d isn't a multiple of %d", len(padded), bs)
+					}
+
+					got, err := p.Unpad(padded, bs)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(in, got) {
+						t.Errorf("want %x, got %x", in, got)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestPadderInvalid(t *testing.T) {
+	cases := []struct {
+		p  Padder
+		in string
+	}{
+		{PKCS7Padder{}, ""},
+		{PKCS7Padder{}, "ICE ICE BABY\x05\x05\x05\x05"},
+		{PKCS7Padder{}, "ICE ICE BABY\x01\x02\x03\x04"},
+		{PKCS7Padder{}, "ICE ICE BABY\x00\x00\x00\x00"},
+		{PKCS7Padder{}, "ICE ICE BABY\x04\x04\x04"},
+		{ANSIX923Padder{}, "ICE ICE BABY\x00\x01\x00\x04"},
+		{ANSIX923Padder{}, "ICE ICE BABY\x00\x00\x00\x11"},
+		{ISO7816Padder{}, "ICE ICE BABY\x00\x00\x00\x00"},
+		{ISO7816Padder{}, "ICE ICE BABY\x80\x00\x01\x00"},
+		{SSL3Padder{}, "ICE ICE BABY\x00\x00\x00\x10"},
+		{SSL3Padder{}, "ICE ICE BABY\x00\x00\x00"},
+		{ZeroPadder{}, "ICE ICE BABY\x00"},
+	}
+
+	for _, tc := range cases {
+		if _, err := tc.p.Unpad([]byte(tc.in), 16); !errors.Is(err, ErrInvalidPadding) {
+			t.Errorf("%T %q: want ErrInvalidPadding, got %v", tc.p, tc.in, err)
+		}
+	}
+}
+
+func TestPKCS7WriterReader(t *testing.T) {
+	for _, n := range []int{0, 1, 15, 16, 17, 100_000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			in := bytes.Repeat([]byte("0123456789"), n/10+1)[:n]
+
+			var buf bytes.Buffer
+			w := NewPKCS7Writer(&buf, 16)
+
+			// Write in uneven pieces.
+			for rest := in; len(rest) > 0; {
+				k := min(len(rest), 7)
+				if _, err := w.Write(rest[:k]); err != nil {
+					t.Fatal(err)
+				}
+				rest = rest[k:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := PadPKCS7(in, 16); !bytes.Equal(want, buf.Bytes()) {
+				t.Fatalf("padded output differs from PadPKCS7")
+			}
+
+			r := NewPKCS7Reader(iotest.HalfReader(&buf), 16)
+			if err := iotest.TestReader(r, in); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPKCS7ReaderInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"ICE ICE BABY\x04\x04\x04",
+		"ICE ICE BABY\x05\x05\x05\x05",
+		"ICE ICE BABY\x01\x02\x03\x04",
+	} {
+		r := NewPKCS7Reader(strings.NewReader(in), 16)
+		if _, err := io.ReadAll(r); !errors.Is(err, ErrInvalidPadding) {
+			t.Errorf("%q: want ErrInvalidPadding, got %v", in, err)
+		}
+	}
+}
+
+func TestPKCS7WriterClosed(t *testing.T) {
+	w := NewPKCS7Writer(io.Discard, 16)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("want error writing after close")
+	}
+}
@@ -0,0 +1,157 @@
+package cryptopals
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// Poly1305 sizes in bytes.
+const (
+	Poly1305KeySize = 32
+	Poly1305TagSize = 16
+	poly1305Block   = 16
+)
+
+var (
+	// poly1305P is the prime 2^130 - 5.
+	poly1305P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 130), big.NewInt(5))
+
+	// poly1305Clamp masks the bits of r that Poly1305 clears.
+	poly1305Clamp, _ = new(big.Int).SetString("0ffffffc0ffffffc0ffffffc0fffffff", 16)
+
+	poly1305Mod = new(big.Int).Lsh(big.NewInt(1), 128)
+)
+
+// leInt returns b as a little-endian integer.
+func leInt(b []byte) *big.Int {
+	return new(big.Int).SetBytes(reverse(b))
+}
+
+// reverse returns a reversed copy of b.
+func reverse(b []byte) []byte {
+	b = slices.Clone(b)
+	slices.Reverse(b)
+	return b
+}
+
+// poly1305Poly returns the polynomial Poly1305 evaluates at r for msg: the
+// coefficient of x^i is the ith block from the end, with a 1 byte appended.
+// It has no constant term.
+func poly1305Poly(msg []byte) modPoly {
+	n := (len(msg) + poly1305Block - 1) / poly1305Block
+	f := make(modPoly, n+1)
+	f[0] = new(big.Int)
+	for j := range n {
+		block := msg[j*poly1305Block : min(len(msg), (j+1)*poly1305Block)]
+		c := leInt(append(slices.Clone(block), 1))
+		f[n-j] = c
+	}
+	return f
+}
+
+// poly1305Tag returns (f(r) mod 2^130-5 + s) mod 2^128 as a tag.
+func poly1305Tag(f modPoly, r, s *big.Int) [Poly1305TagSize]byte {
+	acc := f.eval(r, poly1305P)
+	acc.Add(acc, s)
+	acc.Mod(acc, poly1305Mod)
+
+	var tag [Poly1305TagSize]byte
+	acc.FillBytes(tag[:])
+	slices.Reverse(tag[:])
+	return tag
+}
+
+// Poly1305 returns the Poly1305 tag of msg under the one-time key, as
+// specified in RFC 8439, section 2.5. It panics if key isn't 32 bytes.
+//
+// The first half of the key is r, with some bits cleared, and the second s.
+// The tag is P(r) + s mod 2^128, where P is a polynomial mod 2^130 - 5 with
+// the message blocks as coefficients. A key must authenticate only one
+// message: see RecoverPoly1305Keys.
+func Poly1305(key, msg []byte) [Poly1305TagSize]byte {
+	if len(key) != Poly1305KeySize {
+		panic("invalid key size")
+	}
+	r := leInt(key[:16])
+	r.And(r, poly1305Clamp)
+	s := leInt(key[16:])
+	return poly1305Tag(poly1305Poly(msg), r, s)
+}
+
+// RecoverPoly1305Keys returns the one-time keys that give two different
+// messages their tags, as when a ChaCha20-Poly1305 nonce is reused. There's
+// usually just one. The bits of r that Poly1305 clears are zero in the keys
+// it returns.
+//
+// Subtracting the tags cancels s, leaving P1(r) - P2(r) = t1 - t2 mod 2^128,
+// where P1 and P2 are the messages' polynomials mod 2^130 - 5. The
+// difference of the polynomials' values is in (-p, p), so it's t1 - t2 plus
+// one of a few multiples of 2^128, and r is a root of P1 - P2 - d for one of
+// those d. As in the GCM forbidden attack, it finds the roots by polynomial
+// factoring, here in the prime field, and keeps the ones that are valid r
+// values and reproduce both tags. The work grows with the messages' length
+// in blocks.
+//
+// It returns an error if no key fits.
+func RecoverPoly1305Keys(msg1 []byte, tag1 [Poly1305TagSize]byte, msg2 []byte, tag2 [Poly1305TagSize]byte) ([][Poly1305KeySize]byte, error) {
+	f := polySub(poly1305Poly(msg1), poly1305Poly(msg2), poly1305P)
+	if len(f) == 0 {
+		return nil, errors.New("messages have the same polynomial")
+	}
+
+	t1, t2 := leInt(tag1[:]), leInt(tag2[:])
+	dt := new(big.Int).Sub(t1, t2)
+	dt.Mod(dt, poly1305Mod)
+
+	var keys [][Poly1305KeySize]byte
+	seen := make(map[[Poly1305KeySize]byte]bool)
+	// p < 2^130 = 4*2^128, so the difference is dt + k*2^128 for k in
+	// [-5, 3].
+	for k := -5; k <= 3; k++ {
+		d := new(big.Int).Mul(big.NewInt(int64(k)), poly1305Mod)
+		d.Add(d, dt)
+		g := polySub(f, modPoly{new(big.Int).Mod(d, poly1305P)}, poly1305P)
+
+		for _, r := range polyRoots(g, poly1305P) {
+			if new(big.Int).AndNot(r, poly1305Clamp).Sign() != 0 {
+				continue
+			}
+			s := new(big.Int).Sub(t1, poly1305Poly(msg1).eval(r, poly1305P))
+			s.Mod(s, poly1305Mod)
+
+			var key [Poly1305KeySize]byte
+			r.FillBytes(key[:16])
+			s.FillBytes(key[16:])
+			slices.Reverse(key[:16])
+			slices.Reverse(key[16:])
+			if seen[key] || Poly1305(key[:], msg2) != tag2 {
+				continue
+			}
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no key matches the tags")
+	}
+	return keys, nil
+}
+
+// ForgePoly1305 returns the tag of msg under the one-time key that
+// authenticated two different messages. It returns an error if no key fits
+// their tags, or if several do and they disagree on the forged tag; a third
+// message under the same key settles that.
+func ForgePoly1305(msg1 []byte, tag1 [Poly1305TagSize]byte, msg2 []byte, tag2 [Poly1305TagSize]byte, msg []byte) ([Poly1305TagSize]byte, error) {
+	keys, err := RecoverPoly1305Keys(msg1, tag1, msg2, tag2)
+	if err != nil {
+		return [Poly1305TagSize]byte{}, err
+	}
+	tag := Poly1305(keys[0][:], msg)
+	for _, key := range keys[1:] {
+		if Poly1305(key[:], msg) != tag {
+			return [Poly1305TagSize]byte{}, errors.New("ambiguous key")
+		}
+	}
+	return tag, nil
+}
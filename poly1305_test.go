@@ -0,0 +1,83 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPoly1305(t *testing.T) {
+	// RFC 8439, section 2.5.2.
+	key := decodeHex(t, "85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b")
+	want := decodeHex(t, "a8061dc1305136c6c22b8baf0c0127a9")
+
+	got := Poly1305(key, []byte("Cryptographic Forum Research Group"))
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func TestPoly1305KeyGen(t *testing.T) {
+	// RFC 8439, section 2.6.2: the one-time key is the start of ChaCha20
+	// block 0.
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = 0x80 + byte(i)
+	}
+	nonce := decodeHex(t, "000000000001020304050607")
+	want := decodeHex(t, "8ad5a08b905f81cc815040274ab29471a833b637e3fd0da508dbb8e2fdd1a646")
+
+	block := ChaCha20Block(key, 0, nonce)
+	if !bytes.Equal(block[:Poly1305KeySize], want) {
+		t.Errorf("got %x, want %x", block[:Poly1305KeySize], want)
+	}
+}
+
+func TestRecoverPoly1305Keys(t *testing.T) {
+	for range 20 {
+		key := randBytes(Poly1305KeySize)
+		msg1, msg2 := randBytes(randInt64(100)), randBytes(randInt64(100))
+		if bytes.Equal(msg1, msg2) {
+			continue
+		}
+		tag1, tag2 := Poly1305(key, msg1), Poly1305(key, msg2)
+
+		keys, err := RecoverPoly1305Keys(msg1, tag1, msg2, tag2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The key with r's cleared bits cleared must be among them.
+		want := [Poly1305KeySize]byte(key)
+		for i := 3; i < 16; i += 4 {
+			want[i] &= 0x0f
+		}
+		for i := 4; i < 16; i += 4 {
+			want[i] &= 0xfc
+		}
+		found := false
+		for _, k := range keys {
+			found = found || k == want
+		}
+		if !found {
+			t.Fatalf("key %x not in %x", want, keys)
+		}
+
+		msg := []byte("forged message, never authenticated")
+		tag, err := ForgePoly1305(msg1, tag1, msg2, tag2, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag != Poly1305(key, msg) {
+			t.Errorf("forged tag %x, want %x", tag, Poly1305(key, msg))
+		}
+	}
+}
+
+func TestRecoverPoly1305KeysSamePolynomial(t *testing.T) {
+	key := randBytes(Poly1305KeySize)
+	msg := []byte("same message")
+	tag := Poly1305(key, msg)
+	if _, err := RecoverPoly1305Keys(msg, tag, msg, tag); err == nil {
+		t.Error("no error")
+	}
+}
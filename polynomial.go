@@ -0,0 +1,204 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// A modPoly is a polynomial with coefficients mod some modulus, lowest
+// degree first. The functions on them take the modulus, keep coefficients in
+// [0, m), and return polynomials without leading zeros. The zero polynomial
+// is empty.
+type modPoly []*big.Int
+
+// newModPoly returns the polynomial with coefficients c mod m, lowest degree
+// first.
+func newModPoly(m *big.Int, c ...*big.Int) modPoly {
+	f := make(modPoly, len(c))
+	for i, x := range c {
+		f[i] = new(big.Int).Mod(x, m)
+	}
+	return f.trim()
+}
+
+// trim returns f without leading zero coefficients.
+func (f modPoly) trim() modPoly {
+	for len(f) > 0 && f[len(f)-1].Sign() == 0 {
+		f = f[:len(f)-1]
+	}
+	return f
+}
+
+// deg returns the degree of f, or -1 for the zero polynomial.
+func (f modPoly) deg() int {
+	return len(f) - 1
+}
+
+// eval returns f(x) mod m.
+func (f modPoly) eval(x, m *big.Int) *big.Int {
+	res := new(big.Int)
+	for i := len(f) - 1; i >= 0; i-- {
+		res.Mul(res, x)
+		res.Add(res, f[i])
+		res.Mod(res, m)
+	}
+	return res
+}
+
+func polySub(f, g modPoly, m *big.Int) modPoly {
+	res := make(modPoly, max(len(f), len(g)))
+	for i := range res {
+		res[i] = new(big.Int)
+		if i < len(f) {
+			res[i].Set(f[i])
+		}
+		if i < len(g) {
+			res[i].Sub(res[i], g[i])
+		}
+		res[i].Mod(res[i], m)
+	}
+	return res.trim()
+}
+
+func polyMul(f, g modPoly, m *big.Int) modPoly {
+	if len(f) == 0 || len(g) == 0 {
+		return nil
+	}
+	res := make(modPoly, len(f)+len(g)-1)
+	for i := range res {
+		res[i] = new(big.Int)
+	}
+	t := new(big.Int)
+	for i, a := range f {
+		for j, b := range g {
+			res[i+j].Add(res[i+j], t.Mul(a, b))
+		}
+	}
+	for _, c := range res {
+		c.Mod(c, m)
+	}
+	return res.trim()
+}
+
+// polyDivMod returns the quotient and remainder of f divided by g. The
+// leading coefficient of g must be invertible mod m; if it isn't, polyDivMod
+// returns ok = false.
+func polyDivMod(f, g modPoly, m *big.Int) (q, r modPoly, ok bool) {
+	inv := new(big.Int).ModInverse(g[len(g)-1], m)
+	if inv == nil {
+		return nil, nil, false
+	}
+
+	r = newModPoly(m, f...)
+	if len(r) < len(g) {
+		return nil, r, true
+	}
+	q = make(modPoly, len(r)-len(g)+1)
+	for i := range q {
+		q[i] = new(big.Int)
+	}
+
+	t := new(big.Int)
+	for len(r) >= len(g) {
+		shift := len(r) - len(g)
+		c := new(big.Int).Mul(r[len(r)-1], inv)
+		c.Mod(c, m)
+		q[shift] = c
+		for i, b := range g {
+			r[shift+i].Sub(r[shift+i], t.Mul(c, b))
+			r[shift+i].Mod(r[shift+i], m)
+		}
+		r = r.trim()
+	}
+	return q.trim(), r, true
+}
+
+// polyMonic returns f scaled to have a leading coefficient of 1, or ok =
+// false if its leading coefficient isn't invertible mod m.
+func polyMonic(f modPoly, m *big.Int) (modPoly, bool) {
+	if len(f) == 0 {
+		return f, true
+	}
+	inv := new(big.Int).ModInverse(f[len(f)-1], m)
+	if inv == nil {
+		return nil, false
+	}
+	return polyMul(f, modPoly{inv}, m), true
+}
+
+// polyGCD returns the monic greatest common divisor of f and g. Over a ring
+// that isn't a field, such as the integers mod an RSA modulus, it can run
+// into a coefficient that isn't invertible, and returns ok = false.
+func polyGCD(f, g modPoly, m *big.Int) (modPoly, bool) {
+	for len(g) > 0 {
+		_, r, ok := polyDivMod(f, g, m)
+		if !ok {
+			return nil, false
+		}
+		f, g = g, r
+	}
+	return polyMonic(f, m)
+}
+
+// polyRoots returns the distinct roots of f mod the prime p, in no
+// particular order, using Cantor-Zassenhaus splitting. It's for polynomials
+// of small degree.
+func polyRoots(f modPoly, p *big.Int) []*big.Int {
+	f = f.trim()
+	if len(f) < 2 {
+		return nil
+	}
+
+	// gcd(f, x^p - x) is the product of (x - r) over the roots r of f.
+	x := modPoly{big.NewInt(0), big.NewInt(1)}
+	xp := polyExpMod(x, p, f, p)
+	g, _ := polyGCD(f, polySub(xp, x, p), p)
+
+	var roots []*big.Int
+	var split func(g modPoly)
+	split = func(g modPoly) {
+		switch g.deg() {
+		case 0:
+			return
+		case 1:
+			// g is monic, x + g[0].
+			roots = append(roots, new(big.Int).Mod(new(big.Int).Neg(g[0]), p))
+			return
+		}
+
+		// For random a, (x + a)^((p-1)/2) - 1 has about half the roots of
+		// g as roots, the r for which r + a is a square.
+		e := new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(1)), 1)
+		for {
+			a, err := rand.Int(rand.Reader, p)
+			if err != nil {
+				panic(err)
+			}
+			h := polyExpMod(modPoly{a, big.NewInt(1)}, e, g, p)
+			h = polySub(h, modPoly{big.NewInt(1)}, p)
+			d, _ := polyGCD(g, h, p)
+			if d.deg() > 0 && d.deg() < g.deg() {
+				q, _, _ := polyDivMod(g, d, p)
+				split(d)
+				split(q)
+				return
+			}
+		}
+	}
+	split(g)
+	return roots
+}
+
+// polyExpMod returns f^e mod g, with coefficients mod m. The leading
+// coefficient of g must be invertible mod m.
+func polyExpMod(f modPoly, e *big.Int, g modPoly, m *big.Int) modPoly {
+	res := modPoly{big.NewInt(1)}
+	_, base, _ := polyDivMod(f, g, m)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		_, res, _ = polyDivMod(polyMul(res, res, m), g, m)
+		if e.Bit(i) == 1 {
+			_, res, _ = polyDivMod(polyMul(res, base, m), g, m)
+		}
+	}
+	return res
+}
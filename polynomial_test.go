@@ -0,0 +1,51 @@
+package cryptopals
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestPolyRoots(t *testing.T) {
+	p := big.NewInt(1000003)
+	ints := func(c ...int64) modPoly {
+		f := make(modPoly, len(c))
+		for i, x := range c {
+			f[i] = big.NewInt(x)
+		}
+		return newModPoly(p, f...)
+	}
+
+	// (x - 3)(x - 5)(x - 7)^2 (x^2 + 1), and x^2 + 1 has no roots since
+	// p = 3 mod 4.
+	f := ints(1)
+	for _, r := range []int64{3, 5, 7, 7} {
+		f = polyMul(f, ints(-r, 1), p)
+	}
+	f = polyMul(f, ints(1, 0, 1), p)
+
+	var got []int64
+	for _, r := range polyRoots(f, p) {
+		got = append(got, r.Int64())
+	}
+	slices.Sort(got)
+	if want := []int64{3, 5, 7}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if roots := polyRoots(ints(1, 0, 1), p); len(roots) != 0 {
+		t.Errorf("x^2 + 1: got %v", roots)
+	}
+}
+
+func TestPolyGCD(t *testing.T) {
+	p := big.NewInt(101)
+	a := newModPoly(p, big.NewInt(-2), big.NewInt(1)) // x - 2
+	b := newModPoly(p, big.NewInt(3), big.NewInt(1))  // x + 3
+	c := newModPoly(p, big.NewInt(5), big.NewInt(1))  // x + 5
+
+	g, ok := polyGCD(polyMul(a, b, p), polyMul(polyMul(a, c, p), modPoly{big.NewInt(7)}, p), p)
+	if !ok || !slices.EqualFunc(g, a, func(x, y *big.Int) bool { return x.Cmp(y) == 0 }) {
+		t.Errorf("got %v, want %v", g, a)
+	}
+}
@@ -0,0 +1,163 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"slices"
+)
+
+// poodleMACSize is the size of the MAC in POODLEOracle records.
+const poodleMACSize = sha256.Size
+
+// POODLEOracle encrypts and checks SSL 3.0 style CBC records, which MAC the
+// plaintext, then pad it with SSL3Padder, then encrypt it.
+//
+// It plays both sides of the POODLE attack: Encrypt is a victim's browser
+// that sends a secret cookie in requests the attacker can shape, and IsValid
+// is a server that accepts or rejects records.
+type POODLEOracle struct {
+	block  cipher.Block
+	macKey []byte
+	secret []byte
+	o      *options
+}
+
+// NewPOODLEOracle returns a new POODLE oracle for secret under random keys.
+// The key is 16 bytes unless set with WithKeySize, and WithCipher replaces
+// AES. Records are authenticated with HMAC-SHA256.
+func NewPOODLEOracle(secret []byte, opts ...Option) *POODLEOracle {
+	o := newOptions(opts)
+	return &POODLEOracle{
+		block:  o.newBlock(randBytes(int64(o.keySize))),
+		macKey: randBytes(32),
+		secret: secret,
+		o:      o,
+	}
+}
+
+func (p *POODLEOracle) mac(b []byte) []byte {
+	m := hmac.New(sha256.New, p.macKey)
+	m.Write(b)
+	return m.Sum(nil)
+}
+
+// Encrypt returns a record for the request path || secret || body, as
+// iv || encrypt(pad(msg || mac(msg))) under a random IV.
+func (p *POODLEOracle) Encrypt(path, body []byte) []byte {
+	bs := p.block.BlockSize()
+
+	msg := slices.Concat(path, p.secret, body)
+	pt := SSL3Padder{}.Pad(slices.Concat(msg, p.mac(msg)), bs)
+
+	res := slices.Concat(randBytes(int64(bs)), pt)
+	NewCBCEncrypter(p.block, res[:bs]).CryptBlocks(res[bs:], res[bs:])
+
+	return res
+}
+
+// IsValid reports whether a record decrypts, unpads, and authenticates.
+func (p *POODLEOracle) IsValid(record []byte) bool {
+	bs := p.block.BlockSize()
+	if len(record)%bs != 0 || len(record) < 2*bs {
+		return false
+	}
+
+	pt := make([]byte, len(record)-bs)
+	NewCBCDecrypter(p.block, record[:bs]).CryptBlocks(pt, record[bs:])
+
+	pt, err := SSL3Padder{}.Unpad(pt, bs)
+	if err != nil || len(pt) < poodleMACSize {
+		return false
+	}
+
+	msg, tag := pt[:len(pt)-poodleMACSize], pt[len(pt)-poodleMACSize:]
+	ok := hmac.Equal(tag, p.mac(msg))
+	p.o.debug("record checked", "valid", ok)
+	return ok
+}
+
+// maxPOODLEAttempts bounds the requests spent on each byte. Each try succeeds
+// with probability 1/256, so this many failures in a row means the attack
+// isn't working.
+const maxPOODLEAttempts = 1 << 14
+
+// RecoverPOODLESecret performs a POODLE attack to recover the secret that
+// encrypt places between a chosen path and body. macSize is the size of the
+// record MAC in bytes.
+//
+// The attack first lengthens the body until the padding fills a whole block.
+// Then, for each secret byte, it sizes the path so the byte ends a block,
+// copies that block over the padding block, and sends the record to valid.
+// SSL 3.0 only checks the last padding byte, so the server accepts about one
+// record in 256, and each acceptance reveals the byte. Changing the path and
+// body by the same amount keeps the padding a whole block.
+//
+// Use WithProgress or WithLogger to observe the attack as it runs.
+func RecoverPOODLESecret(encrypt func(path, body []byte) []byte, valid func([]byte) bool, blockSize, macSize int, opts ...Option) (_ []byte, err error) {
+	o := newOptions(opts)
+
+	queries := o.newMeter()
+	defer queries.finish(&err)
+	valid = queries.valid(valid)
+
+	// Find the shortest body that adds a block, so the padding is a whole
+	// block.
+	base := len(encrypt(nil, nil))
+	body := 1
+	for ; len(encrypt(nil, make([]byte, body))) == base; body++ {
+		if body > blockSize {
+			return nil, errors.New("record length never changed")
+		}
+	}
+
+	// The record is now iv || secret || body || mac || a block of padding,
+	// one block longer than before.
+	n := base - body - macSize - blockSize
+	if n < 0 {
+		return nil, errors.New("record too short for the mac size")
+	}
+	o.debug("found secret length", "len", n, "body_len", body)
+
+	res := make([]byte, n)
+
+	for j := range res {
+		// Put secret byte j at the end of block i of the plaintext.
+		path := blockSize - 1 - j%blockSize
+		i := (path + j) / blockSize
+
+		found := false
+		for range maxPOODLEAttempts {
+			ct := encrypt(make([]byte, path), make([]byte, body+blockSize-path))
+
+			// Replace the padding block with block i, which is ct block i+1
+			// after the IV.
+			last := len(ct) - blockSize
+			copy(ct[last:], ct[(i+1)*blockSize:(i+2)*blockSize])
+
+			ok := valid(ct)
+			if err := queries.err(); err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			// The last byte of block i decrypted to blockSize-1, after being
+			// XORed with the block before the last instead of its own.
+			res[j] = byte(blockSize-1) ^ ct[last-1] ^ ct[(i+1)*blockSize-1]
+			found = true
+			break
+		}
+
+		if !found {
+			return nil, errors.New("no record accepted")
+		}
+
+		o.debug("recovered byte", "index", j, "oracle_calls", queries.calls())
+		o.report(Progress{BytesRecovered: j + 1, OracleCalls: queries.calls()})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestPOODLE(t *testing.T) {
+	secret := []byte("Cookie: session=6f70656e2073657361")
+	p := NewPOODLEOracle(secret)
+
+	if !p.IsValid(p.Encrypt([]byte("GET /"), []byte("body"))) {
+		t.Fatal("record rejected")
+	}
+
+	var calls int
+	progress := func(pr Progress) { calls = pr.OracleCalls }
+
+	got, err := RecoverPOODLESecret(p.Encrypt, p.IsValid, aes.BlockSize, sha256.Size, WithProgress(progress))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+
+	t.Logf("%d requests, %.0f per byte", calls, float64(calls)/float64(len(secret)))
+}
+
+func TestPOODLESecretLengths(t *testing.T) {
+	for n := range aes.BlockSize + 2 {
+		secret := bytes.Repeat([]byte{'s'}, n)
+		p := NewPOODLEOracle(secret)
+
+		got, err := RecoverPOODLESecret(p.Encrypt, p.IsValid, aes.BlockSize, sha256.Size)
+		if err != nil {
+			t.Fatalf("length %d: %v", n, err)
+		}
+		if !bytes.Equal(secret, got) {
+			t.Errorf("length %d: want %q, got %q", n, secret, got)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package cryptopals
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// A ProfileFormat is a way of encoding profiles as text.
+type ProfileFormat int
+
+const (
+	// QueryProfiles encodes profiles with url.Values, which escapes values
+	// and sorts fields by key, as in "email=foo%40bar.com&role=user&uid=...".
+	// Uids are random UUIDs.
+	QueryProfiles ProfileFormat = iota
+
+	// CookieProfiles encodes profiles as challenge 13 describes, with fields
+	// in a fixed order and nothing escaped, as in
+	// "email=foo@bar.com&uid=10&role=user". Uids count up from 10.
+	CookieProfiles
+
+	// JSONProfiles encodes profiles as JSON objects, as in
+	// {"email":"foo@bar.com","uid":10,"role":"user"}. Uids count up from 10.
+	JSONProfiles
+)
+
+// WithProfileFormat sets how a profile manager encodes profiles. The default
+// is QueryProfiles.
+func WithProfileFormat(f ProfileFormat) Option {
+	return func(o *options) {
+		o.profileFormat = f
+	}
+}
+
+// A Sanitization is how a profile manager treats the field separators "&"
+// and "=" in emails.
+type Sanitization int
+
+const (
+	// KeepMetachars leaves emails as they are.
+	KeepMetachars Sanitization = iota
+
+	// StripMetachars removes "&" and "=" from emails, as challenge 13
+	// suggests.
+	StripMetachars
+
+	// RejectMetachars refuses to create profiles for emails containing "&"
+	// or "=".
+	RejectMetachars
+)
+
+// ErrRejectedEmail is returned by NewUserProfileErr when a profile manager
+// using RejectMetachars is given an email containing "&" or "=".
+var ErrRejectedEmail = errors.New("email contains \"&\" or \"=\"")
+
+// WithSanitization sets how a profile manager treats "&" and "=" in emails.
+// The default is KeepMetachars.
+//
+// Sanitizing emails stops users from adding fields of their own, such as
+// "foo@bar.com&role=admin", but doesn't stop NewCookieAdminProfile, which
+// never needs either character.
+func WithSanitization(s Sanitization) Option {
+	return func(o *options) {
+		o.sanitization = s
+	}
+}
+
+// sanitize applies s to email. It reports false if email is rejected.
+func (s Sanitization) sanitize(email string) (string, bool) {
+	switch s {
+	case StripMetachars:
+		return strings.NewReplacer("&", "", "=", "").Replace(email), true
+	case RejectMetachars:
+		return email, !strings.ContainsAny(email, "&=")
+	default:
+		return email, true
+	}
+}
+
+// A profile is a user's account details.
+type profile struct {
+	email, uid, role string
+}
+
+// encode returns p encoded in format f.
+func (f ProfileFormat) encode(p profile) string {
+	switch f {
+	case CookieProfiles:
+		return "email=" + p.email + "&uid=" + p.uid + "&role=" + p.role
+	case JSONProfiles:
+		b, err := json.Marshal(struct {
+			Email string      `json:"email"`
+			UID   json.Number `json:"uid"`
+			Role  string      `json:"role"`
+		}{p.email, json.Number(p.uid), p.role})
+		if err != nil {
+			panic(err)
+		}
+		return string(b)
+	default:
+		vals := url.Values{}
+		vals.Add("email", p.email)
+		vals.Add("uid", p.uid)
+		vals.Add("role", p.role)
+		return vals.Encode()
+	}
+}
+
+// decode parses a profile encoded in format f. If a field appears more than
+// once, the first one wins.
+func (f ProfileFormat) decode(s string) (profile, error) {
+	switch f {
+	case CookieProfiles:
+		return parseCookieProfile(s)
+	case JSONProfiles:
+		return parseJSONProfile(s)
+	default:
+		vals, err := url.ParseQuery(s)
+		if err != nil {
+			return profile{}, err
+		}
+		return profile{email: vals.Get("email"), uid: vals.Get("uid"), role: vals.Get("role")}, nil
+	}
+}
+
+// parseCookieProfile parses a profile in the CookieProfiles format.
+func parseCookieProfile(s string) (profile, error) {
+	var (
+		p    profile
+		seen = make(map[string]bool)
+	)
+
+	for _, field := range strings.Split(s, "&") {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok {
+			return profile{}, errors.New("malformed field")
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		switch k {
+		case "email":
+			p.email = v
+		case "uid":
+			p.uid = v
+		case "role":
+			p.role = v
+		}
+	}
+
+	return p, nil
+}
+
+// parseJSONProfile parses a profile in the JSONProfiles format.
+//
+// Unlike encoding/json, which keeps the last value of a repeated key, it
+// keeps the first, like the other formats.
+func parseJSONProfile(s string) (profile, error) {
+	var (
+		p    profile
+		seen = make(map[string]bool)
+	)
+
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return profile{}, errors.New("not a json object")
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return profile{}, err
+		}
+		k := t.(string) // Keys are always strings.
+
+		t, err = dec.Token()
+		if err != nil {
+			return profile{}, err
+		}
+
+		var v string
+		switch t := t.(type) {
+		case string:
+			v = t
+		case json.Number:
+			v = t.String()
+		default:
+			return profile{}, errors.New("unexpected json value")
+		}
+
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		switch k {
+		case "email":
+			p.email = v
+		case "uid":
+			p.uid = v
+		case "role":
+			p.role = v
+		}
+	}
+
+	if t, err := dec.Token(); err != nil || t != json.Delim('}') {
+		return profile{}, errors.New("not a json object")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return profile{}, errors.New("data after json object")
+	}
+
+	return p, nil
+}
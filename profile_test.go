@@ -0,0 +1,80 @@
+package cryptopals
+
+import "testing"
+
+func TestProfileFormats(t *testing.T) {
+	cases := []struct {
+		f    ProfileFormat
+		want string
+	}{
+		{QueryProfiles, "email=foo%40bar.com&role=user&uid=10"},
+		{CookieProfiles, "email=foo@bar.com&uid=10&role=user"},
+		{JSONProfiles, `{"email":"foo@bar.com","uid":10,"role":"user"}`},
+	}
+
+	for _, tc := range cases {
+		p := profile{email: "foo@bar.com", uid: "10", role: "user"}
+
+		got := tc.f.encode(p)
+		if tc.want != got {
+			t.Errorf("format %d: want %q, got %q", tc.f, tc.want, got)
+		}
+
+		decoded, err := tc.f.decode(got)
+		if err != nil {
+			t.Fatalf("format %d: %v", tc.f, err)
+		}
+		if decoded != p {
+			t.Errorf("format %d: want %+v, got %+v", tc.f, p, decoded)
+		}
+	}
+}
+
+func TestCookieProfileFirstFieldWins(t *testing.T) {
+	p, err := CookieProfiles.decode("email=a&role=user&role=admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.role != "user" {
+		t.Errorf("want role %q, got %q", "user", p.role)
+	}
+}
+
+func TestJSONProfileFirstFieldWins(t *testing.T) {
+	p, err := JSONProfiles.decode(`{"email":"a","role":"user","role":"admin"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.role != "user" {
+		t.Errorf("want role %q, got %q", "user", p.role)
+	}
+}
+
+func TestJSONProfileInvalid(t *testing.T) {
+	for _, s := range []string{"", "[]", `{"email":"a"`, `{"role":["admin"]}`, `{"role":"admin"}x`} {
+		if _, err := JSONProfiles.decode(s); err == nil {
+			t.Errorf("%q: want error, got nil", s)
+		}
+	}
+}
+
+func TestSanitization(t *testing.T) {
+	cases := []struct {
+		s    Sanitization
+		in   string
+		want string
+		ok   bool
+	}{
+		{KeepMetachars, "foo@bar.com&role=admin", "foo@bar.com&role=admin", true},
+		{StripMetachars, "foo@bar.com&role=admin", "foo@bar.comroleadmin", true},
+		{RejectMetachars, "foo@bar.com&role=admin", "", false},
+		{RejectMetachars, "foo@bar.com", "foo@bar.com", true},
+	}
+
+	for _, tc := range cases {
+		got, ok := tc.s.sanitize(tc.in)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("sanitization %d, %q: want %q, %t, got %q, %t", tc.s, tc.in, tc.want, tc.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cryptopals
+
+import (
+	"errors"
+	"hash/maphash"
+	"io"
+	"math/bits"
+)
+
+// HammingReader returns the Hamming distance between the contents of a and b,
+// reading both in chunks rather than all at once.
+//
+// It returns an error if a and b have different lengths.
+func HammingReader(a, b io.Reader) (int, error) {
+	var (
+		bufA = make([]byte, 32*1024)
+		bufB = make([]byte, len(bufA))
+		res  int
+	)
+
+	for {
+		n, errA := io.ReadFull(a, bufA)
+		if errA != nil && errA != io.EOF && errA != io.ErrUnexpectedEOF {
+			return 0, errA
+		}
+
+		m, errB := io.ReadFull(b, bufB)
+		if errB != nil && errB != io.EOF && errB != io.ErrUnexpectedEOF {
+			return 0, errB
+		}
+
+		if n != m {
+			return 0, errors.New("different lengths")
+		}
+
+		for i := range n {
+			res += bits.OnesCount8(bufA[i] ^ bufB[i])
+		}
+
+		// A short read means both readers are exhausted.
+		if n < len(bufA) {
+			return res, nil
+		}
+	}
+}
+
+// ECBScoreReader is like ECBScore, but it reads the ciphertext from r one
+// block at a time.
+//
+// Rather than keeping every block, it keeps a 64-bit hash of each distinct
+// block, so memory use is 8 bytes per distinct block and there's a negligible
+// chance of counting two different blocks as repeats.
+func ECBScoreReader(r io.Reader, blockSize int) (float64, error) {
+	if blockSize < 1 {
+		panic("invalid block size")
+	}
+
+	var (
+		seed   = maphash.MakeSeed()
+		seen   = make(map[uint64]struct{})
+		block  = make([]byte, blockSize)
+		blocks int
+		dups   int
+	)
+
+	for {
+		n, err := io.ReadFull(r, block)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			// Not a whole number of blocks.
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		h := maphash.Bytes(seed, block[:n])
+		if _, ok := seen[h]; ok {
+			dups++
+		}
+		seen[h] = struct{}{}
+		blocks++
+	}
+
+	if blocks < 2 {
+		return 0, nil
+	}
+	return float64(dups) / float64(blocks-1), nil
+}
@@ -0,0 +1,73 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHammingReader(t *testing.T) {
+	a := strings.NewReader("this is a test")
+	b := iotest.OneByteReader(strings.NewReader("wokka wokka!!!"))
+	want := 37
+
+	got, err := HammingReader(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestHammingReaderLarge(t *testing.T) {
+	// Span several internal buffers.
+	x := bytes.Repeat([]byte{0x00}, 100_000)
+	y := bytes.Repeat([]byte{0x01}, 100_000)
+
+	got, err := HammingReader(bytes.NewReader(x), bytes.NewReader(y))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Hamming(x, y); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestHammingReaderErrors(t *testing.T) {
+	if _, err := HammingReader(strings.NewReader("abc"), strings.NewReader("ab")); err == nil {
+		t.Error("want error for different lengths")
+	}
+
+	errBoom := errors.New("boom")
+	if _, err := HammingReader(iotest.ErrReader(errBoom), strings.NewReader("")); !errors.Is(err, errBoom) {
+		t.Errorf("want %v, got %v", errBoom, err)
+	}
+}
+
+func TestECBScoreReader(t *testing.T) {
+	in := decodeHexStringsFromFile(t, "testdata/8.txt")
+
+	for i, ct := range in {
+		want := ECBScore(ct, aes.BlockSize)
+
+		got, err := ECBScoreReader(iotest.HalfReader(bytes.NewReader(ct)), aes.BlockSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != got {
+			t.Errorf("ciphertext %d: want %v, got %v", i, want, got)
+		}
+	}
+
+	got, err := ECBScoreReader(strings.NewReader("AAAAAAAAA"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 0 {
+		t.Errorf("partial block: want 0, got %v", got)
+	}
+}
@@ -0,0 +1,501 @@
+package cryptopals
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithTimeout sets how long a remote oracle waits for each attempt at a
+// request. The default is 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithRetries sets how many times a remote oracle retries a request after a
+// network error or timeout. The default is 2, and n < 0 means no retries.
+func WithRetries(n int) Option {
+	return func(o *options) {
+		o.retries = max(n, 0)
+	}
+}
+
+// WithMaxInFlight limits how many requests a remote oracle makes at once.
+// If n < 1, which is the default, there's no limit.
+func WithMaxInFlight(n int) Option {
+	return func(o *options) {
+		o.inFlight = n
+	}
+}
+
+// permanentError wraps an error that retrying won't fix, such as a rejected
+// request.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// remote applies the timeouts, retries, and concurrency limit shared by
+// the remote oracles.
+type remote struct {
+	o   *options
+	sem chan struct{} // Nil if there's no limit.
+}
+
+func newRemote(o *options) *remote {
+	r := &remote{o: o}
+	if o.inFlight > 0 {
+		r.sem = make(chan struct{}, o.inFlight)
+	}
+	return r
+}
+
+// do calls f until it succeeds, it returns a permanentError, or the retries
+// run out. Each call gets a context with the configured timeout. Errors from
+// f are returned unwrapped.
+func (r *remote) do(f func(ctx context.Context) error) error {
+	if r.sem != nil {
+		r.sem <- struct{}{}
+		defer func() { <-r.sem }()
+	}
+
+	var err error
+	for attempt := range r.o.retries + 1 {
+		if attempt > 0 {
+			r.o.debug("retrying request", "attempt", attempt, "err", err)
+			time.Sleep(time.Duration(attempt) * 50 * time.Millisecond)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.o.timeout)
+		err = f(ctx)
+		cancel()
+
+		var perm permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// httpGet fetches u and returns the status code and body. Responses other
+// than 200 and 500 are errors, and 4xx responses are permanent.
+func httpGet(ctx context.Context, c *http.Client, u string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, nil, permanentError{err}
+	}
+	return httpDo(c, req)
+}
+
+func httpDo(c *http.Client, req *http.Request) (int, []byte, error) {
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusInternalServerError:
+		return resp.StatusCode, body, nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return 0, nil, permanentError{fmt.Errorf("server returned %s", resp.Status)}
+	default:
+		return 0, nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+}
+
+// lineClient sends requests to a line-based TCP server, keeping idle
+// connections open for reuse.
+type lineClient struct {
+	addr string
+
+	mu   sync.Mutex
+	idle []*lineConn
+}
+
+type lineConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// roundTrip sends line and returns the reply line, without its newline.
+func (c *lineClient) roundTrip(ctx context.Context, line string) (string, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if d, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(d)
+	}
+
+	if _, err := io.WriteString(conn, line+"\n"); err != nil {
+		conn.Close()
+		return "", err
+	}
+
+	resp, err := conn.r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.idle = append(c.idle, conn)
+	c.mu.Unlock()
+
+	return strings.TrimSpace(resp), nil
+}
+
+// get returns an idle connection or dials a new one.
+func (c *lineClient) get(ctx context.Context) (*lineConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	return &lineConn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Close closes the idle connections.
+func (c *lineClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for _, conn := range c.idle {
+		errs = append(errs, conn.Close())
+	}
+	c.idle = nil
+	return errors.Join(errs...)
+}
+
+// A RemoteOracle queries an oracle over the network, such as one served by
+// NewOracleHandler or ServeOracle.
+//
+// Requests that fail with a network error or time out are retried. Use
+// WithTimeout, WithRetries, and WithMaxInFlight to configure this.
+type RemoteOracle struct {
+	r     *remote
+	query func(ctx context.Context, input []byte) ([]byte, error)
+	close func() error
+}
+
+// NewHTTPOracle returns a remote oracle that POSTs each input to url, as
+// served by NewOracleHandler.
+func NewHTTPOracle(url string, opts ...Option) *RemoteOracle {
+	c := &http.Client{}
+	return &RemoteOracle{
+		r: newRemote(newOptions(opts)),
+		query: func(ctx context.Context, input []byte) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(input))
+			if err != nil {
+				return nil, permanentError{err}
+			}
+			req.Header.Set("Content-Type", "application/octet-stream")
+
+			code, body, err := httpDo(c, req)
+			if err != nil {
+				return nil, err
+			}
+			if code != http.StatusOK {
+				return nil, fmt.Errorf("server returned %d", code)
+			}
+			return body, nil
+		},
+		close: func() error {
+			c.CloseIdleConnections()
+			return nil
+		},
+	}
+}
+
+// NewTCPOracle returns a remote oracle that queries the line-based server at
+// addr, as served by ServeOracle.
+func NewTCPOracle(addr string, opts ...Option) *RemoteOracle {
+	c := &lineClient{addr: addr}
+	return &RemoteOracle{
+		r: newRemote(newOptions(opts)),
+		query: func(ctx context.Context, input []byte) ([]byte, error) {
+			resp, err := c.roundTrip(ctx, hex.EncodeToString(input))
+			if err != nil {
+				return nil, err
+			}
+			out, err := hex.DecodeString(resp)
+			if err != nil {
+				return nil, permanentError{fmt.Errorf("bad response %q", resp)}
+			}
+			return out, nil
+		},
+		close: c.Close,
+	}
+}
+
+// Query sends input to the oracle and returns its output.
+func (o *RemoteOracle) Query(input []byte) ([]byte, error) {
+	var out []byte
+	err := o.r.do(func(ctx context.Context) error {
+		var err error
+		out, err = o.query(ctx, input)
+		return err
+	})
+	return out, err
+}
+
+// Oracle is like Query, but panics if the query fails. It has the same
+// signature as the oracles in this package, so o.Oracle can be passed to
+// their attacks.
+func (o *RemoteOracle) Oracle(input []byte) []byte {
+	out, err := o.Query(input)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Close closes idle connections to the server.
+func (o *RemoteOracle) Close() error {
+	return o.close()
+}
+
+// A RemotePaddingOracle queries a CBC padding oracle over the network, such
+// as one served by NewCBCPaddingOracleHandler or ServeCBCPaddingOracle. It
+// retries requests just as RemoteOracle does.
+type RemotePaddingOracle struct {
+	r          *remote
+	ciphertext func(ctx context.Context) ([]byte, error)
+	check      func(ctx context.Context, ct []byte) error
+	close      func() error
+}
+
+// NewHTTPPaddingOracle returns a remote padding oracle for the server at
+// baseURL, as served by NewCBCPaddingOracleHandler.
+func NewHTTPPaddingOracle(baseURL string, opts ...Option) *RemotePaddingOracle {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	c := &http.Client{}
+	return &RemotePaddingOracle{
+		r: newRemote(newOptions(opts)),
+		ciphertext: func(ctx context.Context) ([]byte, error) {
+			code, body, err := httpGet(ctx, c, baseURL+"/ciphertext")
+			if err != nil {
+				return nil, err
+			}
+			if code != http.StatusOK {
+				return nil, fmt.Errorf("server returned %d", code)
+			}
+			return decodeHexResponse(string(body))
+		},
+		check: func(ctx context.Context, ct []byte) error {
+			u := baseURL + "/check?ct=" + url.QueryEscape(hex.EncodeToString(ct))
+			code, _, err := httpGet(ctx, c, u)
+			if err != nil {
+				return err
+			}
+			if code != http.StatusOK {
+				return permanentError{ErrInvalidPadding}
+			}
+			return nil
+		},
+		close: func() error {
+			c.CloseIdleConnections()
+			return nil
+		},
+	}
+}
+
+// NewTCPPaddingOracle returns a remote padding oracle for the line-based
+// server at addr, as served by ServeCBCPaddingOracle.
+func NewTCPPaddingOracle(addr string, opts ...Option) *RemotePaddingOracle {
+	c := &lineClient{addr: addr}
+	return &RemotePaddingOracle{
+		r: newRemote(newOptions(opts)),
+		ciphertext: func(ctx context.Context) ([]byte, error) {
+			resp, err := c.roundTrip(ctx, "ciphertext")
+			if err != nil {
+				return nil, err
+			}
+			return decodeHexResponse(resp)
+		},
+		check: func(ctx context.Context, ct []byte) error {
+			resp, err := c.roundTrip(ctx, hex.EncodeToString(ct))
+			if err != nil {
+				return err
+			}
+			switch resp {
+			case "ok":
+				return nil
+			case "error", ErrInvalidPadding.Error():
+				// A terse server gives no reason, so assume it's the padding.
+				return permanentError{ErrInvalidPadding}
+			default:
+				return permanentError{fmt.Errorf("server returned %q", resp)}
+			}
+		},
+		close: c.Close,
+	}
+}
+
+// decodeHexResponse decodes a hex-encoded ciphertext sent by a server.
+func decodeHexResponse(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, permanentError{fmt.Errorf("bad response: %w", err)}
+	}
+	return b, nil
+}
+
+// Ciphertext fetches a fresh iv || ct from the server.
+func (p *RemotePaddingOracle) Ciphertext() ([]byte, error) {
+	var ct []byte
+	err := p.r.do(func(ctx context.Context) error {
+		var err error
+		ct, err = p.ciphertext(ctx)
+		return err
+	})
+	return ct, err
+}
+
+// Check asks the server whether iv || ct decrypts to validly padded
+// plaintext. It returns nil if it does, ErrInvalidPadding if it doesn't, and
+// another error if the request fails.
+func (p *RemotePaddingOracle) Check(ct []byte) error {
+	return p.r.do(func(ctx context.Context) error {
+		return p.check(ctx, ct)
+	})
+}
+
+// IsValid reports whether Check(ct) returns nil. It panics if the request
+// fails, rather than reporting a network error as invalid padding. It can be
+// passed to RecoverCBCPaddingOracleSecret.
+func (p *RemotePaddingOracle) IsValid(ct []byte) bool {
+	err := p.Check(ct)
+	if err != nil && !errors.Is(err, ErrInvalidPadding) {
+		panic(err)
+	}
+	return err == nil
+}
+
+// Query is like IsValid, but answers as ValidityFunc does and returns an
+// error if the request fails, so p is an Oracle for CBCPaddingOracleAttack.
+func (p *RemotePaddingOracle) Query(ct []byte) ([]byte, error) {
+	err := p.Check(ct)
+	if err != nil && !errors.Is(err, ErrInvalidPadding) {
+		return nil, err
+	}
+	return validityAnswer(err == nil), nil
+}
+
+// Close closes idle connections to the server.
+func (p *RemotePaddingOracle) Close() error {
+	return p.close()
+}
+
+// An SRPConn is a connection to an SRP server, as served by ServeSRP.
+type SRPConn struct {
+	ch Channel
+}
+
+// DialSRP connects to the SRP server at addr. Each message has to go through
+// within the timeout set with WithTimeout. Unlike the remote oracles, it
+// doesn't retry, since a login can't carry on over a new connection.
+func DialSRP(addr string, opts ...Option) (*SRPConn, error) {
+	ch, err := DialChannel(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SRPConn{ch}, nil
+}
+
+// Hello sends the first message of a login, the client's email and public
+// value A, and returns the server's challenge. It returns an error if the
+// server rejects the login.
+func (c *SRPConn) Hello(email string, A *big.Int) (SRPChallenge, error) {
+	return srpHello(c.ch, email, A)
+}
+
+// Prove sends the proof for the challenge from Hello, and reports whether
+// the server accepted it.
+func (c *SRPConn) Prove(proof []byte) (bool, error) {
+	return srpProve(c.ch, proof)
+}
+
+// Login logs in as client, and reports whether the server accepted it.
+func (c *SRPConn) Login(client *SRPClient) (bool, error) {
+	l := SRPLogin{Client: client}
+	err := l.Run(c.ch)
+	return l.Accepted, err
+}
+
+// Close closes the connection.
+func (c *SRPConn) Close() error {
+	return c.ch.Close()
+}
+
+// A DHEchoConn is a connection to an echo server, as served by ServeDHEcho,
+// acting as Alice from challenge 34.
+type DHEchoConn struct {
+	ch    Channel
+	block cipher.Block
+}
+
+// DialDHEcho connects to the echo server at addr and agrees on a key with
+// it, over the group from challenge 33. As with DialSRP, each message has to
+// go through within the timeout set with WithTimeout.
+func DialDHEcho(addr string, opts ...Option) (*DHEchoConn, error) {
+	ch, err := DialChannel(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	block, err := dhEchoHandshake(ch)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return &DHEchoConn{ch, block}, nil
+}
+
+// Echo sends msg encrypted and returns the server's decrypted reply. It
+// returns an error if the reply doesn't decrypt, as when the server didn't
+// agree on the same key.
+func (c *DHEchoConn) Echo(msg []byte) ([]byte, error) {
+	return dhEcho(c.ch, c.block, msg)
+}
+
+// Close closes the connection.
+func (c *DHEchoConn) Close() error {
+	return c.ch.Close()
+}
@@ -0,0 +1,199 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// listen starts a line-based server on a random local port and returns its
+// address.
+func listen(t testing.TB, serve func(net.Listener)) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go serve(l)
+
+	return l.Addr().String()
+}
+
+func TestRemoteOracleChallenge12(t *testing.T) {
+	secret := []byte("remote oracles look just like local ones")
+	oracle := NewECBSuffixOracle(secret)
+
+	srv := httptest.NewServer(NewOracleHandler(oracle))
+	defer srv.Close()
+
+	addr := listen(t, func(l net.Listener) { ServeOracle(l, oracle) })
+
+	remotes := map[string]*RemoteOracle{
+		"http": NewHTTPOracle(srv.URL),
+		"tcp":  NewTCPOracle(addr),
+	}
+
+	for name, r := range remotes {
+		got := RecoverECBSuffixOracleSecret(r.Oracle)
+		if !bytes.Equal(secret, got) {
+			t.Errorf("%s: want %q, got %q", name, secret, got)
+		}
+		r.Close()
+	}
+}
+
+func TestRemotePaddingOracleChallenge17(t *testing.T) {
+	secret := []byte("attack at dawn!")
+	p := NewCBCPaddingOracle(secret)
+
+	srv := httptest.NewServer(NewCBCPaddingOracleHandler(p))
+	defer srv.Close()
+
+	addr := listen(t, func(l net.Listener) { ServeCBCPaddingOracle(l, p, WithVerboseErrors()) })
+
+	remotes := map[string]*RemotePaddingOracle{
+		"http": NewHTTPPaddingOracle(srv.URL),
+		"tcp":  NewTCPPaddingOracle(addr),
+	}
+
+	for name, r := range remotes {
+		ct, err := r.Ciphertext()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if err := r.Check(ct[:len(ct)-1]); err == nil || errors.Is(err, ErrInvalidPadding) {
+			t.Errorf("%s: want malformed ciphertext error, got %v", name, err)
+		}
+
+		got, err := RecoverCBCPaddingOracleSecret(ct, p.BlockSize(), r.IsValid)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if !bytes.Equal(secret, got) {
+			t.Errorf("%s: want %q, got %q", name, secret, got)
+		}
+		r.Close()
+	}
+}
+
+func TestRemoteOracleRetries(t *testing.T) {
+	var calls atomic.Int64
+
+	// Fail the first two requests.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTPOracle(srv.URL, WithRetries(1)).Query(nil); err == nil {
+		t.Error("want error with 1 retry, got nil")
+	}
+
+	// A negative count still makes one request.
+	calls.Store(0)
+	if _, err := NewHTTPOracle(srv.URL, WithRetries(-1)).Query(nil); err == nil {
+		t.Error("want error with -1 retries, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("want 1 call with -1 retries, got %d", n)
+	}
+
+	calls.Store(0)
+
+	out, err := NewHTTPOracle(srv.URL, WithRetries(2)).Query(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "ok" {
+		t.Errorf("want %q, got %q", "ok", out)
+	}
+}
+
+func TestRemoteOracleNoRetryOnRejection(t *testing.T) {
+	var calls atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "no", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if _, err := NewHTTPOracle(srv.URL, WithRetries(5)).Query(nil); err == nil {
+		t.Error("want error, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("want 1 call, got %d", n)
+	}
+}
+
+func TestRemoteOracleTimeout(t *testing.T) {
+	oracle := func(b []byte) []byte { return b }
+
+	addr := listen(t, func(l net.Listener) {
+		ServeOracle(l, oracle, WithLatency(200*time.Millisecond))
+	})
+
+	r := NewTCPOracle(addr, WithTimeout(20*time.Millisecond), WithRetries(0))
+	defer r.Close()
+
+	if _, err := r.Query([]byte("x")); err == nil {
+		t.Error("want timeout error, got nil")
+	}
+}
+
+func TestRemoteOracleMaxInFlight(t *testing.T) {
+	const limit = 2
+
+	var (
+		cur, peak atomic.Int64
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := cur.Add(1)
+		defer cur.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	r := NewHTTPOracle(srv.URL, WithMaxInFlight(limit))
+	defer r.Close()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.Query(nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("want at most %d requests in flight, got %d", limit, p)
+	}
+}
@@ -0,0 +1,163 @@
+package cryptopals
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+// A CiphertextRecord is one captured message, such as a packet or a stored
+// value.
+type CiphertextRecord struct {
+	KeyID      string // Identifies the key, such as by session or connection.
+	Nonce      []byte // The nonce or IV sent with the message.
+	Ciphertext []byte
+}
+
+// A NonceReuse is a nonce used for more than one record under the same key.
+type NonceReuse struct {
+	KeyID   string
+	Nonce   []byte
+	Records []int // Indexes of the records, in order.
+}
+
+// FindNonceReuse returns every nonce that appears in more than one record
+// with the same KeyID, ordered by the record it first appears in. The same
+// nonce under different keys is fine and isn't reported.
+//
+// Records sharing a nonce under a stream cipher such as CTR or ChaCha20 share
+// a keystream, so RecoverReusedKeystream or RecoverKeystream applies. Under
+// CBC, they reveal which plaintexts start the same way.
+func FindNonceReuse(records []CiphertextRecord) []NonceReuse {
+	type keyNonce struct{ key, nonce string }
+
+	var (
+		res  []NonceReuse
+		seen = make(map[keyNonce]int) // First record index for each pair.
+		idx  = make(map[keyNonce]int) // Index into res, once reused.
+	)
+	for i, r := range records {
+		k := keyNonce{r.KeyID, string(r.Nonce)}
+		first, ok := seen[k]
+		if !ok {
+			seen[k] = i
+			continue
+		}
+		j, ok := idx[k]
+		if !ok {
+			j = len(res)
+			idx[k] = j
+			res = append(res, NonceReuse{KeyID: r.KeyID, Nonce: slices.Clone(r.Nonce), Records: []int{first}})
+		}
+		res[j].Records = append(res[j].Records, i)
+	}
+	return res
+}
+
+// A ReuseKind is a sign that ciphertexts without explicit nonces were
+// encrypted deterministically.
+type ReuseKind int
+
+const (
+	// ECBReuse is a ciphertext with a repeated block, as ECB mode gives for
+	// repeated plaintext blocks. See IsECBCiphertext.
+	ECBReuse ReuseKind = iota
+
+	// StaticIVReuse is ciphertexts that start with the same block, as CBC
+	// mode with a fixed IV, or ECB mode, gives for plaintexts that start the
+	// same way.
+	StaticIVReuse
+
+	// KeystreamReuse is ciphertexts that XOR to what looks like two ASCII
+	// texts XORed together, as a stream cipher with a fixed nonce gives.
+	KeystreamReuse
+)
+
+var reuseKindNames = []string{"ecb", "static-iv", "keystream"}
+
+func (k ReuseKind) String() string {
+	if k < 0 || int(k) >= len(reuseKindNames) {
+		return fmt.Sprintf("ReuseKind(%d)", int(k))
+	}
+	return reuseKindNames[k]
+}
+
+// A ReuseFinding is a group of ciphertexts showing one ReuseKind.
+type ReuseFinding struct {
+	Kind    ReuseKind
+	Records []int // Indexes of the ciphertexts, in order.
+}
+
+// keystreamReuseMinOverlap is the fewest bytes two ciphertexts must overlap
+// by to be checked for KeystreamReuse, so that random ciphertexts rarely
+// pass by chance.
+const keystreamReuseMinOverlap = 16
+
+// DetectCiphertextReuse looks for signs of ECB mode, a static IV, or a reused
+// keystream across cts, for corpora where nonces aren't explicit, to help
+// choose an attack. It reports ECBReuse for each ciphertext on its own, then
+// StaticIVReuse and KeystreamReuse for each group, ordered by the first
+// ciphertext in the group.
+//
+// These are heuristics. KeystreamReuse assumes ASCII plaintexts, and checks
+// that nearly all of the bytes the two ciphertexts share XOR to a value below
+// 0x80, which random bytes do only half the time. Ciphertexts with little
+// plaintext in common show no sign of a static IV.
+func DetectCiphertextReuse(cts [][]byte, blockSize int) []ReuseFinding {
+	var res []ReuseFinding
+
+	for i, ct := range cts {
+		if IsECBCiphertext(ct, blockSize) {
+			res = append(res, ReuseFinding{Kind: ECBReuse, Records: []int{i}})
+		}
+	}
+
+	res = append(res, groupCiphertexts(cts, StaticIVReuse, func(a, b []byte) bool {
+		return len(a) >= blockSize && len(b) >= blockSize && bytes.Equal(a[:blockSize], b[:blockSize])
+	})...)
+	res = append(res, groupCiphertexts(cts, KeystreamReuse, looksLikeTextXOR)...)
+
+	return res
+}
+
+// groupCiphertexts groups each ciphertext with the later ones that match it,
+// skipping any already in a group.
+func groupCiphertexts(cts [][]byte, kind ReuseKind, match func(a, b []byte) bool) []ReuseFinding {
+	var res []ReuseFinding
+	grouped := make([]bool, len(cts))
+
+	for i := range cts {
+		if grouped[i] {
+			continue
+		}
+		group := []int{i}
+		for j := i + 1; j < len(cts); j++ {
+			if !grouped[j] && match(cts[i], cts[j]) {
+				grouped[j] = true
+				group = append(group, j)
+			}
+		}
+		if len(group) > 1 {
+			res = append(res, ReuseFinding{Kind: kind, Records: group})
+		}
+	}
+	return res
+}
+
+// looksLikeTextXOR reports whether at least 95% of the bytes a and b share
+// XOR to a value below 0x80, as they do if both are ASCII text under the same
+// keystream.
+func looksLikeTextXOR(a, b []byte) bool {
+	n := min(len(a), len(b))
+	if n < keystreamReuseMinOverlap {
+		return false
+	}
+
+	var low int
+	for i := range n {
+		if a[i]^b[i] < 0x80 {
+			low++
+		}
+	}
+	return low*100 >= n*95
+}
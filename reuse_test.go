@@ -0,0 +1,80 @@
+package cryptopals
+
+import (
+	"crypto/aes"
+	"reflect"
+	"testing"
+)
+
+func TestFindNonceReuse(t *testing.T) {
+	records := []CiphertextRecord{
+		{KeyID: "a", Nonce: []byte{1}},
+		{KeyID: "a", Nonce: []byte{2}},
+		{KeyID: "b", Nonce: []byte{1}},
+		{KeyID: "a", Nonce: []byte{2}},
+		{KeyID: "b", Nonce: []byte{1}},
+		{KeyID: "a", Nonce: []byte{2}},
+		{KeyID: "a", Nonce: []byte{3}},
+	}
+	want := []NonceReuse{
+		{KeyID: "a", Nonce: []byte{2}, Records: []int{1, 3, 5}},
+		{KeyID: "b", Nonce: []byte{1}, Records: []int{2, 4}},
+	}
+	if got := FindNonceReuse(records); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindNonceReuse() = %+v, want %+v", got, want)
+	}
+
+	if got := FindNonceReuse(records[:3]); len(got) != 0 {
+		t.Errorf("FindNonceReuse() with no reuse = %+v", got)
+	}
+}
+
+func TestDetectCiphertextReuse(t *testing.T) {
+	block, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecb := func(pt []byte) []byte {
+		pt = PadPKCS7(pt, 16)
+		NewECBEncrypter(block).CryptBlocks(pt, pt)
+		return pt
+	}
+	staticCBC := func(pt []byte) []byte {
+		pt = PadPKCS7(pt, 16)
+		NewCBCEncrypter(block, make([]byte, 16)).CryptBlocks(pt, pt)
+		return pt
+	}
+	fixedCTR := func(pt []byte) []byte {
+		ct := make([]byte, len(pt))
+		NewNonceCTR(block, 0).XORKeyStream(ct, pt)
+		return ct
+	}
+
+	cts := [][]byte{
+		0: EncryptPadded(block, []byte("user=alice&role=user&tag=0")),
+		1: ecb([]byte("YELLOW SUBMARINEYELLOW SUBMARINE")),
+		2: staticCBC([]byte("user=alice&role=user&tag=1")),
+		3: fixedCTR([]byte("Now that the party is jumping")),
+		4: EncryptPadded(block, []byte("user=alice&role=user&tag=2")),
+		5: staticCBC([]byte("user=alice&role=admin&tag=2")),
+		6: fixedCTR([]byte("With the bass kicked in and the Vega's are pumpin'")),
+		7: randBytes(48),
+	}
+	want := []ReuseFinding{
+		{Kind: ECBReuse, Records: []int{1}},
+		{Kind: StaticIVReuse, Records: []int{2, 5}},
+		{Kind: KeystreamReuse, Records: []int{3, 6}},
+	}
+	if got := DetectCiphertextReuse(cts, 16); !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectCiphertextReuse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReuseKindString(t *testing.T) {
+	if got := KeystreamReuse.String(); got != "keystream" {
+		t.Errorf("KeystreamReuse.String() = %q", got)
+	}
+	if got := ReuseKind(9).String(); got != "ReuseKind(9)" {
+		t.Errorf("ReuseKind(9).String() = %q", got)
+	}
+}
@@ -0,0 +1,156 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// An RSAPublicKey is an RSA modulus and public exponent.
+type RSAPublicKey struct {
+	N, E *big.Int
+}
+
+// An RSAPrivateKey is an RSA key pair, with the factors of the modulus.
+type RSAPrivateKey struct {
+	RSAPublicKey
+	D, P, Q *big.Int
+}
+
+// GenerateRSAKey returns a random RSA key with a modulus of bits bits and the
+// public exponent e, such as 3 as in challenge 39 or 65537. It returns an
+// error if bits is less than 16 or e isn't odd and at least 3.
+func GenerateRSAKey(bits int, e int64) (*RSAPrivateKey, error) {
+	if bits < 16 {
+		return nil, errors.New("modulus too small")
+	}
+	if e < 3 || e%2 == 0 {
+		return nil, errors.New("invalid public exponent")
+	}
+
+	E := big.NewInt(e)
+	one := big.NewInt(1)
+	for {
+		p, err := rand.Prime(rand.Reader, (bits+1)/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || n.BitLen() != bits {
+			continue
+		}
+
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(E, phi)
+		if d == nil {
+			continue
+		}
+		return &RSAPrivateKey{RSAPublicKey: RSAPublicKey{N: n, E: E}, D: d, P: p, Q: q}, nil
+	}
+}
+
+// rsaKeyFromFactors returns the private key for pub, given the factors of
+// its modulus, or nil if e isn't invertible.
+func rsaKeyFromFactors(pub *RSAPublicKey, p, q *big.Int) *RSAPrivateKey {
+	one := big.NewInt(1)
+	phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+	d := new(big.Int).ModInverse(pub.E, phi)
+	if d == nil {
+		return nil
+	}
+	return &RSAPrivateKey{RSAPublicKey: *pub, D: d, P: p, Q: q}
+}
+
+// Size returns the length of the modulus in bytes.
+func (k *RSAPublicKey) Size() int {
+	return (k.N.BitLen() + 7) / 8
+}
+
+// Encrypt returns m^e mod n, textbook RSA with no padding.
+func (k *RSAPublicKey) Encrypt(m *big.Int) *big.Int {
+	return new(big.Int).Exp(m, k.E, k.N)
+}
+
+// Decrypt returns c^d mod n, textbook RSA with no padding. If the key has
+// its factors, it works mod p and q and combines the results with the
+// Chinese remainder theorem, which is several times faster.
+func (k *RSAPrivateKey) Decrypt(c *big.Int) *big.Int {
+	if k.P == nil || k.Q == nil {
+		return new(big.Int).Exp(c, k.D, k.N)
+	}
+	one := big.NewInt(1)
+	mp := new(big.Int).Exp(c, new(big.Int).Mod(k.D, new(big.Int).Sub(k.P, one)), k.P)
+	mq := new(big.Int).Exp(c, new(big.Int).Mod(k.D, new(big.Int).Sub(k.Q, one)), k.Q)
+
+	// m = mq + q * ((mp - mq) * q^-1 mod p).
+	h := new(big.Int).Sub(mp, mq)
+	h.Mul(h, new(big.Int).ModInverse(k.Q, k.P)).Mod(h, k.P)
+	return h.Mul(h, k.Q).Add(h, mq)
+}
+
+// rsaBytes returns x as a big-endian number of n bytes.
+func rsaBytes(x *big.Int, n int) []byte {
+	return x.FillBytes(make([]byte, n))
+}
+
+// EncryptPKCS1v15 encrypts msg under k with PKCS #1 v1.5 padding: the
+// encryption block 00 || 02 || nonzero random bytes || 00 || msg, as long as
+// the modulus. It returns an error if msg is longer than k.Size() - 11.
+func EncryptPKCS1v15(k *RSAPublicKey, msg []byte) ([]byte, error) {
+	n := k.Size()
+	if len(msg) > n-11 {
+		return nil, errors.New("message too long")
+	}
+
+	em := make([]byte, n)
+	em[1] = 2
+	ps := em[2 : n-len(msg)-1]
+	for i := range ps {
+		for ps[i] == 0 {
+			ps[i] = randBytes(1)[0]
+		}
+	}
+	copy(em[n-len(msg):], msg)
+
+	c := k.Encrypt(new(big.Int).SetBytes(em))
+	return rsaBytes(c, n), nil
+}
+
+// DecryptPKCS1v15 decrypts ct under k and removes the PKCS #1 v1.5 padding.
+// It returns an error if the padding is malformed, which makes it a
+// Bleichenbacher oracle: see NewBleichenbacherOracle.
+func DecryptPKCS1v15(k *RSAPrivateKey, ct []byte) ([]byte, error) {
+	n := k.Size()
+	c := new(big.Int).SetBytes(ct)
+	if len(ct) != n || c.Cmp(k.N) >= 0 {
+		return nil, errors.New("invalid ciphertext")
+	}
+
+	em := rsaBytes(k.Decrypt(c), n)
+	msg, ok := unpadPKCS1v15(em)
+	if !ok {
+		return nil, errors.New("invalid padding")
+	}
+	return msg, nil
+}
+
+// unpadPKCS1v15 returns the message in the encryption block em, and reports
+// whether em has at least 8 bytes of padding and a separator.
+func unpadPKCS1v15(em []byte) ([]byte, bool) {
+	if len(em) < 11 || em[0] != 0 || em[1] != 2 {
+		return nil, false
+	}
+	for i := 2; i < len(em); i++ {
+		if em[i] == 0 {
+			if i < 10 {
+				return nil, false
+			}
+			return em[i+1:], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,74 @@
+package cryptopals
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestRSA(t *testing.T) {
+	k, err := GenerateRSAKey(512, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.N.BitLen() != 512 || k.Size() != 64 {
+		t.Errorf("modulus has %d bits", k.N.BitLen())
+	}
+
+	m := big.NewInt(42)
+	if got := k.Decrypt(k.Encrypt(m)); got.Cmp(m) != 0 {
+		t.Errorf("got %v, want %v", got, m)
+	}
+}
+
+func TestGenerateRSAKeyErrors(t *testing.T) {
+	for _, tt := range []struct {
+		bits int
+		e    int64
+	}{{8, 3}, {512, 2}, {512, 1}} {
+		if _, err := GenerateRSAKey(tt.bits, tt.e); err == nil {
+			t.Errorf("%d bits, e = %d: no error", tt.bits, tt.e)
+		}
+	}
+}
+
+func TestPKCS1v15(t *testing.T) {
+	k, err := GenerateRSAKey(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range [][]byte{nil, []byte("kick it, CC"), make([]byte, 64-11)} {
+		ct, err := EncryptPKCS1v15(&k.RSAPublicKey, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecryptPKCS1v15(k, ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("got %q, want %q", got, msg)
+		}
+	}
+
+	if _, err := EncryptPKCS1v15(&k.RSAPublicKey, make([]byte, 64-10)); err == nil {
+		t.Error("long message: no error")
+	}
+	if _, err := DecryptPKCS1v15(k, k.Encrypt(big.NewInt(1)).FillBytes(make([]byte, 64))); err == nil {
+		t.Error("bad padding: no error")
+	}
+}
+
+func TestRSADecryptWithoutFactors(t *testing.T) {
+	k, err := GenerateRSAKey(256, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noCRT := &RSAPrivateKey{RSAPublicKey: k.RSAPublicKey, D: k.D}
+
+	c := k.Encrypt(big.NewInt(1234567))
+	if a, b := k.Decrypt(c), noCRT.Decrypt(c); a.Cmp(b) != 0 {
+		t.Errorf("CRT gives %v, plain gives %v", a, b)
+	}
+}
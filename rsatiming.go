@@ -0,0 +1,247 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	mrand "math/rand/v2"
+)
+
+// WithBlinding makes a TimingRSA blind each ciphertext before decrypting
+// it: it multiplies c by r^e for a random r, and the result by r^-1. The
+// exponentiation then works on a value the attacker doesn't know, which
+// defeats RecoverTimingRSAExponent.
+func WithBlinding() Option {
+	return func(o *options) {
+		o.blinding = true
+	}
+}
+
+// Simulated cycle counts for a Montgomery multiplication.
+const (
+	montMulCycles        = 500
+	extraReductionCycles = 100
+)
+
+// montgomery does Montgomery multiplication mod an odd n, with R = 2^w for
+// the bit length w of n.
+type montgomery struct {
+	n, nInv *big.Int // nInv is -n^-1 mod R.
+	r2      *big.Int // R^2 mod n, to convert into Montgomery form.
+	w       uint
+	mask    *big.Int // R - 1.
+}
+
+func newMontgomery(n *big.Int) *montgomery {
+	w := uint(n.BitLen())
+	r := new(big.Int).Lsh(big.NewInt(1), w)
+	nInv := new(big.Int).ModInverse(n, r)
+	nInv.Sub(r, nInv)
+	r2 := new(big.Int).Lsh(big.NewInt(1), 2*w)
+	return &montgomery{
+		n:    n,
+		nInv: nInv,
+		r2:   r2.Mod(r2, n),
+		w:    w,
+		mask: new(big.Int).Sub(r, big.NewInt(1)),
+	}
+}
+
+// mul returns a*b/R mod n, for a and b less than n, and reports whether it
+// needed the final extra reduction, the step whose timing leaks.
+func (m *montgomery) mul(a, b *big.Int) (*big.Int, bool) {
+	t := new(big.Int).Mul(a, b)
+	u := new(big.Int).And(t, m.mask)
+	u.Mul(u, m.nInv).And(u, m.mask)
+	u.Mul(u, m.n).Add(u, t).Rsh(u, m.w)
+	if u.Cmp(m.n) >= 0 {
+		return u.Sub(u, m.n), true
+	}
+	return u, false
+}
+
+// A TimingRSA is an RSA decryption oracle that exponentiates by left-to-
+// right square-and-multiply over Montgomery multiplication, without the
+// Chinese remainder theorem, and reports the simulated time each decryption
+// took. A multiplication that needs Montgomery's final extra reduction takes
+// longer, and which ones do depends on the ciphertext and the private
+// exponent's bits, so the time leaks the exponent.
+type TimingRSA struct {
+	key   *RSAPrivateKey
+	m     *montgomery
+	noise int
+	blind bool
+	rng   *mrand.ChaCha8
+}
+
+// NewTimingRSA returns a TimingRSA for key. Use WithTimingNoise to add
+// noise, WithSeed to make it deterministic, and WithBlinding for the
+// countermeasure. It panics if the modulus is even.
+func NewTimingRSA(key *RSAPrivateKey, opts ...Option) *TimingRSA {
+	if key.N.Bit(0) == 0 {
+		panic("even modulus")
+	}
+	o := newOptions(opts)
+	return &TimingRSA{
+		key:   key,
+		m:     newMontgomery(key.N),
+		noise: o.timingNoise,
+		blind: o.blinding,
+		rng:   o.newRand(),
+	}
+}
+
+// PublicKey returns the oracle's public key.
+func (t *TimingRSA) PublicKey() *RSAPublicKey {
+	return &t.key.RSAPublicKey
+}
+
+// Decrypt returns c^d mod n and the simulated cycles it took. Blinding, if
+// enabled, isn't counted.
+func (t *TimingRSA) Decrypt(c *big.Int) (m *big.Int, cycles int) {
+	n := t.key.N
+	c = new(big.Int).Mod(c, n)
+
+	var r *big.Int
+	if t.blind {
+		for r == nil || new(big.Int).GCD(nil, nil, r, n).Cmp(big.NewInt(1)) != 0 {
+			var err error
+			if r, err = rand.Int(t.rng, n); err != nil {
+				panic(err)
+			}
+		}
+		c.Mul(c, t.key.Encrypt(r)).Mod(c, n)
+	}
+
+	mul := func(a, b *big.Int) *big.Int {
+		p, extra := t.m.mul(a, b)
+		cycles += montMulCycles
+		if extra {
+			cycles += extraReductionCycles
+		}
+		return p
+	}
+
+	x := mul(c, t.m.r2)
+	acc := x
+	d := t.key.D
+	for i := d.BitLen() - 2; i >= 0; i-- {
+		acc = mul(acc, acc)
+		if d.Bit(i) == 1 {
+			acc = mul(acc, x)
+		}
+	}
+	m = mul(acc, big.NewInt(1))
+
+	if t.blind {
+		m.Mul(m, new(big.Int).ModInverse(r, n)).Mod(m, n)
+	}
+	if t.noise > 0 {
+		cycles += int(t.rng.Uint64() % uint64(t.noise+1))
+	}
+	return m, cycles
+}
+
+// timingSplit returns the mean of times where extra is set minus the mean
+// where it isn't, or 0 if either set is empty.
+func timingSplit(times []int, extra []bool) float64 {
+	var sum [2]float64
+	var count [2]int
+	for j, e := range extra {
+		k := 0
+		if e {
+			k = 1
+		}
+		sum[k] += float64(times[j])
+		count[k]++
+	}
+	if count[0] == 0 || count[1] == 0 {
+		return 0
+	}
+	return sum[1]/float64(count[1]) - sum[0]/float64(count[0])
+}
+
+// RecoverTimingRSAExponent returns the private exponent of the RSA key pub
+// from the times decrypt takes on random ciphertexts, as from a TimingRSA,
+// with Kocher's timing attack in the form Dhem et al. gave it for Montgomery
+// multiplication.
+//
+// It recovers the exponent a bit at a time from the top, simulating each
+// sample's exponentiation up to the current bit. The next squaring is of the
+// product with the ciphertext if the bit is 1 and of the square alone if it's
+// 0, and only the right guess predicts which samples' squarings need the
+// extra reduction, so only its split of the samples shows a time
+// difference. (The multiplication itself is a poor guide: whether it
+// reduces depends mostly on the ciphertext, which every multiplication
+// shares.) It stops at the first exponent that undoes e. A wrong bit derails
+// all the ones after it, so it needs enough samples for every decision:
+// about 20000, with the default noise, for a 64-bit modulus.
+//
+// It returns an error if no exponent up to the modulus's length works, as
+// when the oracle uses blinding.
+func RecoverTimingRSAExponent(pub *RSAPublicKey, samples int, decrypt func(c *big.Int) int, opts ...Option) (*big.Int, error) {
+	o := newOptions(opts)
+	n := pub.N
+	if n.Bit(0) == 0 {
+		return nil, errors.New("even modulus")
+	}
+	m := newMontgomery(n)
+
+	// works reports whether d inverts e, by checking it on one value.
+	base := big.NewInt(2)
+	check := pub.Encrypt(base)
+	works := func(d *big.Int) bool {
+		return new(big.Int).Exp(check, d, n).Cmp(base) == 0
+	}
+
+	rng := o.newRand()
+	times := make([]int, samples)
+	xs := make([]*big.Int, samples)
+	accs := make([]*big.Int, samples)
+	for j := range samples {
+		c := new(big.Int).SetUint64(rng.Uint64())
+		for c.BitLen() < n.BitLen()+64 {
+			c.Lsh(c, 64).Or(c, new(big.Int).SetUint64(rng.Uint64()))
+		}
+		c.Mod(c, n)
+		times[j] = decrypt(c)
+		xs[j], _ = m.mul(c, m.r2)
+		accs[j] = xs[j]
+	}
+
+	d := big.NewInt(1)
+	squares, muls := make([]*big.Int, samples), make([]*big.Int, samples)
+	extra0, extra1 := make([]bool, samples), make([]bool, samples)
+	for bit := 1; bit < n.BitLen(); bit++ {
+		if works(d) {
+			return d, nil
+		}
+
+		for j := range samples {
+			squares[j], _ = m.mul(accs[j], accs[j])
+			muls[j], _ = m.mul(squares[j], xs[j])
+			_, extra1[j] = m.mul(muls[j], muls[j])
+			_, extra0[j] = m.mul(squares[j], squares[j])
+		}
+		one := timingSplit(times, extra1) > timingSplit(times, extra0)
+
+		d.Lsh(d, 1)
+		if one {
+			d.SetBit(d, 0, 1)
+			copy(accs, muls)
+		} else {
+			copy(accs, squares)
+		}
+
+		// The last bit has no next squaring to tell it by, so try
+		// flipping it.
+		if alt := new(big.Int).Xor(d, big.NewInt(1)); works(alt) {
+			return alt, nil
+		}
+		o.debug("timing rsa bit", "bit", bit, "one", one)
+	}
+	if works(d) {
+		return d, nil
+	}
+	return nil, errors.New("no exponent fits the timings")
+}
@@ -0,0 +1,50 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTimingRSADecrypt(t *testing.T) {
+	key, err := GenerateRSAKey(128, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range [][]Option{nil, {WithBlinding()}} {
+		r := NewTimingRSA(key, opts...)
+		for range 20 {
+			m := new(big.Int).SetBytes(randBytes(15))
+			got, _ := r.Decrypt(key.Encrypt(m))
+			if got.Cmp(m) != 0 {
+				t.Fatalf("got %x, want %x", got, m)
+			}
+		}
+	}
+}
+
+func TestRecoverTimingRSAExponent(t *testing.T) {
+	// A fixed key and seeds keep the samples, and so the result, the same on
+	// every run.
+	p, q := big.NewInt(4294967291), big.NewInt(4294967279)
+	pub := &RSAPublicKey{N: new(big.Int).Mul(p, q), E: big.NewInt(65537)}
+	key := rsaKeyFromFactors(pub, p, q)
+
+	decrypt := func(r *TimingRSA) func(*big.Int) int {
+		return func(c *big.Int) int {
+			_, cycles := r.Decrypt(c)
+			return cycles
+		}
+	}
+
+	got, err := RecoverTimingRSAExponent(pub, 20000, decrypt(NewTimingRSA(key, WithSeed(1))), WithSeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(key.D) != 0 {
+		t.Errorf("got %x, want %x", got, key.D)
+	}
+
+	if d, err := RecoverTimingRSAExponent(pub, 2000, decrypt(NewTimingRSA(key, WithBlinding(), WithSeed(1))), WithSeed(2)); err == nil {
+		t.Errorf("blinded: got %x, want an error", d)
+	}
+}
@@ -0,0 +1,110 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/bits"
+)
+
+// Salsa20 sizes in bytes.
+const (
+	Salsa20KeySize   = 32
+	Salsa20NonceSize = 8
+)
+
+// salsaQuarterRound is the Salsa20 quarter round. It's ChaCha's predecessor:
+// the same add, rotate, and XOR operations, but each step updates one word
+// from the sum of two others.
+func salsaQuarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	b ^= bits.RotateLeft32(a+d, 7)
+	c ^= bits.RotateLeft32(b+a, 9)
+	d ^= bits.RotateLeft32(c+b, 13)
+	a ^= bits.RotateLeft32(d+c, 18)
+	return a, b, c, d
+}
+
+// SalsaCore is the Salsa20 block function with a configurable number of
+// rounds. It runs rounds rounds on in, alternating column and row rounds
+// starting with a column round, and adds in to the result. Salsa20 uses 20,
+// and Salsa20/8 and Salsa20/12 8 and 12.
+func SalsaCore(in *[16]uint32, rounds int) [16]uint32 {
+	x := *in
+	for i := range rounds {
+		if i%2 == 0 {
+			x[0], x[4], x[8], x[12] = salsaQuarterRound(x[0], x[4], x[8], x[12])
+			x[5], x[9], x[13], x[1] = salsaQuarterRound(x[5], x[9], x[13], x[1])
+			x[10], x[14], x[2], x[6] = salsaQuarterRound(x[10], x[14], x[2], x[6])
+			x[15], x[3], x[7], x[11] = salsaQuarterRound(x[15], x[3], x[7], x[11])
+		} else {
+			x[0], x[1], x[2], x[3] = salsaQuarterRound(x[0], x[1], x[2], x[3])
+			x[5], x[6], x[7], x[4] = salsaQuarterRound(x[5], x[6], x[7], x[4])
+			x[10], x[11], x[8], x[9] = salsaQuarterRound(x[10], x[11], x[8], x[9])
+			x[15], x[12], x[13], x[14] = salsaQuarterRound(x[15], x[12], x[13], x[14])
+		}
+	}
+	for i := range x {
+		x[i] += in[i]
+	}
+	return x
+}
+
+// salsa20State returns the initial Salsa20 state for key, nonce, and
+// counter. It panics if key or nonce is the wrong size.
+func salsa20State(key, nonce []byte, counter uint64) [16]uint32 {
+	if len(key) != Salsa20KeySize {
+		panic("invalid key size")
+	}
+	if len(nonce) != Salsa20NonceSize {
+		panic("invalid nonce size")
+	}
+
+	// The constants are on the diagonal, with the key around them.
+	var s [16]uint32
+	s[0], s[5], s[10], s[15] = chachaConstants[0], chachaConstants[1], chachaConstants[2], chachaConstants[3]
+	for i := range 4 {
+		s[1+i] = binary.LittleEndian.Uint32(key[4*i:])
+		s[11+i] = binary.LittleEndian.Uint32(key[16+4*i:])
+	}
+	s[6] = binary.LittleEndian.Uint32(nonce)
+	s[7] = binary.LittleEndian.Uint32(nonce[4:])
+	s[8], s[9] = uint32(counter), uint32(counter>>32)
+	return s
+}
+
+// Salsa20Block returns the 64-byte Salsa20 keystream block for key, nonce,
+// and counter. It panics if key isn't 32 bytes or nonce isn't 8.
+func Salsa20Block(key, nonce []byte, counter uint64) [arxBlockSize]byte {
+	s := salsa20State(key, nonce, counter)
+	return arxBytes(SalsaCore(&s, 20))
+}
+
+// NewSalsa20 returns a cipher.Stream that encrypts and decrypts with the
+// Salsa20 stream cipher with a 32-byte key, implemented from the
+// specification, starting at block 0. It panics if key isn't 32 bytes or
+// nonce isn't 8.
+//
+// Like ChaCha20, reusing a key and nonce reuses the keystream.
+func NewSalsa20(key, nonce []byte) cipher.Stream {
+	return NewSalsa(key, nonce, 0, 20)
+}
+
+// NewSalsa is like NewSalsa20, but starting at block counter and with the
+// given number of rounds. It panics if rounds isn't positive, and
+// XORKeyStream panics if the 64-bit block counter overflows.
+func NewSalsa(key, nonce []byte, counter uint64, rounds int) cipher.Stream {
+	checkARXRounds(rounds)
+	return &arxStream{
+		state:  salsa20State(key, nonce, counter),
+		core:   SalsaCore,
+		rounds: rounds,
+		step: func(s *[16]uint32) bool {
+			s[8]++
+			if s[8] == 0 {
+				s[9]++
+				return s[9] == 0
+			}
+			return false
+		},
+		off: arxBlockSize,
+	}
+}
@@ -0,0 +1,60 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSalsaQuarterRound(t *testing.T) {
+	// From the Salsa20 specification.
+	tests := []struct {
+		in, want [4]uint32
+	}{
+		{[4]uint32{1, 0, 0, 0}, [4]uint32{0x08008145, 0x00000080, 0x00010200, 0x20500000}},
+		{[4]uint32{0, 1, 0, 0}, [4]uint32{0x88000100, 0x00000001, 0x00000200, 0x00402000}},
+		{[4]uint32{0xe7e8c006, 0xc4f9417d, 0x6479b4b2, 0x68c67137}, [4]uint32{0xe876d72b, 0x9361dfd5, 0xf1460244, 0x948541a3}},
+	}
+	for _, tt := range tests {
+		var got [4]uint32
+		got[0], got[1], got[2], got[3] = salsaQuarterRound(tt.in[0], tt.in[1], tt.in[2], tt.in[3])
+		if got != tt.want {
+			t.Errorf("salsaQuarterRound(%08x) = %08x, want %08x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSalsa20(t *testing.T) {
+	// eSTREAM 256-bit key test vectors, set 1, vector 0.
+	key := make([]byte, Salsa20KeySize)
+	key[0] = 0x80
+	nonce := make([]byte, Salsa20NonceSize)
+	want := decodeHex(t, "e3be8fdd8beca2e3ea8ef9475b29a6e7003951e1097a5c38d23b7a5fad9f6844b22c97559e2723c7cbbd3fe4fc8d9a0744652a83e72a9c461876af4d7ef1a117")
+
+	got := Salsa20Block(key, nonce, 0)
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("Salsa20Block: got %x, want %x", got, want)
+	}
+
+	// The stream continues into block 1, in pieces.
+	ks := make([]byte, 2*arxBlockSize)
+	s := NewSalsa20(key, nonce)
+	for i := 0; i < len(ks); i += 7 {
+		j := min(i+7, len(ks))
+		s.XORKeyStream(ks[i:j], ks[i:j])
+	}
+	next := Salsa20Block(key, nonce, 1)
+	if !bytes.Equal(ks[:arxBlockSize], want) || !bytes.Equal(ks[arxBlockSize:], next[:]) {
+		t.Errorf("NewSalsa20: got %x", ks)
+	}
+}
+
+func TestSalsaCounterCarry(t *testing.T) {
+	key, nonce := randBytes(Salsa20KeySize), randBytes(Salsa20NonceSize)
+	ks := make([]byte, 2*arxBlockSize)
+	NewSalsa(key, nonce, 1<<32-1, 20).XORKeyStream(ks, ks)
+
+	want := Salsa20Block(key, nonce, 1<<32)
+	if !bytes.Equal(ks[arxBlockSize:], want[:]) {
+		t.Errorf("got %x, want %x", ks[arxBlockSize:], want)
+	}
+}
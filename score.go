@@ -0,0 +1,248 @@
+package cryptopals
+
+import (
+	_ "embed"
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+// Samples of ordinary prose.
+var (
+	//go:embed english-corpus.txt
+	englishText []byte
+
+	//go:embed german-corpus.txt
+	germanText []byte
+)
+
+// A Scorer scores plaintexts on how much they resemble a language. Higher is
+// better.
+type Scorer interface {
+	Score(b []byte) float64
+}
+
+// ScorerFunc adapts a function to the Scorer interface.
+type ScorerFunc func(b []byte) float64
+
+// Score returns f(b).
+func (f ScorerFunc) Score(b []byte) float64 {
+	return f(b)
+}
+
+// WithScorer sets the scorer that an attack uses to recognize plaintexts. The
+// default is ScorerFunc(Englishness).
+func WithScorer(s Scorer) Option {
+	return func(o *options) {
+		o.scorer = s
+	}
+}
+
+// An EnglishScorer scores plaintexts by adding up per-byte weights, like
+// Englishness, but with weights that can be tuned for plaintexts that aren't
+// prose, such as source code or JSON.
+//
+// Scores are length-normalized. Higher is better. An EnglishScorer is safe
+// for concurrent use as long as its fields aren't changed.
+type EnglishScorer struct {
+	Weights [256]float64 // Weight of each byte.
+
+	SpaceBonus float64 // Added to the weight of a space.
+
+	// ControlPenalty is subtracted from the weight of each ASCII control
+	// byte other than tab, newline, and carriage return.
+	ControlPenalty float64
+
+	// FoldCase weighs each uppercase ASCII letter as its lowercase form.
+	FoldCase bool
+}
+
+// NewEnglishScorer returns an EnglishScorer that scores exactly like
+// Englishness, as a starting point for tuning.
+func NewEnglishScorer() *EnglishScorer {
+	s := &EnglishScorer{Weights: englishWeights}
+	s.SpaceBonus, s.Weights[' '] = s.Weights[' '], 0
+	return s
+}
+
+// Score returns the average weight of the bytes in b.
+//
+// If len(b) == 0, Score returns 0.
+func (s *EnglishScorer) Score(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	var n float64
+	for _, v := range b {
+		w := s.Weights[v]
+		switch {
+		case s.FoldCase && 'A' <= v && v <= 'Z':
+			w = s.Weights[v+'a'-'A']
+		case v == ' ':
+			w += s.SpaceBonus
+		case (v < 0x20 && v != '\t' && v != '\n' && v != '\r') || v == 0x7f:
+			w -= s.ControlPenalty
+		}
+		n += w
+	}
+	return n / float64(len(b))
+}
+
+// NGramScorer scores plaintexts by the log-likelihood of their n-grams under a
+// model built from a corpus.
+type NGramScorer struct {
+	n        int
+	logProbs map[string]float64
+	floor    float64 // Log probability of an n-gram missing from the corpus.
+}
+
+// NewNGramScorer returns a scorer for byte n-grams of length n counted from
+// corpus.
+//
+// It panics if n < 1 or the corpus is shorter than n bytes.
+func NewNGramScorer(corpus []byte, n int) *NGramScorer {
+	if n < 1 {
+		panic("n < 1")
+	}
+	if len(corpus) < n {
+		panic("corpus too short")
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i+n <= len(corpus); i++ {
+		counts[string(corpus[i:i+n])]++
+	}
+
+	total := float64(len(corpus) - n + 1)
+
+	logProbs := make(map[string]float64, len(counts))
+	for gram, c := range counts {
+		logProbs[gram] = math.Log(float64(c) / total)
+	}
+
+	return &NGramScorer{
+		n:        n,
+		logProbs: logProbs,
+		floor:    math.Log(0.01 / total),
+	}
+}
+
+// NewEnglishNGramScorer returns an NGramScorer for English built from the
+// embedded corpus.
+//
+// Bigrams (n = 2) and trigrams (n = 3) recognize short plaintexts far more
+// reliably than single-letter frequencies.
+func NewEnglishNGramScorer(n int) *NGramScorer {
+	return englishCorpus.NGramScorer(n)
+}
+
+// Score returns the average log-likelihood of the n-grams in b, which is at
+// most 0. Higher is better.
+//
+// If len(b) < n, Score returns the score of a single unseen n-gram.
+func (s *NGramScorer) Score(b []byte) float64 {
+	if len(b) < s.n {
+		return s.floor
+	}
+
+	var sum float64
+	for i := 0; i+s.n <= len(b); i++ {
+		lp, ok := s.logProbs[string(b[i:i+s.n])]
+		if !ok {
+			lp = s.floor
+		}
+		sum += lp
+	}
+	return sum / float64(len(b)-s.n+1)
+}
+
+// The embedded corpora.
+var (
+	englishCorpus = &Corpus{Name: "english", text: englishText}
+	germanCorpus  = &Corpus{Name: "german", text: germanText}
+)
+
+// A Corpus is a sample of text in some language.
+type Corpus struct {
+	Name string // For example, "english".
+
+	text []byte
+
+	mu      sync.Mutex
+	scorers map[int]*NGramScorer // Keyed by n.
+}
+
+// LoadCorpus reads a corpus from r. The caller may set its Name.
+func LoadCorpus(r io.Reader) (*Corpus, error) {
+	text, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(text) == 0 {
+		return nil, errors.New("empty corpus")
+	}
+	return &Corpus{text: text}, nil
+}
+
+// EnglishCorpus returns the embedded English corpus.
+func EnglishCorpus() *Corpus {
+	return englishCorpus
+}
+
+// GermanCorpus returns the embedded German corpus.
+func GermanCorpus() *Corpus {
+	return germanCorpus
+}
+
+// Corpora returns the embedded corpora.
+func Corpora() []*Corpus {
+	return []*Corpus{englishCorpus, germanCorpus}
+}
+
+// NGramScorer returns an NGramScorer for n-grams counted from c. Scorers are
+// built once per n and shared.
+//
+// It panics if n < 1 or the corpus is shorter than n bytes.
+func (c *Corpus) NGramScorer(n int) *NGramScorer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.scorers[n]; ok {
+		return s
+	}
+
+	s := NewNGramScorer(c.text, n)
+
+	if c.scorers == nil {
+		c.scorers = make(map[int]*NGramScorer)
+	}
+	c.scorers[n] = s
+
+	return s
+}
+
+// DetectLanguage returns the corpus whose trigrams b most resembles. If no
+// corpora are given, it chooses among the embedded corpora.
+func DetectLanguage(b []byte, corpora ...*Corpus) *Corpus {
+	if len(corpora) == 0 {
+		corpora = Corpora()
+	}
+
+	var (
+		best      *Corpus
+		bestScore = math.Inf(-1) // Higher is better.
+	)
+
+	for _, c := range corpora {
+		score := c.NGramScorer(3).Score(b)
+
+		if score > bestScore {
+			bestScore = score
+			best = c
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,152 @@
+package cryptopals
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNGramScorer(t *testing.T) {
+	english := []byte("the cat sat on the mat")
+	gibberish := []byte("qzx vjk wqp zzx kqj xv")
+
+	for _, n := range []int{1, 2, 3} {
+		s := NewEnglishNGramScorer(n)
+
+		if s.Score(english) <= s.Score(gibberish) {
+			t.Errorf("n=%d: english scored %v, gibberish scored %v", n, s.Score(english), s.Score(gibberish))
+		}
+
+		if got := s.Score(english); got > 0 {
+			t.Errorf("n=%d: want score <= 0, got %v", n, got)
+		}
+	}
+}
+
+func TestNGramScorerShortInput(t *testing.T) {
+	s := NewEnglishNGramScorer(3)
+
+	if s.Score([]byte("ab")) != s.Score(nil) {
+		t.Error("inputs shorter than n should score the same")
+	}
+}
+
+func TestRecoverSingleByteXORKeyShort(t *testing.T) {
+	// Englishness gets most of these wrong for most keys.
+	pts := []string{"Hello there", "attack at dawn", "Cooking MCs", "Burning"}
+
+	for _, n := range []int{2, 3} {
+		s := NewEnglishNGramScorer(n)
+
+		for _, pt := range pts {
+			t.Run(fmt.Sprintf("%d/%s", n, pt), func(t *testing.T) {
+				for k := range 256 {
+					key := byte(k)
+
+					ct := []byte(pt)
+					NewSingleByteXORCipher(key).XORKeyStream(ct, ct)
+
+					if got := RecoverSingleByteXORKey(ct, WithScorer(s)); key != got {
+						t.Fatalf("want %d, got %d", key, got)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		in   string
+		want *Corpus
+	}{
+		{"The weather was cold, so we stayed inside and read.", EnglishCorpus()},
+		{"Das Wetter war kalt, also blieben wir drinnen und lasen.", GermanCorpus()},
+		{"Where is the train station?", EnglishCorpus()},
+		{"Wo ist der Bahnhof?", GermanCorpus()},
+	}
+
+	for _, tc := range cases {
+		if got := DetectLanguage([]byte(tc.in)); tc.want != got {
+			t.Errorf("%q: want %s, got %s", tc.in, tc.want.Name, got.Name)
+		}
+	}
+}
+
+func TestLoadCorpus(t *testing.T) {
+	digits, err := LoadCorpus(strings.NewReader("3141592653589793238462643383279502884197"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digits.Name = "digits"
+
+	if got := DetectLanguage([]byte("2718281828"), EnglishCorpus(), digits); got != digits {
+		t.Errorf("want digits, got %s", got.Name)
+	}
+
+	if _, err := LoadCorpus(strings.NewReader("")); err == nil {
+		t.Error("want error for empty corpus")
+	}
+}
+
+func TestRecoverSingleByteXORKeyGerman(t *testing.T) {
+	pt := []byte("Guten Morgen, wie geht es dir?")
+	key := byte(0x5c)
+
+	ct := make([]byte, len(pt))
+	NewSingleByteXORCipher(key).XORKeyStream(ct, pt)
+
+	got := RecoverSingleByteXORKey(ct, WithScorer(GermanCorpus().NGramScorer(2)))
+	if key != got {
+		t.Errorf("want %d, got %d", key, got)
+	}
+}
+
+func TestEnglishScorerMatchesEnglishness(t *testing.T) {
+	s := NewEnglishScorer()
+
+	for _, in := range []string{"", "the cat sat on the mat", "QZX\x00\x01\xff", "  eta ETA  "} {
+		if want, got := Englishness([]byte(in)), s.Score([]byte(in)); want != got {
+			t.Errorf("%q: want %v, got %v", in, want, got)
+		}
+	}
+}
+
+func TestEnglishScorerOptions(t *testing.T) {
+	s := NewEnglishScorer()
+	s.FoldCase = true
+	if want, got := s.Score([]byte("eat tea")), s.Score([]byte("EAT TEA")); want != got {
+		t.Errorf("FoldCase: lowercase scored %v, uppercase scored %v", want, got)
+	}
+
+	s = NewEnglishScorer()
+	s.ControlPenalty = 10
+	if got := s.Score([]byte("\x00\x01")); got != -10 {
+		t.Errorf("ControlPenalty: want -10, got %v", got)
+	}
+	if got := s.Score([]byte("\t\n\r")); got != 0 {
+		t.Errorf("ControlPenalty: whitespace: want 0, got %v", got)
+	}
+
+	s = NewEnglishScorer()
+	s.SpaceBonus = 1
+	if got := s.Score([]byte("  ")); got != 1 {
+		t.Errorf("SpaceBonus: want 1, got %v", got)
+	}
+}
+
+func TestRecoverSingleByteXORKeyEnglishScorer(t *testing.T) {
+	pt := []byte("ATTACK AT DAWN, THEN RETREAT TO THE EASTERN GATE")
+	key := byte(0x5a)
+
+	ct := make([]byte, len(pt))
+	NewSingleByteXORCipher(key).XORKeyStream(ct, pt)
+
+	s := NewEnglishScorer()
+	s.FoldCase = true
+	s.ControlPenalty = 5
+
+	if got := RecoverSingleByteXORKey(ct, WithScorer(s)); got != key {
+		t.Errorf("want key %#x, got %#x", key, got)
+	}
+}
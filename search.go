@@ -0,0 +1,126 @@
+package cryptopals
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// WithWorkers sets how many goroutines a search spreads its candidates across.
+// If n < 1, it uses runtime.GOMAXPROCS(0). The default is 1.
+//
+// Scorers set with WithScorer must be safe for concurrent use when n > 1.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		o.workers = n
+	}
+}
+
+// WithThreshold makes a search stop as soon as a candidate scores at least t,
+// instead of scoring every candidate. By default every candidate is scored.
+//
+// With more than one worker, each worker stops at its first candidate scoring
+// at least t, and the lowest-indexed such candidate wins.
+func WithThreshold(t float64) Option {
+	return func(o *options) {
+		o.threshold = t
+	}
+}
+
+// argmax returns the i in [0, n) with the highest score, and that score. Ties
+// go to the lowest i. It panics if n < 1.
+//
+// If some score is at least threshold, argmax may return that i early
+// instead.
+//
+// The range is split across up to workers goroutines. Each goroutine calls
+// newScore once to get a scoring function of its own, which may keep private
+// buffers between calls.
+func argmax(n, workers int, threshold float64, newScore func() func(i int) float64) (int, float64) {
+	if n < 1 {
+		panic("n < 1")
+	}
+
+	type result struct {
+		i     int
+		score float64
+	}
+
+	search := func(lo, hi int) result {
+		score := newScore()
+		best := result{lo, score(lo)}
+		for i := lo + 1; i < hi && best.score < threshold; i++ {
+			if s := score(i); s > best.score {
+				best = result{i, s}
+			}
+		}
+		return best
+	}
+
+	workers = max(1, min(workers, n))
+	if workers == 1 {
+		r := search(0, n)
+		return r.i, r.score
+	}
+
+	results := make([]result, workers)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[w] = search(w*n/workers, (w+1)*n/workers)
+		}()
+	}
+	wg.Wait()
+
+	// Workers cover ascending ranges, so keeping the first of equal scores
+	// keeps the lowest index, and the first score at the threshold is the
+	// lowest-indexed one.
+	best := results[0]
+	for _, r := range results[1:] {
+		if best.score >= threshold {
+			break
+		}
+		if r.score > best.score {
+			best = r
+		}
+	}
+	return best.i, best.score
+}
+
+// bestSingleByteXORKey returns the key that makes ct score highest under s,
+// and that score, stopping early at the first key that scores at least
+// threshold. It uses pt as scratch space, so pt must be at least as long as
+// ct.
+func bestSingleByteXORKey(ct, pt []byte, s Scorer, threshold float64) (byte, float64) {
+	pt = pt[:len(ct)]
+
+	var (
+		bestKey   byte
+		bestScore = math.Inf(-1) // Higher is better.
+	)
+
+	for i := range math.MaxUint8 + 1 {
+		key := byte(i)
+
+		NewSingleByteXORCipher(key).XORKeyStream(pt, ct)
+
+		score := s.Score(pt)
+
+		if score > bestScore {
+			bestScore = score
+			bestKey = key
+		}
+
+		if bestScore >= threshold {
+			break
+		}
+	}
+
+	return bestKey, bestScore
+}
@@ -0,0 +1,94 @@
+package cryptopals
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+func TestArgmax(t *testing.T) {
+	scores := []float64{1, 5, 3, 5, 2, 5, 0}
+
+	for _, workers := range []int{1, 2, 3, 7, 100} {
+		i, score := argmax(len(scores), workers, math.Inf(1), func() func(int) float64 {
+			return func(i int) float64 { return scores[i] }
+		})
+		if i != 1 || score != 5 {
+			t.Errorf("workers=%d: want (1, 5), got (%d, %v)", workers, i, score)
+		}
+	}
+}
+
+func TestArgmaxThreshold(t *testing.T) {
+	scores := []float64{1, 4, 3, 5, 2, 6, 0}
+
+	for _, workers := range []int{1, 2, 3, 7} {
+		var calls atomic.Int64
+		i, score := argmax(len(scores), workers, 4, func() func(int) float64 {
+			return func(i int) float64 {
+				calls.Add(1)
+				return scores[i]
+			}
+		})
+		if i != 1 || score != 4 {
+			t.Errorf("workers=%d: want (1, 4), got (%d, %v)", workers, i, score)
+		}
+		if workers == 1 && calls.Load() != 2 {
+			t.Errorf("want 2 calls, got %d", calls.Load())
+		}
+	}
+}
+
+func TestChallenge3Parallel(t *testing.T) {
+	ct := decodeHex(t, "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+	want := byte(88)
+
+	for _, workers := range []int{0, 2, 5} {
+		if got := RecoverSingleByteXORKey(ct, WithWorkers(workers)); want != got {
+			t.Errorf("workers=%d: want %d, got %d", workers, want, got)
+		}
+	}
+}
+
+func TestChallenge4Parallel(t *testing.T) {
+	in := decodeHexStringsFromFile(t, "testdata/4.txt")
+	want := 170
+
+	for _, workers := range []int{0, 2, 5} {
+		if got := FindSingleByteXORCiphertext(in, WithWorkers(workers)); want != got {
+			t.Errorf("workers=%d: want %d, got %d", workers, want, got)
+		}
+	}
+}
+
+func BenchmarkChallenge4Parallel(b *testing.B) {
+	in := decodeHexStringsFromFile(b, "testdata/4.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		FindSingleByteXORCiphertext(in, WithWorkers(0))
+	}
+}
+
+func TestChallenge4Threshold(t *testing.T) {
+	in := decodeHexStringsFromFile(t, "testdata/4.txt")
+	want := 170
+
+	for _, workers := range []int{1, 4} {
+		got := FindSingleByteXORCiphertext(in, WithThreshold(1), WithWorkers(workers))
+		if want != got {
+			t.Errorf("workers=%d: want %d, got %d", workers, want, got)
+		}
+	}
+}
+
+func BenchmarkChallenge4Threshold(b *testing.B) {
+	in := decodeHexStringsFromFile(b, "testdata/4.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		FindSingleByteXORCiphertext(in, WithThreshold(1))
+	}
+}
@@ -0,0 +1,330 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// secp256k1 constants, from SEC 2: the field prime p, the order n of the
+// base point, and the base point G itself. The curve is y^2 = x^3 + 7.
+var (
+	k1P, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	k1N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	k1G    = func() k1Point {
+		x, _ := new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+		y, _ := new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+		return k1Point{x, y}
+	}()
+)
+
+// A k1Point is a point on secp256k1 in affine coordinates, or the point at
+// infinity if x is nil. The addition law never uses the curve's constant
+// term, so it works just as well on points that aren't on the curve, which
+// is what invalid-curve attacks rely on.
+type k1Point struct {
+	x, y *big.Int
+}
+
+// isInfinity reports whether a is the point at infinity.
+func (a k1Point) isInfinity() bool {
+	return a.x == nil
+}
+
+// onCurve reports whether a is a point on the curve, with coordinates less
+// than p.
+func (a k1Point) onCurve() bool {
+	if a.isInfinity() || a.x.Cmp(k1P) >= 0 || a.y.Cmp(k1P) >= 0 {
+		return false
+	}
+	lhs := new(big.Int).Mul(a.y, a.y)
+	rhs := new(big.Int).Exp(a.x, big.NewInt(3), k1P)
+	rhs.Add(rhs, big.NewInt(7))
+	return lhs.Sub(lhs, rhs).Mod(lhs, k1P).Sign() == 0
+}
+
+// errK1NoInverse is returned by k1Add when the slope's denominator has no
+// inverse mod p, which can only happen for points that aren't on any one
+// curve.
+var errK1NoInverse = errors.New("point addition has no inverse")
+
+// k1Add returns a + b. The coordinates must be less than p.
+func k1Add(a, b k1Point) (k1Point, error) {
+	switch {
+	case a.isInfinity():
+		return b, nil
+	case b.isInfinity():
+		return a, nil
+	}
+
+	var num, den *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if sum := new(big.Int).Add(a.y, b.y); sum.Mod(sum, k1P).Sign() == 0 {
+			return k1Point{}, nil
+		}
+		// Doubling: slope = 3x^2 / 2y.
+		num = new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den = new(big.Int).Lsh(a.y, 1)
+	} else {
+		num = new(big.Int).Sub(b.y, a.y)
+		den = new(big.Int).Sub(b.x, a.x)
+	}
+	if den.ModInverse(den.Mod(den, k1P), k1P) == nil {
+		return k1Point{}, errK1NoInverse
+	}
+	slope := num.Mul(num, den).Mod(num, k1P)
+
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, k1P)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, k1P)
+	return k1Point{x, y}, nil
+}
+
+// k1Mul returns k*a, by double-and-add.
+func k1Mul(k *big.Int, a k1Point) (k1Point, error) {
+	var res k1Point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		var err error
+		if res, err = k1Add(res, res); err != nil {
+			return k1Point{}, err
+		}
+		if k.Bit(i) == 1 {
+			if res, err = k1Add(res, a); err != nil {
+				return k1Point{}, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// k1BaseMul returns k*G, which can't fail.
+func k1BaseMul(k *big.Int) k1Point {
+	res, err := k1Mul(k, k1G)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// encode returns the 65-byte uncompressed SEC 1 encoding of a: 0x04 || x ||
+// y.
+func (a k1Point) encode() []byte {
+	return slices.Concat([]byte{4}, a.x.FillBytes(make([]byte, 32)), a.y.FillBytes(make([]byte, 32)))
+}
+
+// k1Decode returns the point with the uncompressed encoding b, and reports
+// whether b is well formed. If validate is set, it also reports whether the
+// point is on the curve, with coordinates less than p. Otherwise it reduces
+// the coordinates mod p.
+func k1Decode(b []byte, validate bool) (k1Point, bool) {
+	if len(b) != 65 || b[0] != 4 {
+		return k1Point{}, false
+	}
+	a := k1Point{new(big.Int).SetBytes(b[1:33]), new(big.Int).SetBytes(b[33:])}
+	if validate {
+		return a, a.onCurve()
+	}
+	a.x.Mod(a.x, k1P)
+	a.y.Mod(a.y, k1P)
+	return a, true
+}
+
+// k1Hash returns the digest hash as an integer, truncated to n's bit length
+// as ECDSA specifies.
+func k1Hash(hash []byte) *big.Int {
+	z := new(big.Int).SetBytes(hash)
+	if excess := 8*len(hash) - k1N.BitLen(); excess > 0 {
+		z.Rsh(z, uint(excess))
+	}
+	return z
+}
+
+// A Secp256k1Key is an ECDSA private key on secp256k1, the curve Bitcoin
+// and Ethereum use, implemented with big.Int arithmetic, so it's slow and
+// not constant time.
+type Secp256k1Key struct {
+	d        *big.Int
+	pub      k1Point
+	validate bool
+}
+
+// NewSecp256k1Key returns the key with the 32-byte big-endian private
+// scalar d. Use WithPointValidation to make ECDH check peers' keys. It
+// panics if d isn't 32 bytes or isn't between 1 and n - 1.
+func NewSecp256k1Key(d []byte, opts ...Option) *Secp256k1Key {
+	if len(d) != 32 {
+		panic("invalid private key size")
+	}
+	k := new(big.Int).SetBytes(d)
+	if k.Sign() == 0 || k.Cmp(k1N) >= 0 {
+		panic("invalid private key")
+	}
+	o := newOptions(opts)
+	return &Secp256k1Key{d: k, pub: k1BaseMul(k), validate: o.pointValidation}
+}
+
+// GenerateSecp256k1Key returns a random key.
+func GenerateSecp256k1Key(opts ...Option) *Secp256k1Key {
+	d, err := rand.Int(rand.Reader, new(big.Int).Sub(k1N, big.NewInt(1)))
+	if err != nil {
+		panic(err)
+	}
+	return NewSecp256k1Key(d.Add(d, big.NewInt(1)).FillBytes(make([]byte, 32)), opts...)
+}
+
+// PublicKey returns the 65-byte uncompressed public key, dG.
+func (k *Secp256k1Key) PublicKey() []byte {
+	return k.pub.encode()
+}
+
+// Sign returns the 65-byte recoverable signature r || s || v of the digest
+// hash, with a random nonce. R = kG for the nonce k, r is R's x-coordinate
+// mod n, and s = (z + rd) / k mod n. s is normalized to the lower half of
+// its range, as Bitcoin and Ethereum require.
+//
+// The recovery ID v, from 0 to 3, records what r and s lose about R: bit 0
+// is the parity of R's y-coordinate, and bit 1 is set if R's x-coordinate
+// was n or more. Ethereum's transactions add 27 to it.
+func (k *Secp256k1Key) Sign(hash []byte) []byte {
+	z := k1Hash(hash)
+	halfN := new(big.Int).Rsh(k1N, 1)
+	for {
+		nonce, err := rand.Int(rand.Reader, k1N)
+		if err != nil {
+			panic(err)
+		}
+		if nonce.Sign() == 0 {
+			continue
+		}
+		R := k1BaseMul(nonce)
+		r := new(big.Int).Mod(R.x, k1N)
+		if r.Sign() == 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, k.d)
+		s.Add(s, z).Mul(s, nonce.ModInverse(nonce, k1N)).Mod(s, k1N)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		v := byte(R.y.Bit(0))
+		if R.x.Cmp(k1N) >= 0 {
+			v |= 2
+		}
+		if s.Cmp(halfN) > 0 {
+			// -R has the same x and the other y.
+			s.Sub(k1N, s)
+			v ^= 1
+		}
+		return slices.Concat(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32)), []byte{v})
+	}
+}
+
+// ECDH returns the x-coordinate of d times the peer's uncompressed public
+// key. By default it doesn't check that the peer's key is on the curve, so
+// an invalid-curve point, on a curve with a different constant term and an
+// order with small factors, leaks d mod those factors. Use
+// WithPointValidation to check. It returns an error if the key is
+// malformed, or, with validation, isn't on the curve, or if the result is
+// the point at infinity or can't be computed.
+func (k *Secp256k1Key) ECDH(peer []byte) ([]byte, error) {
+	q, ok := k1Decode(peer, k.validate)
+	if !ok {
+		return nil, errors.New("invalid public key")
+	}
+	shared, err := k1Mul(k.d, q)
+	if err != nil {
+		return nil, err
+	}
+	if shared.isInfinity() {
+		return nil, errors.New("shared secret is the point at infinity")
+	}
+	return shared.x.FillBytes(make([]byte, 32)), nil
+}
+
+// parseK1Signature returns the r, s, and v of a 65-byte signature, and
+// reports whether r and s are between 1 and n - 1 and v is at most 3.
+func parseK1Signature(sig []byte) (r, s *big.Int, v byte, ok bool) {
+	if len(sig) != 65 {
+		return nil, nil, 0, false
+	}
+	r, s, v = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), sig[64]
+	for _, x := range []*big.Int{r, s} {
+		if x.Sign() == 0 || x.Cmp(k1N) >= 0 {
+			return nil, nil, 0, false
+		}
+	}
+	return r, s, v, v <= 3
+}
+
+// VerifySecp256k1 reports whether sig is a valid signature of the digest
+// hash under the uncompressed public key pub: whether the x-coordinate of
+// (z/s)G + (r/s)Q is r mod n. It ignores the recovery ID.
+func VerifySecp256k1(pub, hash, sig []byte) bool {
+	q, ok := k1Decode(pub, true)
+	if !ok {
+		return false
+	}
+	r, s, _, ok := parseK1Signature(sig)
+	if !ok {
+		return false
+	}
+	w := new(big.Int).ModInverse(s, k1N)
+	u1 := new(big.Int).Mul(k1Hash(hash), w)
+	u2 := new(big.Int).Mul(r, w)
+	uq, err := k1Mul(u2.Mod(u2, k1N), q)
+	if err != nil {
+		return false
+	}
+	R, err := k1Add(k1BaseMul(u1.Mod(u1, k1N)), uq)
+	return err == nil && !R.isInfinity() && new(big.Int).Mod(R.x, k1N).Cmp(r) == 0
+}
+
+// RecoverSecp256k1PublicKey returns the uncompressed public key that sig, a
+// recoverable signature of the digest hash, verifies under, as Bitcoin's
+// signed messages and Ethereum's transactions do to save sending it.
+//
+// The recovery ID gives R from r: its x-coordinate is r, or r + n, and its
+// y-coordinate is the square root of x^3 + 7 with the given parity. Then
+// sR = zG + rQ, so Q = (sR - zG) / r.
+//
+// It returns an error if sig is malformed or no key verifies it.
+func RecoverSecp256k1PublicKey(hash, sig []byte) ([]byte, error) {
+	r, s, v, ok := parseK1Signature(sig)
+	if !ok {
+		return nil, errors.New("invalid signature")
+	}
+	x := new(big.Int).Set(r)
+	if v&2 != 0 {
+		x.Add(x, k1N)
+	}
+	if x.Cmp(k1P) >= 0 {
+		return nil, errors.New("invalid signature")
+	}
+	y2 := new(big.Int).Exp(x, big.NewInt(3), k1P)
+	y2.Add(y2, big.NewInt(7)).Mod(y2, k1P)
+	y := new(big.Int).ModSqrt(y2, k1P)
+	if y == nil {
+		return nil, errors.New("invalid signature")
+	}
+	if y.Bit(0) != uint(v&1) {
+		y.Sub(k1P, y)
+	}
+
+	rInv := new(big.Int).ModInverse(r, k1N)
+	u1 := new(big.Int).Mul(k1Hash(hash), rInv)
+	u1.Neg(u1).Mod(u1, k1N)
+	u2 := new(big.Int).Mul(s, rInv)
+	ur, err := k1Mul(u2.Mod(u2, k1N), k1Point{x, y})
+	if err != nil {
+		return nil, errors.New("invalid signature")
+	}
+	q, err := k1Add(k1BaseMul(u1), ur)
+	if err != nil || q.isInfinity() {
+		return nil, errors.New("invalid signature")
+	}
+	return q.encode(), nil
+}
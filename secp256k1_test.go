@@ -0,0 +1,112 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+	"testing"
+)
+
+func TestSecp256k1(t *testing.T) {
+	// 2G and 3G, from the SEC 2 base point.
+	tests := []struct {
+		d    byte
+		x, y string
+	}{
+		{2, "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5", "1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a"},
+		{3, "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9", "388f7b0f632de8140fe337e62a37f3566500a99934c2231b6cb9fd7584b8e672"},
+	}
+	for _, tt := range tests {
+		d := make([]byte, 32)
+		d[31] = tt.d
+		want := append([]byte{4}, append(decodeHex(t, tt.x), decodeHex(t, tt.y)...)...)
+		if got := NewSecp256k1Key(d).PublicKey(); !bytes.Equal(got, want) {
+			t.Errorf("%dG: got %x, want %x", tt.d, got, want)
+		}
+	}
+}
+
+func TestSecp256k1Signatures(t *testing.T) {
+	k := GenerateSecp256k1Key()
+	hash := sha256.Sum256([]byte("send 1 BTC to bob"))
+	for range 4 {
+		sig := k.Sign(hash[:])
+		if !VerifySecp256k1(k.PublicKey(), hash[:], sig) {
+			t.Fatal("signature doesn't verify")
+		}
+		other := sha256.Sum256([]byte("send 1 BTC to mallory"))
+		if VerifySecp256k1(k.PublicKey(), other[:], sig) {
+			t.Error("signature verifies for another digest")
+		}
+
+		pub, err := RecoverSecp256k1PublicKey(hash[:], sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(pub, k.PublicKey()) {
+			t.Errorf("recovered %x, want %x", pub, k.PublicKey())
+		}
+
+		// The wrong parity recovers a different key.
+		sig[64] ^= 1
+		if pub, err := RecoverSecp256k1PublicKey(hash[:], sig); err == nil && bytes.Equal(pub, k.PublicKey()) {
+			t.Error("wrong recovery ID recovers the key")
+		}
+	}
+}
+
+func TestSecp256k1ECDH(t *testing.T) {
+	a, b := GenerateSecp256k1Key(), GenerateSecp256k1Key()
+	s1, err := a.ECDH(b.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := b.ECDH(a.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s1, s2) {
+		t.Errorf("shared secrets differ: %x and %x", s1, s2)
+	}
+
+	// (1, 1) is on y^2 = x^3, not secp256k1.
+	invalid := make([]byte, 65)
+	invalid[0], invalid[32], invalid[64] = 4, 1, 1
+	if _, err := a.ECDH(invalid); err != nil {
+		t.Errorf("without validation: %v", err)
+	}
+	validating := GenerateSecp256k1Key(WithPointValidation(true))
+	if _, err := validating.ECDH(invalid); err == nil {
+		t.Error("with validation: no error")
+	}
+	if _, err := validating.ECDH(a.PublicKey()); err != nil {
+		t.Errorf("with validation, valid key: %v", err)
+	}
+}
+
+func TestSecp256k1ECDHUnreducedKey(t *testing.T) {
+	// (p, 1) is (0, 1), a point of order 3 on y^2 = x^3 + 1, with its
+	// x-coordinate unreduced. With d = 3, adding it to 2P used to compare
+	// unreduced x-coordinates and crash inverting zero.
+	unreduced := slices.Concat([]byte{4}, k1P.FillBytes(make([]byte, 32)), make([]byte, 31), []byte{1})
+	reduced := slices.Concat([]byte{4}, make([]byte, 63), []byte{1})
+	for d := range byte(6) {
+		if d == 0 {
+			continue
+		}
+		k := NewSecp256k1Key(append(make([]byte, 31), d))
+		got, gotErr := k.ECDH(unreduced)
+		want, wantErr := k.ECDH(reduced)
+		if !bytes.Equal(got, want) || (gotErr == nil) != (wantErr == nil) {
+			t.Errorf("d = %d: got %x, %v, want %x, %v", d, got, gotErr, want, wantErr)
+		}
+		if d%3 == 0 && gotErr == nil {
+			t.Errorf("d = %d: no error for the point at infinity", d)
+		}
+	}
+
+	validating := GenerateSecp256k1Key(WithPointValidation(true))
+	if _, err := validating.ECDH(unreduced); err == nil {
+		t.Error("with validation: no error")
+	}
+}
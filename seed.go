@@ -0,0 +1,51 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"time"
+)
+
+// RecoverSeed returns the seed of a generator seeded with a Unix time in
+// seconds, such as MT19937 in challenge 22, an LCG, or a token generator. It
+// tries every second within window of around, starting from around and
+// working outward, and returns the first seed for which the output of gen
+// starts with observed.
+//
+// Use WithWorkers to spread the seeds across goroutines; gen must then be
+// safe for concurrent use. It returns an error if no seed matches, and panics
+// if window is negative.
+func RecoverSeed(gen func(seed int64) []byte, observed []byte, around time.Time, window time.Duration, opts ...Option) (int64, error) {
+	o := newOptions(opts)
+
+	center := around.Unix()
+	span := int64(window / time.Second)
+	if span < 0 {
+		panic("negative window")
+	}
+
+	// Index 0 is center, and later indexes alternate between later and
+	// earlier seeds, so lower indexes are closer to center.
+	seed := func(i int) int64 {
+		d := int64(i+1) / 2
+		if i%2 == 0 {
+			d = -d
+		}
+		return center + d
+	}
+
+	i, score := argmax(int(2*span+1), o.workers, 1, func() func(int) float64 {
+		return func(i int) float64 {
+			if bytes.HasPrefix(gen(seed(i)), observed) {
+				return 1
+			}
+			return 0
+		}
+	})
+	if score == 0 {
+		return 0, errors.New("no seed matches")
+	}
+
+	o.debug("found seed", "seed", seed(i), "offset", time.Duration(seed(i)-center)*time.Second)
+	return seed(i), nil
+}
@@ -0,0 +1,43 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// lcgOutput returns the first few outputs of a glibc LCG seeded with seed.
+func lcgOutput(seed int64) []byte {
+	l := NewLCG(GlibcLCG, uint64(seed))
+	var b []byte
+	for range 4 {
+		b = binary.BigEndian.AppendUint32(b, uint32(l.Next()))
+	}
+	return b
+}
+
+func TestRecoverSeed(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	for _, offset := range []time.Duration{0, -37 * time.Second, 999 * time.Second, -1000 * time.Second} {
+		want := now.Add(offset).Unix()
+		observed := lcgOutput(want)[:8]
+
+		for _, workers := range []int{1, 4} {
+			got, err := RecoverSeed(lcgOutput, observed, now, 1000*time.Second, WithWorkers(workers))
+			if err != nil {
+				t.Fatalf("offset %v, %d workers: %v", offset, workers, err)
+			}
+			if got != want {
+				t.Errorf("offset %v, %d workers: got %d, want %d", offset, workers, got, want)
+			}
+		}
+	}
+}
+
+func TestRecoverSeedOutsideWindow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	observed := lcgOutput(now.Unix() + 61)
+	if _, err := RecoverSeed(lcgOutput, observed, now, time.Minute); err == nil {
+		t.Error("no error")
+	}
+}
@@ -0,0 +1,269 @@
+package cryptopals
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WithLatency sets how long a server waits before each response, to make a
+// remote oracle slower to query. The default is no delay.
+func WithLatency(d time.Duration) Option {
+	return func(o *options) {
+		o.latency = d
+	}
+}
+
+// WithVerboseErrors makes a server say why a ciphertext was rejected, rather
+// than giving the same terse response for every failure.
+func WithVerboseErrors() Option {
+	return func(o *options) {
+		o.verbose = true
+	}
+}
+
+// errMalformed is reported when a client sends something other than a
+// hex-encoded ciphertext of whole blocks.
+var errMalformed = errors.New("malformed ciphertext")
+
+// checkHex decodes a hex-encoded ciphertext and checks its padding with p.
+// It returns errMalformed, ErrInvalidPadding, or nil.
+func checkHex(p *CBCPaddingOracle, s string) error {
+	ct, err := hex.DecodeString(s)
+	if err != nil {
+		return errMalformed
+	}
+	if err := p.Check(ct); err != nil {
+		if errors.Is(err, ErrInvalidPadding) {
+			return err
+		}
+		return errMalformed
+	}
+	return nil
+}
+
+// NewCBCPaddingOracleHandler returns an HTTP handler that exposes p as a
+// remote padding oracle. It serves two endpoints:
+//
+//	GET /ciphertext     returns a fresh hex-encoded iv || ct
+//	GET /check?ct=HEX   checks the padding of a hex-encoded iv || ct
+//
+// The check endpoint responds with 200 OK for valid padding, 500 Internal
+// Server Error for invalid padding, and 400 Bad Request for input that isn't
+// hex or isn't at least two whole blocks. Response bodies only say why if
+// WithVerboseErrors is set.
+func NewCBCPaddingOracleHandler(p *CBCPaddingOracle, opts ...Option) http.Handler {
+	o := newOptions(opts)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /ciphertext", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(o.latency)
+		fmt.Fprintln(w, hex.EncodeToString(p.Ciphertext()))
+	})
+
+	mux.HandleFunc("GET /check", func(w http.ResponseWriter, r *http.Request) {
+		err := checkHex(p, r.URL.Query().Get("ct"))
+		o.debug("http check", "remote", r.RemoteAddr, "err", err)
+
+		time.Sleep(o.latency)
+
+		code := http.StatusOK
+		switch err {
+		case ErrInvalidPadding:
+			code = http.StatusInternalServerError
+		case errMalformed:
+			code = http.StatusBadRequest
+		}
+
+		msg := http.StatusText(code)
+		if o.verbose && err != nil {
+			msg = err.Error()
+		}
+
+		if code == http.StatusOK {
+			fmt.Fprintln(w, msg)
+			return
+		}
+		http.Error(w, msg, code)
+	})
+
+	return mux
+}
+
+// ServeCBCPaddingOracle accepts connections on l and exposes p as a remote
+// padding oracle over a line-based protocol, handling each connection in its
+// own goroutine.
+//
+// Each request is one line. The line "ciphertext" gets a fresh hex-encoded
+// iv || ct in reply. Any other line is taken as a hex-encoded iv || ct to
+// check, and gets "ok" for valid padding or "error" otherwise. With
+// WithVerboseErrors, failures are instead reported as "invalid padding" or
+// "malformed ciphertext".
+//
+// ServeCBCPaddingOracle returns when l.Accept fails, such as when l is
+// closed.
+func ServeCBCPaddingOracle(l net.Listener, p *CBCPaddingOracle, opts ...Option) error {
+	o := newOptions(opts)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveCBCPaddingOracleConn(conn, p, o)
+	}
+}
+
+// serveCBCPaddingOracleConn answers requests on conn until the client
+// disconnects.
+func serveCBCPaddingOracleConn(conn net.Conn, p *CBCPaddingOracle, o *options) {
+	defer conn.Close()
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, 1<<20)
+
+	w := bufio.NewWriter(conn)
+
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+
+		var resp string
+		if line == "ciphertext" {
+			resp = hex.EncodeToString(p.Ciphertext())
+		} else {
+			err := checkHex(p, line)
+			o.debug("tcp check", "remote", conn.RemoteAddr(), "err", err)
+
+			switch {
+			case err == nil:
+				resp = "ok"
+			case o.verbose:
+				resp = err.Error()
+			default:
+				resp = "error"
+			}
+		}
+
+		time.Sleep(o.latency)
+
+		fmt.Fprintln(w, resp)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// NewOracleHandler returns an HTTP handler that exposes oracle remotely. Each
+// POST request's body is passed to oracle, and the result is the response
+// body.
+//
+// The handler may call oracle from several goroutines at once.
+func NewOracleHandler(oracle func([]byte) []byte, opts ...Option) http.Handler {
+	o := newOptions(opts)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		input, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		out := oracle(input)
+		o.debug("http query", "remote", r.RemoteAddr, "input_len", len(input))
+
+		time.Sleep(o.latency)
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(out)
+	})
+}
+
+// ServeOracle accepts connections on l and exposes oracle remotely over a
+// line-based protocol, handling each connection in its own goroutine. Each
+// request line is a hex-encoded input, and each reply line is the
+// hex-encoded output, or "error" if the input isn't hex.
+//
+// ServeOracle may call oracle from several goroutines at once. It returns
+// when l.Accept fails, such as when l is closed.
+func ServeOracle(l net.Listener, oracle func([]byte) []byte, opts ...Option) error {
+	o := newOptions(opts)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveOracleConn(conn, oracle, o)
+	}
+}
+
+// serveOracleConn answers requests on conn until the client disconnects.
+func serveOracleConn(conn net.Conn, oracle func([]byte) []byte, o *options) {
+	defer conn.Close()
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, 1<<20)
+
+	w := bufio.NewWriter(conn)
+
+	for sc.Scan() {
+		resp := "error"
+		if input, err := hex.DecodeString(strings.TrimSpace(sc.Text())); err == nil {
+			resp = hex.EncodeToString(oracle(input))
+			o.debug("tcp query", "remote", conn.RemoteAddr(), "input_len", len(input))
+		}
+
+		time.Sleep(o.latency)
+
+		fmt.Fprintln(w, resp)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// ServeSRP accepts connections on l and answers SRP logins on each with
+// SRPParty(h, opts...), as ServeParty does, so messages are sequences of
+// length-prefixed fields. A login is four messages:
+//
+//	client: email, A
+//	server: status, salt, B, u
+//	client: proof
+//	server: status
+//
+// Numbers are big-endian, and u is empty unless the server uses simplified
+// SRP. The status is "ok", or "error" if the login fails, in which case it's
+// the only field. With WithVerboseErrors, failures say why instead. A
+// connection can carry any number of logins, one after another.
+//
+// ServeSRP returns when l.Accept fails, such as when l is closed.
+func ServeSRP(l net.Listener, h SRPHandler, opts ...Option) error {
+	return ServeParty(l, SRPParty(h, opts...), opts...)
+}
+
+// ServeDHEcho accepts connections on l and runs NewDHEchoServer(opts...) on
+// each, as ServeParty does: Bob from challenge 34, who agrees on a key with
+// Diffie-Hellman, then echoes messages encrypted under it.
+//
+// The client sends p, g, and its public value A, and the server replies with
+// B. The key is the first 16 bytes of the SHA-1 hash of the shared secret.
+// Each later message is one AES-128-CBC iv || ct, as from EncryptPadded, and
+// the server replies with its plaintext encrypted under a fresh IV. It takes
+// p and g on trust, along with A.
+//
+// ServeDHEcho returns when l.Accept fails, such as when l is closed.
+func ServeDHEcho(l net.Listener, opts ...Option) error {
+	return ServeParty(l, NewDHEchoServer(opts...), opts...)
+}
@@ -0,0 +1,109 @@
+package cryptopals
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCBCPaddingOracleHandler(t *testing.T) {
+	secret := []byte("attack at dawn!")
+	p := NewCBCPaddingOracle(secret)
+
+	srv := httptest.NewServer(NewCBCPaddingOracleHandler(p))
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, strings.TrimSpace(string(body))
+	}
+
+	_, s := get("/ciphertext")
+	ct := decodeHex(t, s)
+
+	if code, _ := get("/check?ct=zz"); code != http.StatusBadRequest {
+		t.Errorf("malformed: want %d, got %d", http.StatusBadRequest, code)
+	}
+
+	valid := func(b []byte) bool {
+		code, _ := get("/check?ct=" + hex.EncodeToString(b))
+		return code == http.StatusOK
+	}
+
+	got, err := RecoverCBCPaddingOracleSecret(ct, p.BlockSize(), valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
+
+func TestServeCBCPaddingOracle(t *testing.T) {
+	secret := []byte("attack at dawn!")
+	p := NewCBCPaddingOracle(secret)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go ServeCBCPaddingOracle(l, p, WithVerboseErrors())
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	send := func(line string) string {
+		t.Helper()
+		if _, err := fmt.Fprintln(conn, line); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(resp)
+	}
+
+	ct := decodeHex(t, send("ciphertext"))
+
+	if resp := send("00"); resp != "malformed ciphertext" {
+		t.Errorf("want %q, got %q", "malformed ciphertext", resp)
+	}
+
+	valid := func(b []byte) bool {
+		return send(hex.EncodeToString(b)) == "ok"
+	}
+
+	got, err := RecoverCBCPaddingOracleSecret(ct, p.BlockSize(), valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
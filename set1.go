@@ -1,15 +1,33 @@
 package cryptopals
 
 import (
+	"bytes"
+	"cmp"
 	"crypto/cipher"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"math"
 	"math/bits"
+	"slices"
 )
 
-// HexToBase64 converts a hex-encoded string to a Base64-encoded string.
-func HexToBase64(s string) (string, error) {
+// HexToBase64 converts a hex-encoded string to a Base64-encoded string. Use
+// WithScratchEncodings to convert with LowerHexCodec and StdBase64Codec
+// rather than encoding/hex and encoding/base64.
+func HexToBase64(s string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+
+	if o.scratchEncodings {
+		data, err := LowerHexCodec.DecodeString(s)
+		if err != nil {
+			return "", err
+		}
+		return StdBase64Codec.EncodeToString(data), nil
+	}
+
 	data, err := hex.DecodeString(s)
 	if err != nil {
 		return "", err
@@ -21,14 +39,23 @@ func HexToBase64(s string) (string, error) {
 //
 // It panics if a and b have different lengths.
 func XOR(a, b []byte) []byte {
+	res := make([]byte, len(a))
+	XORInto(res, a, b)
+	return res
+}
+
+// XORInto sets dst[i] = a[i] ^ b[i] for each index of a. It's the
+// allocation-free form of XOR, and dst may overlap exactly with a or b.
+//
+// It panics if a and b have different lengths or if dst is shorter than a.
+func XORInto(dst, a, b []byte) {
 	if len(a) != len(b) {
 		panic("different lengths")
 	}
-	res := make([]byte, len(a))
-	for i := range a {
-		res[i] = a[i] ^ b[i]
+	if len(dst) < len(a) {
+		panic("dst too small")
 	}
-	return res
+	subtle.XORBytes(dst, a, b)
 }
 
 // singleByteXORCipher represents a single-byte XOR cipher.
@@ -42,34 +69,41 @@ func NewSingleByteXORCipher(key byte) cipher.Stream {
 }
 
 func (s singleByteXORCipher) XORKeyStream(dst, src []byte) {
-	if len(dst) < len(src) {
-		panic("dst too small")
+	checkBuffers(dst, src)
+
+	// XOR a word at a time, then finish the tail byte by byte.
+	word := uint64(s.key) * 0x0101010101010101
+	for len(src) >= 8 {
+		binary.LittleEndian.PutUint64(dst, binary.LittleEndian.Uint64(src)^word)
+		dst, src = dst[8:], src[8:]
 	}
 	for i := range src {
 		dst[i] = src[i] ^ s.key
 	}
 }
 
+// englishWeights are the per-byte weights used by Englishness.
+var englishWeights = [256]float64{
+	' ': 5,
+	'e': 2,
+	't': 2,
+	'a': 2,
+}
+
 // Englishness scores b on how much it resembles English.
 //
 // Scores are length-normalized and between 0 and 1 inclusive. Higher is better.
 //
-// If len(b) == 0, Englishness returns 0.
+// If len(b) == 0, Englishness returns 0. See EnglishScorer for a version with
+// tunable weights.
 func Englishness(b []byte) float64 {
 	if len(b) == 0 {
 		return 0
 	}
 
-	weights := map[byte]float64{
-		' ': 5,
-		'e': 2,
-		't': 2,
-		'a': 2,
-	}
-
 	var n float64
 	for _, v := range b {
-		n += weights[v]
+		n += englishWeights[v]
 	}
 	return n / float64(len(b))
 }
@@ -77,83 +111,79 @@ func Englishness(b []byte) float64 {
 // RecoverSingleByteXORKey returns the most likely key for a single-byte XOR
 // ciphertext.
 //
-// It assumes the plaintext is English.
-func RecoverSingleByteXORKey(ct []byte) byte {
-	var (
-		bestKey   byte
-		bestScore float64 // Higher is better.
-	)
-
-	pt := make([]byte, len(ct))
+// It assumes the plaintext is English. Use WithScorer to change how
+// plaintexts are scored, WithWorkers to try keys concurrently, and
+// WithThreshold to stop at the first key that scores well enough.
+func RecoverSingleByteXORKey(ct []byte, opts ...Option) byte {
+	o := newOptions(opts)
 
-	for i := range math.MaxUint8 {
-		key := byte(i)
-
-		NewSingleByteXORCipher(key).XORKeyStream(pt, ct)
-
-		score := Englishness(pt)
-
-		if score > bestScore {
-			bestScore = score
-			bestKey = key
+	key, _ := argmax(math.MaxUint8+1, o.workers, o.threshold, func() func(int) float64 {
+		pt := make([]byte, len(ct))
+		return func(k int) float64 {
+			NewSingleByteXORCipher(byte(k)).XORKeyStream(pt, ct)
+			return o.scorer.Score(pt)
 		}
-	}
-
-	return bestKey
+	})
+	return byte(key)
 }
 
 // FindSingleByteXORCiphertext returns the index of the ciphertext most likely
 // to be single-byte XOR encrypted.
 //
 // FindSingleByteXORCiphertext returns -1 if no ciphertext was found.
-func FindSingleByteXORCiphertext(cts [][]byte) int {
+//
+// It assumes the plaintext is English. Use WithScorer to change how
+// plaintexts are scored, WithWorkers to search ciphertexts concurrently, and
+// WithThreshold to stop at the first ciphertext that scores well enough.
+func FindSingleByteXORCiphertext(cts [][]byte, opts ...Option) int {
 	if len(cts) == 0 {
 		return -1
 	}
 
-	var (
-		bestIndex int
-		bestScore float64 // Higher is better.
-	)
+	o := newOptions(opts)
 
-	for i, ct := range cts {
-		pt := make([]byte, len(ct))
-
-		for k := range math.MaxUint8 {
-			key := byte(k)
-
-			NewSingleByteXORCipher(key).XORKeyStream(pt, ct)
-
-			score := Englishness(pt)
-
-			if score > bestScore {
-				bestScore = score
-				bestIndex = i
+	i, _ := argmax(len(cts), o.workers, o.threshold, func() func(int) float64 {
+		var pt []byte
+		return func(i int) float64 {
+			if len(pt) < len(cts[i]) {
+				pt = make([]byte, len(cts[i]))
 			}
+			_, score := bestSingleByteXORKey(cts[i], pt, o.scorer, o.threshold)
+			return score
 		}
-	}
-
-	return bestIndex
+	})
+	return i
 }
 
 // repeatingKeyXORCipher represents a repeating-key XOR cipher.
 type repeatingKeyXORCipher struct {
 	key []byte
-	i   int
+	ks  []byte // The key repeated enough times to XOR a word at a time.
+	i   int    // Index into key of the next keystream byte.
 }
 
 // NewRepeatingKeyXORCipher returns a new repeating-key XOR cipher.
 func NewRepeatingKeyXORCipher(key []byte) cipher.Stream {
-	return &repeatingKeyXORCipher{key: key}
+	// Repeat the key at least 64 bytes' worth, plus one extra copy so that a
+	// window of whole keys can start at any offset.
+	n := (64+len(key)-1)/len(key) + 1
+	return &repeatingKeyXORCipher{
+		key: key,
+		ks:  bytes.Repeat(key, n),
+	}
 }
 
 func (r *repeatingKeyXORCipher) XORKeyStream(dst, src []byte) {
-	if len(dst) < len(src) {
-		panic("dst too small")
-	}
-	for i := range src {
-		dst[i] = src[i] ^ r.key[r.i]
-		r.i = (r.i + 1) % len(r.key)
+	checkBuffers(dst, src)
+
+	// The window is a whole number of keys long, so XORing a full window
+	// leaves r.i unchanged.
+	window := r.ks[r.i : r.i+len(r.ks)-len(r.key)]
+
+	for len(src) > 0 {
+		n := subtle.XORBytes(dst, src, window)
+		dst, src = dst[n:], src[n:]
+		r.i = (r.i + n) % len(r.key)
 	}
 }
 
@@ -175,56 +205,238 @@ func Hamming(a, b []byte) int {
 // RecoverRepeatingKeyXORKeySize returns the most likely key size for a
 // repeating-key XOR ciphertext, within lo to hi inclusive.
 //
-// It assumes that the plaintext is English.
+// It assumes that the plaintext is English. It returns an error if ct is
+// shorter than 2*hi bytes. Use WithKeySizeEstimator to change how key sizes
+// are estimated.
+func RecoverRepeatingKeyXORKeySize(ct []byte, lo, hi int, opts ...Option) (int, error) {
+	sizes, err := RecoverRepeatingKeyXORKeySizes(ct, lo, hi, 1, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return sizes[0], nil
+}
+
+// RecoverRepeatingKeyXORKeySizes returns the n most likely key sizes for a
+// repeating-key XOR ciphertext, within lo to hi inclusive, from most to least
+// likely.
 //
-// TODO: Avoid panicking when a or b is large compared to len(ct).
-func RecoverRepeatingKeyXORKeySize(ct []byte, lo, hi int) int {
+// It assumes that the plaintext is English. It returns an error if ct is
+// shorter than 2*hi bytes. Use WithKeySizeEstimator to change how key sizes
+// are estimated. By default, they're scored as in
+// RankRepeatingKeyXORKeySizes.
+func RecoverRepeatingKeyXORKeySizes(ct []byte, lo, hi, n int, opts ...Option) ([]int, error) {
+	if err := checkKeySizeRange(ct, lo, hi, n); err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+	sizes := rankKeySizes(ct, lo, hi, o.keySizeEstimator)
+	return sizes[:min(n, len(sizes))], nil
+}
+
+// checkKeySizeRange panics if lo, hi, or n are invalid, and returns an error
+// if ct is too short for key sizes up to hi.
+func checkKeySizeRange(ct []byte, lo, hi, n int) error {
+	if lo < 1 {
+		panic("lo < 1")
+	}
 	if lo > hi {
 		panic("lo > hi")
 	}
+	if n < 1 {
+		panic("n < 1")
+	}
+	if len(ct) < 2*hi {
+		return fmt.Errorf("ciphertext too short: need %d bytes, got %d", 2*hi, len(ct))
+	}
+	return nil
+}
 
-	var (
-		bestKeySize int
-		bestScore   = math.MaxFloat64 // Lower is better.
-	)
-
-	for ks := lo; ks <= hi; ks++ {
-		x, y := ct[:ks*4], ct[ks*4:ks*8]
+// A KeySizeCandidate is a candidate key size with its score.
+type KeySizeCandidate struct {
+	KeySize  int
+	Distance float64 // Lower is better.
+}
 
-		h := Hamming(x, y)
+// RankRepeatingKeyXORKeySizes is like RecoverRepeatingKeyXORKeySizes with
+// the default estimator, but returns the scores along with the key sizes.
+//
+// Each key size is scored by splitting ct into chunks of that size and
+// averaging the normalized Hamming distance between every pair of adjacent
+// chunks. Ties go to the smaller key size.
+func RankRepeatingKeyXORKeySizes(ct []byte, lo, hi, n int) ([]KeySizeCandidate, error) {
+	if err := checkKeySizeRange(ct, lo, hi, n); err != nil {
+		return nil, err
+	}
 
-		score := float64(h) / float64(ks)
+	var candidates []KeySizeCandidate
 
-		if score < bestScore {
-			bestScore = score
-			bestKeySize = ks
+	for ks := lo; ks <= hi; ks++ {
+		var (
+			pairs = len(ct)/ks - 1
+			sum   float64
+		)
+
+		for i := range pairs {
+			x, y := ct[i*ks:(i+1)*ks], ct[(i+1)*ks:(i+2)*ks]
+			sum += float64(Hamming(x, y)) / float64(ks)
 		}
+
+		candidates = append(candidates, KeySizeCandidate{ks, sum / float64(pairs)})
 	}
 
-	return bestKeySize
+	slices.SortStableFunc(candidates, func(a, b KeySizeCandidate) int {
+		return cmp.Compare(a.Distance, b.Distance)
+	})
+
+	return candidates[:min(n, len(candidates))], nil
 }
 
 // RecoverRepeatingKeyXORKey returns the most likely key for a repeating-key
 // XOR ciphertext.
 //
 // It assumes the plaintext is English. It also assumes that the key size is
-// between 2 and 40 bytes.
-func RecoverRepeatingKeyXORKey(ct []byte) []byte {
-	var key []byte
+// between 2 and 40 bytes, so it returns an error if ct is shorter than 80
+// bytes. Use WithScorer to change how plaintexts are scored, and
+// WithKeySizeEstimator to change how the key size is estimated.
+func RecoverRepeatingKeyXORKey(ct []byte, opts ...Option) ([]byte, error) {
+	ks, err := RecoverRepeatingKeyXORKeySize(ct, 2, 40, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	ks := RecoverRepeatingKeyXORKeySize(ct, 2, 40)
+	return repeatingKeyXORKey(ct, ks, opts), nil
+}
 
-	for i := range ks {
-		var b []byte
-		for j := i; j < len(ct); j += ks {
-			b = append(b, ct[j])
-		}
-		key = append(key, RecoverSingleByteXORKey(b))
+// repeatingKeyXORKey returns the most likely ks-byte key for a repeating-key
+// XOR ciphertext, recovering each byte from its column of ct.
+func repeatingKeyXORKey(ct []byte, ks int, opts []Option) []byte {
+	key := make([]byte, 0, ks)
+	for _, col := range Transpose(ct, ks) {
+		key = append(key, RecoverSingleByteXORKey(col, opts...))
 	}
-
 	return key
 }
 
+// A KeyCandidate is a candidate key with the score of the plaintext it
+// decrypts to.
+type KeyCandidate struct {
+	Key   []byte
+	Score float64 // Higher is better.
+}
+
+// sortKeyCandidates sorts candidates from best to worst, keeping the order of
+// ties.
+func sortKeyCandidates(candidates []KeyCandidate) {
+	slices.SortStableFunc(candidates, func(a, b KeyCandidate) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+}
+
+// RankSingleByteXORKeys returns the n most likely keys for a single-byte XOR
+// ciphertext, from most to least likely, with the scores of their
+// plaintexts. Each Key is one byte long. Ties go to the lower key.
+//
+// Unlike RecoverSingleByteXORKey, it always scores every key, so callers can
+// break close calls themselves, such as with a dictionary check. It assumes
+// the plaintext is English. Use WithScorer to change how plaintexts are
+// scored.
+func RankSingleByteXORKeys(ct []byte, n int, opts ...Option) []KeyCandidate {
+	if n < 1 {
+		panic("n < 1")
+	}
+
+	o := newOptions(opts)
+
+	candidates := make([]KeyCandidate, 0, math.MaxUint8+1)
+	pt := make([]byte, len(ct))
+
+	for k := range math.MaxUint8 + 1 {
+		NewSingleByteXORCipher(byte(k)).XORKeyStream(pt, ct)
+		candidates = append(candidates, KeyCandidate{[]byte{byte(k)}, o.scorer.Score(pt)})
+	}
+
+	sortKeyCandidates(candidates)
+	return candidates[:min(n, len(candidates))]
+}
+
+// RankRepeatingKeyXORKeys returns up to n likely keys for a repeating-key XOR
+// ciphertext, from most to least likely, with the scores of their plaintexts.
+//
+// It recovers a key for each of the n most likely key sizes, as
+// RecoverRepeatingKeyXORKey does for the most likely one, then ranks the keys
+// by how well the whole plaintext scores. This helps when the key size
+// estimate is unreliable, as it is for ciphertexts not much longer than the
+// 80 bytes it needs.
+//
+// It has the same assumptions, errors, and options as
+// RecoverRepeatingKeyXORKey, so it also returns an error if ct is shorter
+// than 80 bytes.
+func RankRepeatingKeyXORKeys(ct []byte, n int, opts ...Option) ([]KeyCandidate, error) {
+	sizes, err := RecoverRepeatingKeyXORKeySizes(ct, 2, 40, n, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+
+	var (
+		candidates []KeyCandidate
+		pt         = make([]byte, len(ct))
+	)
+
+	for _, ks := range sizes {
+		key := repeatingKeyXORKey(ct, ks, opts)
+		NewRepeatingKeyXORCipher(key).XORKeyStream(pt, ct)
+		candidates = append(candidates, KeyCandidate{key, o.scorer.Score(pt)})
+	}
+
+	sortKeyCandidates(candidates)
+	return candidates, nil
+}
+
+// Transpose splits b into n columns, where column i holds b[i], b[i+n],
+// b[i+2n], and so on. For a repeating-key XOR ciphertext with an n-byte key,
+// each column is single-byte XOR encrypted.
+//
+// If len(b) isn't a multiple of n, the first len(b)%n columns are one byte
+// longer than the rest. Columns are new slices. It panics if n < 1.
+func Transpose(b []byte, n int) [][]byte {
+	if n < 1 {
+		panic("invalid column count")
+	}
+
+	res := make([][]byte, n)
+	for i := range res {
+		res[i] = make([]byte, 0, (len(b)-i+n-1)/n)
+	}
+	for i, c := range b {
+		res[i%n] = append(res[i%n], c)
+	}
+	return res
+}
+
+// Untranspose is the inverse of Transpose. It interleaves cols into a new
+// slice, taking one byte from each column in turn, and skipping columns once
+// they run out.
+func Untranspose(cols [][]byte) []byte {
+	var n, rows int
+	for _, col := range cols {
+		n += len(col)
+		rows = max(rows, len(col))
+	}
+
+	res := make([]byte, 0, n)
+	for j := range rows {
+		for _, col := range cols {
+			if j < len(col) {
+				res = append(res, col[j])
+			}
+		}
+	}
+	return res
+}
+
 type ecbEncrypter struct {
 	b cipher.Block
 }
@@ -247,9 +459,7 @@ func (e ecbEncrypter) CryptBlocks(dst, src []byte) {
 	if len(src)%bs != 0 {
 		panic("input not full blocks")
 	}
-	if len(dst) < len(src) {
-		panic("dst too small")
-	}
+	checkBuffers(dst, src)
 	if len(src) == 0 {
 		return
 	}
@@ -267,9 +477,7 @@ func (e ecbDecrypter) CryptBlocks(dst, src []byte) {
 	if len(src)%bs != 0 {
 		panic("input not full blocks")
 	}
-	if len(dst) < len(src) {
-		panic("dst too small")
-	}
+	checkBuffers(dst, src)
 	if len(src) == 0 {
 		return
 	}
@@ -295,18 +503,63 @@ func NewECBDecrypter(b cipher.Block) cipher.BlockMode {
 
 // IsECBCiphertext returns true if b is likely to be ECB encrypted.
 func IsECBCiphertext(b []byte, blockSize int) bool {
-	if len(b)%blockSize != 0 {
-		return false
+	return ECBScore(b, blockSize) > 0
+}
+
+// ECBScore scores b on how much it resembles an ECB ciphertext.
+//
+// The score is the fraction of blocks after the first that repeat an earlier
+// block, so it is between 0 and 1 inclusive. Higher is better.
+//
+// If b is not a whole number of blocks or has fewer than two blocks, ECBScore
+// returns 0.
+func ECBScore(b []byte, blockSize int) float64 {
+	score, _ := ecbScore(b, blockSize, nil)
+	return score
+}
+
+// ecbScore is ECBScore, but it takes a scratch slice to hold views of the
+// blocks of b and returns it for reuse, avoiding allocations when called in a
+// loop.
+func ecbScore(b []byte, blockSize int, blocks [][]byte) (float64, [][]byte) {
+	if len(b)%blockSize != 0 || len(b) < 2*blockSize {
+		return 0, blocks
 	}
 
-	// TODO: Use slices.Chunks once it's available in the standard library.
-	seen := make(map[string]struct{})
-	for i := 0; i < len(b); i += blockSize {
-		block := string(b[i : i+blockSize])
-		if _, ok := seen[block]; ok {
-			return true
+	// Sort views of the blocks so that equal blocks are adjacent, instead of
+	// copying each block into a set.
+	blocks = slices.AppendSeq(blocks[:0], Blocks(b, blockSize))
+	slices.SortFunc(blocks, bytes.Compare)
+
+	var dups int
+	for i := 1; i < len(blocks); i++ {
+		if bytes.Equal(blocks[i-1], blocks[i]) {
+			dups++
+		}
+	}
+	return float64(dups) / float64(len(blocks)-1), blocks
+}
+
+// FindMostECBLike returns the index of the ciphertext with the highest
+// ECBScore. Ties go to the lowest index.
+//
+// FindMostECBLike returns -1 if no ciphertext has a score above 0.
+func FindMostECBLike(cts [][]byte, blockSize int) int {
+	var (
+		bestIndex = -1
+		bestScore float64 // Higher is better.
+		blocks    [][]byte
+		score     float64
+	)
+
+	for i, ct := range cts {
+		score, blocks = ecbScore(ct, blockSize, blocks)
+
+		if score > bestScore {
+			bestScore = score
+			bestIndex = i
 		}
-		seen[block] = struct{}{}
 	}
-	return false
+
+	return bestIndex
 }
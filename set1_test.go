@@ -3,7 +3,10 @@ package cryptopals
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
 	"encoding/base64"
 	"encoding/hex"
 	"io"
@@ -16,18 +19,20 @@ func TestChallenge1(t *testing.T) {
 	in := "49276d206b696c6c696e6720796f757220627261696e206c696b65206120706f69736f6e6f7573206d757368726f6f6d"
 	want := "SSdtIGtpbGxpbmcgeW91ciBicmFpbiBsaWtlIGEgcG9pc29ub3VzIG11c2hyb29t"
 
-	got, err := HexToBase64(in)
-	if err != nil {
-		t.Error(err)
-	}
+	for _, opts := range [][]Option{nil, {WithScratchEncodings()}} {
+		got, err := HexToBase64(in, opts...)
+		if err != nil {
+			t.Error(err)
+		}
 
-	if want != got {
-		t.Errorf("want %q, got %q", want, got)
+		if want != got {
+			t.Errorf("%d options: want %q, got %q", len(opts), want, got)
+		}
 	}
 }
 
 // decodeHex wraps hex.DecodeString for testing.
-func decodeHex(t *testing.T, s string) []byte {
+func decodeHex(t testing.TB, s string) []byte {
 	t.Helper()
 	data, err := hex.DecodeString(s)
 	if err != nil {
@@ -61,9 +66,34 @@ func TestChallenge3(t *testing.T) {
 	t.Logf("plaintext: %q", ct)
 }
 
+func TestXORInto(t *testing.T) {
+	a := decodeHex(t, "1c0111001f010100061a024b53535009181c")
+	b := decodeHex(t, "686974207468652062756c6c277320657965")
+	want := decodeHex(t, "746865206b696420646f6e277420706c6179")
+
+	// In place.
+	XORInto(a, a, b)
+	if !bytes.Equal(want, a) {
+		t.Errorf("want %q, got %q", want, a)
+	}
+}
+
+func TestSingleByteXORCipherLengths(t *testing.T) {
+	for n := range 40 {
+		src := bytes.Repeat([]byte{0xa5}, n)
+		dst := make([]byte, n)
+
+		NewSingleByteXORCipher(0x0f).XORKeyStream(dst, src)
+
+		if want := bytes.Repeat([]byte{0xaa}, n); !bytes.Equal(want, dst) {
+			t.Errorf("length %d: want %x, got %x", n, want, dst)
+		}
+	}
+}
+
 // decodeHexStringsFromFile decodes newline-delimited, hex-encoded strings from
 // a file.
-func decodeHexStringsFromFile(t *testing.T, name string) [][]byte {
+func decodeHexStringsFromFile(t testing.TB, name string) [][]byte {
 	t.Helper()
 	f, err := os.Open(name)
 	if err != nil {
@@ -115,6 +145,28 @@ I go crazy when I hear a cymbal`)
 	}
 }
 
+func TestRepeatingKeyXORCipherChunks(t *testing.T) {
+	pt := bytes.Repeat([]byte("a fairly long plaintext for chunking "), 10)
+	key := []byte("seven!!")
+
+	want := make([]byte, len(pt))
+	for i := range pt {
+		want[i] = pt[i] ^ key[i%len(key)]
+	}
+
+	// Encrypt in uneven chunks so the key offset varies between calls.
+	got := make([]byte, len(pt))
+	s := NewRepeatingKeyXORCipher(key)
+	for i, n := 0, 1; i < len(pt); i, n = i+n, n+3 {
+		end := min(i+n, len(pt))
+		s.XORKeyStream(got[i:end], pt[i:end])
+	}
+
+	if !bytes.Equal(want, got) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+}
+
 // decodeBase64FromFile decodes Base64-encoded data from a file for testing.
 func decodeBase64FromFile(t *testing.T, name string) []byte {
 	t.Helper()
@@ -149,7 +201,10 @@ func TestChallenge6(t *testing.T) {
 	in := decodeBase64FromFile(t, "testdata/6.txt")
 	want := []byte("Terminator X: Bring the noise")
 
-	got := RecoverRepeatingKeyXORKey(in)
+	got, err := RecoverRepeatingKeyXORKey(in)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if !bytes.Equal(want, got) {
 		t.Errorf("want %q, got %q", want, got)
@@ -159,6 +214,44 @@ func TestChallenge6(t *testing.T) {
 	t.Logf("plaintext: %q", in)
 }
 
+func TestTranspose(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want []string
+	}{
+		{"abcdefgh", 2, []string{"aceg", "bdfh"}},
+		{"abcdefg", 3, []string{"adg", "be", "cf"}},
+		{"ab", 4, []string{"a", "b", "", ""}},
+		{"", 2, []string{"", ""}},
+		{"abc", 1, []string{"abc"}},
+	}
+
+	for _, tc := range cases {
+		got := Transpose([]byte(tc.in), tc.n)
+		if len(got) != len(tc.want) {
+			t.Fatalf("Transpose(%q, %d): got %d columns, want %d", tc.in, tc.n, len(got), len(tc.want))
+		}
+		for i := range got {
+			if string(got[i]) != tc.want[i] {
+				t.Errorf("Transpose(%q, %d): column %d: want %q, got %q", tc.in, tc.n, i, tc.want[i], got[i])
+			}
+		}
+
+		if back := Untranspose(got); string(back) != tc.in {
+			t.Errorf("Untranspose(Transpose(%q, %d)): got %q", tc.in, tc.n, back)
+		}
+	}
+}
+
+func TestTransposeColumnsDontAlias(t *testing.T) {
+	cols := Transpose([]byte("abcdef"), 2)
+	cols[0] = append(cols[0], 'x')
+	if string(cols[1]) != "bdf" {
+		t.Errorf("appending to a column changed its neighbor: %q", cols[1])
+	}
+}
+
 func TestChallenge7(t *testing.T) {
 	in := decodeBase64FromFile(t, "testdata/7.txt")
 	key := []byte("YELLOW SUBMARINE")
@@ -194,3 +287,220 @@ func TestChallenge8(t *testing.T) {
 
 	t.Logf("picked ciphertext: %x", in[got])
 }
+
+func TestIsECBCiphertextDES(t *testing.T) {
+	key := []byte("8bytekey")
+	pt := []byte("ABCDEFGHabcdefghABCDEFGH") // blocks 1 and 3 are equal
+
+	block, err := des.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecb := make([]byte, len(pt))
+	NewECBEncrypter(block).CryptBlocks(ecb, pt)
+
+	if !IsECBCiphertext(ecb, des.BlockSize) {
+		t.Errorf("des-ecb ciphertext not detected: %x", ecb)
+	}
+
+	cbc := make([]byte, len(pt))
+	cipher.NewCBCEncrypter(block, make([]byte, des.BlockSize)).CryptBlocks(cbc, pt)
+
+	if IsECBCiphertext(cbc, des.BlockSize) {
+		t.Errorf("des-cbc ciphertext detected as ecb: %x", cbc)
+	}
+}
+
+func TestECBScore(t *testing.T) {
+	cases := []struct {
+		in   string
+		want float64
+	}{
+		{"", 0},
+		{"AAAA", 0},     // one block
+		{"AAAAA", 0},    // not full blocks
+		{"AAAABBBB", 0}, // no repeats
+		{"AAAAAAAA", 1}, // every block repeats
+		{"AAAABBBBAAAACCCCDDDD", 0.25},
+		{"AAAAAAAAAAAABBBB", 2.0 / 3},
+	}
+
+	for _, tc := range cases {
+		got := ECBScore([]byte(tc.in), 4)
+		if tc.want != got {
+			t.Errorf("%q: want %v, got %v", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestFindMostECBLike(t *testing.T) {
+	in := decodeHexStringsFromFile(t, "testdata/8.txt")
+	want := 132
+
+	got := FindMostECBLike(in, aes.BlockSize)
+	if want != got {
+		t.Errorf("wrong index: want %d, got %d", want, got)
+	}
+
+	if got := FindMostECBLike(in[:want], aes.BlockSize); got != -1 {
+		t.Errorf("no ecb ciphertexts: want -1, got %d", got)
+	}
+}
+
+func TestRecoverRepeatingKeyXORKeySizes(t *testing.T) {
+	in := decodeBase64FromFile(t, "testdata/6.txt")
+	want := 29
+
+	got, err := RecoverRepeatingKeyXORKeySizes(in, 2, 40, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("want 3 key sizes, got %d", len(got))
+	}
+	if want != got[0] {
+		t.Errorf("want %d first, got %v", want, got)
+	}
+}
+
+func TestRecoverRepeatingKeyXORKeySizeTooShort(t *testing.T) {
+	in := make([]byte, 79)
+
+	if _, err := RecoverRepeatingKeyXORKeySize(in, 2, 40); err == nil {
+		t.Error("want error for short ciphertext")
+	}
+
+	if _, err := RecoverRepeatingKeyXORKeySize(in[:2], 1, 1); err != nil {
+		t.Errorf("want no error, got %v", err)
+	}
+}
+
+func TestRankRepeatingKeyXORKeySizes(t *testing.T) {
+	in := decodeBase64FromFile(t, "testdata/6.txt")
+
+	got, err := RankRepeatingKeyXORKeySizes(in, 2, 40, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("want 5 candidates, got %d", len(got))
+	}
+	if got[0].KeySize != 29 {
+		t.Errorf("want 29 first, got %v", got)
+	}
+	if !slices.IsSortedFunc(got, func(a, b KeySizeCandidate) int { return cmp.Compare(a.Distance, b.Distance) }) {
+		t.Errorf("not sorted by distance: %v", got)
+	}
+}
+
+func TestRankSingleByteXORKeys(t *testing.T) {
+	ct := decodeHex(t, "1b37373331363f78151b7f2b783431333d78397828372d363c78373e783a393b3736")
+
+	got := RankSingleByteXORKeys(ct, 3)
+	if len(got) != 3 {
+		t.Fatalf("want 3 candidates, got %d", len(got))
+	}
+	if !bytes.Equal(got[0].Key, []byte{88}) {
+		t.Errorf("want key 88 first, got %v", got)
+	}
+	if got[0].Score < got[1].Score || got[1].Score < got[2].Score {
+		t.Errorf("not sorted by score: %v", got)
+	}
+
+	if all := RankSingleByteXORKeys(ct, 1000); len(all) != 256 {
+		t.Errorf("want 256 candidates, got %d", len(all))
+	}
+}
+
+func TestRankSingleByteXORKeysTies(t *testing.T) {
+	// Every key scores the same under a constant scorer.
+	got := RankSingleByteXORKeys([]byte("abc"), 2, WithScorer(ScorerFunc(func([]byte) float64 { return 1 })))
+	if got[0].Key[0] != 0 || got[1].Key[0] != 1 {
+		t.Errorf("ties not broken by key: %v", got)
+	}
+}
+
+func TestRankRepeatingKeyXORKeys(t *testing.T) {
+	in := decodeBase64FromFile(t, "testdata/6.txt")
+	want := []byte("Terminator X: Bring the noise")
+
+	got, err := RankRepeatingKeyXORKeys(in, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("want 4 candidates, got %d", len(got))
+	}
+	if !bytes.Equal(want, got[0].Key) {
+		t.Errorf("want %q first, got %q", want, got[0].Key)
+	}
+}
+
+func BenchmarkEnglishness(b *testing.B) {
+	pt := []byte("Now that the party is jumping\n")
+
+	b.ReportAllocs()
+	for range b.N {
+		Englishness(pt)
+	}
+}
+
+func BenchmarkChallenge4(b *testing.B) {
+	in := decodeHexStringsFromFile(b, "testdata/4.txt")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		FindSingleByteXORCiphertext(in)
+	}
+}
+
+func BenchmarkXOR(b *testing.B) {
+	x, y := make([]byte, 4096), make([]byte, 4096)
+
+	b.SetBytes(int64(len(x)))
+	b.ReportAllocs()
+	for range b.N {
+		XOR(x, y)
+	}
+}
+
+func BenchmarkSingleByteXORCipher(b *testing.B) {
+	buf := make([]byte, 4096)
+	s := NewSingleByteXORCipher('X')
+
+	b.SetBytes(int64(len(buf)))
+	for range b.N {
+		s.XORKeyStream(buf, buf)
+	}
+}
+
+func BenchmarkRepeatingKeyXORCipher(b *testing.B) {
+	buf := make([]byte, 4096)
+	s := NewRepeatingKeyXORCipher([]byte("ICE"))
+
+	b.SetBytes(int64(len(buf)))
+	for range b.N {
+		s.XORKeyStream(buf, buf)
+	}
+}
+
+func BenchmarkChallenge8(b *testing.B) {
+	in := decodeHexStringsFromFile(b, "testdata/8.txt")
+
+	// Scan thousands of ciphertexts.
+	var cts [][]byte
+	for range 20 {
+		cts = append(cts, in...)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		FindMostECBLike(cts, aes.BlockSize)
+	}
+}
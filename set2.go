@@ -2,36 +2,87 @@ package cryptopals
 
 import (
 	"bytes"
-	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
+	"errors"
 	"math"
 	"math/big"
-	"net/url"
 	"slices"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 )
 
-// PadPKCS7 returns a new slice that concatenates b with PKCS #7 padding to
-// guarantee block size n.
-func PadPKCS7(b []byte, n int) []byte {
-	if n < 1 || n > math.MaxUint8 {
-		panic("invalid block size")
+// A CBCMode is a cipher block chaining mode whose IV can be read and replaced,
+// so that one mode can be reused across messages.
+type CBCMode interface {
+	cipher.BlockMode
+
+	// IV returns a copy of the IV for the next call to CryptBlocks, which
+	// is the last ciphertext block processed so far.
+	IV() []byte
+
+	// SetIV replaces the IV for the next call to CryptBlocks. It panics if
+	// iv isn't one block long.
+	SetIV(iv []byte)
+}
+
+type cbcEncrypter struct {
+	b  cipher.Block
+	iv []byte
+}
+
+func (c *cbcEncrypter) BlockSize() int {
+	return c.b.BlockSize()
+}
+
+func (c *cbcEncrypter) CryptBlocks(dst, src []byte) {
+	bs := c.b.BlockSize()
+
+	if len(src)%bs != 0 {
+		panic("input not full blocks")
+	}
+	checkBuffers(dst, src)
+
+	prev := c.iv
+
+	for start := 0; start < len(src); start += bs {
+		end := start + bs
+
+		// XOR the previous ciphertext block into the plaintext block, then
+		// encrypt it.
+		subtle.XORBytes(dst[start:end], src[start:end], prev)
+		c.b.Encrypt(dst[start:end], dst[start:end])
+
+		prev = dst[start:end]
 	}
 
-	p := byte(n - len(b)%n)
+	// Save the last ciphertext block to use as the new IV.
+	copy(c.iv, prev)
+}
 
-	padding := bytes.Repeat([]byte{p}, int(p))
+func (c *cbcEncrypter) IV() []byte {
+	return bytes.Clone(c.iv)
+}
 
-	return slices.Concat(b, padding)
+func (c *cbcEncrypter) SetIV(iv []byte) {
+	if len(iv) != len(c.iv) {
+		panic("invalid iv length")
+	}
+	copy(c.iv, iv)
 }
 
-// UnpadPKCS7 returns a subslice of b with PKCS #7 padding removed.
-func UnpadPKCS7(b []byte) []byte {
-	n := int(b[len(b)-1])
-	return b[:len(b)-n]
+// NewCBCEncrypter returns a CBCMode which encrypts in cipher block chaining
+// mode.
+func NewCBCEncrypter(b cipher.Block, iv []byte) CBCMode {
+	if len(iv) != b.BlockSize() {
+		panic("invalid iv length")
+	}
+	return &cbcEncrypter{b, bytes.Clone(iv)}
 }
 
 type cbcDecrypter struct {
@@ -49,9 +100,7 @@ func (c *cbcDecrypter) CryptBlocks(dst, src []byte) {
 	if len(src)%bs != 0 {
 		panic("input not full blocks")
 	}
-	if len(dst) < len(src) {
-		panic("dst too small")
-	}
+	checkBuffers(dst, src)
 	if len(src) == 0 {
 		return
 	}
@@ -92,15 +141,57 @@ func (c *cbcDecrypter) CryptBlocks(dst, src []byte) {
 	c.iv = tmp
 }
 
-// NewCBCDecrypter returns a cipher.BlockMode which decrypts in cipher block
-// chaining mode.
-func NewCBCDecrypter(b cipher.Block, iv []byte) cipher.BlockMode {
+func (c *cbcDecrypter) IV() []byte {
+	return bytes.Clone(c.iv)
+}
+
+func (c *cbcDecrypter) SetIV(iv []byte) {
+	if len(iv) != len(c.iv) {
+		panic("invalid iv length")
+	}
+	c.iv = bytes.Clone(iv)
+}
+
+// NewCBCDecrypter returns a CBCMode which decrypts in cipher block chaining
+// mode.
+func NewCBCDecrypter(b cipher.Block, iv []byte) CBCMode {
 	if len(iv) != b.BlockSize() {
 		panic("invalid iv length")
 	}
 	return &cbcDecrypter{b, iv}
 }
 
+// EncryptPadded pads pt with PKCS #7 and encrypts it under b in CBC mode with
+// a random IV. It returns iv || ct.
+func EncryptPadded(b cipher.Block, pt []byte) []byte {
+	bs := b.BlockSize()
+
+	res := slices.Concat(randBytes(int64(bs)), PadPKCS7(pt, bs))
+	iv, ct := res[:bs], res[bs:]
+
+	NewCBCEncrypter(b, iv).CryptBlocks(ct, ct)
+
+	return res
+}
+
+// DecryptPadded decrypts iv || ct under b in CBC mode and removes the PKCS #7
+// padding. It returns ErrInvalidPadding if the padding is malformed, and
+// another error if the input isn't at least two whole blocks.
+func DecryptPadded(b cipher.Block, ct []byte) ([]byte, error) {
+	bs := b.BlockSize()
+
+	if len(ct)%bs != 0 || len(ct) < 2*bs {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	iv, ct := ct[:bs], ct[bs:]
+
+	pt := make([]byte, len(ct))
+	NewCBCDecrypter(b, iv).CryptBlocks(pt, ct)
+
+	return PKCS7Padder{}.Unpad(pt, bs)
+}
+
 // randBool returns a random boolean.
 func randBool() bool {
 	return randInt64(2) == 0
@@ -110,28 +201,31 @@ func randBool() bool {
 // as described in challenge 11.
 //
 // The oracle returns encrypt(pad(prefix || input || suffix)) under either
-// AES-128-ECB or AES-128-CBC.
-func NewECBOrCBCPrefixSuffixOracle() func([]byte) []byte {
+// AES-ECB or AES-CBC. The key is 16 bytes unless set with WithKeySize, and
+// WithCipher replaces AES.
+func NewECBOrCBCPrefixSuffixOracle(opts ...Option) func([]byte) []byte {
+	o := newOptions(opts)
+
 	var (
-		key    = randBytes(16)
-		iv     = randBytes(16)
+		block  = o.newBlock(randBytes(int64(o.keySize)))
+		iv     = randBytes(int64(block.BlockSize()))
 		prefix = randBytes(5 + randInt64(6))
 		suffix = randBytes(5 + randInt64(6))
 		useECB = randBool()
 	)
 
+	o.debug("oracle created", "ecb", useECB, "prefix_len", len(prefix), "suffix_len", len(suffix))
+
 	return func(input []byte) []byte {
-		block, err := aes.NewCipher(key)
-		if err != nil {
-			panic(err)
-		}
+		o.debug("oracle called", "input_len", len(input))
 
+		// CBC modes carry the IV between calls, so make a new one each time.
 		var mode cipher.BlockMode
 
 		if useECB {
 			mode = NewECBEncrypter(block)
 		} else {
-			mode = cipher.NewCBCEncrypter(block, iv)
+			mode = NewCBCEncrypter(block, iv)
 		}
 
 		res := slices.Concat(prefix, input, suffix)
@@ -162,23 +256,20 @@ func IsECBOracle(oracle func([]byte) []byte) bool {
 // NewECBSuffixOracle returns an oracle that encrypts inputs as described in
 // challenge 12.
 //
-// The oracle returns encrypt(pad(input || secret)).
-func NewECBSuffixOracle(secret []byte) func([]byte) []byte {
-	key := randBytes(16)
+// The oracle returns encrypt(pad(input || secret)) under AES-ECB. The key is 16
+// bytes unless set with WithKeySize, and WithCipher replaces AES.
+func NewECBSuffixOracle(secret []byte, opts ...Option) func([]byte) []byte {
+	o := newOptions(opts)
+	mode := NewECBEncrypter(o.newBlock(randBytes(int64(o.keySize))))
 
 	return func(input []byte) []byte {
-		block, err := aes.NewCipher(key)
-		if err != nil {
-			panic(err)
-		}
-
-		mode := NewECBEncrypter(block)
+		o.debug("oracle called", "input_len", len(input))
 
 		// input || secret
 		b := slices.Concat(input, secret)
 
 		// pad(input || secret)
-		b = PadPKCS7(b, aes.BlockSize)
+		b = PadPKCS7(b, mode.BlockSize())
 
 		// encrypt(pad(input || secret))
 		mode.CryptBlocks(b, b)
@@ -189,6 +280,13 @@ func NewECBSuffixOracle(secret []byte) func([]byte) []byte {
 
 // FindBlockSize returns the block size used by an encryption oracle.
 func FindBlockSize(oracle func([]byte) []byte) int {
+	bs, _ := findBlockSize(oracle, func() error { return nil })
+	return bs
+}
+
+// findBlockSize is FindBlockSize, but stops with check's error if it
+// returns one after a query.
+func findBlockSize(oracle func([]byte) []byte, check func() error) (int, error) {
 	// Find the ciphertext length for a 1-byte input.
 	input := make([]byte, 1)
 	start := len(oracle(input))
@@ -196,129 +294,287 @@ func FindBlockSize(oracle func([]byte) []byte) int {
 	// Grow the input until the ciphertext length changes.
 	n := start
 	for n == start {
+		if err := check(); err != nil {
+			return 0, err
+		}
 		input = append(input, 0)
 		n = len(oracle(input))
 	}
+	if err := check(); err != nil {
+		return 0, err
+	}
 
 	// The delta is the block size.
-	return n - start
+	return n - start, nil
 }
 
 // RecoverECBSuffixOracleSecret takes an encryption oracle that behaves as
 // described in challenge 12 and recovers the secret used.
-func RecoverECBSuffixOracleSecret(oracle func([]byte) []byte) []byte {
-	bs := FindBlockSize(oracle)
+//
+// After finding the block size, it makes one oracle call per block-aligned
+// offset and one per recovered byte. Use WithProgress or WithLogger to observe
+// the attack as it runs. It panics if the attack fails; use
+// RecoverECBSuffixOracleSecretErr to get an error instead.
+func RecoverECBSuffixOracleSecret(oracle func([]byte) []byte, opts ...Option) []byte {
+	secret, err := RecoverECBSuffixOracleSecretErr(oracle, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return secret
+}
 
-	if !IsECBOracle(oracle) {
-		panic("not ecb")
+// RecoverECBSuffixOracleSecretErr is like RecoverECBSuffixOracleSecret, but
+// returns an error if the oracle doesn't use ECB mode, if no guess for a byte
+// matches, or, with WithBudget, if it would go over budget.
+func RecoverECBSuffixOracleSecretErr(oracle func([]byte) []byte, opts ...Option) (_ []byte, err error) {
+	o := newOptions(opts)
+
+	queries := o.newMeter()
+	defer queries.finish(&err)
+	oracle = queries.oracle(oracle)
+
+	bs, err := findBlockSize(oracle, queries.err)
+	if err != nil {
+		return nil, err
 	}
+	o.debug("found block size", "block_size", bs)
 
-	var res []byte
+	// As in IsECBOracle, three blocks of input always encrypt to a repeated
+	// block under ECB.
+	if bs == 1 || !IsECBCiphertext(oracle(make([]byte, 3*bs)), bs) {
+		if err := queries.err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("oracle doesn't use ecb")
+	}
+	o.debug("detected ecb")
+
+	// Create reference outputs to compare guesses against, one for each prefix
+	// length a guess might need.
+	//
+	// refs[n] = encrypt(zeros(n) || secret || pad)
+	refs := make([][]byte, bs)
+	for n := range refs {
+		refs[n] = oracle(make([]byte, n))
+	}
+	if err := queries.err(); err != nil {
+		return nil, err
+	}
+
+	// The ciphertext first grows by a block when the input fills the secret's
+	// final block, which tells us the secret length.
+	secretLen := len(refs[0]) - bs
+	for n := 1; n < bs; n++ {
+		if len(refs[n]) > len(refs[0]) {
+			secretLen = len(refs[0]) - n
+			break
+		}
+	}
+	o.debug("found secret length", "len", secretLen)
+
+	// Guess every value of a byte at once: block b of the input holds the
+	// bs-1 bytes before the unknown byte, followed by b.
+	guesses := make([]byte, (math.MaxUint8+1)*bs)
+	for b := range math.MaxUint8 + 1 {
+		guesses[b*bs+bs-1] = byte(b)
+	}
 
-outer:
-	for {
-		// Choose an prefix length such that our 'guess' byte b will be the last
-		// byte of a plaintext block.
-		prefix := make([]byte, bs-(len(res)%bs)-1)
+	// known is zeros(bs-1) || res, so the bs-1 bytes before any secret byte
+	// are always available.
+	known := make([]byte, bs-1, bs-1+secretLen)
 
-		// Create a reference output to compare guesses against.
-		//
-		// encrypt(prefix || secret || pad)
-		want := oracle(prefix)
+	for len(known)-(bs-1) < secretLen {
+		i := len(known) - (bs - 1) // Index of the secret byte to recover.
 
-		for i := range math.MaxUint8 {
-			b := byte(i)
+		// Choose a prefix length such that secret byte i is the last byte of
+		// a plaintext block.
+		n := bs - 1 - i%bs
+		start := n + i + 1 - bs // Start of that block.
+		want := refs[n][start : start+bs]
 
-			// prefix || res || b
-			input := slices.Concat(prefix, res, []byte{b})
+		context := known[len(known)-(bs-1):]
+		for b := range math.MaxUint8 + 1 {
+			copy(guesses[b*bs:], context)
+		}
 
-			// encrypt(prefix || res || b || secret || pad)
-			output := oracle(input)
+		// encrypt(context || 0) || encrypt(context || 1) || ...
+		output := oracle(guesses)
+		if err := queries.err(); err != nil {
+			return nil, err
+		}
 
-			// We now know these two values:
-			//
-			//  1. encrypt(prefix || secret || ... )
-			//  2. encrypt(prefix || res || b || ... )
-			//
-			// Compare leading blocks to determine if b was the correct guess.
-			if bytes.Equal(output[:len(input)], want[:len(input)]) {
-				res = append(res, b)
-				continue outer
+		found := false
+		for b := range math.MaxUint8 + 1 {
+			if bytes.Equal(output[b*bs:(b+1)*bs], want) {
+				known = append(known, byte(b))
+				found = true
+				break
 			}
 		}
+		if !found {
+			return nil, errors.New("no guess matched")
+		}
 
-		// No guesses were correct, so we're done.
-		//
-		// TODO: Use len(input) == len(want) as the completion criteria instead.
-		break
+		o.debug("recovered byte", "index", i, "byte", known[len(known)-1], "oracle_calls", queries.calls())
+		o.report(Progress{BytesRecovered: i + 1, OracleCalls: queries.calls()})
 	}
 
-	// We guessed some padding as well, so remove it.
-	//
-	// TODO: Can we avoid guessing any padding?
-	res = UnpadPKCS7(res)
+	res := known[bs-1:]
+	o.debug("recovered secret", "len", len(res), "oracle_calls", queries.calls())
 
-	return res
+	return res, nil
 }
 
 // ProfileManager manages profiles as described in challenge 13.
 type ProfileManager struct {
-	key []byte
+	block   cipher.Block
+	macKey  []byte // Nil unless profiles are authenticated.
+	o       *options
+	nextUID atomic.Int64
 }
 
-// NewProfileManager returns a new profile manager.
-func NewProfileManager() *ProfileManager {
-	key := randBytes(16)
-	return &ProfileManager{key: key}
+// NewProfileManager returns a new profile manager. The key is 16 bytes unless
+// set with WithKeySize, and WithCipher replaces AES. Profiles are encoded as
+// QueryProfiles unless set with WithProfileFormat.
+func NewProfileManager(opts ...Option) *ProfileManager {
+	o := newOptions(opts)
+	block := o.newBlock(randBytes(int64(o.keySize)))
+	m := &ProfileManager{block: block, o: o}
+	if o.authProfiles {
+		m.macKey = randBytes(32)
+	}
+	m.nextUID.Store(o.uidStart)
+	return m
 }
 
-// NewUserProfile returns a new profile with user permissions.
-func (p ProfileManager) NewUserProfile(email string) []byte {
-	vals := url.Values{}
+// WithUIDCounter makes a profile manager hand out sequential uids starting
+// from start, rather than random UUIDs. Profiles for the same email then
+// have the same length, so attack offsets are reproducible.
+//
+// CookieProfiles and JSONProfiles always use a counter, starting from 10
+// unless set with WithUIDCounter.
+func WithUIDCounter(start int64) Option {
+	return func(o *options) {
+		o.uidCounter = true
+		o.uidStart = start
+	}
+}
 
-	vals.Add("email", email)
-	vals.Add("uid", uuid.NewString())
-	vals.Add("role", "user")
+// WithAuthenticatedProfiles makes a profile manager append an HMAC-SHA256 of
+// each encrypted profile and reject profiles whose HMAC doesn't match. Spliced
+// profiles, such as those from NewAdminProfile, are rejected.
+func WithAuthenticatedProfiles() Option {
+	return func(o *options) {
+		o.authProfiles = true
+	}
+}
 
-	res := []byte(vals.Encode())
-	res = PadPKCS7(res, aes.BlockSize)
+// profileMAC returns the HMAC of an encrypted profile.
+func (p *ProfileManager) profileMAC(ct []byte) []byte {
+	m := hmac.New(sha256.New, p.macKey)
+	m.Write(ct)
+	return m.Sum(nil)
+}
 
-	block, err := aes.NewCipher(p.key)
-	if err != nil {
-		panic(err)
+// newUID returns the uid for a new profile.
+func (p *ProfileManager) newUID() string {
+	if p.o.uidCounter || p.o.profileFormat != QueryProfiles {
+		return strconv.FormatInt(p.nextUID.Add(1)-1, 10)
+	}
+	return uuid.NewString()
+}
+
+// NewUserProfile returns a new profile with user permissions.
+//
+// If the manager was created with WithSanitization(RejectMetachars) and email
+// contains "&" or "=", NewUserProfile returns nil. Use NewUserProfileErr to
+// get an error instead.
+func (p *ProfileManager) NewUserProfile(email string) []byte {
+	profile, _ := p.NewUserProfileErr(email)
+	return profile
+}
+
+// NewUserProfileErr is like NewUserProfile, but returns ErrRejectedEmail if
+// the manager rejects email.
+func (p *ProfileManager) NewUserProfileErr(email string) ([]byte, error) {
+	email, ok := p.o.sanitization.sanitize(email)
+	if !ok {
+		p.o.debug("email rejected", "email", email)
+		return nil, ErrRejectedEmail
 	}
 
-	mode := NewECBEncrypter(block)
+	s := p.o.profileFormat.encode(profile{email: email, uid: p.newUID(), role: "user"})
+
+	p.o.debug("profile created", "profile", s)
+
+	mode := NewECBEncrypter(p.block)
+
+	res := PadPKCS7([]byte(s), mode.BlockSize())
+
 	mode.CryptBlocks(res, res)
 
-	return res
+	if p.macKey != nil {
+		res = append(res, p.profileMAC(res)...)
+	}
+
+	return res, nil
 }
 
 // IsAdmin returns true if the profile has admin permissions.
-func (p ProfileManager) IsAdmin(profile []byte) bool {
-	block, err := aes.NewCipher(p.key)
-	if err != nil {
-		panic(err)
+func (p *ProfileManager) IsAdmin(profile []byte) bool {
+	role, err := p.Role(profile)
+	return err == nil && role == "admin"
+}
+
+// Role returns the role in an encrypted profile. If the manager
+// authenticates profiles, it returns ErrInvalidMAC for a profile whose HMAC
+// doesn't match, before decrypting it.
+func (p *ProfileManager) Role(profile []byte) (string, error) {
+	if p.macKey != nil {
+		if len(profile) < sha256.Size {
+			return "", ErrInvalidMAC
+		}
+
+		var tag []byte
+		profile, tag = profile[:len(profile)-sha256.Size], profile[len(profile)-sha256.Size:]
+
+		if !hmac.Equal(tag, p.profileMAC(profile)) {
+			p.o.debug("profile rejected", "reason", "invalid mac")
+			return "", ErrInvalidMAC
+		}
+	}
+
+	bs := p.block.BlockSize()
+	if len(profile)%bs != 0 {
+		return "", errors.New("profile isn't a whole number of blocks")
 	}
 
 	pt := make([]byte, len(profile))
 
-	mode := NewECBDecrypter(block)
+	mode := NewECBDecrypter(p.block)
 	mode.CryptBlocks(pt, profile)
 
-	pt = UnpadPKCS7(pt)
+	pt, err := PKCS7Padder{}.Unpad(pt, bs)
+	if err != nil {
+		return "", err
+	}
 
-	vals, err := url.ParseQuery(string(pt))
+	prof, err := p.o.profileFormat.decode(string(pt))
 	if err != nil {
-		return false
+		return "", err
 	}
 
-	return vals.Get("role") == "admin"
+	p.o.debug("profile checked", "role", prof.role)
+
+	return prof.role, nil
 }
 
 // NewAdminProfile performs a cut-and-paste ECB attack to create an admin
 // profile from multiple user profiles.
 //
+// NewAdminProfile assumes m uses a block cipher with 16-byte blocks and
+// encodes profiles as QueryProfiles.
+//
 // TODO: Is this possible to do without using an invalid TLD (.admin)?
 func NewAdminProfile(m *ProfileManager) []byte {
 	// Note that profileManager decodes profiles into url.Values, which exhibits
@@ -349,6 +605,66 @@ func NewAdminProfile(m *ProfileManager) []byte {
 	return append(a[:32], b[16:]...)
 }
 
+// NewCookieAdminProfile performs the cut-and-paste ECB attack from challenge
+// 13 to create an admin profile from two user profiles.
+//
+// NewCookieAdminProfile assumes m uses a block cipher with 16-byte blocks,
+// encodes profiles as CookieProfiles, and hands out two-digit uids.
+func NewCookieAdminProfile(m *ProfileManager) []byte {
+	// Make a1 hold "admin" and its padding, as if it were the last block.
+	//
+	// |<-----a0----->||<-----a1----->||<-----a2----->|
+	// email=xxxxxxxxxxadmin...........&uid=10&role=user
+
+	a := m.NewUserProfile("xxxxxxxxxx" + string(PadPKCS7([]byte("admin"), 16)))
+
+	// Make b1 end in "role=".
+	//
+	// |<-----b0----->||<-----b1----->||<-----b2----->|
+	// email=bagel@exa.com&uid=11&role=user
+
+	b := m.NewUserProfile("bagel@exa.com")
+
+	// Replace b2 with a1.
+	//
+	// |<-----b0----->||<-----b1----->||<-----a1----->|
+	// email=bagel@exa.com&uid=11&role=admin
+
+	return slices.Concat(b[:32], a[16:32])
+}
+
+// NewJSONAdminProfile performs a cut-and-paste ECB attack to create an admin
+// profile from two JSON user profiles.
+//
+// It works because, like the other formats, JSON profiles keep the first
+// value of a repeated key. JSON escapes quotes in emails, so the attack can't
+// inject keys of its own.
+//
+// NewJSONAdminProfile assumes m uses a block cipher with 16-byte blocks,
+// encodes profiles as JSONProfiles, and hands out two-digit uids.
+func NewJSONAdminProfile(m *ProfileManager) []byte {
+	// Make a2 end in "role":".
+	//
+	// |<-----a0----->||<-----a1----->||<-----a2----->||<-----a3----->|
+	// {"email":"bagels@examples.com","uid":10,"role":"user"}
+
+	a := m.NewUserProfile("bagels@examples.com")
+
+	// Make b1 start with "admin".
+	//
+	// |<-----b0----->||<-----b1----->||<-----b2----->|
+	// {"email":"xxxxxxadmin","uid":11,"role":"user"}
+
+	b := m.NewUserProfile("xxxxxxadmin")
+
+	// Replace a3 with everything from b1 on. The first role is "admin".
+	//
+	// |<-----a0----->||<-----a1----->||<-----a2----->||<-----b1----->||<-----b2----->|
+	// {"email":"bagels@examples.com","uid":10,"role":"admin","uid":11,"role":"user"}
+
+	return slices.Concat(a[:48], b[16:])
+}
+
 // randInt64 generates a random int64 using crypto/rand.Int.
 func randInt64(max int64) int64 {
 	n, err := rand.Int(rand.Reader, big.NewInt(max))
@@ -370,24 +686,24 @@ func randBytes(n int64) []byte {
 // NewECBPrefixSuffixOracle returns an encryption oracle that behaves as
 // described in challenge 14.
 //
-// It returns AES-128-ECB(key, prefix || input || secret). The key and prefix
-// are random and fixed.
-func NewECBPrefixSuffixOracle(secret []byte) func([]byte) []byte {
+// It returns AES-ECB(key, prefix || input || secret). The key and prefix are
+// random and fixed. The key is 16 bytes unless set with WithKeySize, and
+// WithCipher replaces AES.
+func NewECBPrefixSuffixOracle(secret []byte, opts ...Option) func([]byte) []byte {
+	o := newOptions(opts)
+
 	var (
-		key    = randBytes(16)
+		mode   = NewECBEncrypter(o.newBlock(randBytes(int64(o.keySize))))
 		prefix = randBytes(1 + randInt64(50))
 	)
 
-	return func(input []byte) []byte {
-		b := slices.Concat(prefix, input, secret)
-		b = PadPKCS7(b, aes.BlockSize)
+	o.debug("oracle created", "prefix_len", len(prefix))
 
-		block, err := aes.NewCipher(key)
-		if err != nil {
-			panic(err)
-		}
+	return func(input []byte) []byte {
+		o.debug("oracle called", "input_len", len(input))
 
-		mode := NewECBEncrypter(block)
+		b := slices.Concat(prefix, input, secret)
+		b = PadPKCS7(b, mode.BlockSize())
 
 		mode.CryptBlocks(b, b)
 
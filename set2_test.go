@@ -3,7 +3,13 @@ package cryptopals
 import (
 	"bytes"
 	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
 	"encoding/base64"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -41,6 +47,118 @@ func TestChallenge10(t *testing.T) {
 	t.Logf("plaintext: %q", in)
 }
 
+func TestCBCEncrypter(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iv := make([]byte, block.BlockSize())
+	pt := bytes.Repeat([]byte("sixteen byte blk"), 4)
+
+	want := make([]byte, len(pt))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(want, pt)
+
+	// Encrypting in two calls must chain the same as one call.
+	got := make([]byte, len(pt))
+	enc := NewCBCEncrypter(block, iv)
+	enc.CryptBlocks(got[:32], pt[:32])
+	enc.CryptBlocks(got[32:], pt[32:])
+
+	if !bytes.Equal(want, got) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+
+	if !bytes.Equal(enc.IV(), got[len(got)-16:]) {
+		t.Errorf("IV isn't the last ciphertext block: %x", enc.IV())
+	}
+}
+
+func TestCBCSetIV(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iv := []byte("an iv of 16 byte")
+	msgs := [][]byte{
+		[]byte("first message..."),
+		[]byte("second message, two blocks long!"),
+	}
+
+	enc := NewCBCEncrypter(block, iv)
+	dec := NewCBCDecrypter(block, make([]byte, block.BlockSize()))
+
+	for _, msg := range msgs {
+		enc.SetIV(iv)
+		ct := make([]byte, len(msg))
+		enc.CryptBlocks(ct, msg)
+
+		dec.SetIV(iv)
+		pt := make([]byte, len(ct))
+		dec.CryptBlocks(pt, ct)
+
+		if !bytes.Equal(msg, pt) {
+			t.Errorf("want %q, got %q", msg, pt)
+		}
+		if !bytes.Equal(dec.IV(), ct[len(ct)-16:]) {
+			t.Errorf("decrypter IV isn't the last ciphertext block: %x", dec.IV())
+		}
+	}
+
+	// The IV passed in stays the caller's.
+	if string(iv) != "an iv of 16 byte" {
+		t.Errorf("iv modified: %q", iv)
+	}
+}
+
+func TestEncryptPadded(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range 3*aes.BlockSize + 1 {
+		pt := bytes.Repeat([]byte{'a'}, n)
+
+		ct := EncryptPadded(block, pt)
+		if want := (n/aes.BlockSize + 2) * aes.BlockSize; len(ct) != want {
+			t.Errorf("length %d: want %d bytes of iv || ct, got %d", n, want, len(ct))
+		}
+
+		got, err := DecryptPadded(block, ct)
+		if err != nil {
+			t.Fatalf("length %d: %v", n, err)
+		}
+		if !bytes.Equal(pt, got) {
+			t.Errorf("length %d: want %q, got %q", n, pt, got)
+		}
+	}
+
+	if bytes.Equal(EncryptPadded(block, nil), EncryptPadded(block, nil)) {
+		t.Error("IV reused")
+	}
+}
+
+func TestDecryptPaddedErrors(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct := EncryptPadded(block, []byte("attack at dawn"))
+
+	if _, err := DecryptPadded(block, ct[:aes.BlockSize]); err == nil || errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("iv only: want length error, got %v", err)
+	}
+
+	// The plaintext ends in 02 02, so flipping the last IV byte breaks it.
+	ct[aes.BlockSize-1] ^= 0xff
+	if _, err := DecryptPadded(block, ct); !errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("want ErrInvalidPadding, got %v", err)
+	}
+}
+
 func TestChallenge11(t *testing.T) {
 	var (
 		nECB int
@@ -97,3 +215,316 @@ func TestChallenge13(t *testing.T) {
 		t.Errorf("not an admin profile: %x", profile)
 	}
 }
+
+func TestChallenge13Cookie(t *testing.T) {
+	m := NewProfileManager(WithProfileFormat(CookieProfiles))
+
+	profile := NewCookieAdminProfile(m)
+
+	if !m.IsAdmin(profile) {
+		t.Errorf("not an admin profile: %x", profile)
+	}
+}
+
+func TestChallenge13JSON(t *testing.T) {
+	m := NewProfileManager(WithProfileFormat(JSONProfiles))
+
+	// Quotes in emails are escaped, so keys can't be injected.
+	if m.IsAdmin(m.NewUserProfile(`foo@bar.com","role":"admin`)) {
+		t.Error("injected role accepted")
+	}
+
+	if !m.IsAdmin(NewJSONAdminProfile(m)) {
+		t.Error("not an admin profile")
+	}
+}
+
+func TestChallenge13Authenticated(t *testing.T) {
+	attacks := []struct {
+		name   string
+		f      ProfileFormat
+		attack func(*ProfileManager) []byte
+	}{
+		{"query", QueryProfiles, NewAdminProfile},
+		{"cookie", CookieProfiles, NewCookieAdminProfile},
+		{"json", JSONProfiles, NewJSONAdminProfile},
+	}
+
+	for _, a := range attacks {
+		m := NewProfileManager(WithProfileFormat(a.f), WithAuthenticatedProfiles())
+
+		user := m.NewUserProfile("foo@bar.com")
+		if role, err := m.Role(user); err != nil || role != "user" {
+			t.Errorf("%s: user profile: got role %q, %v", a.name, role, err)
+		}
+
+		// Tampering with a real profile, even keeping its length, fails the
+		// MAC check.
+		user[0] ^= 1
+		if _, err := m.Role(user); !errors.Is(err, ErrInvalidMAC) {
+			t.Errorf("%s: tampered profile: got %v, want ErrInvalidMAC", a.name, err)
+		}
+
+		spliced := a.attack(m)
+		if m.IsAdmin(spliced) {
+			t.Errorf("%s: spliced profile accepted", a.name)
+		}
+		if _, err := m.Role(spliced); !errors.Is(err, ErrInvalidMAC) {
+			t.Errorf("%s: spliced profile: got %v, want ErrInvalidMAC", a.name, err)
+		}
+	}
+}
+
+func TestAuthenticatedProfileRoundTrip(t *testing.T) {
+	m := NewProfileManager(WithProfileFormat(CookieProfiles), WithAuthenticatedProfiles())
+
+	// Forge an admin profile's plaintext under the manager's keys, to check
+	// that valid profiles still get through.
+	pt := PadPKCS7([]byte("email=foo@bar.com&uid=10&role=admin"), m.block.BlockSize())
+	NewECBEncrypter(m.block).CryptBlocks(pt, pt)
+	profile := append(pt, m.profileMAC(pt)...)
+
+	if !m.IsAdmin(profile) {
+		t.Error("valid admin profile rejected")
+	}
+
+	profile[0] ^= 1
+	if m.IsAdmin(profile) {
+		t.Error("tampered profile accepted")
+	}
+}
+
+func TestProfileManagerUIDCounter(t *testing.T) {
+	m := NewProfileManager(WithUIDCounter(42))
+
+	for i := range 3 {
+		want := PadPKCS7([]byte("email=foo%40bar.com&role=user&uid="+strconv.Itoa(42+i)), 16)
+		NewECBEncrypter(m.block).CryptBlocks(want, want)
+
+		if got := m.NewUserProfile("foo@bar.com"); !bytes.Equal(want, got) {
+			t.Errorf("profile %d: want %x, got %x", i, want, got)
+		}
+	}
+
+	// The cut-and-paste attack doesn't depend on how uids are made.
+	m = NewProfileManager(WithUIDCounter(10))
+	if !m.IsAdmin(NewAdminProfile(m)) {
+		t.Error("not an admin profile")
+	}
+}
+
+func TestChallenge13Sanitized(t *testing.T) {
+	for _, s := range []Sanitization{StripMetachars, RejectMetachars} {
+		m := NewProfileManager(WithProfileFormat(CookieProfiles), WithSanitization(s))
+
+		// Sanitizing stops the naive attack...
+		if m.IsAdmin(m.NewUserProfile("foo@bar.com&role=admin")) {
+			t.Errorf("sanitization %d: injected role accepted", s)
+		}
+
+		_, err := m.NewUserProfileErr("foo@bar.com&role=admin")
+		if rejected := errors.Is(err, ErrRejectedEmail); rejected != (s == RejectMetachars) {
+			t.Errorf("sanitization %d: want rejected %t, got error %v", s, s == RejectMetachars, err)
+		}
+
+		// ...but not cutting and pasting blocks.
+		if !m.IsAdmin(NewCookieAdminProfile(m)) {
+			t.Errorf("sanitization %d: not an admin profile", s)
+		}
+	}
+}
+
+// keySizes are the AES key sizes in bytes.
+var keySizes = []int{16, 24, 32}
+
+func TestChallenge11KeySizes(t *testing.T) {
+	for _, ks := range keySizes {
+		for range 10 {
+			// The oracle logs the mode it chose when it's created.
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			oracle := NewECBOrCBCPrefixSuffixOracle(WithKeySize(ks), WithLogger(logger))
+			want := strings.Contains(buf.String(), "ecb=true")
+
+			if got := IsECBOracle(oracle); got != want {
+				t.Errorf("key size %d: detected ecb = %v, want %v", ks, got, want)
+			}
+		}
+	}
+}
+
+func TestChallenge12KeySizes(t *testing.T) {
+	secret := []byte("attacks on ecb don't depend on the key size")
+
+	for _, ks := range keySizes {
+		enc := NewECBSuffixOracle(secret, WithKeySize(ks))
+
+		if !IsECBOracle(enc) {
+			t.Errorf("key size %d: not detected as ecb", ks)
+		}
+
+		got := RecoverECBSuffixOracleSecret(enc)
+		if !bytes.Equal(secret, got) {
+			t.Errorf("key size %d: want %q, got %q", ks, secret, got)
+		}
+	}
+}
+
+func TestChallenge13KeySizes(t *testing.T) {
+	for _, ks := range keySizes {
+		m := NewProfileManager(WithKeySize(ks))
+
+		profile := NewAdminProfile(m)
+
+		if !m.IsAdmin(profile) {
+			t.Errorf("key size %d: not an admin profile: %x", ks, profile)
+		}
+	}
+}
+
+// desCiphers are block ciphers with 8-byte blocks.
+var desCiphers = []struct {
+	name string
+	opt  Option
+}{
+	{"des", WithCipher(des.NewCipher, 8)},
+	{"3des", WithCipher(des.NewTripleDESCipher, 24)},
+	{"des from scratch", WithCipher(NewDES, 8)},
+}
+
+func TestChallenge11DES(t *testing.T) {
+	for _, c := range desCiphers {
+		var nECB int
+		for range 100 {
+			oracle := NewECBOrCBCPrefixSuffixOracle(c.opt)
+			if got := FindBlockSize(oracle); got != des.BlockSize {
+				t.Fatalf("%s: want block size %d, got %d", c.name, des.BlockSize, got)
+			}
+			if IsECBOracle(oracle) {
+				nECB++
+			}
+		}
+
+		if nECB < 30 || nECB > 70 {
+			t.Errorf("%s: bias: nECB=%d", c.name, nECB)
+		}
+	}
+}
+
+func TestChallenge12DES(t *testing.T) {
+	secret := []byte("byte-at-a-time ecb decryption works with 8-byte blocks too")
+
+	for _, c := range desCiphers {
+		enc := NewECBSuffixOracle(secret, c.opt)
+
+		got := RecoverECBSuffixOracleSecret(enc)
+		if !bytes.Equal(secret, got) {
+			t.Errorf("%s: want %q, got %q", c.name, secret, got)
+		}
+	}
+}
+
+func TestChallenge12Progress(t *testing.T) {
+	secret := []byte("progress")
+	enc := NewECBSuffixOracle(secret)
+
+	var reports []Progress
+	RecoverECBSuffixOracleSecret(enc, WithProgress(func(p Progress) {
+		reports = append(reports, p)
+	}))
+
+	if len(reports) != len(secret) {
+		t.Fatalf("want %d reports, got %d", len(secret), len(reports))
+	}
+
+	for i, p := range reports {
+		if p.BytesRecovered != i+1 {
+			t.Errorf("report %d: want %d bytes recovered, got %d", i, i+1, p.BytesRecovered)
+		}
+		if i > 0 && p.OracleCalls <= reports[i-1].OracleCalls {
+			t.Errorf("report %d: oracle calls didn't increase: %+v", i, p)
+		}
+	}
+}
+
+func TestChallenge12Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	enc := NewECBSuffixOracle([]byte("logger"))
+	RecoverECBSuffixOracleSecret(enc, WithLogger(logger))
+
+	for _, msg := range []string{"found block size", "detected ecb", "recovered byte", "recovered secret"} {
+		if !strings.Contains(buf.String(), "msg=\""+msg+"\"") {
+			t.Errorf("missing log message %q", msg)
+		}
+	}
+}
+
+func TestChallenge12OracleCalls(t *testing.T) {
+	secret := bytes.Repeat([]byte("0123456789"), 20)
+	enc := NewECBSuffixOracle(secret)
+
+	var last Progress
+	RecoverECBSuffixOracleSecret(enc, WithProgress(func(p Progress) {
+		last = p
+	}))
+
+	// One call per byte, plus a few dozen for finding the block size and
+	// reference blocks.
+	if limit := len(secret) + 64; last.OracleCalls > limit {
+		t.Errorf("want at most %d oracle calls, got %d", limit, last.OracleCalls)
+	}
+
+	t.Logf("oracle calls: %d", last.OracleCalls)
+}
+
+func TestChallenge12SecretLengths(t *testing.T) {
+	for n := range 3*aes.BlockSize + 1 {
+		secret := bytes.Repeat([]byte{'s'}, n)
+		enc := NewECBSuffixOracle(secret)
+
+		if got := RecoverECBSuffixOracleSecret(enc); !bytes.Equal(secret, got) {
+			t.Errorf("length %d: want %q, got %q", n, secret, got)
+		}
+	}
+}
+
+func TestChallenge12NotECB(t *testing.T) {
+	// Output grows a byte at a time, so there are no blocks to repeat.
+	enc := func(b []byte) []byte { return b }
+
+	if _, err := RecoverECBSuffixOracleSecretErr(enc); err == nil {
+		t.Error("want error for non-ecb oracle")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for non-ecb oracle")
+		}
+	}()
+	RecoverECBSuffixOracleSecret(enc)
+}
+
+func BenchmarkECBSuffixOracle10k(b *testing.B) {
+	enc := NewECBSuffixOracle([]byte("benchmark secret"))
+	input := make([]byte, 32)
+
+	b.ReportAllocs()
+	for range b.N {
+		for range 10_000 {
+			enc(input)
+		}
+	}
+}
+
+func BenchmarkProfileManager10k(b *testing.B) {
+	m := NewProfileManager()
+
+	b.ReportAllocs()
+	for range b.N {
+		for range 10_000 {
+			m.IsAdmin(m.NewUserProfile("foo@bar.com"))
+		}
+	}
+}
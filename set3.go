@@ -0,0 +1,271 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// WithFlakiness makes a CBCPaddingOracle give the wrong answer about a
+// ciphertext's padding with probability rate, as a remote oracle that
+// sometimes times out or hits a different backend does. WithSeed makes the
+// wrong answers deterministic. The default is 0.
+func WithFlakiness(rate float64) Option {
+	return func(o *options) {
+		o.flakiness = rate
+	}
+}
+
+// WithVotes makes RecoverCBCPaddingOracleSecret ask the oracle again about
+// each ciphertext it says has valid padding, until one answer has more than
+// n/2 votes, and go with that answer, to get past a flaky oracle. It trusts
+// answers of invalid padding, which nearly all are; a wrong one shows up
+// later, and the attack backtracks past it. The default is 1, which trusts
+// every answer.
+func WithVotes(n int) Option {
+	return func(o *options) {
+		o.votes = n
+	}
+}
+
+// CBCPaddingOracle encrypts a secret and checks ciphertext padding as
+// described in challenge 17.
+type CBCPaddingOracle struct {
+	block  cipher.Block
+	secret []byte
+	o      *options
+
+	mu  sync.Mutex
+	rng *rand.Rand // For WithFlakiness.
+}
+
+// NewCBCPaddingOracle returns a new padding oracle for secret under a random
+// AES key. The key is 16 bytes unless set with WithKeySize, and WithCipher
+// replaces AES. Use WithFlakiness to make it unreliable.
+func NewCBCPaddingOracle(secret []byte, opts ...Option) *CBCPaddingOracle {
+	o := newOptions(opts)
+	block := o.newBlock(randBytes(int64(o.keySize)))
+	return &CBCPaddingOracle{block: block, secret: secret, o: o, rng: rand.New(o.newRand())}
+}
+
+// BlockSize returns the block size of the oracle's cipher.
+func (p *CBCPaddingOracle) BlockSize() int {
+	return p.block.BlockSize()
+}
+
+// Ciphertext returns iv || encrypt(pad(secret)) under a random IV.
+func (p *CBCPaddingOracle) Ciphertext() []byte {
+	return EncryptPadded(p.block, p.secret)
+}
+
+// Check decrypts iv || ct and reports whether the plaintext has valid PKCS #7
+// padding. It returns nil if it does, ErrInvalidPadding if it doesn't, and
+// another error if the input isn't at least two whole blocks. With
+// WithFlakiness, it sometimes swaps nil and ErrInvalidPadding.
+func (p *CBCPaddingOracle) Check(ct []byte) error {
+	_, err := DecryptPadded(p.block, ct)
+	if (err == nil || errors.Is(err, ErrInvalidPadding)) && p.flake() {
+		if err == nil {
+			err = ErrInvalidPadding
+		} else {
+			err = nil
+		}
+	}
+	p.o.debug("padding checked", "valid", err == nil)
+	return err
+}
+
+// flake reports whether the next answer should be wrong.
+func (p *CBCPaddingOracle) flake() bool {
+	if p.o.flakiness <= 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rng.Float64() < p.o.flakiness
+}
+
+// IsValid reports whether Check(ct) returns nil. It can be passed to
+// RecoverCBCPaddingOracleSecret.
+func (p *CBCPaddingOracle) IsValid(ct []byte) bool {
+	return p.Check(ct) == nil
+}
+
+// RecoverCBCPaddingOracleSecret performs a CBC padding oracle attack as
+// described in challenge 17, decrypting iv || ct.
+//
+// The valid function reports whether a ciphertext, in the same iv || ct form,
+// decrypts to validly padded plaintext. It returns an error if no byte guess
+// is accepted by valid, or if the recovered plaintext isn't validly padded.
+//
+// An unreliable oracle's false positives show up as a byte for which no
+// guess is accepted, because the bytes after it were set from a wrong one,
+// and the attack then backtracks to the byte before and searches on from
+// the guess it accepted there. Use WithVotes to make each answer more
+// reliable too: a false positive for the first byte of a block has nothing
+// after it to expose it.
+//
+// Use WithProgress or WithLogger to observe the attack as it runs.
+func RecoverCBCPaddingOracleSecret(ct []byte, blockSize int, valid func([]byte) bool, opts ...Option) (_ []byte, err error) {
+	o := newOptions(opts)
+
+	if len(ct)%blockSize != 0 || len(ct) < 2*blockSize {
+		return nil, errors.New("invalid ciphertext length")
+	}
+
+	queries := o.newMeter()
+	defer queries.finish(&err)
+	valid = majority(queries.valid(valid), o.votes)
+
+	res := make([]byte, 0, len(ct)-blockSize)
+
+	// Decrypt each block using the one before it, starting with the IV.
+	for start := blockSize; start < len(ct); start += blockSize {
+		prev, cur := ct[start-blockSize:start], ct[start:start+blockSize]
+
+		inter, err := recoverCBCIntermediate(cur, blockSize, valid, o)
+		if err := queries.err(); err != nil {
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Each plaintext byte is the intermediate byte XOR the previous
+		// ciphertext byte.
+		res = append(res, XOR(inter, prev)...)
+
+		o.debug("recovered block", "index", start/blockSize-1, "oracle_calls", queries.calls())
+		o.report(Progress{BytesRecovered: len(res), OracleCalls: queries.calls()})
+	}
+
+	return PKCS7Padder{}.Unpad(res, blockSize)
+}
+
+// majority returns a wrapper around valid that, when valid reports a
+// ciphertext valid, calls it again until one answer has more than n/2
+// votes, and returns that answer.
+func majority(valid func([]byte) bool, n int) func([]byte) bool {
+	if n <= 1 {
+		return valid
+	}
+	return func(ct []byte) bool {
+		if !valid(ct) {
+			return false
+		}
+		yes, no := 1, 0
+		for yes <= n/2 && no <= n/2 {
+			if valid(ct) {
+				yes++
+			} else {
+				no++
+			}
+		}
+		return yes > no
+	}
+}
+
+// maxPaddingOracleBacktracks is the most times recoverCBCIntermediate
+// backtracks in a block before giving up.
+const maxPaddingOracleBacktracks = 64
+
+// recoverCBCIntermediate returns the block cipher decryption of cur, using
+// valid to check the padding of forged two-block ciphertexts.
+func recoverCBCIntermediate(cur []byte, blockSize int, valid func([]byte) bool, o *options) ([]byte, error) {
+	var (
+		inter = make([]byte, blockSize)
+		input = make([]byte, 2*blockSize) // forged || cur
+		forge = input[:blockSize]
+	)
+	copy(input[blockSize:], cur)
+
+	// accepted[i] is the guess accepted for byte i, or -1 if there's none,
+	// so a search there starts from 0 rather than after it. retried[i] is
+	// whether the search for byte i has failed since it was last reached.
+	accepted := make([]int, blockSize)
+	for i := range accepted {
+		accepted[i] = -1
+	}
+	retried := make([]bool, blockSize)
+	backtracks := 0
+
+	// Recover intermediate bytes from last to first. To learn byte i, set the
+	// bytes after it so they decrypt to the padding value, then find the
+	// forged byte that makes byte i decrypt to the padding value too.
+	for i := blockSize - 1; ; {
+		if i < 0 {
+			// Nothing after the first byte exposes a false positive for it,
+			// so with votes, check it once more.
+			if o.votes <= 1 || valid(input) {
+				break
+			}
+			if backtracks == maxPaddingOracleBacktracks {
+				return nil, errors.New("no guess produced valid padding")
+			}
+			backtracks++
+			i = 0
+		}
+		pad := byte(blockSize - i)
+
+		for j := i + 1; j < blockSize; j++ {
+			forge[j] = inter[j] ^ pad
+		}
+
+		found := false
+		for k := range math.MaxUint8 + 1 {
+			g := (accepted[i] + 1 + k) % (math.MaxUint8 + 1)
+			if k == math.MaxUint8 && accepted[i] >= 0 {
+				break // Back at the rejected guess.
+			}
+			forge[i] = byte(g)
+
+			if !valid(input) {
+				continue
+			}
+
+			// For the last byte, the padding might be valid because the
+			// plaintext happens to end in 02 02 (or 03 03 03, ...) instead of
+			// 01. Changing the byte before it rules that out.
+			if i == blockSize-1 && i > 0 {
+				forge[i-1] ^= 0xff
+				ok := valid(input)
+				forge[i-1] ^= 0xff
+				if !ok {
+					continue
+				}
+			}
+
+			inter[i] = byte(g) ^ pad
+			accepted[i] = g
+			found = true
+			break
+		}
+
+		if found {
+			if i > 0 {
+				retried[i-1] = false
+			}
+			i--
+			continue
+		}
+
+		// This byte's right guess got a false negative, or the guess for
+		// the byte after was a false positive. Search this byte again, then
+		// search on from the byte after.
+		if backtracks == maxPaddingOracleBacktracks {
+			return nil, errors.New("no guess produced valid padding")
+		}
+		backtracks++
+		accepted[i] = -1
+		if !retried[i] || i == blockSize-1 {
+			retried[i] = true
+		} else {
+			i++
+		}
+		o.debug("backtracked", "byte", i, "backtracks", backtracks)
+	}
+
+	return inter, nil
+}
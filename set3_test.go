@@ -0,0 +1,92 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"errors"
+	"testing"
+)
+
+func TestChallenge17(t *testing.T) {
+	for _, secret := range Challenge17Secrets() {
+		p := NewCBCPaddingOracle(secret)
+
+		got, err := RecoverCBCPaddingOracleSecret(p.Ciphertext(), aes.BlockSize, p.IsValid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(secret, got) {
+			t.Errorf("want %q, got %q", secret, got)
+		}
+
+		t.Logf("plaintext: %q", got)
+	}
+}
+
+func TestChallenge17DES(t *testing.T) {
+	secret := []byte("padding oracles work on 8-byte blocks too")
+	p := NewCBCPaddingOracle(secret, WithCipher(des.NewCipher, 8))
+
+	got, err := RecoverCBCPaddingOracleSecret(p.Ciphertext(), des.BlockSize, p.IsValid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
+
+func TestChallenge17EveryLength(t *testing.T) {
+	// Secrets ending in 01, 02 02, ... exercise the false-positive check.
+	for n := range 2*aes.BlockSize + 1 {
+		secret := bytes.Repeat([]byte{0x02}, n)
+		p := NewCBCPaddingOracle(secret)
+
+		got, err := RecoverCBCPaddingOracleSecret(p.Ciphertext(), aes.BlockSize, p.IsValid)
+		if err != nil {
+			t.Fatalf("length %d: %v", n, err)
+		}
+
+		if !bytes.Equal(secret, got) {
+			t.Errorf("length %d: want %x, got %x", n, secret, got)
+		}
+	}
+}
+
+func TestChallenge17Flaky(t *testing.T) {
+	// One answer in 20 is wrong. Trusting every answer fails almost always.
+	secret := []byte("remote oracles flake, so vote and backtrack")
+	p := NewCBCPaddingOracle(secret, WithFlakiness(0.05))
+
+	got, err := RecoverCBCPaddingOracleSecret(p.Ciphertext(), aes.BlockSize, p.IsValid, WithVotes(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(secret, got) {
+		t.Errorf("want %q, got %q", secret, got)
+	}
+}
+
+func TestCBCPaddingOracleCheck(t *testing.T) {
+	p := NewCBCPaddingOracle([]byte("secret"))
+	ct := p.Ciphertext()
+
+	if err := p.Check(ct); err != nil {
+		t.Errorf("want valid padding, got %v", err)
+	}
+
+	if err := p.Check(ct[:len(ct)-1]); err == nil || errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("want length error, got %v", err)
+	}
+
+	// Flipping the last IV byte breaks the 0a 0a ... padding of the only
+	// block.
+	ct[aes.BlockSize-1] ^= 1
+	if err := p.Check(ct); !errors.Is(err, ErrInvalidPadding) {
+		t.Errorf("want ErrInvalidPadding, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// sha1MD is SHA-1 as specified in FIPS 180-4.
+var sha1MD = &MerkleDamgard{
+	BlockSize: 64,
+	IV:        encodeWords(binary.BigEndian, 0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0),
+	Compress:  sha1Block,
+	Length:    BigEndianLength,
+}
+
+// NewSHA1 returns a new SHA-1 hash.Hash, implemented from the specification
+// rather than with crypto/sha1, so its state can be set through SHA1.
+func NewSHA1() hash.Hash {
+	return sha1MD.New()
+}
+
+// sha1Block runs the compression function on one 64-byte block, updating the
+// registers encoded in state.
+func sha1Block(state, p []byte) {
+	h := decodeWords(binary.BigEndian, state)
+
+	var w [80]uint32
+	for i := range 16 {
+		w[i] = binary.BigEndian.Uint32(p[4*i:])
+	}
+	for i := 16; i < 80; i++ {
+		w[i] = bits.RotateLeft32(w[i-3]^w[i-8]^w[i-14]^w[i-16], 1)
+	}
+
+	a, b, c, d, e := h[0], h[1], h[2], h[3], h[4]
+	for i := range 80 {
+		var f, k uint32
+		switch {
+		case i < 20:
+			f, k = b&c|^b&d, 0x5a827999
+		case i < 40:
+			f, k = b^c^d, 0x6ed9eba1
+		case i < 60:
+			f, k = b&c|b&d|c&d, 0x8f1bbcdc
+		default:
+			f, k = b^c^d, 0xca62c1d6
+		}
+		t := bits.RotateLeft32(a, 5) + f + e + k + w[i]
+		e, d, c, b, a = d, c, bits.RotateLeft32(b, 30), a, t
+	}
+
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+	h[4] += e
+	copy(state, encodeWords(binary.BigEndian, h...))
+}
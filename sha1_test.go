@@ -0,0 +1,21 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestSHA1(t *testing.T) {
+	for n := range 300 {
+		msg := englishText[:n]
+
+		h := NewSHA1()
+		h.Write(msg)
+
+		want := sha1.Sum(msg)
+		if got := h.Sum(nil); !bytes.Equal(want[:], got) {
+			t.Errorf("%d bytes: want %x, got %x", n, want, got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cryptopals
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// SHA-256 sizes in bytes.
+const (
+	sha256Size      = 32
+	sha256BlockSize = 64
+)
+
+// sha256IV holds the initial SHA-256 registers.
+var sha256IV = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// sha256K holds the SHA-256 round constants.
+var sha256K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// sha256MD is SHA-256 as specified in FIPS 180-4.
+var sha256MD = &MerkleDamgard{
+	BlockSize: sha256BlockSize,
+	IV:        encodeWords(binary.BigEndian, sha256IV[:]...),
+	Compress:  sha256Block,
+	Length:    BigEndianLength,
+}
+
+// NewSHA256 returns a new SHA-256 hash.Hash, implemented from the
+// specification rather than with crypto/sha256, so its state can be set with
+// NewSHA256FromState or through SHA256().
+func NewSHA256() hash.Hash {
+	return sha256MD.New()
+}
+
+// NewSHA256FromState returns a SHA-256 hash.Hash that continues from the
+// registers h, as if n bytes had already been hashed. A SHA-256 digest is its
+// registers in big-endian order, so this resumes hashing from a digest.
+//
+// It panics if n isn't a multiple of the 64-byte block size, since the
+// registers only change at block boundaries. Reset returns to this state.
+func NewSHA256FromState(h [8]uint32, n uint64) hash.Hash {
+	return sha256MD.NewFromState(encodeWords(binary.BigEndian, h[:]...), n)
+}
+
+// SHA256Registers returns the registers encoded in a SHA-256 digest. It
+// panics if digest isn't 32 bytes.
+func SHA256Registers(digest []byte) [8]uint32 {
+	if len(digest) != sha256Size {
+		panic("invalid digest length")
+	}
+	return [8]uint32(decodeWords(binary.BigEndian, digest))
+}
+
+// SHA256Padding returns the padding SHA-256 appends to an n-byte message: a
+// 0x80 byte, zeros, and the message length in bits as a big-endian uint64, so
+// that the padded message is a whole number of blocks.
+func SHA256Padding(n uint64) []byte {
+	return sha256MD.Padding(n)
+}
+
+// ExtendSHA256 performs a length extension on a SHA-256 secret-prefix tag.
+// It's Extend with SHA256.
+func ExtendSHA256(tag, msg, suffix []byte, keySize int) (forged, forgedTag []byte) {
+	return Extend(sha256MD, tag, msg, suffix, keySize)
+}
+
+// sha256Block runs the compression function on one 64-byte block, updating
+// the registers encoded in state.
+func sha256Block(state, p []byte) {
+	h0 := SHA256Registers(state)
+
+	var w [64]uint32
+	for i := range 16 {
+		w[i] = binary.BigEndian.Uint32(p[4*i:])
+	}
+	for i := 16; i < 64; i++ {
+		s0 := bits.RotateLeft32(w[i-15], -7) ^ bits.RotateLeft32(w[i-15], -18) ^ w[i-15]>>3
+		s1 := bits.RotateLeft32(w[i-2], -17) ^ bits.RotateLeft32(w[i-2], -19) ^ w[i-2]>>10
+		w[i] = w[i-16] + s0 + w[i-7] + s1
+	}
+
+	a, b, c, e, f, g, h := h0[0], h0[1], h0[2], h0[4], h0[5], h0[6], h0[7]
+	dd := h0[3]
+
+	for i := range 64 {
+		s1 := bits.RotateLeft32(e, -6) ^ bits.RotateLeft32(e, -11) ^ bits.RotateLeft32(e, -25)
+		ch := e&f ^ ^e&g
+		t1 := h + s1 + ch + sha256K[i] + w[i]
+		s0 := bits.RotateLeft32(a, -2) ^ bits.RotateLeft32(a, -13) ^ bits.RotateLeft32(a, -22)
+		maj := a&b ^ a&c ^ b&c
+		t2 := s0 + maj
+
+		h, g, f, e = g, f, e, dd+t1
+		dd, c, b, a = c, b, a, t1+t2
+	}
+
+	h0[0] += a
+	h0[1] += b
+	h0[2] += c
+	h0[3] += dd
+	h0[4] += e
+	h0[5] += f
+	h0[6] += g
+	h0[7] += h
+	copy(state, encodeWords(binary.BigEndian, h0[:]...))
+}
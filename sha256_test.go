@@ -0,0 +1,71 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestSHA256(t *testing.T) {
+	for n := range 300 {
+		msg := bytes.Repeat([]byte{byte(n)}, n)
+
+		h := NewSHA256()
+		h.Write(msg)
+
+		want := sha256.Sum256(msg)
+		if got := h.Sum(nil); !bytes.Equal(want[:], got) {
+			t.Errorf("%d bytes: want %x, got %x", n, want, got)
+		}
+	}
+}
+
+func TestSHA256Chunks(t *testing.T) {
+	msg := englishText[:1000]
+	want := sha256.Sum256(msg)
+
+	h := NewSHA256()
+	for i, n := 0, 1; i < len(msg); i, n = i+n, n+7 {
+		h.Write(msg[i:min(i+n, len(msg))])
+	}
+
+	// Sum doesn't change the state, so it can be called twice.
+	h.Sum(nil)
+	if got := h.Sum(nil); !bytes.Equal(want[:], got) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+
+	h.Reset()
+	h.Write(msg)
+	if got := h.Sum(nil); !bytes.Equal(want[:], got) {
+		t.Errorf("after Reset: want %x, got %x", want, got)
+	}
+}
+
+func TestSHA256FromState(t *testing.T) {
+	msg := englishText[:200]
+	prefix := 128 // Two whole blocks.
+	want := sha256.Sum256(msg)
+
+	h := NewSHA256()
+	h.Write(msg[:prefix])
+	partial := SHA256Registers(h.(*mdDigest).h)
+
+	resumed := NewSHA256FromState(partial, uint64(prefix))
+	resumed.Write(msg[prefix:])
+	if got := resumed.Sum(nil); !bytes.Equal(want[:], got) {
+		t.Errorf("want %x, got %x", want, got)
+	}
+}
+
+func TestSHA256Padding(t *testing.T) {
+	for n := range uint64(200) {
+		pad := SHA256Padding(n)
+		if (n+uint64(len(pad)))%64 != 0 {
+			t.Errorf("%d bytes: padded length %d isn't whole blocks", n, n+uint64(len(pad)))
+		}
+		if len(pad) < 9 || len(pad) > 72 {
+			t.Errorf("%d bytes: padding has %d bytes", n, len(pad))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// A ShamirShare is one share of a secret split with SplitSecret: the value
+// of the dealer's polynomial at X.
+type ShamirShare struct {
+	X, Y *big.Int
+}
+
+// SplitSecret splits secret into count shares over GF(p), any threshold of
+// which recover it with CombineShares, with Shamir's scheme: the shares are
+// f(1), ..., f(count) for a random polynomial f of degree threshold - 1
+// with f(0) = secret. Fewer shares than threshold say nothing about the
+// secret, as long as the coefficients are really random. It returns an
+// error if p isn't prime, if secret isn't less than p, or if threshold
+// isn't between 1 and count or count isn't less than p.
+func SplitSecret(secret, p *big.Int, threshold, count int) ([]ShamirShare, error) {
+	return splitSecret(secret, p, threshold, count, func() *big.Int {
+		a, err := rand.Int(rand.Reader, p)
+		if err != nil {
+			panic(err)
+		}
+		return a
+	})
+}
+
+// SplitSecretLCG is like SplitSecret, but takes the coefficients of f, from
+// the lowest degree after the secret up, from successive outputs of l, and
+// works over GF(l.M), so l.M must be prime. That's a dealer that uses a
+// fast generator for "randomness" that never leaves it. See
+// RecoverShamirSecret.
+func SplitSecretLCG(secret *big.Int, l *LCG, threshold, count int) ([]ShamirShare, error) {
+	return splitSecret(secret, new(big.Int).SetUint64(l.M), threshold, count, func() *big.Int {
+		return new(big.Int).SetUint64(l.Next())
+	})
+}
+
+// splitSecret is SplitSecret, with coefficients from coeff.
+func splitSecret(secret, p *big.Int, threshold, count int, coeff func() *big.Int) ([]ShamirShare, error) {
+	if !p.ProbablyPrime(20) {
+		return nil, errors.New("modulus isn't prime")
+	}
+	if secret.Sign() < 0 || secret.Cmp(p) >= 0 {
+		return nil, errors.New("secret out of range")
+	}
+	if threshold < 1 || threshold > count || big.NewInt(int64(count)).Cmp(p) >= 0 {
+		return nil, errors.New("invalid threshold or share count")
+	}
+
+	f := modPoly{new(big.Int).Set(secret)}
+	for range threshold - 1 {
+		f = append(f, coeff())
+	}
+	shares := make([]ShamirShare, count)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+		shares[i] = ShamirShare{X: x, Y: f.eval(x, p)}
+	}
+	return shares, nil
+}
+
+// CombineShares returns the secret that shares of a secret split over
+// GF(p) recover, f(0) by Lagrange interpolation. Given fewer shares than
+// the threshold, the result is meaningless. It returns an error if two
+// shares have the same X.
+func CombineShares(shares []ShamirShare, p *big.Int) (*big.Int, error) {
+	secret := new(big.Int)
+	for i, si := range shares {
+		// The Lagrange basis polynomial for si at 0 is the product of
+		// x_j / (x_j - x_i) over the other shares.
+		num, den := big.NewInt(1), big.NewInt(1)
+		for j, sj := range shares {
+			if j == i {
+				continue
+			}
+			num.Mul(num, sj.X).Mod(num, p)
+			d := new(big.Int).Sub(sj.X, si.X)
+			den.Mul(den, d).Mod(den, p)
+		}
+		if den.ModInverse(den, p) == nil {
+			return nil, errors.New("duplicate share")
+		}
+		num.Mul(num, den).Mul(num, si.Y)
+		secret.Add(secret, num).Mod(secret, p)
+	}
+	return secret, nil
+}
+
+// RecoverShamirSecret returns the secret that SplitSecretLCG split with
+// threshold, using a generator with parameters params, from any 2 shares,
+// however high the threshold is.
+//
+// Each coefficient is an affine function of the one before, a[j+1] = A*a[j]
+// + C mod p, so all of them are affine functions of the first: f(x) = s +
+// a[1]*u(x) + v(x), for polynomials u and v that depend only on params. Two
+// shares give two linear equations in s and a[1], which determine them. A
+// weak generator collapses the threshold-1 unknown coefficients the scheme's
+// security rests on into one, and the seed doesn't matter.
+//
+// It returns an error if there are fewer than 2 shares, or if the shares
+// aren't consistent with such a dealer.
+func RecoverShamirSecret(params LCGParams, threshold int, shares []ShamirShare) (*big.Int, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("need at least 2 shares")
+	}
+	p := new(big.Int).SetUint64(params.M)
+	if threshold < 2 {
+		return new(big.Int).Mod(shares[0].Y, p), nil
+	}
+
+	// a[j] = alpha[j]*a[1] + beta[j].
+	alpha, beta := make(modPoly, threshold), make(modPoly, threshold)
+	alpha[0], beta[0] = new(big.Int), new(big.Int)
+	alpha[1], beta[1] = big.NewInt(1), new(big.Int)
+	a, c := new(big.Int).SetUint64(params.A), new(big.Int).SetUint64(params.C)
+	for j := 2; j < threshold; j++ {
+		alpha[j] = new(big.Int).Mul(alpha[j-1], a)
+		alpha[j].Mod(alpha[j], p)
+		beta[j] = new(big.Int).Mul(beta[j-1], a)
+		beta[j].Add(beta[j], c).Mod(beta[j], p)
+	}
+
+	// y - v(x) = s + a[1]*u(x), so subtracting two shares' equations gives
+	// a[1].
+	w := func(sh ShamirShare) *big.Int {
+		r := new(big.Int).Sub(sh.Y, beta.eval(sh.X, p))
+		return r.Mod(r, p)
+	}
+	for i := 1; i < len(shares); i++ {
+		du := new(big.Int).Sub(alpha.eval(shares[0].X, p), alpha.eval(shares[i].X, p))
+		if du.ModInverse(du.Mod(du, p), p) == nil {
+			continue
+		}
+		a1 := new(big.Int).Sub(w(shares[0]), w(shares[i]))
+		a1.Mul(a1, du).Mod(a1, p)
+		s := new(big.Int).Mul(a1, alpha.eval(shares[0].X, p))
+		s.Sub(w(shares[0]), s).Mod(s, p)
+
+		f := modPoly{s}
+		for j := 1; j < threshold; j++ {
+			cj := new(big.Int).Mul(alpha[j], a1)
+			f = append(f, cj.Add(cj, beta[j]).Mod(cj, p))
+		}
+		for _, sh := range shares {
+			if f.eval(sh.X, p).Cmp(new(big.Int).Mod(sh.Y, p)) != 0 {
+				return nil, errors.New("shares don't match the generator")
+			}
+		}
+		return s, nil
+	}
+	return nil, errors.New("shares don't determine the secret")
+}
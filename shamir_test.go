@@ -0,0 +1,72 @@
+package cryptopals
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestShamir(t *testing.T) {
+	p := big.NewInt(1<<61 - 1)
+	secret := big.NewInt(randInt64(1 << 60))
+	shares, err := SplitSecret(secret, p, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, idx := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 2, 3, 4}} {
+		var subset []ShamirShare
+		for _, i := range idx {
+			subset = append(subset, shares[i])
+		}
+		got, err := CombineShares(subset, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(secret) != 0 {
+			t.Errorf("shares %v: got %v, want %v", idx, got, secret)
+		}
+	}
+	if got, _ := CombineShares(shares[:2], p); got.Cmp(secret) == 0 {
+		t.Error("2 shares recover the secret")
+	}
+	if _, err := CombineShares([]ShamirShare{shares[0], shares[0]}, p); err == nil {
+		t.Error("duplicate shares: no error")
+	}
+
+	if _, err := SplitSecret(secret, big.NewInt(1<<61), 3, 5); err == nil {
+		t.Error("composite modulus: no error")
+	}
+	if _, err := SplitSecret(secret, p, 6, 5); err == nil {
+		t.Error("threshold above count: no error")
+	}
+}
+
+func TestRecoverShamirSecret(t *testing.T) {
+	params := LCGParams{A: 48271, C: 12345, M: 1<<61 - 1}
+	p := new(big.Int).SetUint64(params.M)
+	secret := big.NewInt(randInt64(1 << 60))
+	shares, err := SplitSecretLCG(secret, NewLCG(params, uint64(randInt64(1<<60))), 5, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := CombineShares(shares[:5], p); got.Cmp(secret) != 0 {
+		t.Fatalf("5 shares: got %v, want %v", got, secret)
+	}
+
+	got, err := RecoverShamirSecret(params, 5, []ShamirShare{shares[6], shares[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(secret) != 0 {
+		t.Errorf("got %v, want %v", got, secret)
+	}
+
+	// Really random coefficients don't fit the generator.
+	shares, err = SplitSecret(secret, p, 5, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverShamirSecret(params, 5, shares[:4]); err == nil {
+		t.Error("random coefficients: no error")
+	}
+}
@@ -0,0 +1,237 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"slices"
+)
+
+// CBCMAC returns the raw CBC-MAC of msg under b: the last block of its CBC
+// encryption with a zero IV, after zero padding. Raw CBC-MAC is only secure
+// for messages of one fixed length, and here is the building block for
+// ForgeCTRThenCBCMAC.
+func CBCMAC(b cipher.Block, msg []byte) []byte {
+	tag := make([]byte, b.BlockSize())
+	for block := range PaddedBlocks(msg, len(tag), ZeroPadder{}) {
+		subtle.XORBytes(tag, tag, block)
+		b.Encrypt(tag, tag)
+	}
+	return tag
+}
+
+// CMAC returns the CMAC of msg under b, as in RFC 4493. b must have 8- or
+// 16-byte blocks.
+func CMAC(b cipher.Block, msg []byte) []byte {
+	bs := b.BlockSize()
+
+	k1 := make([]byte, bs)
+	b.Encrypt(k1, k1)
+	k1 = cmacDouble(k1)
+	k2 := cmacDouble(k1)
+
+	// The last block is masked with k1 if it's whole and k2 if it's padded,
+	// so that padding can't be confused with data.
+	n := max(1, (len(msg)+bs-1)/bs)
+	last := make([]byte, bs)
+	if rest := msg[(n-1)*bs:]; len(rest) == bs {
+		subtle.XORBytes(last, rest, k1)
+	} else {
+		copy(last, ISO7816Padder{}.Pad(rest, bs))
+		subtle.XORBytes(last, last, k2)
+	}
+
+	tag := make([]byte, bs)
+	for block := range Blocks(msg[:(n-1)*bs], bs) {
+		subtle.XORBytes(tag, tag, block)
+		b.Encrypt(tag, tag)
+	}
+	subtle.XORBytes(tag, tag, last)
+	b.Encrypt(tag, tag)
+	return tag
+}
+
+// cmacDouble returns b times x in GF(2^(8*len(b))), CMAC's subkey and S2V's
+// doubling step.
+func cmacDouble(b []byte) []byte {
+	var r byte
+	switch len(b) {
+	case 8:
+		r = 0x1b
+	case 16:
+		r = 0x87
+	default:
+		panic("invalid block size")
+	}
+
+	res := make([]byte, len(b))
+	for i := range b {
+		res[i] = b[i] << 1
+		if i+1 < len(b) {
+			res[i] |= b[i+1] >> 7
+		}
+	}
+	if b[0]&0x80 != 0 {
+		res[len(res)-1] ^= r
+	}
+	return res
+}
+
+// siv implements SIV mode: a CMAC-based synthetic IV, then CTR mode.
+type siv struct {
+	mac cipher.Block
+	ctr cipher.Block
+	ad  [][]byte
+}
+
+// NewSIV returns an AuthCipher in SIV mode, as in RFC 5297. Seal derives an
+// IV from the associated data ad and the plaintext with S2V, a CMAC-based PRF
+// under macBlock, then encrypts the plaintext in CTR mode under ctrBlock
+// starting from that IV. Open decrypts and checks that the IV matches.
+//
+// Since the IV depends on the plaintext, sealing the same message twice gives
+// the same ciphertext, which reveals only that the messages are equal. There's
+// no nonce to misuse. The two blocks must use independent keys: compare the naive
+// composition in NewCTRThenCBCMAC.
+func NewSIV(macBlock, ctrBlock cipher.Block, ad ...[]byte) AuthCipher {
+	if macBlock.BlockSize() != 16 || ctrBlock.BlockSize() != 16 {
+		panic("invalid block size")
+	}
+	ad = slices.Clone(ad)
+	for i := range ad {
+		ad[i] = slices.Clone(ad[i])
+	}
+	return &siv{mac: macBlock, ctr: ctrBlock, ad: ad}
+}
+
+// s2v returns the S2V PRF of strs, folding them together with CMAC and
+// doubling.
+func (s *siv) s2v(strs ...[]byte) []byte {
+	d := CMAC(s.mac, make([]byte, 16))
+	for _, str := range strs[:len(strs)-1] {
+		d = cmacDouble(d)
+		subtle.XORBytes(d, d, CMAC(s.mac, str))
+	}
+
+	last := strs[len(strs)-1]
+	if len(last) >= 16 {
+		t := slices.Clone(last)
+		subtle.XORBytes(t[len(t)-16:], t[len(t)-16:], d)
+		return CMAC(s.mac, t)
+	}
+	t := cmacDouble(d)
+	subtle.XORBytes(t, t, ISO7816Padder{}.Pad(last, 16))
+	return CMAC(s.mac, t)
+}
+
+// crypt runs src through CTR mode from the synthetic IV v, clearing the two
+// bits RFC 5297 clears so that implementations can use 32-bit counters.
+func (s *siv) crypt(v, src []byte) []byte {
+	q := slices.Clone(v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	dst := make([]byte, len(src))
+	NewCTR(s.ctr, q, nil).XORKeyStream(dst, src)
+	return dst
+}
+
+// Seal returns v || ct, where v is the synthetic IV.
+func (s *siv) Seal(pt []byte) []byte {
+	v := s.s2v(append(slices.Clip(s.ad), pt)...)
+	return slices.Concat(v, s.crypt(v, pt))
+}
+
+func (s *siv) Open(ct []byte) ([]byte, error) {
+	if len(ct) < 16 {
+		return nil, ErrInvalidMAC
+	}
+
+	v, ct := ct[:16], ct[16:]
+	pt := s.crypt(v, ct)
+	if subtle.ConstantTimeCompare(v, s.s2v(append(slices.Clip(s.ad), pt)...)) != 1 {
+		return nil, ErrInvalidMAC
+	}
+	return pt, nil
+}
+
+// ctrThenCBCMAC implements CTR encryption followed by a CBC-MAC of the nonce
+// and ciphertext, both under the same key.
+type ctrThenCBCMAC struct {
+	block cipher.Block
+}
+
+// NewCTRThenCBCMAC returns an AuthCipher that encrypts under b in CTR mode
+// from a random nonce, then appends the CBC-MAC of nonce || ct, also under b.
+//
+// Using one key for both is the mistake. The CTR keystream is b's encryption
+// of successive counter blocks, which is exactly what CBC-MAC computes for
+// the right messages, so anyone who knows one plaintext can forge others. See
+// ForgeCTRThenCBCMAC.
+func NewCTRThenCBCMAC(b cipher.Block) AuthCipher {
+	return &ctrThenCBCMAC{block: b}
+}
+
+// Seal returns nonce || ct || mac(nonce || ct).
+func (e *ctrThenCBCMAC) Seal(pt []byte) []byte {
+	bs := e.block.BlockSize()
+
+	res := slices.Concat(randBytes(int64(bs)), pt)
+	NewCTR(e.block, res[:bs], nil).XORKeyStream(res[bs:], res[bs:])
+
+	return append(res, CBCMAC(e.block, res)...)
+}
+
+func (e *ctrThenCBCMAC) Open(ct []byte) ([]byte, error) {
+	bs := e.block.BlockSize()
+	if len(ct) < 2*bs {
+		return nil, ErrInvalidMAC
+	}
+
+	ct, tag := ct[:len(ct)-bs], ct[len(ct)-bs:]
+	if subtle.ConstantTimeCompare(tag, CBCMAC(e.block, ct)) != 1 {
+		return nil, ErrInvalidMAC
+	}
+
+	pt := make([]byte, len(ct)-bs)
+	NewCTR(e.block, ct[:bs], nil).XORKeyStream(pt, ct[bs:])
+	return pt, nil
+}
+
+// ForgeCTRThenCBCMAC forges a message for a cipher from NewCTRThenCBCMAC,
+// given one sealed message and its plaintext pt, at least two blocks long.
+// It returns the forgery and the plaintext it opens to.
+//
+// The known plaintext gives the keystream E(n), E(n+1), ..., E(n+k) for the
+// nonce n. With ciphertext blocks E(n+i-1) ⊕ (n+i), each step of CBC-MAC over
+// n || ct encrypts the next counter block, so the tag is E(n+k), which is
+// known. The forged plaintext is the counter blocks n+1, ..., n+k. SIV avoids
+// this by keeping the MAC key apart from the encryption key, so the keystream
+// says nothing about the MAC.
+func ForgeCTRThenCBCMAC(sealed, pt []byte, blockSize int) (forged, forgedPt []byte, err error) {
+	bs := blockSize
+	if len(sealed) != len(pt)+2*bs {
+		return nil, nil, errors.New("plaintext doesn't match sealed message")
+	}
+	k := len(pt)/bs - 1
+	if k < 1 {
+		return nil, nil, errors.New("plaintext too short")
+	}
+
+	nonce, ct := sealed[:bs], sealed[bs:bs+len(pt)]
+	keystream := make([]byte, len(pt))
+	subtle.XORBytes(keystream, ct, pt)
+
+	counter := slices.Clone(nonce)
+	forged = slices.Clone(nonce)
+	for i := range k {
+		BigEndianCounter{}.Increment(counter)
+		forgedPt = append(forgedPt, counter...)
+		block := make([]byte, bs)
+		subtle.XORBytes(block, keystream[i*bs:], counter)
+		forged = append(forged, block...)
+	}
+	forged = append(forged, keystream[k*bs:(k+1)*bs]...)
+
+	return forged, forgedPt, nil
+}
@@ -0,0 +1,148 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"testing"
+)
+
+func TestCMAC(t *testing.T) {
+	// RFC 4493, section 4.
+	b, err := aes.NewCipher(decodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := decodeHex(t, "6bc1bee22e409f96e93d7e117393172a"+
+		"ae2d8a571e03ac9c9eb76fac45af8e51"+
+		"30c81c46a35ce411e5fbc1191a0a52ef"+
+		"f69f2445df4f9b17ad2b417be66c3710")
+
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "bb1d6929e95937287fa37d129b756746"},
+		{16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{40, "dfa66747de9ae63030ca32611497c827"},
+		{64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+	for _, tt := range tests {
+		if got := CMAC(b, msg[:tt.n]); !bytes.Equal(got, decodeHex(t, tt.want)) {
+			t.Errorf("CMAC(%d bytes) = %x, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCBCMAC(t *testing.T) {
+	b, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := randBytes(48)
+
+	// The tag is the last block of the CBC encryption.
+	ct := make([]byte, len(msg))
+	NewCBCEncrypter(b, make([]byte, 16)).CryptBlocks(ct, msg)
+	if got := CBCMAC(b, msg); !bytes.Equal(got, ct[32:]) {
+		t.Errorf("CBCMAC() = %x, want %x", got, ct[32:])
+	}
+}
+
+func TestSIV(t *testing.T) {
+	// RFC 5297, appendix A.1.
+	key := decodeHex(t, "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0"+
+		"f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	ad := decodeHex(t, "101112131415161718191a1b1c1d1e1f2021222324252627")
+	pt := decodeHex(t, "112233445566778899aabbccddee")
+	want := decodeHex(t, "85632d07c6e8f37f950acd320a2ecc93"+
+		"40c02b9690c4dc04daef7f6afe5c")
+
+	macBlock, err := aes.NewCipher(key[:16])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrBlock, err := aes.NewCipher(key[16:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSIV(macBlock, ctrBlock, ad)
+
+	got := s.Seal(pt)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Seal() = %x, want %x", got, want)
+	}
+	if pt2, err := s.Open(got); err != nil || !bytes.Equal(pt2, pt) {
+		t.Errorf("Open() = %x, %v, want %x", pt2, err, pt)
+	}
+
+	for _, n := range []int{0, 5, 16, 33} {
+		pt := randBytes(int64(n))
+		ct := s.Seal(pt)
+		if ct2 := s.Seal(pt); !bytes.Equal(ct, ct2) {
+			t.Errorf("Seal isn't deterministic for %d bytes", n)
+		}
+		if got, err := s.Open(ct); err != nil || !bytes.Equal(got, pt) {
+			t.Errorf("Open(Seal(%d bytes)) = %x, %v, want %x", n, got, err, pt)
+		}
+
+		for i := range ct {
+			ct[i] ^= 1
+			if _, err := s.Open(ct); !errors.Is(err, ErrInvalidMAC) {
+				t.Errorf("Open accepted %d-byte ciphertext with byte %d flipped", n, i)
+			}
+			ct[i] ^= 1
+		}
+	}
+
+	if _, err := NewSIV(macBlock, ctrBlock).Open(got); !errors.Is(err, ErrInvalidMAC) {
+		t.Errorf("Open accepted ciphertext with different associated data")
+	}
+}
+
+func TestCTRThenCBCMAC(t *testing.T) {
+	b, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCTRThenCBCMAC(b)
+
+	pt := []byte("a message of at least two blocks")
+	ct := c.Seal(pt)
+	if got, err := c.Open(ct); err != nil || !bytes.Equal(got, pt) {
+		t.Fatalf("Open(Seal()) = %q, %v, want %q", got, err, pt)
+	}
+	ct[20] ^= 1
+	if _, err := c.Open(ct); !errors.Is(err, ErrInvalidMAC) {
+		t.Errorf("Open accepted tampered ciphertext")
+	}
+}
+
+func TestForgeCTRThenCBCMAC(t *testing.T) {
+	b, err := aes.NewCipher(randBytes(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCTRThenCBCMAC(b)
+
+	pt := randBytes(16*4 + 7)
+	forged, forgedPt, err := ForgeCTRThenCBCMAC(c.Seal(pt), pt, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forgedPt) != 16*3 {
+		t.Errorf("forged %d bytes, want %d", len(forgedPt), 16*3)
+	}
+
+	got, err := c.Open(forged)
+	if err != nil {
+		t.Fatalf("Open(forged) failed: %v", err)
+	}
+	if !bytes.Equal(got, forgedPt) {
+		t.Errorf("Open(forged) = %x, want %x", got, forgedPt)
+	}
+
+	if _, _, err := ForgeCTRThenCBCMAC(c.Seal(pt[:20]), pt[:20], 16); err == nil {
+		t.Errorf("ForgeCTRThenCBCMAC succeeded with less than two blocks of plaintext")
+	}
+}
@@ -0,0 +1,280 @@
+package cryptopals
+
+import (
+	"bytes"
+	"errors"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+)
+
+// maxSquareSets is how many sets of 256 chosen plaintexts the Square attacks
+// use at most.
+const maxSquareSets = 8
+
+// square5Sets is how many sets RecoverAES5RoundKey uses. With 3, a wrong
+// guess of a column of the last round key passes with probability about
+// 2^-64, against 2^32 guesses.
+const square5Sets = 3
+
+// squareSet returns the encryptions of a Λ-set: 256 plaintexts that take
+// every value in their first byte and share random values in the others.
+// After three rounds of AES, every byte of the state XORs to zero over the
+// set.
+func squareSet(encrypt func([]byte) []byte, rng *rand.ChaCha8) [][aesBlockSize]byte {
+	pt := make([]byte, aesBlockSize)
+	for i := range pt {
+		pt[i] = byte(rng.Uint64())
+	}
+
+	cts := make([][aesBlockSize]byte, 256)
+	for v := range cts {
+		pt[0] = byte(v)
+		ct := encrypt(pt)
+		if len(ct) < aesBlockSize {
+			panic("short ciphertext")
+		}
+		cts[v] = [aesBlockSize]byte(ct)
+	}
+	return cts
+}
+
+// squareKey returns the AES-128 key with the given last round key, after
+// checking it against a fresh encryption from the oracle.
+func squareKey(encrypt func([]byte) []byte, lastKey []byte, rounds int, rng *rand.ChaCha8) ([]byte, error) {
+	key := InvertKeySchedule(lastKey, rounds)
+
+	pt := make([]byte, aesBlockSize)
+	for i := range pt {
+		pt[i] = byte(rng.Uint64())
+	}
+	block, _ := NewAESRounds(key, rounds)
+	ct := make([]byte, aesBlockSize)
+	block.Encrypt(ct, pt)
+	if !bytes.Equal(ct, encrypt(pt)[:aesBlockSize]) {
+		return nil, errors.New("recovered key doesn't match the oracle")
+	}
+	return key, nil
+}
+
+// RecoverAES4RoundKey returns the key of 4-round AES-128, as from
+// NewAESRounds(key, 4), with the Square attack. encrypt is a chosen-plaintext
+// oracle for single blocks.
+//
+// Every byte of the state after three rounds sums to zero over a Λ-set, and
+// the last round only applies the S-box, ShiftRows, and a round key. So for
+// each ciphertext byte, the right guess of its last round key byte undoes
+// the round to give bytes that XOR to zero. A wrong guess passes with
+// probability 1/256, so one set of 256 plaintexts leaves about one extra
+// candidate per byte, and another set usually rules them out. The last
+// round key then gives the key through InvertKeySchedule.
+//
+// It returns an error if it runs out of sets, or if the key it recovers
+// doesn't match the oracle, as when it isn't 4-round AES-128. Use WithSeed
+// to choose the plaintexts deterministically.
+func RecoverAES4RoundKey(encrypt func([]byte) []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	rng := o.newRand()
+
+	// candidates[j][g] is whether g is still a candidate for byte j of the
+	// last round key.
+	var candidates [aesBlockSize][256]bool
+	for j := range candidates {
+		for g := range candidates[j] {
+			candidates[j][g] = true
+		}
+	}
+
+	for set := range maxSquareSets {
+		cts := squareSet(encrypt, rng)
+
+		done := true
+		for j := range candidates {
+			left := 0
+			for g := range candidates[j] {
+				if !candidates[j][g] {
+					continue
+				}
+				var sum byte
+				for _, ct := range cts {
+					sum ^= aesInvSbox[ct[j]^byte(g)]
+				}
+				if sum != 0 {
+					candidates[j][g] = false
+					continue
+				}
+				left++
+			}
+			if left == 0 {
+				return nil, errors.New("no last round key byte balances")
+			}
+			done = done && left == 1
+		}
+		o.debug("square set", "set", set, "done", done)
+		if !done {
+			continue
+		}
+
+		lastKey := make([]byte, aesBlockSize)
+		for j := range lastKey {
+			for g, ok := range candidates[j] {
+				if ok {
+					lastKey[j] = byte(g)
+				}
+			}
+		}
+		return squareKey(encrypt, lastKey, 4, rng)
+	}
+	return nil, errors.New("too many candidate keys")
+}
+
+// square5Positions returns the ciphertext byte positions that ShiftRows
+// moves out of column c of the state.
+func square5Positions(c int) [4]int {
+	var pos [4]int
+	for r := range pos {
+		pos[r] = 4*((c-r+4)%4) + r
+	}
+	return pos
+}
+
+// aesInvMixMul[j][x] is x times byte j of the first row of the InvMixColumns
+// matrix.
+var aesInvMixMul = func() (t [4][256]byte) {
+	for j, m := range aesInvMixRow {
+		for x := range 256 {
+			t[j][x] = aesMul(m, byte(x))
+		}
+	}
+	return t
+}()
+
+// squareInvSboxBits[k][b] is the set of bytes v, as a 256-bit mask, for
+// which bit b of the inverse S-box of v^k is set.
+var squareInvSboxBits = func() (t [256][8][4]uint64) {
+	for k := range 256 {
+		for v := range 256 {
+			y := aesInvSbox[v^k]
+			for b := range 8 {
+				if y>>b&1 == 1 {
+					t[k][b][v/64] |= 1 << (v % 64)
+				}
+			}
+		}
+	}
+	return t
+}()
+
+// squareBalanced reports whether the inverse S-box of v^k XORs to zero over
+// the set of bytes v in odd.
+func squareBalanced(odd *[4]uint64, k int) bool {
+	for b := range 8 {
+		mask := &squareInvSboxBits[k][b]
+		var n int
+		for w := range odd {
+			n += bits.OnesCount64(odd[w] & mask[w])
+		}
+		if n%2 != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// square5Odd returns the values row r of the column takes an odd number of
+// times over cts, as a 256-bit mask, after undoing the last round with key
+// bytes g at positions pos and then MixColumns. Values taken an even number
+// of times cancel in a sum.
+func square5Odd(cts [][aesBlockSize]byte, pos [4]int, g uint32, r int) [4]uint64 {
+	var odd [4]uint64
+	for _, ct := range cts {
+		var u byte
+		for j := range 4 {
+			row := (r + j) % 4
+			u ^= aesInvMixMul[j][aesInvSbox[ct[pos[row]]^byte(g>>(8*row))]]
+		}
+		odd[u/64] ^= 1 << (u % 64)
+	}
+	return odd
+}
+
+// square5Guess reports whether g, four bytes of the last round key at the
+// positions of column col, undoes the last round and the MixColumns of the
+// one before it so that each row of the column balances on every set, for
+// some byte of the fourth round key. Most wrong guesses fail on the first
+// row of the first set.
+func square5Guess(sets [][][aesBlockSize]byte, col int, g uint32) bool {
+	pos := square5Positions(col)
+	for r := range 4 {
+		odd := square5Odd(sets[0], pos, g, r)
+		var ks []int
+		for k := range 256 {
+			if squareBalanced(&odd, k) {
+				ks = append(ks, k)
+			}
+		}
+		for _, cts := range sets[1:] {
+			if len(ks) == 0 {
+				break
+			}
+			odd := square5Odd(cts, pos, g, r)
+			ks = slices.DeleteFunc(ks, func(k int) bool { return !squareBalanced(&odd, k) })
+		}
+		if len(ks) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// square5Column searches guesses lo to lo+n-1 of column col of the last round
+// key, returning the first that passes square5Guess.
+func square5Column(sets [][][aesBlockSize]byte, col int, lo uint32, n int, workers int) (uint32, bool) {
+	i, score := argmax(n, workers, 1, func() func(i int) float64 {
+		return func(i int) float64 {
+			if square5Guess(sets, col, lo+uint32(i)) {
+				return 1
+			}
+			return 0
+		}
+	})
+	return lo + uint32(i), score == 1
+}
+
+// RecoverAES5RoundKey returns the key of 5-round AES-128, as from
+// NewAESRounds(key, 5), by extending the Square attack one round. encrypt is
+// a chosen-plaintext oracle for single blocks.
+//
+// Each byte of the state after three rounds depends on a column of the
+// state after four, and through the last round on four bytes of the last
+// round key. MixColumns is linear, so the fourth round key can be moved
+// after it as one more byte per row. So the attack guesses the last round
+// key a column at a time, 2^32 guesses, and keeps the one for which every
+// row balances over three Λ-sets for some fourth round key byte.
+//
+// That's 2^32 guesses per column, each taking microseconds: more than a day
+// on one core. WithWorkers spreads the search across more. It returns an
+// error if the key it recovers doesn't match the oracle. Use WithSeed to
+// choose the plaintexts deterministically.
+func RecoverAES5RoundKey(encrypt func([]byte) []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	rng := o.newRand()
+
+	sets := make([][][aesBlockSize]byte, square5Sets)
+	for i := range sets {
+		sets[i] = squareSet(encrypt, rng)
+	}
+
+	lastKey := make([]byte, aesBlockSize)
+	for col := range 4 {
+		g, ok := square5Column(sets, col, 0, 1<<32, o.workers)
+		if !ok {
+			return nil, errors.New("no last round key column balances")
+		}
+		for r, p := range square5Positions(col) {
+			lastKey[p] = byte(g >> (8 * r))
+		}
+		o.debug("recovered last round key column", "column", col)
+	}
+	return squareKey(encrypt, lastKey, 5, rng)
+}
@@ -0,0 +1,99 @@
+package cryptopals
+
+import (
+	"bytes"
+	"testing"
+)
+
+// aesOracle returns a single-block chosen-plaintext oracle for rounds-round
+// AES with key.
+func aesOracle(t *testing.T, key []byte, rounds int) func([]byte) []byte {
+	t.Helper()
+	block, err := NewAESRounds(key, rounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(pt []byte) []byte {
+		ct := make([]byte, aesBlockSize)
+		block.Encrypt(ct, pt)
+		return ct
+	}
+}
+
+func TestRecoverAES4RoundKey(t *testing.T) {
+	for range 10 {
+		key := randBytes(16)
+		got, err := RecoverAES4RoundKey(aesOracle(t, key, 4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, key) {
+			t.Errorf("got %x, want %x", got, key)
+		}
+	}
+
+	// Five rounds aren't balanced after the fourth.
+	if _, err := RecoverAES4RoundKey(aesOracle(t, randBytes(16), 5)); err == nil {
+		t.Error("5 rounds: no error")
+	}
+}
+
+func TestRecoverAES5RoundKeyColumns(t *testing.T) {
+	// The full search takes too long for a test, so search a range of
+	// guesses around each column of the real last round key, and check the
+	// rest of the attack with the result.
+	key := randBytes(16)
+	encrypt := aesOracle(t, key, 5)
+	rng := newOptions([]Option{WithSeed(1)}).newRand()
+	sets := make([][][aesBlockSize]byte, square5Sets)
+	for i := range sets {
+		sets[i] = squareSet(encrypt, rng)
+	}
+
+	want := AESExpandKey(key, 5)[5]
+	lastKey := make([]byte, aesBlockSize)
+	for col := range 4 {
+		pos := square5Positions(col)
+		var g uint32
+		for r, p := range pos {
+			g |= uint32(want[p]) << (8 * r)
+		}
+
+		got, ok := square5Column(sets, col, g-5000, 10000, 1)
+		if !ok || got != g {
+			t.Fatalf("column %d: got %08x, %v, want %08x", col, got, ok, g)
+		}
+		for r, p := range pos {
+			lastKey[p] = byte(got >> (8 * r))
+		}
+	}
+
+	got, err := squareKey(encrypt, lastKey, 5, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("got %x, want %x", got, key)
+	}
+}
+
+func TestRecoverAES5RoundKey(t *testing.T) {
+	// The full search takes too long for a test, but it tries the guesses
+	// for each column in order, so a key whose last round key has small
+	// columns is found quickly.
+	lastKey := make([]byte, aesBlockSize)
+	for col := range 4 {
+		pos := square5Positions(col)
+		lastKey[pos[0]] = byte(0x3a + col)
+		lastKey[pos[1]] = byte(1 + col)
+	}
+	key := InvertKeySchedule(lastKey, 5)
+
+	got, err := RecoverAES5RoundKey(aesOracle(t, key, 5), WithSeed(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("got %x, want %x", got, key)
+	}
+}
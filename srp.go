@@ -0,0 +1,404 @@
+package cryptopals
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// srpK is the SRP multiplier k.
+var srpK = big.NewInt(3)
+
+// WithSimpleSRP makes an SRP server use the simplified protocol from
+// challenge 38: B is g^b without the verifier, and u is random rather than
+// derived from A and B. This makes a login attackable offline by whoever
+// plays the server. By default servers use SRP as in challenge 36.
+func WithSimpleSRP() Option {
+	return func(o *options) {
+		o.simpleSRP = true
+	}
+}
+
+// srpHash returns the SHA-256 hash of the concatenated parts, as a number.
+func srpHash(parts ...[]byte) *big.Int {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// srpProof returns the proof of the shared secret s that a client sends:
+// HMAC-SHA256(SHA256(s), salt).
+func srpProof(s *big.Int, salt []byte) []byte {
+	k := sha256.Sum256(s.Bytes())
+	mac := hmac.New(sha256.New, k[:])
+	mac.Write(salt)
+	return mac.Sum(nil)
+}
+
+// srpServerSecret returns the server's shared secret (A v^u)^b.
+func srpServerSecret(A, v, u, b *big.Int) *big.Int {
+	s := new(big.Int).Exp(v, u, dhP)
+	s.Mul(s, A).Mod(s, dhP)
+	return s.Exp(s, b, dhP)
+}
+
+// An SRPChallenge is a server's reply to the start of an SRP login.
+type SRPChallenge struct {
+	Salt []byte
+	B    *big.Int
+	U    *big.Int // Nil unless the server uses simplified SRP.
+}
+
+// An SRPHandler answers SRP logins, as SRPServer does.
+type SRPHandler interface {
+	// Hello starts a login for email, with the client's public value A. It
+	// returns the challenge for the client, and a function that reports
+	// whether the client's proof is right.
+	Hello(email string, A *big.Int) (ch SRPChallenge, verify func(proof []byte) bool, err error)
+}
+
+// An SRPServer checks logins for one user with the Secure Remote Password
+// protocol from challenge 36, over the group from challenge 33 with SHA-256.
+// It stores a salt and a verifier derived from the password, not the
+// password itself.
+//
+// It doesn't check that A isn't a multiple of the prime, so a client that
+// sends one can log in without the password: see SRPZeroKeyProof.
+type SRPServer struct {
+	email  string
+	salt   []byte
+	v      *big.Int
+	simple bool
+}
+
+// NewSRPServer returns a server for the user with the given email and
+// password, and a random salt. Use WithSimpleSRP for simplified SRP.
+func NewSRPServer(email, password string, opts ...Option) *SRPServer {
+	o := newOptions(opts)
+	salt := randBytes(16)
+	x := srpHash(salt, []byte(password))
+	return &SRPServer{
+		email:  email,
+		salt:   salt,
+		v:      new(big.Int).Exp(dhG, x, dhP),
+		simple: o.simpleSRP,
+	}
+}
+
+// Hello implements SRPHandler. It returns an error if email isn't the
+// server's user.
+func (s *SRPServer) Hello(email string, A *big.Int) (SRPChallenge, func([]byte) bool, error) {
+	if email != s.email {
+		return SRPChallenge{}, nil, errors.New("unknown user")
+	}
+
+	b := dhExponent()
+	ch := SRPChallenge{Salt: s.salt, B: new(big.Int).Exp(dhG, b, dhP)}
+
+	var u *big.Int
+	if s.simple {
+		u = new(big.Int).SetBytes(randBytes(16))
+		ch.U = u
+	} else {
+		kv := new(big.Int).Mul(srpK, s.v)
+		ch.B.Add(ch.B, kv).Mod(ch.B, dhP)
+		u = srpHash(A.Bytes(), ch.B.Bytes())
+	}
+
+	want := srpProof(srpServerSecret(A, s.v, u, b), s.salt)
+	return ch, func(proof []byte) bool {
+		return hmac.Equal(proof, want)
+	}, nil
+}
+
+// An SRPClient logs in to an SRP server as one user.
+type SRPClient struct {
+	email, password string
+	a, pub          *big.Int // The private value and A.
+}
+
+// NewSRPClient returns a client for the user with the given email and
+// password, with a fresh private value.
+func NewSRPClient(email, password string) *SRPClient {
+	a := dhExponent()
+	return &SRPClient{
+		email:    email,
+		password: password,
+		a:        a,
+		pub:      new(big.Int).Exp(dhG, a, dhP),
+	}
+}
+
+// Hello returns the client's first message: its email and public value A.
+func (c *SRPClient) Hello() (email string, A *big.Int) {
+	return c.email, c.pub
+}
+
+// Proof returns the client's proof for the server's challenge. It follows
+// simplified SRP if ch.U is set.
+func (c *SRPClient) Proof(ch SRPChallenge) []byte {
+	x := srpHash(ch.Salt, []byte(c.password))
+
+	base, u := ch.B, ch.U
+	if u == nil {
+		kgx := new(big.Int).Exp(dhG, x, dhP)
+		kgx.Mul(kgx, srpK)
+		base = new(big.Int).Sub(ch.B, kgx)
+		base.Mod(base, dhP)
+		u = srpHash(c.pub.Bytes(), ch.B.Bytes())
+	}
+
+	e := new(big.Int).Mul(u, x)
+	e.Add(e, c.a)
+	return srpProof(new(big.Int).Exp(base, e, dhP), ch.Salt)
+}
+
+// SRPZeroKeyProof returns a proof that logs in to an SRPServer without the
+// password, for challenge 37, if A was sent as a multiple of the prime, such
+// as 0. The server's secret (A v^u)^b is then zero, whatever the password.
+func SRPZeroKeyProof(ch SRPChallenge) []byte {
+	return srpProof(new(big.Int), ch.Salt)
+}
+
+// A SimpleSRPCracker is a malicious simplified SRP server, for challenge 38.
+// It answers every login with an empty salt, b = 1, and u = 1, so B is g,
+// and rejects it, keeping the client's A and proof. Crack then searches for
+// the password offline. Real clients can't tell it from a server with
+// WithSimpleSRP until the login fails.
+//
+// The zero value is ready to use.
+type SimpleSRPCracker struct {
+	mu    sync.Mutex
+	email string
+	pub   *big.Int // The client's A.
+	proof []byte
+}
+
+// Hello implements SRPHandler.
+func (c *SimpleSRPCracker) Hello(email string, A *big.Int) (SRPChallenge, func([]byte) bool, error) {
+	ch := SRPChallenge{B: dhG, U: big.NewInt(1)}
+	return ch, func(proof []byte) bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.email, c.pub, c.proof = email, A, proof
+		return false
+	}, nil
+}
+
+// Crack returns the email and password of the last login, trying each
+// password in words. The client's secret is g^(a+x) = A g^x, so each guess
+// costs one exponentiation. WithWorkers spreads the search across more
+// goroutines.
+//
+// It returns an error if there hasn't been a login, or if no word fits.
+func (c *SimpleSRPCracker) Crack(words []string, opts ...Option) (email, password string, err error) {
+	o := newOptions(opts)
+
+	c.mu.Lock()
+	email, A, proof := c.email, c.pub, c.proof
+	c.mu.Unlock()
+	if A == nil {
+		return "", "", errors.New("no login to crack")
+	}
+	if len(words) == 0 {
+		return "", "", errors.New("no password fits")
+	}
+
+	i, score := argmax(len(words), o.workers, 1, func() func(i int) float64 {
+		s := new(big.Int)
+		return func(i int) float64 {
+			s.Exp(dhG, srpHash([]byte(words[i])), dhP)
+			s.Mul(s, A).Mod(s, dhP)
+			if hmac.Equal(srpProof(s, nil), proof) {
+				return 1
+			}
+			return 0
+		}
+	})
+	if score != 1 {
+		return "", "", errors.New("no password fits")
+	}
+	return email, words[i], nil
+}
+
+// errInvalidProof is reported when an SRP client's proof is wrong.
+var errInvalidProof = errors.New("invalid proof")
+
+// errMalformedMessage is reported for a message with the wrong fields.
+var errMalformedMessage = errors.New("malformed message")
+
+// srpStatus returns the status field a server sends for the result of a
+// login step, which only says why it failed if verbose is set.
+func srpStatus(err error, verbose bool) []byte {
+	switch {
+	case err == nil:
+		return []byte("ok")
+	case verbose:
+		return []byte(err.Error())
+	default:
+		return []byte("error")
+	}
+}
+
+// srpChallengeMessage returns the message that sends ch to a client.
+func srpChallengeMessage(ch SRPChallenge) Message {
+	var u []byte
+	if ch.U != nil {
+		u = ch.U.Bytes()
+	}
+	return Message{srpStatus(nil, false), ch.Salt, ch.B.Bytes(), u}
+}
+
+// SRPParty returns a Party that answers SRP logins with h, such as an
+// SRPServer, one after another until the client closes its end. See
+// ServeSRP for the messages.
+func SRPParty(h SRPHandler, opts ...Option) Party {
+	o := newOptions(opts)
+
+	return PartyFunc(func(ch Channel) error {
+		for {
+			hello, err := ch.Receive()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if len(hello) != 2 {
+				return errMalformedMessage
+			}
+			email := string(hello[0])
+			c, verify, err := h.Hello(email, new(big.Int).SetBytes(hello[1]))
+			o.debug("srp hello", "email", email, "err", err)
+
+			time.Sleep(o.latency)
+
+			if err != nil {
+				if err := ch.Send(Message{srpStatus(err, o.verbose)}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := ch.Send(srpChallengeMessage(c)); err != nil {
+				return err
+			}
+
+			proof, err := ch.Receive()
+			if err != nil {
+				return noEOF(err)
+			}
+			if len(proof) != 1 {
+				return errMalformedMessage
+			}
+			err = nil
+			if !verify(proof[0]) {
+				err = errInvalidProof
+			}
+			o.debug("srp login", "email", email, "err", err)
+
+			time.Sleep(o.latency)
+
+			if err := ch.Send(Message{srpStatus(err, o.verbose)}); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// srpReceiveStatus receives a server message and checks that it has n
+// fields after the status, if the status is "ok".
+func srpReceiveStatus(ch Channel, n int) (status string, m Message, err error) {
+	m, err = ch.Receive()
+	if err != nil {
+		return "", nil, noEOF(err)
+	}
+	if len(m) == 0 {
+		return "", nil, errMalformedMessage
+	}
+	status = string(m[0])
+	if status == "ok" && len(m) != n+1 {
+		return "", nil, errMalformedMessage
+	}
+	return status, m[1:], nil
+}
+
+// srpHello sends a client's first message on ch and returns the challenge.
+func srpHello(ch Channel, email string, A *big.Int) (SRPChallenge, error) {
+	if err := ch.Send(Message{[]byte(email), A.Bytes()}); err != nil {
+		return SRPChallenge{}, err
+	}
+	status, m, err := srpReceiveStatus(ch, 3)
+	if err != nil {
+		return SRPChallenge{}, err
+	}
+	if status != "ok" {
+		return SRPChallenge{}, fmt.Errorf("server returned %q", status)
+	}
+
+	c := SRPChallenge{Salt: m[0], B: new(big.Int).SetBytes(m[1])}
+	if len(m[2]) > 0 {
+		c.U = new(big.Int).SetBytes(m[2])
+	}
+	return c, nil
+}
+
+// srpProve sends a client's proof on ch and reports whether the server
+// accepted it.
+func srpProve(ch Channel, proof []byte) (bool, error) {
+	if err := ch.Send(Message{proof}); err != nil {
+		return false, err
+	}
+	status, _, err := srpReceiveStatus(ch, 0)
+	if err != nil {
+		return false, err
+	}
+	return status == "ok", nil
+}
+
+// An SRPLogin is a Party that logs in once as Client, and records whether the
+// server accepted it.
+type SRPLogin struct {
+	Client   *SRPClient
+	Accepted bool
+}
+
+// Run implements Party. It returns an error if the server rejects the login
+// before the proof.
+func (l *SRPLogin) Run(ch Channel) error {
+	c, err := srpHello(ch, l.Client.email, l.Client.pub)
+	if err != nil {
+		return err
+	}
+	l.Accepted, err = srpProve(ch, l.Client.Proof(c))
+	return err
+}
+
+// Interceptor returns an interceptor for one connection that answers the
+// client's logins with c, in place of the server, which never sees them. So
+// the attack works as a man in the middle too, with the client connecting to
+// the real server's address.
+func (c *SimpleSRPCracker) Interceptor() Interceptor {
+	var verify func([]byte) bool
+	return InterceptorFunc(func(dir Direction, m Message, send func(Direction, Message) error) error {
+		switch {
+		case dir == ToClient:
+			return send(dir, m)
+		case verify == nil && len(m) == 2:
+			ch, v, _ := c.Hello(string(m[0]), new(big.Int).SetBytes(m[1]))
+			verify = v
+			return send(ToClient, srpChallengeMessage(ch))
+		case verify != nil && len(m) == 1:
+			verify(m[0])
+			verify = nil
+			return send(ToClient, Message{srpStatus(errInvalidProof, false)})
+		}
+		return errMalformedMessage
+	})
+}
@@ -0,0 +1,134 @@
+package cryptopals
+
+import (
+	"math/big"
+	"net"
+	"testing"
+)
+
+// srpLogin runs a login against h in process.
+func srpLogin(t *testing.T, h SRPHandler, c *SRPClient) bool {
+	t.Helper()
+	ch, verify, err := h.Hello(c.Hello())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verify(c.Proof(ch))
+}
+
+func TestSRP(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSimpleSRP()}} {
+		s := NewSRPServer("alice@example.com", "correct horse", opts...)
+
+		if !srpLogin(t, s, NewSRPClient("alice@example.com", "correct horse")) {
+			t.Errorf("%d options: right password rejected", len(opts))
+		}
+		if srpLogin(t, s, NewSRPClient("alice@example.com", "battery staple")) {
+			t.Errorf("%d options: wrong password accepted", len(opts))
+		}
+		if _, _, err := s.Hello("bob@example.com", big.NewInt(2)); err == nil {
+			t.Errorf("%d options: unknown user accepted", len(opts))
+		}
+	}
+}
+
+func TestSRPZeroKey(t *testing.T) {
+	s := NewSRPServer("alice@example.com", "correct horse")
+
+	for _, k := range []int64{0, 1, 2} {
+		A := new(big.Int).Mul(dhP, big.NewInt(k))
+		ch, verify, err := s.Hello("alice@example.com", A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verify(SRPZeroKeyProof(ch)) {
+			t.Errorf("A = %d N: forged proof rejected", k)
+		}
+	}
+}
+
+func TestSimpleSRPCracker(t *testing.T) {
+	var c SimpleSRPCracker
+	words := []string{"password", "letmein", "hunter2", "dragon", "trustno1"}
+
+	if _, _, err := c.Crack(words); err == nil {
+		t.Error("no login: no error")
+	}
+
+	if srpLogin(t, &c, NewSRPClient("alice@example.com", "hunter2")) {
+		t.Error("cracker accepted the login")
+	}
+	email, password, err := c.Crack(words, WithWorkers(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "alice@example.com" || password != "hunter2" {
+		t.Errorf("got %q, %q", email, password)
+	}
+
+	srpLogin(t, &c, NewSRPClient("alice@example.com", "not in the list"))
+	if _, _, err := c.Crack(words); err == nil {
+		t.Error("password not in words: no error")
+	}
+}
+
+func TestSRPOverTCP(t *testing.T) {
+	s := NewSRPServer("alice@example.com", "correct horse")
+	addr := listen(t, func(l net.Listener) { ServeSRP(l, s, WithVerboseErrors()) })
+
+	conn, err := DialSRP(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Logins share the connection, and a failed one doesn't end it.
+	if _, err := conn.Hello("bob@example.com", big.NewInt(2)); err == nil {
+		t.Error("unknown user: no error")
+	}
+	for _, tt := range []struct {
+		password string
+		want     bool
+	}{
+		{"battery staple", false},
+		{"correct horse", true},
+	} {
+		ok, err := conn.Login(NewSRPClient("alice@example.com", tt.password))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("password %q: got %v, want %v", tt.password, ok, tt.want)
+		}
+	}
+
+	ch, err := conn.Hello("alice@example.com", big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := conn.Prove(SRPZeroKeyProof(ch)); err != nil || !ok {
+		t.Errorf("zero key: got %v, %v", ok, err)
+	}
+}
+
+func TestSimpleSRPCrackerOverTCP(t *testing.T) {
+	var c SimpleSRPCracker
+	addr := listen(t, func(l net.Listener) { ServeSRP(l, &c) })
+
+	conn, err := DialSRP(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if ok, err := conn.Login(NewSRPClient("alice@example.com", "dragon")); err != nil || ok {
+		t.Fatalf("got %v, %v", ok, err)
+	}
+	_, password, err := c.Crack([]string{"password", "dragon"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "dragon" {
+		t.Errorf("got %q, want %q", password, "dragon")
+	}
+}
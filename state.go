@@ -0,0 +1,126 @@
+package cryptopals
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+// SaveAttackState writes st to the named file, replacing it only once the
+// whole state is written, so a crash mid-save leaves the last snapshot
+// intact.
+func SaveAttackState(name string, st encoding.BinaryMarshaler) error {
+	data, err := st.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// LoadAttackState reads a state that SaveAttackState wrote into st.
+func LoadAttackState(name string, st encoding.BinaryUnmarshaler) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return st.UnmarshalBinary(data)
+}
+
+// errBadState is returned when unmarshaling a malformed attack state.
+var errBadState = errors.New("malformed attack state")
+
+// stateWriter appends the fields of an attack state.
+type stateWriter struct {
+	buf []byte
+}
+
+func (w *stateWriter) uint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *stateWriter) int(x *big.Int) {
+	b := x.Bytes()
+	w.uint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+// optInt writes x, which may be nil.
+func (w *stateWriter) optInt(x *big.Int) {
+	if x == nil {
+		w.uint(0)
+		return
+	}
+	w.uint(1)
+	w.int(x)
+}
+
+// stateReader reads the fields that a stateWriter wrote, recording the
+// first error.
+type stateReader struct {
+	buf []byte
+	err error
+}
+
+func (r *stateReader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = errBadState
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *stateReader) int() *big.Int {
+	n := r.uint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.buf)) {
+		r.err = errBadState
+		return nil
+	}
+	x := new(big.Int).SetBytes(r.buf[:n])
+	r.buf = r.buf[n:]
+	return x
+}
+
+// optInt reads an int that optInt wrote, which may be nil.
+func (r *stateReader) optInt() *big.Int {
+	switch r.uint() {
+	case 0:
+		return nil
+	case 1:
+		return r.int()
+	}
+	r.err = errBadState
+	return nil
+}
+
+// done returns the first error, or errBadState if there's data left over.
+func (r *stateReader) done() error {
+	if r.err == nil && len(r.buf) > 0 {
+		r.err = errBadState
+	}
+	return r.err
+}
@@ -0,0 +1,173 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"slices"
+)
+
+// A StreamMode is a way of turning a block cipher into a stream cipher.
+type StreamMode int
+
+const (
+	// CTRStream encrypts successive counter blocks, laid out by a
+	// CounterEncoding, to make the keystream.
+	CTRStream StreamMode = iota
+
+	// CFBStream is full-block cipher feedback, as NewCFBEncrypter.
+	CFBStream
+
+	// CFB8Stream is 8-bit cipher feedback, as NewCFB8Encrypter.
+	CFB8Stream
+
+	// OFBStream is output feedback, as NewOFB.
+	OFBStream
+)
+
+// WithCounterEncoding sets how CTRStream moves from one counter block to the
+// next. The default is BigEndianCounter.
+func WithCounterEncoding(e CounterEncoding) Option {
+	return func(o *options) {
+		o.counterEncoding = e
+	}
+}
+
+// WithStreamMode sets the mode an oracle that takes it encrypts in, such as
+// NewOFBFixedIVOracle.
+func WithStreamMode(m StreamMode) Option {
+	return func(o *options) {
+		o.streamMode = &m
+	}
+}
+
+// NewStreamEncrypter returns a cipher.Stream that encrypts with b in mode,
+// starting from iv, which must be one block long. Any block cipher works,
+// such as NewDES, NewAES, or NewToyCipher. Use WithCounterEncoding to choose
+// CTR's counter layout.
+func NewStreamEncrypter(mode StreamMode, b cipher.Block, iv []byte, opts ...Option) cipher.Stream {
+	return newStream(mode, b, iv, false, opts)
+}
+
+// NewStreamDecrypter returns a cipher.Stream that decrypts what
+// NewStreamEncrypter encrypts. For CTRStream and OFBStream, encrypting and
+// decrypting are the same.
+func NewStreamDecrypter(mode StreamMode, b cipher.Block, iv []byte, opts ...Option) cipher.Stream {
+	return newStream(mode, b, iv, true, opts)
+}
+
+func newStream(mode StreamMode, b cipher.Block, iv []byte, decrypt bool, opts []Option) cipher.Stream {
+	switch mode {
+	case CTRStream:
+		o := newOptions(opts)
+		return NewCTR(b, iv, o.counterEncoding)
+	case CFBStream:
+		return newCFB(b, iv, decrypt)
+	case CFB8Stream:
+		return newCFB8(b, iv, decrypt)
+	case OFBStream:
+		return NewOFB(b, iv)
+	default:
+		panic("unknown stream mode")
+	}
+}
+
+// A CounterEncoding lays out CTR mode's counter blocks, by how it increments
+// one to get the next.
+type CounterEncoding interface {
+	// Increment sets counter, one block long, to the next counter block.
+	Increment(counter []byte)
+}
+
+// BigEndianCounter increments the whole counter block as one big-endian
+// number, as crypto/cipher's CTR mode does.
+type BigEndianCounter struct{}
+
+// Increment adds one to counter, wrapping around to zero after all ones.
+func (BigEndianCounter) Increment(counter []byte) {
+	for i := len(counter) - 1; i >= 0; i-- {
+		counter[i]++
+		if counter[i] != 0 {
+			return
+		}
+	}
+}
+
+// A NonceCounter increments only the last Size bytes of the counter block,
+// leaving the nonce before them fixed. The counter wraps around, leaving the
+// nonce unchanged.
+type NonceCounter struct {
+	Size         int  // Bytes of counter, from 1 to the block size.
+	LittleEndian bool // Whether the counter is little-endian.
+}
+
+// Increment adds one to the last n.Size bytes of counter. It panics if
+// n.Size isn't from 1 to len(counter).
+func (n NonceCounter) Increment(counter []byte) {
+	if n.Size < 1 || n.Size > len(counter) {
+		panic("invalid counter size")
+	}
+	c := counter[len(counter)-n.Size:]
+	if n.LittleEndian {
+		for i := range c {
+			c[i]++
+			if c[i] != 0 {
+				return
+			}
+		}
+		return
+	}
+	BigEndianCounter{}.Increment(c)
+}
+
+// ctr implements counter mode.
+type ctr struct {
+	b       cipher.Block
+	enc     CounterEncoding
+	counter []byte // The next counter block to encrypt.
+	out     []byte // Keystream for the current block.
+	used    int    // Keystream bytes used so far.
+}
+
+func (c *ctr) XORKeyStream(dst, src []byte) {
+	checkBuffers(dst, src)
+
+	for len(src) > 0 {
+		if c.used == len(c.out) {
+			c.b.Encrypt(c.out, c.counter)
+			c.enc.Increment(c.counter)
+			c.used = 0
+		}
+
+		n := subtle.XORBytes(dst, src, c.out[c.used:])
+		dst, src = dst[n:], src[n:]
+		c.used += n
+	}
+}
+
+// NewCTR returns a cipher.Stream which encrypts or decrypts in counter mode,
+// starting from the counter block iv and moving to the next with enc. If enc
+// is nil, it's BigEndianCounter.
+func NewCTR(b cipher.Block, iv []byte, enc CounterEncoding) cipher.Stream {
+	bs := b.BlockSize()
+	if len(iv) != bs {
+		panic("invalid iv length")
+	}
+	if enc == nil {
+		enc = BigEndianCounter{}
+	}
+	return &ctr{b: b, enc: enc, counter: slices.Clone(iv), out: make([]byte, bs), used: bs}
+}
+
+// NewNonceCTR returns a cipher.Stream which encrypts or decrypts in counter
+// mode with the layout from challenge 18: a 64-bit little-endian nonce,
+// followed by a 64-bit little-endian block counter starting at zero. b must
+// have 16-byte blocks.
+func NewNonceCTR(b cipher.Block, nonce uint64) cipher.Stream {
+	if b.BlockSize() != 16 {
+		panic("invalid block size")
+	}
+	iv := binary.LittleEndian.AppendUint64(nil, nonce)
+	iv = append(iv, make([]byte, 8)...)
+	return NewCTR(b, iv, NonceCounter{Size: 8, LittleEndian: true})
+}
@@ -0,0 +1,96 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"testing"
+)
+
+func TestStreamModesMatchStdlib(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := bytes.Repeat([]byte{0xff}, aes.BlockSize) // Carries all the way up.
+	pt := bytes.Repeat([]byte("stream modes "), 10)
+
+	tests := []struct {
+		mode StreamMode
+		std  cipher.Stream
+	}{
+		{CTRStream, cipher.NewCTR(block, iv)},
+		{CFBStream, cipher.NewCFBEncrypter(block, iv)},
+		{OFBStream, cipher.NewOFB(block, iv)},
+	}
+	for _, tt := range tests {
+		want := make([]byte, len(pt))
+		tt.std.XORKeyStream(want, pt)
+
+		got := make([]byte, len(pt))
+		NewStreamEncrypter(tt.mode, block, iv).XORKeyStream(got, pt)
+		if !bytes.Equal(got, want) {
+			t.Errorf("mode %d: output differs from crypto/cipher", tt.mode)
+		}
+
+		NewStreamDecrypter(tt.mode, block, iv).XORKeyStream(got, got)
+		if !bytes.Equal(got, pt) {
+			t.Errorf("mode %d: round trip failed", tt.mode)
+		}
+	}
+}
+
+func TestNonceCounter(t *testing.T) {
+	tests := []struct {
+		enc      NonceCounter
+		in, want []byte
+	}{
+		{NonceCounter{Size: 2}, []byte{9, 9, 0x00, 0xff}, []byte{9, 9, 0x01, 0x00}},
+		{NonceCounter{Size: 2}, []byte{9, 9, 0xff, 0xff}, []byte{9, 9, 0x00, 0x00}},
+		{NonceCounter{Size: 2, LittleEndian: true}, []byte{9, 9, 0xff, 0x00}, []byte{9, 9, 0x00, 0x01}},
+		{NonceCounter{Size: 2, LittleEndian: true}, []byte{9, 9, 0xff, 0xff}, []byte{9, 9, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		got := bytes.Clone(tt.in)
+		tt.enc.Increment(got)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%+v: %x: want %x, got %x", tt.enc, tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestNewNonceCTR(t *testing.T) {
+	block, err := aes.NewCipher([]byte("YELLOW SUBMARINE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := decodeBase64(t, "L77na/nrFsKvynd6HzOoG7GHTLXsTVu9qvY/2syLXzhPweyyMTJULu/6/kXX0KSvoOLSFQ==")
+
+	got := make([]byte, len(ct))
+	NewNonceCTR(block, 0).XORKeyStream(got, ct)
+	if want := "Yo, VIP Let's kick it Ice, Ice, baby Ice, Ice, baby "; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFixedIVKeystreamAnyCipher(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"aes ofb":         nil,
+		"aes ctr":         {WithStreamMode(CTRStream)},
+		"des ctr":         {WithCipher(des.NewCipher, 8), WithStreamMode(CTRStream)},
+		"scratch des ofb": {WithCipher(NewDES, 8)},
+		"scratch aes ctr": {WithCipher(NewAES, 16), WithStreamMode(CTRStream), WithCounterEncoding(NonceCounter{Size: 8, LittleEndian: true})},
+	} {
+		oracle := NewOFBFixedIVOracle(opts...)
+
+		known := []byte("a message the attacker wrote or guessed")
+		secret := []byte("a secret under the same key and iv")
+
+		ks := XOR(known, oracle(known))
+		ct := oracle(secret)
+		if got := XOR(ct, ks[:len(ct)]); !bytes.Equal(secret, got) {
+			t.Errorf("%s: want %q, got %q", name, secret, got)
+		}
+	}
+}
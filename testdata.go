@@ -0,0 +1,143 @@
+package cryptopals
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TestdataURL is where the challenge data files are published.
+const TestdataURL = "https://cryptopals.com/static/challenge-data/"
+
+// testdataSums are the SHA-256 checksums of the known challenge data files.
+var testdataSums = map[string]string{
+	"4.txt":  "c87c921c561bf2a69cf4847dd6649f6d05430fcabae80fe5e78b56d78978a436",
+	"6.txt":  "9cce7ff2a0ade90b54c0e20ee8283c0cd8caa7663f995eff2d7b9ace3bd53d8d",
+	"7.txt":  "c50fd4291beb52b9fbac8c4bddc5454c7757d0988359e5625e430bc7cdd709c3",
+	"8.txt":  "d61d668f428e48b70c4148ba6a3201afb6d6bd8f630686f23162400683a066b7",
+	"10.txt": "81fbeb6c3194bac8191cfcc8dbb63b705f1d499d3d43ab7795627501dbe34925",
+}
+
+// WithTestdataURL sets the base URL FetchTestdata downloads data files from.
+// The default is TestdataURL.
+func WithTestdataURL(u string) Option {
+	return func(o *options) {
+		o.testdataURL = u
+	}
+}
+
+// checkTestdata reports whether b has the known checksum for name.
+func checkTestdata(name string, b []byte) bool {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]) == testdataSums[name]
+}
+
+// FetchTestdata returns the contents of the challenge data file name, such as
+// "6.txt", cached in dir.
+//
+// If the file isn't cached, or the cached copy is corrupt, it's downloaded
+// and saved to dir first. Downloads are retried just as RemoteOracle requests
+// are, so WithTimeout and WithRetries apply, and WithTestdataURL changes
+// where they come from. It returns an error for files without a known
+// checksum, and for downloads that don't match it.
+func FetchTestdata(dir, name string, opts ...Option) ([]byte, error) {
+	if _, ok := testdataSums[name]; !ok {
+		return nil, fmt.Errorf("unknown testdata file %q", name)
+	}
+
+	path := filepath.Join(dir, name)
+	if b, err := os.ReadFile(path); err == nil && checkTestdata(name, b) {
+		return b, nil
+	}
+
+	o := newOptions(opts)
+	u := o.testdataURL
+	if u == "" {
+		u = TestdataURL
+	}
+	u = strings.TrimSuffix(u, "/") + "/" + name
+
+	o.debug("fetching testdata", "url", u)
+
+	c := &http.Client{}
+	defer c.CloseIdleConnections()
+
+	var b []byte
+	err := newRemote(o).do(func(ctx context.Context) error {
+		code, body, err := httpGet(ctx, c, u)
+		if err != nil {
+			return err
+		}
+		if code != http.StatusOK {
+			return fmt.Errorf("server returned %d", code)
+		}
+		b = body
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", name, err)
+	}
+
+	if !checkTestdata(name, b) {
+		return nil, fmt.Errorf("%s: checksum mismatch", name)
+	}
+
+	if err := writeFileAtomic(path, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// writeFileAtomic writes b to path through a temporary file, so readers never
+// see a partial file.
+func writeFileAtomic(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if err := errors.Join(err, f.Close()); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// testdataFS serves challenge data files through FetchTestdata.
+type testdataFS struct {
+	dir  string
+	opts []Option
+}
+
+// NewTestdataFS returns a file system of the challenge data files cached in
+// dir, which fetches each file with FetchTestdata on first use. Pass it to
+// WithTestdata to let Solve download what it needs.
+func NewTestdataFS(dir string, opts ...Option) fs.FS {
+	return testdataFS{dir: dir, opts: opts}
+}
+
+func (t testdataFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := testdataSums[name]; !ok {
+		return os.DirFS(t.dir).Open(name)
+	}
+
+	if _, err := FetchTestdata(t.dir, name, t.opts...); err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return os.DirFS(t.dir).Open(name)
+}
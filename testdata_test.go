@@ -0,0 +1,105 @@
+package cryptopals
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// newTestdataServer serves the vendored testdata directory, counting
+// requests in *n.
+func newTestdataServer(t *testing.T, n *atomic.Int64) *httptest.Server {
+	t.Helper()
+	files := http.FileServer(http.Dir("testdata"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.Add(1)
+		files.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchTestdata(t *testing.T) {
+	var n atomic.Int64
+	srv := newTestdataServer(t, &n)
+	dir := t.TempDir()
+
+	want, err := os.ReadFile("testdata/6.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		got, err := FetchTestdata(dir, "6.txt", WithTestdataURL(srv.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want, got) {
+			t.Error("wrong contents")
+		}
+	}
+
+	// The second call should use the cache.
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestFetchTestdataCorruptCache(t *testing.T) {
+	var n atomic.Int64
+	srv := newTestdataServer(t, &n)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "8.txt")
+	if err := os.WriteFile(path, []byte("corrupt"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := FetchTestdata(dir, "8.txt", WithTestdataURL(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkTestdata("8.txt", got) {
+		t.Error("cache wasn't repaired")
+	}
+}
+
+func TestFetchTestdataErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not the real file"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	if _, err := FetchTestdata(dir, "7.txt", WithTestdataURL(srv.URL)); err == nil {
+		t.Error("bad checksum: no error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "7.txt")); !os.IsNotExist(err) {
+		t.Errorf("bad download was cached: %v", err)
+	}
+
+	if _, err := FetchTestdata(dir, "1000.txt", WithTestdataURL(srv.URL)); err == nil {
+		t.Error("unknown file: no error")
+	}
+}
+
+func TestSolveWithTestdataFS(t *testing.T) {
+	var n atomic.Int64
+	srv := newTestdataServer(t, &n)
+
+	fsys := NewTestdataFS(t.TempDir(), WithTestdataURL(srv.URL))
+	if _, err := Solve(6, WithTestdata(fsys)); err != nil {
+		t.Fatal(err)
+	}
+}
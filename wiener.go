@@ -0,0 +1,129 @@
+package cryptopals
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// continuedFraction returns the terms of the continued fraction of a/b, for
+// b > 0.
+func continuedFraction(a, b *big.Int) []*big.Int {
+	var terms []*big.Int
+	a, b = new(big.Int).Set(a), new(big.Int).Set(b)
+	for b.Sign() != 0 {
+		q, r := new(big.Int).DivMod(a, b, new(big.Int))
+		terms = append(terms, q)
+		a, b = b, r
+	}
+	return terms
+}
+
+// convergents calls f with each convergent h/k of the continued fraction
+// terms, in order, until f returns false.
+func convergents(terms []*big.Int, f func(h, k *big.Int) bool) {
+	// h and k follow h_i = a_i h_(i-1) + h_(i-2), from h_(-1) = 1 and
+	// h_(-2) = 0, and the same for k from k_(-1) = 0 and k_(-2) = 1.
+	h0, h1 := big.NewInt(0), big.NewInt(1)
+	k0, k1 := big.NewInt(1), big.NewInt(0)
+	for _, a := range terms {
+		h0, h1 = h1, new(big.Int).Add(new(big.Int).Mul(a, h1), h0)
+		k0, k1 = k1, new(big.Int).Add(new(big.Int).Mul(a, k1), k0)
+		if !f(h1, k1) {
+			return
+		}
+	}
+}
+
+// GenerateWienerKey returns a random RSA key with a modulus of bits bits and
+// a private exponent of about a quarter as many, small enough for
+// RecoverWienerKey to recover it from the public key alone. Such keys make
+// decryption fast, which is why they were once tempting. It returns an
+// error if bits is less than 32.
+func GenerateWienerKey(bits int) (*RSAPrivateKey, error) {
+	if bits < 32 {
+		return nil, errors.New("modulus too small")
+	}
+
+	one := big.NewInt(1)
+	for {
+		p, err := rand.Prime(rand.Reader, (bits+1)/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || n.BitLen() != bits {
+			continue
+		}
+
+		// Wiener's bound is d < n^(1/4) / 3.
+		d, err := rand.Int(rand.Reader, new(big.Int).Lsh(one, uint(bits/4-2)))
+		if err != nil {
+			return nil, err
+		}
+		d.SetBit(d, 0, 1)
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		e := new(big.Int).ModInverse(d, phi)
+		if d.Cmp(one) == 0 || e == nil {
+			continue
+		}
+		return &RSAPrivateKey{RSAPublicKey: RSAPublicKey{N: n, E: e}, D: d, P: p, Q: q}, nil
+	}
+}
+
+// RecoverWienerKey returns the private key for pub, with Wiener's attack,
+// if its private exponent d is below about n^(1/4).
+//
+// Since e*d = 1 + k*phi(n), and phi(n) is close to n, e/n is close to k/d,
+// so close that k/d is one of the convergents of e/n's continued fraction.
+// Each convergent gives a candidate phi(n) = (e*d - 1) / k, and with it p
+// and q as the roots of x^2 - (n - phi(n) + 1)x + n; the right one is the
+// one whose roots are integers.
+//
+// It returns an error if no convergent works.
+func RecoverWienerKey(pub *RSAPublicKey) (*RSAPrivateKey, error) {
+	n := pub.N
+	one := big.NewInt(1)
+	var key *RSAPrivateKey
+	convergents(continuedFraction(pub.E, n), func(k, d *big.Int) bool {
+		if k.Sign() == 0 {
+			return true
+		}
+		phi, r := new(big.Int).Mul(pub.E, d), new(big.Int)
+		phi.Sub(phi, one).DivMod(phi, k, r)
+		if r.Sign() != 0 {
+			return true
+		}
+
+		// p + q = n - phi + 1, and (p - q)^2 = (p + q)^2 - 4n.
+		s := new(big.Int).Sub(n, phi)
+		s.Add(s, one)
+		disc := new(big.Int).Mul(s, s)
+		disc.Sub(disc, new(big.Int).Lsh(n, 2))
+		if disc.Sign() < 0 {
+			return true
+		}
+		t := new(big.Int).Sqrt(disc)
+		if new(big.Int).Mul(t, t).Cmp(disc) != 0 {
+			return true
+		}
+		p := new(big.Int).Add(s, t)
+		p.Rsh(p, 1)
+		q := new(big.Int).Sub(s, t)
+		q.Rsh(q, 1)
+		if new(big.Int).Mul(p, q).Cmp(n) != 0 {
+			return true
+		}
+
+		key = &RSAPrivateKey{RSAPublicKey: *pub, D: new(big.Int).Set(d), P: p, Q: q}
+		return false
+	})
+	if key == nil {
+		return nil, errors.New("private exponent too large")
+	}
+	return key, nil
+}
@@ -0,0 +1,56 @@
+package cryptopals
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+)
+
+func TestContinuedFraction(t *testing.T) {
+	// 415/93 = [4; 2, 6, 7], with convergents 4, 9/2, 58/13, 415/93.
+	terms := continuedFraction(big.NewInt(415), big.NewInt(93))
+	var got []int64
+	for _, a := range terms {
+		got = append(got, a.Int64())
+	}
+	if want := []int64{4, 2, 6, 7}; !slices.Equal(got, want) {
+		t.Errorf("terms: got %v, want %v", got, want)
+	}
+
+	var fracs [][2]int64
+	convergents(terms, func(h, k *big.Int) bool {
+		fracs = append(fracs, [2]int64{h.Int64(), k.Int64()})
+		return true
+	})
+	if want := [][2]int64{{4, 1}, {9, 2}, {58, 13}, {415, 93}}; !slices.Equal(fracs, want) {
+		t.Errorf("convergents: got %v, want %v", fracs, want)
+	}
+}
+
+func TestRecoverWienerKey(t *testing.T) {
+	k, err := GenerateWienerKey(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := big.NewInt(1234567)
+	if got := k.Decrypt(k.Encrypt(m)); got.Cmp(m) != 0 {
+		t.Fatalf("got %v, want %v", got, m)
+	}
+
+	got, err := RecoverWienerKey(&k.RSAPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.D.Cmp(k.D) != 0 {
+		t.Errorf("got d = %x, want %x", got.D, k.D)
+	}
+
+	// An ordinary key's private exponent is far too large.
+	k, err = GenerateRSAKey(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RecoverWienerKey(&k.RSAPublicKey); err == nil {
+		t.Error("recovered an ordinary key")
+	}
+}
@@ -0,0 +1,266 @@
+package cryptopals
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// x25519A24 is (A - 2) / 4 for Curve25519's A = 486662, as the ladder uses
+// it.
+const x25519A24 = 121665
+
+// X25519Basepoint is the u-coordinate of Curve25519's base point, 9.
+var X25519Basepoint = append([]byte{9}, make([]byte, 31)...)
+
+// Field25519Mul returns a*b mod p, for p = 2^255 - 19, the prime of the
+// field both Curve25519 and Edwards25519 are over. The field functions
+// accept any integers, reduced or not, and return reduced results, so they
+// work as well for points on the twist as on the curve.
+func Field25519Mul(a, b *big.Int) *big.Int {
+	res := new(big.Int).Mul(a, b)
+	return res.Mod(res, edP)
+}
+
+// Field25519Add returns a+b mod p.
+func Field25519Add(a, b *big.Int) *big.Int {
+	res := new(big.Int).Add(a, b)
+	return res.Mod(res, edP)
+}
+
+// Field25519Sub returns a-b mod p.
+func Field25519Sub(a, b *big.Int) *big.Int {
+	res := new(big.Int).Sub(a, b)
+	return res.Mod(res, edP)
+}
+
+// Field25519Inv returns 1/a mod p, or 0 for 0, as a^(p-2).
+func Field25519Inv(a *big.Int) *big.Int {
+	return new(big.Int).Exp(a, new(big.Int).Sub(edP, big.NewInt(2)), edP)
+}
+
+// decodeU returns the little-endian u-coordinate b, with its top bit
+// masked, and not reduced, as RFC 7748 specifies.
+func decodeU(b []byte) *big.Int {
+	le := slices.Clone(b)
+	le[31] &= 0x7f
+	slices.Reverse(le)
+	return new(big.Int).SetBytes(le)
+}
+
+// encodeU returns the 32-byte little-endian encoding of u mod p.
+func encodeU(u *big.Int) []byte {
+	b := new(big.Int).Mod(u, edP).FillBytes(make([]byte, 32))
+	slices.Reverse(b)
+	return b
+}
+
+// x25519Ladder returns the u-coordinate of k times the point with
+// u-coordinate u, with the Montgomery ladder from RFC 7748. The ladder only
+// ever needs u-coordinates, so any u works, including ones on the curve's
+// twist rather than the curve.
+func x25519Ladder(k, u *big.Int) *big.Int {
+	x1 := new(big.Int).Mod(u, edP)
+	x2, z2 := big.NewInt(1), big.NewInt(0)
+	x3, z3 := new(big.Int).Set(x1), big.NewInt(1)
+	a24 := big.NewInt(x25519A24)
+
+	swap := uint(0)
+	for t := 254; t >= 0; t-- {
+		kt := k.Bit(t)
+		if swap^kt == 1 {
+			x2, x3 = x3, x2
+			z2, z3 = z3, z2
+		}
+		swap = kt
+
+		a := Field25519Add(x2, z2)
+		aa := Field25519Mul(a, a)
+		b := Field25519Sub(x2, z2)
+		bb := Field25519Mul(b, b)
+		e := Field25519Sub(aa, bb)
+		c := Field25519Add(x3, z3)
+		d := Field25519Sub(x3, z3)
+		da := Field25519Mul(d, a)
+		cb := Field25519Mul(c, b)
+		x3 = Field25519Add(da, cb)
+		x3 = Field25519Mul(x3, x3)
+		z3 = Field25519Sub(da, cb)
+		z3 = Field25519Mul(x1, Field25519Mul(z3, z3))
+		x2 = Field25519Mul(aa, bb)
+		z2 = Field25519Mul(e, Field25519Add(aa, Field25519Mul(a24, e)))
+	}
+	if swap == 1 {
+		x2, z2 = x3, z3
+	}
+	return Field25519Mul(x2, Field25519Inv(z2))
+}
+
+// X25519 returns the X25519 function of RFC 7748 on the 32-byte scalar and
+// u-coordinate: the u-coordinate of the clamped scalar times the point,
+// without the check for an all-zero result that key agreement should make.
+// It panics if either isn't 32 bytes.
+func X25519(scalar, u []byte) []byte {
+	if len(scalar) != 32 || len(u) != 32 {
+		panic("invalid input size")
+	}
+	return encodeU(x25519Ladder(clampScalar(scalar), decodeU(u)))
+}
+
+// An X25519Key is an X25519 key pair.
+type X25519Key struct {
+	scalar []byte
+	pub    []byte
+}
+
+// NewX25519Key returns the key pair with the 32-byte private scalar. It
+// panics if scalar isn't 32 bytes.
+func NewX25519Key(scalar []byte) *X25519Key {
+	return &X25519Key{scalar: slices.Clone(scalar), pub: X25519(scalar, X25519Basepoint)}
+}
+
+// GenerateX25519Key returns a random X25519 key pair.
+func GenerateX25519Key() *X25519Key {
+	return NewX25519Key(randBytes(32))
+}
+
+// PublicKey returns the 32-byte public key, the private scalar times the
+// base point.
+func (k *X25519Key) PublicKey() []byte {
+	return slices.Clone(k.pub)
+}
+
+// ECDH returns the shared secret with the peer's public key. It returns an
+// error if peer isn't 32 bytes.
+func (k *X25519Key) ECDH(peer []byte) ([]byte, error) {
+	if len(peer) != 32 {
+		return nil, errors.New("invalid public key size")
+	}
+	return X25519(k.scalar, peer), nil
+}
+
+// x25519LowOrder holds the u-coordinates of the points of small order on
+// Curve25519 and its twist: 0, of order 2; 1 and p - 1, of order 4; the two
+// of order 8; and p and p + 1, the non-canonical encodings of 0 and 1.
+// Multiplying any of them by a clamped scalar, a multiple of 8, gives the
+// point at infinity, which the ladder returns as 0.
+var x25519LowOrder = func() []*big.Int {
+	order8a, _ := new(big.Int).SetString("325606250916557431795983626356110631294008115727848805560023387167927233504", 10)
+	order8b, _ := new(big.Int).SetString("39382357235489614581723060781553021112529911719440698176882885853963445705823", 10)
+	return []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		order8a,
+		order8b,
+		new(big.Int).Sub(edP, big.NewInt(1)),
+		new(big.Int).Set(edP),
+		new(big.Int).Add(edP, big.NewInt(1)),
+	}
+}()
+
+// LowOrderX25519Points returns the encodings of the points of small order
+// on Curve25519 and its twist, which force the shared secret to zero
+// whatever the other party's private key is.
+func LowOrderX25519Points() [][]byte {
+	res := make([][]byte, len(x25519LowOrder))
+	for i, u := range x25519LowOrder {
+		res[i] = u.FillBytes(make([]byte, 32))
+		slices.Reverse(res[i])
+	}
+	return res
+}
+
+// CheckX25519Point returns an error if the public key u is one of the
+// low-order points, or isn't 32 bytes, as the blocklist libsodium checks
+// peers' keys against. Checking that the shared secret isn't all zeros
+// instead, as RFC 7748 recommends, catches the same points.
+func CheckX25519Point(u []byte) error {
+	if len(u) != 32 {
+		return errors.New("invalid public key size")
+	}
+	x := decodeU(u)
+	for _, v := range x25519LowOrder {
+		if x.Cmp(v) == 0 {
+			return errors.New("low-order point")
+		}
+	}
+	return nil
+}
+
+// CheckX25519Output returns an error if the shared secret is all zeros, as
+// it is for a low-order peer key.
+func CheckX25519Output(shared []byte) error {
+	if subtle.ConstantTimeCompare(shared, make([]byte, len(shared))) == 1 {
+		return errors.New("all-zero shared secret")
+	}
+	return nil
+}
+
+// x25519Cipher returns AES-128 keyed with SHA-256(shared)[:16].
+func x25519Cipher(shared []byte) cipher.Block {
+	k := sha256.Sum256(shared)
+	b, _ := aes.NewCipher(k[:16])
+	return b
+}
+
+// An X25519Responder answers key agreements with a fresh key pair each
+// time, and sends a secret encrypted under the shared key. By default it
+// doesn't check the peer's key or the shared secret, so it lacks X25519's
+// contributory behavior: a low-order peer key fixes the shared secret at
+// zero, and the peer needs no private key to read the secret.
+type X25519Responder struct {
+	secret   []byte
+	validate bool
+}
+
+// NewX25519Responder returns a responder that sends secret. Use
+// WithPointValidation to check peers' keys.
+func NewX25519Responder(secret []byte, opts ...Option) *X25519Responder {
+	o := newOptions(opts)
+	return &X25519Responder{secret: slices.Clone(secret), validate: o.pointValidation}
+}
+
+// Respond agrees a key with the peer's public key, and returns the
+// responder's public key and iv || AES-CBC(SHA-256(shared)[:16], secret),
+// with PKCS #7 padding. With point validation, it returns an error for a
+// low-order peer key.
+func (r *X25519Responder) Respond(peer []byte) (pub, ct []byte, err error) {
+	if r.validate {
+		if err := CheckX25519Point(peer); err != nil {
+			return nil, nil, err
+		}
+	}
+	k := GenerateX25519Key()
+	shared, err := k.ECDH(peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.validate {
+		if err := CheckX25519Output(shared); err != nil {
+			return nil, nil, err
+		}
+	}
+	return k.PublicKey(), EncryptPadded(x25519Cipher(shared), r.secret), nil
+}
+
+// RecoverX25519ResponderSecret returns the secret a responder like
+// X25519Responder sends, without a private key, by sending it low-order
+// points as public keys until it accepts one. The shared secret is then all
+// zeros. It returns an error if the responder rejects them all.
+func RecoverX25519ResponderSecret(respond func(peer []byte) (pub, ct []byte, err error)) ([]byte, error) {
+	zero := x25519Cipher(make([]byte, 32))
+	for _, u := range LowOrderX25519Points() {
+		_, ct, err := respond(u)
+		if err != nil {
+			continue
+		}
+		if secret, err := DecryptPadded(zero, ct); err == nil {
+			return secret, nil
+		}
+	}
+	return nil, errors.New("responder rejects low-order points")
+}
@@ -0,0 +1,150 @@
+package cryptopals
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"math/big"
+	"testing"
+)
+
+func TestX25519(t *testing.T) {
+	// From RFC 7748, section 5.2.
+	tests := []struct{ scalar, u, want string }{
+		{
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+	}
+	for _, tt := range tests {
+		if got := X25519(decodeHex(t, tt.scalar), decodeHex(t, tt.u)); !bytes.Equal(got, decodeHex(t, tt.want)) {
+			t.Errorf("got %x, want %s", got, tt.want)
+		}
+	}
+
+	// One iteration of k = X25519(k, u), u = old k, from k = u = 9. The
+	// RFC's 1000-iteration value takes a second with big.Int arithmetic.
+	want := decodeHex(t, "422c8e7a6227d7bca1350b3e2bb7279f7897b87bb6854b783c60e80311ae3079")
+	if got := X25519(X25519Basepoint, X25519Basepoint); !bytes.Equal(got, want) {
+		t.Errorf("one iteration: got %x, want %x", got, want)
+	}
+}
+
+func TestField25519(t *testing.T) {
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	pm1 := new(big.Int).Sub(p, big.NewInt(1))
+	one := big.NewInt(1)
+
+	if got := Field25519Add(pm1, one); got.Sign() != 0 {
+		t.Errorf("(p-1) + 1 = %v, want 0", got)
+	}
+	if got := Field25519Sub(big.NewInt(0), one); got.Cmp(pm1) != 0 {
+		t.Errorf("0 - 1 = %v, want p-1", got)
+	}
+	if got := Field25519Mul(pm1, pm1); got.Cmp(one) != 0 {
+		t.Errorf("(p-1)^2 = %v, want 1", got)
+	}
+	a := big.NewInt(121665)
+	if got := Field25519Mul(a, Field25519Inv(a)); got.Cmp(one) != 0 {
+		t.Errorf("a / a = %v, want 1", got)
+	}
+	if got := Field25519Inv(big.NewInt(0)); got.Sign() != 0 {
+		t.Errorf("1/0 = %v, want 0", got)
+	}
+}
+
+func TestX25519Key(t *testing.T) {
+	// From RFC 7748, section 6.1.
+	alice := NewX25519Key(decodeHex(t, "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"))
+	bob := NewX25519Key(decodeHex(t, "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb"))
+	if want := decodeHex(t, "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a"); !bytes.Equal(alice.PublicKey(), want) {
+		t.Errorf("Alice's public key: got %x, want %x", alice.PublicKey(), want)
+	}
+	want := decodeHex(t, "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742")
+	for _, s := range [][2]*X25519Key{{alice, bob}, {bob, alice}} {
+		got, err := s[0].ECDH(s[1].PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("shared secret: got %x, want %x", got, want)
+		}
+	}
+
+	// Agreement with the standard library.
+	k := GenerateX25519Key()
+	std, err := ecdh.X25519().NewPrivateKey(randBytes(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := k.ECDH(std.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := ecdh.X25519().NewPublicKey(k.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdGot, err := std.ECDH(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, stdGot) {
+		t.Errorf("got %x, crypto/ecdh got %x", got, stdGot)
+	}
+}
+
+func TestLowOrderX25519Points(t *testing.T) {
+	k := GenerateX25519Key()
+	for _, u := range LowOrderX25519Points() {
+		if got := X25519(k.scalar, u); CheckX25519Output(got) == nil {
+			t.Errorf("point %x: got %x, want zeros", u, got)
+		}
+		if err := CheckX25519Point(u); err == nil {
+			t.Errorf("point %x: no error", u)
+		}
+	}
+
+	// Doubling an order-8 point gives one of order 4, with u = 1 or p - 1.
+	for _, u := range x25519LowOrder[2:4] {
+		d := x25519Ladder(big.NewInt(2), u)
+		if d.Cmp(x25519LowOrder[1]) != 0 && d.Cmp(x25519LowOrder[4]) != 0 {
+			t.Errorf("2 * %v: got %v", u, d)
+		}
+	}
+
+	if err := CheckX25519Point(k.PublicKey()); err != nil {
+		t.Errorf("ordinary key: %v", err)
+	}
+	shared, err := k.ECDH(GenerateX25519Key().PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckX25519Output(shared); err != nil {
+		t.Errorf("ordinary key: %v", err)
+	}
+}
+
+func TestRecoverX25519ResponderSecret(t *testing.T) {
+	secret := []byte("the eagle lands at midnight")
+	r := NewX25519Responder(secret)
+
+	got, err := RecoverX25519ResponderSecret(r.Respond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Errorf("got %q, want %q", got, secret)
+	}
+
+	// A validating responder is safe.
+	r = NewX25519Responder(secret, WithPointValidation(true))
+	if _, err := RecoverX25519ResponderSecret(r.Respond); err == nil {
+		t.Error("validating responder: no error")
+	}
+}
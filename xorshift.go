@@ -0,0 +1,226 @@
+package cryptopals
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// A Xorshift128Plus is the xorshift128+ generator, which V8 and other
+// JavaScript engines have used for Math.random.
+type Xorshift128Plus struct {
+	s [2]uint64
+}
+
+// NewXorshift128Plus returns a xorshift128+ generator with state s. It
+// panics if s is all zeros.
+func NewXorshift128Plus(s [2]uint64) *Xorshift128Plus {
+	if s == [2]uint64{} {
+		panic("zero state")
+	}
+	return &Xorshift128Plus{s}
+}
+
+// Next steps the generator and returns its next output.
+func (x *Xorshift128Plus) Next() uint64 {
+	s1, s0 := x.s[0], x.s[1]
+	x.s[0] = s0
+	s1 ^= s1 << 23
+	x.s[1] = s1 ^ s0 ^ s1>>17 ^ s0>>26
+	return x.s[1] + s0
+}
+
+// State returns the current state.
+func (x *Xorshift128Plus) State() [2]uint64 {
+	return x.s
+}
+
+// A Xoshiro256StarStar is the xoshiro256** generator, the default in several
+// languages' standard libraries.
+type Xoshiro256StarStar struct {
+	s [4]uint64
+}
+
+// NewXoshiro256StarStar returns a xoshiro256** generator with state s. It
+// panics if s is all zeros.
+func NewXoshiro256StarStar(s [4]uint64) *Xoshiro256StarStar {
+	if s == [4]uint64{} {
+		panic("zero state")
+	}
+	return &Xoshiro256StarStar{s}
+}
+
+// Next steps the generator and returns its next output.
+func (x *Xoshiro256StarStar) Next() uint64 {
+	s := &x.s
+	res := bits.RotateLeft64(s[1]*5, 7) * 9
+
+	t := s[1] << 17
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = bits.RotateLeft64(s[3], 45)
+
+	return res
+}
+
+// State returns the current state.
+func (x *Xoshiro256StarStar) State() [4]uint64 {
+	return x.s
+}
+
+// A gf2Word is a 64-bit word whose bits are linear functions of unknown
+// bits, tracked as BitVectors of their coefficients. Both generators' state
+// transitions are linear over GF(2), so running them on gf2Words gives each
+// state bit in terms of the initial state.
+type gf2Word [64]BitVector
+
+// gf2Words returns k words whose bits are the n = 64*k unknowns, in order.
+func gf2Words(k int) []gf2Word {
+	words := make([]gf2Word, k)
+	for w := range words {
+		for i := range 64 {
+			words[w][i] = NewBitVector(64 * k)
+			words[w][i].SetBit(64*w+i, 1)
+		}
+	}
+	return words
+}
+
+func (w gf2Word) xor(v gf2Word) gf2Word {
+	var res gf2Word
+	for i := range res {
+		res[i] = w[i].Clone()
+		res[i].Xor(v[i])
+	}
+	return res
+}
+
+func (w gf2Word) shl(k int) gf2Word {
+	var res gf2Word
+	for i := range res {
+		if i >= k {
+			res[i] = w[i-k]
+		} else {
+			res[i] = NewBitVector(64 * len(w[0]))
+		}
+	}
+	return res
+}
+
+func (w gf2Word) shr(k int) gf2Word {
+	var res gf2Word
+	for i := range res {
+		if i+k < 64 {
+			res[i] = w[i+k]
+		} else {
+			res[i] = NewBitVector(64 * len(w[0]))
+		}
+	}
+	return res
+}
+
+func (w gf2Word) rotl(k int) gf2Word {
+	var res gf2Word
+	for i := range res {
+		res[(i+k)%64] = w[i]
+	}
+	return res
+}
+
+// maxXorshiftOutputs is how many outputs the xorshift attacks use at most.
+const maxXorshiftOutputs = 512
+
+// RecoverXorshift128Plus returns a xorshift128+ generator that continues from
+// consecutive outputs of Next.
+//
+// The output adds two state words, and only the low bit of a sum is linear,
+// but that bit is the XOR of the low bits of the words. So each output gives
+// one linear equation in the 128 state bits, and 128 outputs are usually
+// enough to solve for the state with SolveGF2. It returns an error with too
+// few outputs, or if they aren't from xorshift128+. At most 512 are used.
+func RecoverXorshift128Plus(outputs []uint64) (*Xorshift128Plus, error) {
+	outputs = outputs[:min(len(outputs), maxXorshiftOutputs)]
+
+	s := gf2Words(2)
+	var eqs []BitVector
+	rhs := NewBitVector(len(outputs))
+	for i, out := range outputs {
+		s1, s0 := s[0], s[1]
+		s1 = s1.xor(s1.shl(23))
+		s[0], s[1] = s0, s1.xor(s0).xor(s1.shr(17)).xor(s0.shr(26))
+
+		eq := s[1][0].Clone()
+		eq.Xor(s0[0])
+		eqs = append(eqs, eq)
+		rhs.SetBit(i, uint(out&1))
+	}
+
+	x, err := SolveGF2(eqs, rhs, 128)
+	if err != nil {
+		return nil, err
+	}
+	if x[0] == 0 && x[1] == 0 {
+		return nil, errors.New("outputs aren't from xorshift128+")
+	}
+
+	g := NewXorshift128Plus([2]uint64{x[0], x[1]})
+	for _, out := range outputs {
+		if g.Next() != out {
+			return nil, errors.New("outputs aren't from xorshift128+")
+		}
+	}
+	return g, nil
+}
+
+// RecoverXoshiro256StarStar returns a xoshiro256** generator that continues
+// from consecutive outputs of Next.
+//
+// The output scrambles only the second state word, with invertible steps, so
+// each output reveals that word: 64 linear equations in the 256 state bits.
+// Four outputs are usually enough to solve for the state with SolveGF2. It
+// returns an error with too few outputs, or if they aren't from xoshiro256**.
+// At most 512 are used.
+func RecoverXoshiro256StarStar(outputs []uint64) (*Xoshiro256StarStar, error) {
+	outputs = outputs[:min(len(outputs), maxXorshiftOutputs)]
+
+	// The multiplicative inverses of 5 and 9 mod 2^64.
+	const inv5, inv9 = 0xcccccccccccccccd, 0x8e38e38e38e38e39
+
+	s := gf2Words(4)
+	var eqs []BitVector
+	rhs := NewBitVector(64 * len(outputs))
+	for i, out := range outputs {
+		s1 := bits.RotateLeft64(out*inv9, -7) * inv5
+		for j := range 64 {
+			eqs = append(eqs, s[1][j])
+			rhs.SetBit(64*i+j, uint(s1>>j&1))
+		}
+
+		t := s[1].shl(17)
+		s[2] = s[2].xor(s[0])
+		s[3] = s[3].xor(s[1])
+		s[1] = s[1].xor(s[2])
+		s[0] = s[0].xor(s[3])
+		s[2] = s[2].xor(t)
+		s[3] = s[3].rotl(45)
+	}
+
+	x, err := SolveGF2(eqs, rhs, 256)
+	if err != nil {
+		return nil, err
+	}
+	state := [4]uint64(x)
+	if state == [4]uint64{} {
+		return nil, errors.New("outputs aren't from xoshiro256**")
+	}
+
+	g := NewXoshiro256StarStar(state)
+	for _, out := range outputs {
+		if g.Next() != out {
+			return nil, errors.New("outputs aren't from xoshiro256**")
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,74 @@
+package cryptopals
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestXorshift128Plus(t *testing.T) {
+	x := NewXorshift128Plus([2]uint64{1, 2})
+	if got := x.Next(); got != 0x800045 {
+		t.Errorf("got %#x, want 0x800045", got)
+	}
+	if got := x.State(); got != [2]uint64{2, 0x800043} {
+		t.Errorf("state %x", got)
+	}
+}
+
+func TestXoshiro256StarStar(t *testing.T) {
+	// The reference implementation from state {1, 2, 3, 4}.
+	x := NewXoshiro256StarStar([4]uint64{1, 2, 3, 4})
+	want := []uint64{11520, 0, 1509978240, 1215971899390074240}
+	for i, w := range want {
+		if got := x.Next(); got != w {
+			t.Errorf("output %d: got %d, want %d", i, got, w)
+		}
+	}
+}
+
+func TestRecoverXorshift128Plus(t *testing.T) {
+	for range 5 {
+		g := NewXorshift128Plus([2]uint64{rand.Uint64(), rand.Uint64()})
+		outputs := make([]uint64, 128)
+		for i := range outputs {
+			outputs[i] = g.Next()
+		}
+
+		r, err := RecoverXorshift128Plus(outputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range 10 {
+			if got, want := r.Next(), g.Next(); got != want {
+				t.Fatalf("prediction %d: got %x, want %x", i, got, want)
+			}
+		}
+	}
+}
+
+func TestRecoverXoshiro256StarStar(t *testing.T) {
+	for range 5 {
+		g := NewXoshiro256StarStar([4]uint64{rand.Uint64(), rand.Uint64(), rand.Uint64(), rand.Uint64()})
+		outputs := make([]uint64, 4)
+		for i := range outputs {
+			outputs[i] = g.Next()
+		}
+
+		r, err := RecoverXoshiro256StarStar(outputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.State() != g.State() {
+			t.Fatalf("state %x, want %x", r.State(), g.State())
+		}
+	}
+}
+
+func TestRecoverXorshiftErrors(t *testing.T) {
+	if _, err := RecoverXorshift128Plus([]uint64{1, 2, 3}); err == nil {
+		t.Error("xorshift128+, 3 outputs: no error")
+	}
+	if _, err := RecoverXoshiro256StarStar([]uint64{1, 2}); err == nil {
+		t.Error("xoshiro256**, 2 outputs: no error")
+	}
+}
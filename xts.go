@@ -0,0 +1,106 @@
+package cryptopals
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// An XTS encrypts disk sectors in XTS mode, as in IEEE 1619, using AES from
+// NewAES. Each 16-byte block is encrypted as in ECB mode, but masked before
+// and after with a tweak that depends on its sector number and position in
+// the sector.
+//
+// XTS has no MAC and no IV, so it hides data but doesn't protect it: see
+// SpliceXTSBlocks.
+type XTS struct {
+	k1, k2 cipher.Block
+}
+
+// NewXTS returns an XTS for key, which holds two AES keys of the same size,
+// for 32 or 64 bytes in all. The first encrypts data and the second encrypts
+// sector numbers into tweaks. IEEE 1619 has no XTS-AES-192, so 48-byte keys
+// are rejected.
+func NewXTS(key []byte) (*XTS, error) {
+	if len(key) != 32 && len(key) != 64 {
+		return nil, errors.New("invalid key size")
+	}
+	k1, err := NewAES(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	k2, err := NewAES(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return &XTS{k1: k1, k2: k2}, nil
+}
+
+// Encrypt encrypts the sector pt, a whole number of blocks, into ct. There's
+// no ciphertext stealing.
+func (x *XTS) Encrypt(ct, pt []byte, sector uint64) {
+	x.crypt(ct, pt, sector, x.k1.Encrypt)
+}
+
+// Decrypt decrypts the sector ct, a whole number of blocks, into pt.
+func (x *XTS) Decrypt(pt, ct []byte, sector uint64) {
+	x.crypt(pt, ct, sector, x.k1.Decrypt)
+}
+
+func (x *XTS) crypt(dst, src []byte, sector uint64, f func(dst, src []byte)) {
+	if len(src)%aesBlockSize != 0 {
+		panic("input not full blocks")
+	}
+	checkBuffers(dst, src)
+
+	tweak := make([]byte, aesBlockSize)
+	binary.LittleEndian.PutUint64(tweak, sector)
+	x.k2.Encrypt(tweak, tweak)
+
+	for i := 0; i < len(src); i += aesBlockSize {
+		d := dst[i : i+aesBlockSize]
+		subtle.XORBytes(d, src[i:], tweak)
+		f(d, d)
+		subtle.XORBytes(d, d, tweak)
+		xtsDouble(tweak)
+	}
+}
+
+// xtsDouble sets t to t times x in GF(2^128), with XTS's little-endian bit
+// order.
+func xtsDouble(t []byte) {
+	var carry byte
+	for i := range t {
+		next := t[i] >> 7
+		t[i] = t[i]<<1 | carry
+		carry = next
+	}
+	if carry != 0 {
+		t[0] ^= 0x87
+	}
+}
+
+// SpliceXTSBlocks performs a cut-and-paste attack on XTS, returning a copy
+// of the sector ciphertext cur with the given 16-byte blocks taken from old,
+// an earlier ciphertext of the same sector.
+//
+// A block's tweak depends only on its sector and position, so a block put
+// back where it came from decrypts to its old plaintext, and the rest of the
+// sector decrypts as before. Nothing notices, since XTS has no MAC. This is
+// the ECB cut-and-paste attack of NewAdminProfile, limited to one position
+// in one sector: a block moved anywhere else decrypts to random bytes.
+// Likewise, changing any bit of a block scrambles just that block's
+// plaintext and leaves the rest of the sector alone.
+func SpliceXTSBlocks(cur, old []byte, blocks ...int) []byte {
+	if len(cur) != len(old) {
+		panic("sector size mismatch")
+	}
+
+	res := slices.Clone(cur)
+	for _, i := range blocks {
+		copy(res[i*aesBlockSize:(i+1)*aesBlockSize], old[i*aesBlockSize:])
+	}
+	return res
+}
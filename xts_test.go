@@ -0,0 +1,118 @@
+package cryptopals
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestXTS(t *testing.T) {
+	// IEEE 1619, vectors 1 and 2.
+	tests := []struct {
+		key    string
+		sector uint64
+		pt, ct string
+	}{
+		{
+			key: "0000000000000000000000000000000000000000000000000000000000000000",
+			pt:  "0000000000000000000000000000000000000000000000000000000000000000",
+			ct:  "917cf69ebd68b2ec9b9fe9a3eadda692cd43d2f59598ed858c02c2652fbf922e",
+		},
+		{
+			key:    "1111111111111111111111111111111122222222222222222222222222222222",
+			sector: 0x3333333333,
+			pt:     "4444444444444444444444444444444444444444444444444444444444444444",
+			ct:     "c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0",
+		},
+	}
+	for _, tt := range tests {
+		x, err := NewXTS(decodeHex(t, tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, want := decodeHex(t, tt.pt), decodeHex(t, tt.ct)
+
+		got := make([]byte, len(pt))
+		x.Encrypt(got, pt, tt.sector)
+		if !bytes.Equal(got, want) {
+			t.Errorf("Encrypt(sector %#x) = %x, want %x", tt.sector, got, want)
+		}
+
+		x.Decrypt(got, got, tt.sector)
+		if !bytes.Equal(got, pt) {
+			t.Errorf("Decrypt(sector %#x) = %x, want %x", tt.sector, got, pt)
+		}
+	}
+}
+
+func TestNewXTSKeySize(t *testing.T) {
+	for _, n := range []int{0, 16, 31, 32, 48, 63, 64, 96} {
+		_, err := NewXTS(make([]byte, n))
+		if ok := err == nil; ok != (n == 32 || n == 64) {
+			t.Errorf("%d-byte key: got error %v", n, err)
+		}
+	}
+}
+
+func TestXTSMalleability(t *testing.T) {
+	x, err := NewXTS(randBytes(32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := randBytes(512)
+	ct := make([]byte, len(pt))
+	x.Encrypt(ct, pt, 7)
+
+	// Flipping a bit scrambles only its own block.
+	ct[100] ^= 1
+	got := make([]byte, len(ct))
+	x.Decrypt(got, ct, 7)
+	for i := range len(pt) / 16 {
+		changed := !bytes.Equal(got[i*16:(i+1)*16], pt[i*16:(i+1)*16])
+		if changed != (i == 100/16) {
+			t.Errorf("block %d changed = %t", i, changed)
+		}
+	}
+}
+
+func TestSpliceXTSBlocks(t *testing.T) {
+	x, err := NewXTS(randBytes(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypt := func(pt []byte, sector uint64) []byte {
+		ct := make([]byte, len(pt))
+		x.Encrypt(ct, pt, sector)
+		return ct
+	}
+	decrypt := func(ct []byte, sector uint64) []byte {
+		pt := make([]byte, len(ct))
+		x.Decrypt(pt, ct, sector)
+		return pt
+	}
+
+	old := []byte("user=mallory....role=admin......quota=1000000...")
+	cur := []byte("user=mallory....role=user.......quota=10........")
+	oldCT, curCT := encrypt(old, 3), encrypt(cur, 3)
+
+	// Restoring the old role block keeps the new quota.
+	got := decrypt(SpliceXTSBlocks(curCT, oldCT, 1), 3)
+	want := []byte("user=mallory....role=admin......quota=10........")
+	if !bytes.Equal(got, want) {
+		t.Errorf("spliced sector = %q, want %q", got, want)
+	}
+
+	// A block moved to another position doesn't decrypt.
+	moved := slices.Clone(curCT)
+	copy(moved[32:], oldCT[16:32])
+	if got := decrypt(moved, 3); bytes.Equal(got[32:48], old[16:32]) {
+		t.Errorf("block decrypted after moving within the sector")
+	}
+
+	// Nor does one from another sector, unlike in ECB.
+	other := encrypt(old, 4)
+	if got := decrypt(SpliceXTSBlocks(curCT, other, 1), 3); bytes.Equal(got[16:32], old[16:32]) {
+		t.Errorf("block decrypted after moving to another sector")
+	}
+}